@@ -1,13 +1,43 @@
 package main
 
 import (
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abhinavxd/libredesk/internal/ai"
+	aimodels "github.com/abhinavxd/libredesk/internal/ai/models"
+	amodels "github.com/abhinavxd/libredesk/internal/auth/models"
+	cmodels "github.com/abhinavxd/libredesk/internal/conversation/models"
+	camodels "github.com/abhinavxd/libredesk/internal/custom_attribute/models"
 	"github.com/abhinavxd/libredesk/internal/envelope"
+	"github.com/abhinavxd/libredesk/internal/stringutil"
+	"github.com/valyala/fasthttp"
 	"github.com/zerodha/fastglue"
 )
 
+const (
+	// aiDraftMaxMessages is the number of latest messages a reply is drafted from.
+	aiDraftMaxMessages = 30
+	// aiDraftMaxArticles is the number of articles a reply is drafted from.
+	aiDraftMaxArticles = 3
+)
+
+// aiPromptKeyRe matches valid prompt keys e.g. translate_to_spanish.
+var aiPromptKeyRe = regexp.MustCompile(`^[a-z0-9_]+$`)
+
 type aiCompletionReq struct {
-	PromptKey string `json:"prompt_key"`
-	Content   string `json:"content"`
+	PromptKey        string `json:"prompt_key"`
+	Content          string `json:"content"`
+	ConversationUUID string `json:"conversation_uuid"`
+}
+
+type aiPromptReq struct {
+	Key     string `json:"key"`
+	Title   string `json:"title"`
+	Content string `json:"content"`
 }
 
 type providerUpdateReq struct {
@@ -15,24 +45,159 @@ type providerUpdateReq struct {
 	APIKey   string `json:"api_key"`
 }
 
+type aiDraftReplyReq struct {
+	Instructions string `json:"instructions"`
+}
+
+type providerReq struct {
+	Name     string                  `json:"name"`
+	Provider string                  `json:"provider"`
+	Config   aimodels.ProviderConfig `json:"config"`
+}
+
 // handleAICompletion handles AI completion requests
 func handleAICompletion(r *fastglue.Request) error {
 	var (
-		app = r.Context.(*App)
-		req = aiCompletionReq{}
+		app   = r.Context.(*App)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+		req   = aiCompletionReq{}
 	)
 
 	if err := r.Decode(&req, "json"); err != nil {
 		return sendErrorEnvelope(r, envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil))
 	}
 
-	resp, err := app.ai.Completion(req.PromptKey, req.Content)
+	user, err := getAuthenticatedAgent(app, auser)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	// Prompt variables, the conversation and contact variables are set only when the prompt is used in a conversation.
+	vars := ai.PromptVars{
+		"Agent": map[string]any{
+			"FirstName": user.FirstName,
+			"LastName":  user.LastName,
+			"FullName":  user.FullName(),
+			"Email":     user.Email.String,
+		},
+	}
+	var conversationID int
+	if req.ConversationUUID != "" {
+		conv, err := enforceConversationAccess(app, req.ConversationUUID, user)
+		if err != nil {
+			return sendErrorEnvelope(r, err)
+		}
+		conversationID = conv.ID
+		vars["Conversation"] = map[string]any{
+			"ReferenceNumber": conv.ReferenceNumber,
+			"Subject":         conv.Subject.String,
+			"Priority":        conv.Priority.String,
+			"Status":          conv.Status.String,
+			"UUID":            conv.UUID,
+		}
+		vars["Contact"] = map[string]any{
+			"FirstName": conv.Contact.FirstName,
+			"LastName":  conv.Contact.LastName,
+			"FullName":  conv.Contact.FullName(),
+			"Email":     conv.Contact.Email.String,
+		}
+	}
+
+	resp, err := app.ai.Completion(user.ID, conversationID, req.PromptKey, req.Content, vars)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
 	return r.SendEnvelope(resp)
 }
 
+// handleAIDraftReply drafts a reply to a conversation from its messages, contact and the most relevant published articles.
+func handleAIDraftReply(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		uuid  = r.RequestCtx.UserValue("uuid").(string)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+		req   = aiDraftReplyReq{}
+	)
+
+	if len(r.RequestCtx.PostBody()) > 0 {
+		if err := r.Decode(&req, "json"); err != nil {
+			return sendErrorEnvelope(r, envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil))
+		}
+	}
+
+	user, err := getAuthenticatedAgent(app, auser)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	conv, err := enforceConversationAccess(app, uuid, user)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	// Drafts are only for agents who can reply in the conversation.
+	ok, err := app.authz.EnforceInScope(user, "messages", "write", conv.InboxID, conv.AssignedTeamID.Int)
+	if err != nil {
+		return sendErrorEnvelope(r, envelope.NewError(envelope.GeneralError, app.i18n.Ts("globals.messages.errorChecking", "name", "{globals.terms.permission}"), nil))
+	}
+	if !ok {
+		return sendErrorEnvelope(r, envelope.NewError(envelope.PermissionError, app.i18n.Ts("globals.messages.denied", "name", "{globals.terms.permission}"), nil))
+	}
+
+	messages, _, err := app.conversation.GetConversationMessages(conv.UUID, 1, aiDraftMaxMessages)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	dc := aimodels.DraftContext{
+		Subject: conv.Subject.String,
+		Contact: aimodels.DraftContact{
+			Name:  strings.TrimSpace(conv.Contact.FirstName + " " + conv.Contact.LastName),
+			Email: conv.Contact.Email.String,
+		},
+		Messages:     make([]aimodels.DraftMessage, 0, len(messages)),
+		Instructions: strings.TrimSpace(req.Instructions),
+	}
+
+	// Messages are newest first, private notes and activity messages are not shared with the provider.
+	var searchText strings.Builder
+	searchText.WriteString(conv.Subject.String)
+	for i := len(messages) - 1; i >= 0; i-- {
+		msg := messages[i]
+		if msg.Private || (msg.Type != cmodels.MessageIncoming && msg.Type != cmodels.MessageOutgoing) {
+			continue
+		}
+		content := msg.TextContent
+		if content == "" {
+			content = stringutil.HTML2Text(msg.Content)
+		}
+		if strings.TrimSpace(content) == "" {
+			continue
+		}
+		fromContact := msg.Type == cmodels.MessageIncoming
+		dc.Messages = append(dc.Messages, aimodels.DraftMessage{FromContact: fromContact, Content: content})
+		if fromContact {
+			searchText.WriteString(" " + content)
+		}
+	}
+
+	articles, err := app.article.SearchPublished(searchText.String(), aiDraftMaxArticles)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	for _, a := range articles {
+		dc.Articles = append(dc.Articles, aimodels.DraftArticle{
+			ID:      a.ID,
+			Title:   a.Title,
+			Content: stringutil.HTML2Text(a.Content.String),
+		})
+	}
+
+	draft, err := app.ai.DraftReply(user.ID, conv.ID, dc)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(draft)
+}
+
 // handleGetAIPrompts returns AI prompts
 func handleGetAIPrompts(r *fastglue.Request) error {
 	var (
@@ -45,6 +210,98 @@ func handleGetAIPrompts(r *fastglue.Request) error {
 	return r.SendEnvelope(resp)
 }
 
+// handleGetAIPrompt returns an AI prompt.
+func handleGetAIPrompt(r *fastglue.Request) error {
+	var (
+		app = r.Context.(*App)
+	)
+	id, err := strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	if err != nil || id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+	prompt, err := app.ai.GetPrompt(id)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(prompt)
+}
+
+// handleCreateAIPrompt creates an AI prompt.
+func handleCreateAIPrompt(r *fastglue.Request) error {
+	var (
+		app = r.Context.(*App)
+		req aiPromptReq
+	)
+	if err := r.Decode(&req, "json"); err != nil {
+		return sendErrorEnvelope(r, envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil))
+	}
+	if err := validateAIPrompt(app, &req); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	prompt, err := app.ai.CreatePrompt(req.Key, req.Title, req.Content)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(prompt)
+}
+
+// handleUpdateAIPrompt updates an AI prompt.
+func handleUpdateAIPrompt(r *fastglue.Request) error {
+	var (
+		app = r.Context.(*App)
+		req aiPromptReq
+	)
+	id, err := strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	if err != nil || id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+	if err := r.Decode(&req, "json"); err != nil {
+		return sendErrorEnvelope(r, envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil))
+	}
+	if err := validateAIPrompt(app, &req); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	prompt, err := app.ai.UpdatePrompt(id, req.Key, req.Title, req.Content)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(prompt)
+}
+
+// handleDeleteAIPrompt deletes an AI prompt.
+func handleDeleteAIPrompt(r *fastglue.Request) error {
+	var (
+		app = r.Context.(*App)
+	)
+	id, err := strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	if err != nil || id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+	if err := app.ai.DeletePrompt(id); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(true)
+}
+
+// validateAIPrompt validates an AI prompt request.
+func validateAIPrompt(app *App, req *aiPromptReq) error {
+	req.Key = strings.TrimSpace(req.Key)
+	req.Title = strings.TrimSpace(req.Title)
+	if req.Key == "" || len(req.Key) > 140 || !aiPromptKeyRe.MatchString(req.Key) {
+		return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.invalid", "name", "`key`"), nil)
+	}
+	if req.Title == "" || len(req.Title) > 140 {
+		return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.invalid", "name", "`title`"), nil)
+	}
+	if strings.TrimSpace(req.Content) == "" {
+		return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.empty", "name", "`content`"), nil)
+	}
+	if err := ai.ValidatePrompt(req.Content); err != nil {
+		return envelope.NewError(envelope.InputError, app.i18n.Ts("ai.invalidPromptVariables", "error", err.Error()), nil)
+	}
+	return nil
+}
+
 // handleUpdateAIProvider updates the AI provider
 func handleUpdateAIProvider(r *fastglue.Request) error {
 	var (
@@ -54,8 +311,192 @@ func handleUpdateAIProvider(r *fastglue.Request) error {
 	if err := r.Decode(&req, "json"); err != nil {
 		return sendErrorEnvelope(r, envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil))
 	}
-	if err := app.ai.UpdateProvider(req.Provider, req.APIKey); err != nil {
+	if err := app.ai.SetProviderAPIKey(req.Provider, req.APIKey); err != nil {
 		return sendErrorEnvelope(r, err)
 	}
 	return r.SendEnvelope("Provider updated successfully")
 }
+
+// handleGetAIProviders returns all AI providers.
+func handleGetAIProviders(r *fastglue.Request) error {
+	var (
+		app = r.Context.(*App)
+	)
+	providers, err := app.ai.GetProviders()
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(providers)
+}
+
+// handleGetAIProvider returns an AI provider.
+func handleGetAIProvider(r *fastglue.Request) error {
+	var (
+		app = r.Context.(*App)
+	)
+	id, err := strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	if err != nil || id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+	provider, err := app.ai.GetProvider(id)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(provider)
+}
+
+// handleCreateAIProvider creates an AI provider.
+func handleCreateAIProvider(r *fastglue.Request) error {
+	var (
+		app = r.Context.(*App)
+		req providerReq
+	)
+	if err := r.Decode(&req, "json"); err != nil {
+		return sendErrorEnvelope(r, envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil))
+	}
+	if err := validateAIProvider(app, &req); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	provider, err := app.ai.CreateProvider(req.Name, req.Provider, req.Config)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(provider)
+}
+
+// handleUpdateAIProviderByID updates an AI provider.
+func handleUpdateAIProviderByID(r *fastglue.Request) error {
+	var (
+		app = r.Context.(*App)
+		req providerReq
+	)
+	id, err := strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	if err != nil || id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+	if err := r.Decode(&req, "json"); err != nil {
+		return sendErrorEnvelope(r, envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil))
+	}
+	if err := validateAIProvider(app, &req); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	provider, err := app.ai.UpdateProvider(id, req.Name, req.Provider, req.Config)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(provider)
+}
+
+// handleDeleteAIProvider deletes an AI provider.
+func handleDeleteAIProvider(r *fastglue.Request) error {
+	var (
+		app = r.Context.(*App)
+	)
+	id, err := strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	if err != nil || id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+	if err := app.ai.DeleteProvider(id); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(true)
+}
+
+// handleSetDefaultAIProvider makes an AI provider the default provider used for completions.
+func handleSetDefaultAIProvider(r *fastglue.Request) error {
+	var (
+		app = r.Context.(*App)
+	)
+	id, err := strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	if err != nil || id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+	if err := app.ai.SetDefaultProvider(id); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(true)
+}
+
+// validateAIProvider validates an AI provider request.
+func validateAIProvider(app *App, req *providerReq) error {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.empty", "name", "`name`"), nil)
+	}
+	if !ai.IsValidProvider(req.Provider) {
+		return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.invalid", "name", "`provider`"), nil)
+	}
+	if req.Config.Temperature != nil && (*req.Config.Temperature < 0 || *req.Config.Temperature > 2) {
+		return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.invalid", "name", "`temperature`"), nil)
+	}
+	if req.Config.MaxTokens < 0 {
+		return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.invalid", "name", "`max_tokens`"), nil)
+	}
+	if req.Config.Timeout != "" {
+		if d, err := time.ParseDuration(req.Config.Timeout); err != nil || d <= 0 {
+			return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.invalid", "name", "`timeout`"), nil)
+		}
+	}
+	if req.Config.BaseURL != "" && !strings.HasPrefix(req.Config.BaseURL, "http://") && !strings.HasPrefix(req.Config.BaseURL, "https://") {
+		return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.invalid", "name", "`base_url`"), nil)
+	}
+	return nil
+}
+
+// handleGetAIInboxSettings returns the AI settings of an inbox.
+func handleGetAIInboxSettings(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		id, _ = strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	)
+	if id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+	settings, err := app.ai.GetInboxSettings(id)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(settings)
+}
+
+// handleUpdateAIInboxSettings creates or updates the AI settings of an inbox.
+func handleUpdateAIInboxSettings(r *fastglue.Request) error {
+	var (
+		app      = r.Context.(*App)
+		id, _    = strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+		settings = aimodels.InboxSettings{}
+	)
+	if id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+	if err := r.Decode(&settings, "json"); err != nil {
+		return sendErrorEnvelope(r, envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil))
+	}
+	if _, err := app.inbox.GetDBRecord(id); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	settings.InboxID = id
+
+	// The category attribute must be a conversation list attribute.
+	if settings.CategoryAttributeKey.String == "" {
+		settings.CategoryAttributeKey.Valid = false
+	}
+	if settings.CategoryAttributeKey.Valid {
+		attrs, err := app.customAttribute.GetAll("conversation")
+		if err != nil {
+			return sendErrorEnvelope(r, err)
+		}
+		valid := slices.ContainsFunc(attrs, func(a camodels.CustomAttribute) bool {
+			return a.Key == settings.CategoryAttributeKey.String && a.DataType == "list"
+		})
+		if !valid {
+			return sendErrorEnvelope(r, envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.invalid", "name", "`category_attribute_key`"), nil))
+		}
+	}
+
+	out, err := app.ai.UpsertInboxSettings(settings)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(out)
+}
@@ -1,13 +1,18 @@
 package main
 
 import (
+	"slices"
 	"strconv"
+	"strings"
 
+	auth_ "github.com/abhinavxd/libredesk/internal/auth"
 	amodels "github.com/abhinavxd/libredesk/internal/auth/models"
 	"github.com/abhinavxd/libredesk/internal/envelope"
+	"github.com/abhinavxd/libredesk/internal/oidc/models"
 	"github.com/abhinavxd/libredesk/internal/stringutil"
-	realip "github.com/ferluci/fast-realip"
+	umodels "github.com/abhinavxd/libredesk/internal/user/models"
 	"github.com/valyala/fasthttp"
+	"github.com/volatiletech/null/v9"
 	"github.com/zerodha/fastglue"
 )
 
@@ -54,7 +59,7 @@ func handleOIDCCallback(r *fastglue.Request) error {
 		code            = string(r.RequestCtx.QueryArgs().Peek("code"))
 		state           = string(r.RequestCtx.QueryArgs().Peek("state"))
 		providerID, err = strconv.Atoi(string(r.RequestCtx.UserValue("id").(string)))
-		ip              = realip.FromRequest(r.RequestCtx)
+		ip              = clientIP(r)
 	)
 	if err != nil {
 		app.lo.Error("error parsing provider id", "error", err)
@@ -78,18 +83,38 @@ func handleOIDCCallback(r *fastglue.Request) error {
 			app.i18n.T("globals.messages.errorExchangingToken"), nil, envelope.GeneralError)
 	}
 
-	// Lookup the user by email and set the session.
-	user, err := app.user.GetAgent(0, claims.Email)
+	provider, err := app.oidc.Get(providerID, false)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
 
+	// Lookup the user by email, create the agent if provisioning is enabled for the provider.
+	user, err := app.user.GetAgent(0, claims.Email)
+	if err != nil {
+		envErr, ok := err.(envelope.Error)
+		if !ok || envErr.ErrorType != envelope.NotFoundError || !provider.ProvisionUsers {
+			return sendErrorEnvelope(r, err)
+		}
+		if user, err = provisionOIDCUser(app, provider, claims); err != nil {
+			return sendErrorEnvelope(r, err)
+		}
+	} else {
+		// Existing agents are only linked to a provider that provisions agents or maps groups for an email verified by the provider.
+		if (provider.ProvisionUsers || len(provider.GroupMappings) > 0) && !claims.EmailVerified {
+			app.lo.Warn("unverified email in oidc login", "email", claims.Email, "provider_id", provider.ID)
+			return sendErrorEnvelope(r, envelope.NewError(envelope.PermissionError, app.i18n.T("user.emailNotVerifiedForSSOLogin"), nil))
+		}
+		if err := syncOIDCGroups(app, provider, user.ID, claims.Groups); err != nil {
+			return sendErrorEnvelope(r, err)
+		}
+	}
+
 	if err := app.auth.SaveSession(amodels.User{
 		ID:        user.ID,
 		Email:     user.Email.String,
 		FirstName: user.FirstName,
 		LastName:  user.LastName,
-	}, r); err != nil {
+	}, ip, r); err != nil {
 		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError,
 			app.i18n.Ts("globals.messages.errorSaving", "name", "{globals.terms.session}"), nil, envelope.GeneralError)
 	}
@@ -106,3 +131,101 @@ func handleOIDCCallback(r *fastglue.Request) error {
 
 	return r.Redirect("/", fasthttp.StatusFound, nil, "")
 }
+
+// provisionOIDCUser creates an agent on the first login with the provider, with the roles and teams mapped from the groups
+// or the default role of the provider, only for an email verified by the provider.
+func provisionOIDCUser(app *App, provider models.OIDC, claims auth_.OIDCclaim) (umodels.User, error) {
+	if !claims.EmailVerified {
+		app.lo.Warn("unverified email in oidc login", "email", claims.Email, "provider_id", provider.ID)
+		return umodels.User{}, envelope.NewError(envelope.PermissionError, app.i18n.T("user.emailNotVerifiedForSSOLogin"), nil)
+	}
+	email := strings.TrimSpace(strings.ToLower(claims.Email))
+	if !stringutil.ValidEmail(email) {
+		return umodels.User{}, envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.invalid", "name", "`email`"), nil)
+	}
+
+	roles, teams, err := resolveOIDCGroups(app, provider, claims.Groups)
+	if err != nil {
+		return umodels.User{}, err
+	}
+	if len(roles) == 0 {
+		app.lo.Warn("no role mapped for provisioned oidc user", "email", email, "provider_id", provider.ID, "groups", claims.Groups)
+		return umodels.User{}, envelope.NewError(envelope.PermissionError, app.i18n.T("user.noRoleForSSOLogin"), nil)
+	}
+
+	firstName, lastName := claims.GivenName, claims.FamilyName
+	if firstName == "" {
+		firstName, lastName, _ = strings.Cut(strings.TrimSpace(claims.Name), " ")
+	}
+	if firstName == "" {
+		firstName, _, _ = strings.Cut(email, "@")
+	}
+
+	user := umodels.User{
+		Email:     null.StringFrom(email),
+		FirstName: firstName,
+		LastName:  lastName,
+		Roles:     roles,
+	}
+	if err := app.user.CreateAgent(&user); err != nil {
+		return umodels.User{}, err
+	}
+	if len(teams) > 0 {
+		if err := app.team.UpsertUserTeams(user.ID, teams); err != nil {
+			return umodels.User{}, err
+		}
+	}
+	app.lo.Info("provisioned agent from oidc login", "user_id", user.ID, "email", email, "provider_id", provider.ID, "roles", roles, "teams", teams)
+	return app.user.GetAgent(user.ID, "")
+}
+
+// syncOIDCGroups updates the roles and teams of an agent to the ones mapped from the groups,
+// roles are only replaced when the groups map to at least one role or the provider has a default role so agents never lose all roles.
+func syncOIDCGroups(app *App, provider models.OIDC, userID int, groups []string) error {
+	roles, teams, err := resolveOIDCGroups(app, provider, groups)
+	if err != nil {
+		return err
+	}
+	if provider.GroupMappings.MapsRoles() {
+		if len(roles) > 0 {
+			if err := app.user.UpdateAgentRoles(userID, roles); err != nil {
+				return err
+			}
+		}
+	}
+	if provider.GroupMappings.MapsTeams() {
+		if err := app.team.UpsertUserTeams(userID, teams); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolveOIDCGroups returns the roles and teams the groups map to, falling back to the default role of the provider if no role is mapped.
+// Roles that don't exist, e.g. deleted after the mapping was saved, are skipped.
+func resolveOIDCGroups(app *App, provider models.OIDC, groups []string) ([]string, []string, error) {
+	roles, teams := provider.GroupMappings.Resolve(groups)
+	if len(roles) == 0 && provider.DefaultRole == "" {
+		return roles, teams, nil
+	}
+
+	known, err := knownRoleNames(app)
+	if err != nil {
+		return nil, nil, err
+	}
+	roles = slices.DeleteFunc(roles, func(role string) bool {
+		if slices.Contains(known, role) {
+			return false
+		}
+		app.lo.Warn("skipping unknown role mapped from oidc groups", "role", role, "provider_id", provider.ID)
+		return true
+	})
+	if len(roles) == 0 && provider.DefaultRole != "" {
+		if !slices.Contains(known, provider.DefaultRole) {
+			app.lo.Warn("skipping unknown default role of oidc provider", "role", provider.DefaultRole, "provider_id", provider.ID)
+			return roles, teams, nil
+		}
+		roles = []string{provider.DefaultRole}
+	}
+	return roles, teams, nil
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"slices"
+	"strconv"
+
+	amodels "github.com/abhinavxd/libredesk/internal/auth/models"
+	authzModels "github.com/abhinavxd/libredesk/internal/authz/models"
+	autoModels "github.com/abhinavxd/libredesk/internal/automation/models"
+	"github.com/abhinavxd/libredesk/internal/bulk"
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	"github.com/abhinavxd/libredesk/internal/stringutil"
+	umodels "github.com/abhinavxd/libredesk/internal/user/models"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+)
+
+const (
+	// maxBulkConversations is the max number of conversations a bulk action can be applied to.
+	maxBulkConversations = 1000
+	// bulkFilterPageSize is the page size used to fetch the conversations matching the filters of a bulk action.
+	bulkFilterPageSize = 100
+)
+
+// bulkActionReq is the request to apply actions to multiple conversations,
+// conversations are either listed by UUID or matched by filters.
+type bulkActionReq struct {
+	ConversationUUIDs []string                `json:"conversation_uuids"`
+	Filters           string                  `json:"filters"`
+	Actions           []autoModels.RuleAction `json:"actions"`
+	MacroID           int                     `json:"macro_id"`
+}
+
+// handleBulkConversationAction queues actions to be applied to multiple conversations in the background,
+// progress is sent to the user over the WebSocket and conversations the user cannot access are skipped.
+func handleBulkConversationAction(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+		req   = bulkActionReq{}
+	)
+	if err := r.Decode(&req, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), err.Error(), envelope.InputError)
+	}
+	if len(req.ConversationUUIDs) == 0 && req.Filters == "" {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.empty", "name", "`conversation_uuids`"), nil, envelope.InputError)
+	}
+	if len(req.ConversationUUIDs) > 0 && req.Filters != "" {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.T("bulk.uuidsOrFilters"), nil, envelope.InputError)
+	}
+	if len(req.ConversationUUIDs) > maxBulkConversations {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("bulk.tooManyConversations", "max", strconv.Itoa(maxBulkConversations)), nil, envelope.InputError)
+	}
+	if len(req.Actions) == 0 && req.MacroID == 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.empty", "name", "`actions`"), nil, envelope.InputError)
+	}
+
+	user, err := getAuthenticatedAgent(app, auser)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	// Validate actions.
+	actionTypes := make(map[string]bool, len(req.Actions))
+	for _, act := range req.Actions {
+		if actionTypes[act.Type] {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.T("macro.duplicateActionsNotAllowed"), nil, envelope.InputError)
+		}
+		actionTypes[act.Type] = true
+		if !isBulkActionAllowed(act.Type) {
+			app.lo.Warn("action not allowed in bulk action", "action", act.Type, "user_id", user.ID)
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("macro.actionNotAllowed", "name", act.Type), nil, envelope.InputError)
+		}
+		if len(act.Value) == 0 {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.empty", "name", act.Type), nil, envelope.InputError)
+		}
+		if !hasActionPermission(act.Type, user.Permissions) {
+			app.lo.Warn("no permission to execute bulk action", "action", act.Type, "user_id", user.ID)
+			return sendErrorEnvelope(r, envelope.NewError(envelope.PermissionError, app.i18n.Ts("globals.messages.denied", "name", "{globals.terms.permission}"), nil))
+		}
+	}
+	actions := req.Actions
+
+	// Macro actions are applied after the request actions.
+	if req.MacroID > 0 {
+		if !slices.Contains(user.Permissions, authzModels.PermMacrosApply) {
+			app.lo.Warn("no permission to apply macro in bulk action", "macro_id", req.MacroID, "user_id", user.ID)
+			return sendErrorEnvelope(r, envelope.NewError(envelope.PermissionError, app.i18n.Ts("globals.messages.denied", "name", "{globals.terms.permission}"), nil))
+		}
+		macro, err := app.macro.Get(req.MacroID)
+		if err != nil {
+			return sendErrorEnvelope(r, err)
+		}
+		var macroActions []autoModels.RuleAction
+		if err := json.Unmarshal(macro.Actions, &macroActions); err != nil {
+			return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.macroAction}"), nil, envelope.GeneralError)
+		}
+		if err := validateMacroActions(app, macroActions, user); err != nil {
+			return sendErrorEnvelope(r, err)
+		}
+		actions = append(actions, macroActions...)
+	}
+
+	uuids := req.ConversationUUIDs
+	if req.Filters != "" {
+		// Filters are matched against all conversations.
+		if !slices.Contains(user.Permissions, authzModels.PermConversationsReadAll) {
+			return sendErrorEnvelope(r, envelope.NewError(envelope.PermissionError, app.i18n.Ts("globals.messages.denied", "name", "{globals.terms.permission}"), nil))
+		}
+		if uuids, err = getFilteredConversationUUIDs(app, user, req.Filters); err != nil {
+			return sendErrorEnvelope(r, err)
+		}
+	}
+	uuids = stringutil.DedupAndExcludeString(uuids, "")
+	if len(uuids) == 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.T("bulk.noConversations"), nil, envelope.InputError)
+	}
+
+	jobID, err := app.bulk.Enqueue(bulk.Job{
+		User:              user,
+		Actions:           actions,
+		MacroID:           req.MacroID,
+		ConversationUUIDs: uuids,
+	})
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	return r.SendEnvelope(map[string]any{
+		"job_id": jobID,
+		"total":  len(uuids),
+	})
+}
+
+// getFilteredConversationUUIDs returns the UUIDs of the conversations matching the filters, up to maxBulkConversations.
+func getFilteredConversationUUIDs(app *App, user umodels.User, filters string) ([]string, error) {
+	uuids := make([]string, 0)
+	for page := 1; len(uuids) < maxBulkConversations; page++ {
+		conversations, err := app.conversation.GetAllConversationsList(user, "", "", filters, page, bulkFilterPageSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range conversations {
+			uuids = append(uuids, c.UUID)
+		}
+		if len(conversations) < bulkFilterPageSize {
+			break
+		}
+	}
+	if len(uuids) > maxBulkConversations {
+		uuids = uuids[:maxBulkConversations]
+	}
+	return uuids, nil
+}
+
+// isBulkActionAllowed checks if the action can be applied in a bulk action, replies and notes are only applied through macros.
+func isBulkActionAllowed(action string) bool {
+	switch action {
+	case autoModels.ActionAssignTeam, autoModels.ActionAssignUser, autoModels.ActionSetStatus, autoModels.ActionSetPriority,
+		autoModels.ActionAddTags, autoModels.ActionSetTags, autoModels.ActionRemoveTags:
+		return true
+	default:
+		return false
+	}
+}
@@ -1,11 +1,19 @@
 package main
 
 import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	businessHours "github.com/abhinavxd/libredesk/internal/business_hours"
 	models "github.com/abhinavxd/libredesk/internal/business_hours/models"
 	"github.com/abhinavxd/libredesk/internal/envelope"
+	"github.com/abhinavxd/libredesk/internal/safehttp"
+	"github.com/jmoiron/sqlx/types"
 	"github.com/valyala/fasthttp"
 	"github.com/zerodha/fastglue"
 )
@@ -54,8 +62,11 @@ func handleCreateBusinessHours(r *fastglue.Request) error {
 	if businessHours.Name == "" {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.empty", "name", "`name`"), nil, envelope.InputError)
 	}
+	if err := validateSpecialHours(app, &businessHours); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
 
-	createdBusinessHours, err := app.businessHours.Create(businessHours.Name, businessHours.Description, businessHours.IsAlwaysOpen, businessHours.Hours, businessHours.Holidays)
+	createdBusinessHours, err := app.businessHours.Create(businessHours.Name, businessHours.Description, businessHours.IsAlwaysOpen, businessHours.Hours, businessHours.Holidays, businessHours.SpecialHours)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
@@ -94,9 +105,106 @@ func handleUpdateBusinessHours(r *fastglue.Request) error {
 	if businessHours.Name == "" {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`name`"), nil, envelope.InputError)
 	}
-	updatedBusinessHours, err := app.businessHours.Update(id, businessHours.Name, businessHours.Description, businessHours.IsAlwaysOpen, businessHours.Hours, businessHours.Holidays)
+	if err := validateSpecialHours(app, &businessHours); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	updatedBusinessHours, err := app.businessHours.Update(id, businessHours.Name, businessHours.Description, businessHours.IsAlwaysOpen, businessHours.Hours, businessHours.Holidays, businessHours.SpecialHours)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
 	return r.SendEnvelope(updatedBusinessHours)
 }
+
+// handleImportHolidays parses holidays from an uploaded iCalendar file or an iCalendar URL and returns them,
+// the holidays are saved along with the business hours.
+func handleImportHolidays(r *fastglue.Request) error {
+	var (
+		app = r.Context.(*App)
+		src io.Reader
+	)
+	form, err := r.RequestCtx.MultipartForm()
+	if err != nil {
+		app.lo.Error("error parsing form data", "error", err)
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil, envelope.InputError)
+	}
+
+	if files, ok := form.File["file"]; ok && len(files) > 0 {
+		if files[0].Size > maxICSSize {
+			return r.SendErrorEnvelope(fasthttp.StatusRequestEntityTooLarge, app.i18n.Ts("media.fileSizeTooLarge", "size", "1MB"), nil, envelope.GeneralError)
+		}
+		file, err := files[0].Open()
+		if err != nil {
+			app.lo.Error("error reading uploaded ics file", "error", err)
+			return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, app.i18n.Ts("globals.messages.errorParsing", "name", "`file`"), nil, envelope.GeneralError)
+		}
+		defer file.Close()
+		src = file
+	} else if v, ok := form.Value["url"]; ok && len(v) > 0 && v[0] != "" {
+		body, err := fetchICS(v[0])
+		if err != nil {
+			app.lo.Error("error fetching ics url", "url", v[0], "error", err)
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`url`"), nil, envelope.InputError)
+		}
+		defer body.Close()
+		src = io.LimitReader(body, maxICSSize)
+	} else {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.empty", "name", "`file`"), nil, envelope.InputError)
+	}
+
+	holidays, err := businessHours.ParseICS(src)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`file`"), nil, envelope.InputError)
+	}
+	return r.SendEnvelope(holidays)
+}
+
+// maxICSSize is the maximum size of an imported iCalendar file.
+const maxICSSize = 1 << 20
+
+// fetchICS downloads an iCalendar file from a public address, the caller must close the returned body.
+func fetchICS(url string) (io.ReadCloser, error) {
+	url = strings.TrimSpace(url)
+	// webcal:// is the de facto scheme for calendar subscriptions.
+	if strings.HasPrefix(url, "webcal://") {
+		url = "https://" + strings.TrimPrefix(url, "webcal://")
+	}
+	if !strings.HasPrefix(url, "https://") && !strings.HasPrefix(url, "http://") {
+		return nil, fmt.Errorf("unsupported url scheme")
+	}
+	// The URL is supplied by the user, only public addresses may be fetched.
+	resp, err := safehttp.NewClient(10 * time.Second).Get(url)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return resp.Body, nil
+}
+
+// validateSpecialHours validates the special hours of business hours and defaults them to an empty list.
+func validateSpecialHours(app *App, bh *models.BusinessHours) error {
+	if len(bh.SpecialHours) == 0 || string(bh.SpecialHours) == "null" {
+		bh.SpecialHours = types.JSONText("[]")
+		return nil
+	}
+	var specialHours []models.SpecialHours
+	if err := json.Unmarshal(bh.SpecialHours, &specialHours); err != nil {
+		return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.invalid", "name", "`special_hours`"), nil)
+	}
+	for _, sh := range specialHours {
+		if _, err := time.Parse(time.DateOnly, sh.Date); err != nil {
+			return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.invalid", "name", "`date`"), nil)
+		}
+		openAt, err := time.Parse("15:04", sh.Open)
+		if err != nil {
+			return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.invalid", "name", "`open`"), nil)
+		}
+		closeAt, err := time.Parse("15:04", sh.Close)
+		if err != nil || !closeAt.After(openAt) {
+			return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.invalid", "name", "`close`"), nil)
+		}
+	}
+	return nil
+}
@@ -224,7 +224,7 @@ func handleDeleteContactNote(r *fastglue.Request) error {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`note_id`"), nil, envelope.InputError)
 	}
 
-	agent, err := app.user.GetAgent(auser.ID, "")
+	agent, err := getAuthenticatedAgent(app, auser)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
@@ -35,6 +35,10 @@ type statusUpdateReq struct {
 	SnoozedUntil string `json:"snoozed_until,omitempty"`
 }
 
+type mergeConversationReq struct {
+	TargetUUID string `json:"target_uuid"`
+}
+
 type tagsUpdateReq struct {
 	Tags []string `json:"tags"`
 }
@@ -55,6 +59,7 @@ type createConversationRequest struct {
 func handleGetAllConversations(r *fastglue.Request) error {
 	var (
 		app         = r.Context.(*App)
+		auser       = r.RequestCtx.UserValue("user").(amodels.User)
 		order       = string(r.RequestCtx.QueryArgs().Peek("order"))
 		orderBy     = string(r.RequestCtx.QueryArgs().Peek("order_by"))
 		page, _     = strconv.Atoi(string(r.RequestCtx.QueryArgs().Peek("page")))
@@ -63,7 +68,12 @@ func handleGetAllConversations(r *fastglue.Request) error {
 		total       = 0
 	)
 
-	conversations, err := app.conversation.GetAllConversationsList(order, orderBy, filters, page, pageSize)
+	user, err := getAuthenticatedAgent(app, auser)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	conversations, err := app.conversation.GetAllConversationsList(user, order, orderBy, filters, page, pageSize)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
@@ -85,7 +95,7 @@ func handleGetAllConversations(r *fastglue.Request) error {
 func handleGetAssignedConversations(r *fastglue.Request) error {
 	var (
 		app         = r.Context.(*App)
-		user        = r.RequestCtx.UserValue("user").(amodels.User)
+		auser       = r.RequestCtx.UserValue("user").(amodels.User)
 		order       = string(r.RequestCtx.QueryArgs().Peek("order"))
 		orderBy     = string(r.RequestCtx.QueryArgs().Peek("order_by"))
 		filters     = string(r.RequestCtx.QueryArgs().Peek("filters"))
@@ -93,7 +103,11 @@ func handleGetAssignedConversations(r *fastglue.Request) error {
 		pageSize, _ = strconv.Atoi(string(r.RequestCtx.QueryArgs().Peek("page_size")))
 		total       = 0
 	)
-	conversations, err := app.conversation.GetAssignedConversationsList(user.ID, order, orderBy, filters, page, pageSize)
+	user, err := getAuthenticatedAgent(app, auser)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	conversations, err := app.conversation.GetAssignedConversationsList(user, order, orderBy, filters, page, pageSize)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
@@ -114,6 +128,7 @@ func handleGetAssignedConversations(r *fastglue.Request) error {
 func handleGetUnassignedConversations(r *fastglue.Request) error {
 	var (
 		app         = r.Context.(*App)
+		auser       = r.RequestCtx.UserValue("user").(amodels.User)
 		order       = string(r.RequestCtx.QueryArgs().Peek("order"))
 		orderBy     = string(r.RequestCtx.QueryArgs().Peek("order_by"))
 		filters     = string(r.RequestCtx.QueryArgs().Peek("filters"))
@@ -122,7 +137,12 @@ func handleGetUnassignedConversations(r *fastglue.Request) error {
 		total       = 0
 	)
 
-	conversations, err := app.conversation.GetUnassignedConversationsList(order, orderBy, filters, page, pageSize)
+	user, err := getAuthenticatedAgent(app, auser)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	conversations, err := app.conversation.GetUnassignedConversationsList(user, order, orderBy, filters, page, pageSize)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
@@ -164,7 +184,7 @@ func handleGetViewConversations(r *fastglue.Request) error {
 		return r.SendErrorEnvelope(fasthttp.StatusForbidden, app.i18n.T("conversation.viewPermissionDenied"), nil, envelope.PermissionError)
 	}
 
-	user, err := app.user.GetAgent(auser.ID, "")
+	user, err := getAuthenticatedAgent(app, auser)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
@@ -193,7 +213,7 @@ func handleGetViewConversations(r *fastglue.Request) error {
 		return r.SendErrorEnvelope(fasthttp.StatusForbidden, app.i18n.Ts("globals.messages.denied", "name", "{globals.terms.permission}"), nil, envelope.PermissionError)
 	}
 
-	conversations, err := app.conversation.GetViewConversationsList(user.ID, user.Teams.IDs(), lists, order, orderBy, string(view.Filters), page, pageSize)
+	conversations, err := app.conversation.GetViewConversationsList(user, lists, order, orderBy, string(view.Filters), page, pageSize)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
@@ -238,7 +258,12 @@ func handleGetTeamUnassignedConversations(r *fastglue.Request) error {
 		return sendErrorEnvelope(r, envelope.NewError(envelope.PermissionError, app.i18n.T("conversation.notMemberOfTeam"), nil))
 	}
 
-	conversations, err := app.conversation.GetTeamUnassignedConversationsList(teamID, order, orderBy, filters, page, pageSize)
+	user, err := getAuthenticatedAgent(app, auser)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	conversations, err := app.conversation.GetTeamUnassignedConversationsList(user, teamID, order, orderBy, filters, page, pageSize)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
@@ -263,7 +288,7 @@ func handleGetConversation(r *fastglue.Request) error {
 		auser = r.RequestCtx.UserValue("user").(amodels.User)
 	)
 
-	user, err := app.user.GetAgent(auser.ID, "")
+	user, err := getAuthenticatedAgent(app, auser)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
@@ -273,7 +298,7 @@ func handleGetConversation(r *fastglue.Request) error {
 		return sendErrorEnvelope(r, err)
 	}
 
-	prev, _ := app.conversation.GetContactConversations(conv.ContactID)
+	prev, _ := app.conversation.GetContactConversations(conv.ContactID, user.RoleScopes.For(authzModels.PermConversationsRead))
 	conv.PreviousConversations = filterCurrentConv(prev, conv.UUID)
 	return r.SendEnvelope(conv)
 }
@@ -285,7 +310,7 @@ func handleUpdateConversationAssigneeLastSeen(r *fastglue.Request) error {
 		uuid  = r.RequestCtx.UserValue("uuid").(string)
 		auser = r.RequestCtx.UserValue("user").(amodels.User)
 	)
-	user, err := app.user.GetAgent(auser.ID, "")
+	user, err := getAuthenticatedAgent(app, auser)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
@@ -306,7 +331,7 @@ func handleGetConversationParticipants(r *fastglue.Request) error {
 		uuid  = r.RequestCtx.UserValue("uuid").(string)
 		auser = r.RequestCtx.UserValue("user").(amodels.User)
 	)
-	user, err := app.user.GetAgent(auser.ID, "")
+	user, err := getAuthenticatedAgent(app, auser)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
@@ -321,6 +346,31 @@ func handleGetConversationParticipants(r *fastglue.Request) error {
 	return r.SendEnvelope(p)
 }
 
+// handleGetConversationSLAPauses returns the SLA pause intervals recorded for a conversation's applied SLA.
+func handleGetConversationSLAPauses(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		uuid  = r.RequestCtx.UserValue("uuid").(string)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+	)
+	user, err := getAuthenticatedAgent(app, auser)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	conversation, err := enforceConversationAccess(app, uuid, user)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if !conversation.AppliedSLAID.Valid {
+		return r.SendEnvelope([]any{})
+	}
+	pauses, err := app.sla.GetPauses(conversation.AppliedSLAID.Int)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(pauses)
+}
+
 // handleUpdateUserAssignee updates the user assigned to a conversation.
 func handleUpdateUserAssignee(r *fastglue.Request) error {
 	var (
@@ -335,7 +385,7 @@ func handleUpdateUserAssignee(r *fastglue.Request) error {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil, envelope.InputError)
 	}
 
-	user, err := app.user.GetAgent(auser.ID, "")
+	user, err := getAuthenticatedAgent(app, auser)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
@@ -373,7 +423,7 @@ func handleUpdateTeamAssignee(r *fastglue.Request) error {
 
 	assigneeID := req.AssigneeID
 
-	user, err := app.user.GetAgent(auser.ID, "")
+	user, err := getAuthenticatedAgent(app, auser)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
@@ -418,7 +468,7 @@ func handleUpdateConversationPriority(r *fastglue.Request) error {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.empty", "name", "`priority`"), nil, envelope.InputError)
 	}
 
-	user, err := app.user.GetAgent(auser.ID, "")
+	user, err := getAuthenticatedAgent(app, auser)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
@@ -465,7 +515,7 @@ func handleUpdateConversationStatus(r *fastglue.Request) error {
 	}
 
 	// Enforce conversation access.
-	user, err := app.user.GetAgent(auser.ID, "")
+	user, err := getAuthenticatedAgent(app, auser)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
@@ -516,7 +566,7 @@ func handleUpdateConversationtags(r *fastglue.Request) error {
 
 	tagNames := req.Tags
 
-	user, err := app.user.GetAgent(auser.ID, "")
+	user, err := getAuthenticatedAgent(app, auser)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
@@ -531,6 +581,75 @@ func handleUpdateConversationtags(r *fastglue.Request) error {
 	return r.SendEnvelope(true)
 }
 
+// handleMergeConversation merges the conversation into a target conversation.
+func handleMergeConversation(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+		uuid  = r.RequestCtx.UserValue("uuid").(string)
+		req   = mergeConversationReq{}
+	)
+	if err := r.Decode(&req, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil, envelope.InputError)
+	}
+	if req.TargetUUID == "" {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.empty", "name", "`target_uuid`"), nil, envelope.InputError)
+	}
+
+	user, err := getAuthenticatedAgent(app, auser)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	// User must have access to both conversations.
+	if _, err := enforceConversationAccess(app, uuid, user); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if _, err := enforceConversationAccess(app, req.TargetUUID, user); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	if err := app.conversation.MergeConversations(uuid, req.TargetUUID, user); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(true)
+}
+
+// handleSplitMessage moves a message of the conversation into a new conversation.
+func handleSplitMessage(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+		cuuid = r.RequestCtx.UserValue("cuuid").(string)
+		uuid  = r.RequestCtx.UserValue("uuid").(string)
+	)
+	user, err := getAuthenticatedAgent(app, auser)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	conversation, err := enforceConversationAccess(app, cuuid, user)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	// Make sure the message belongs to the conversation.
+	message, err := app.conversation.GetMessage(uuid)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if message.ConversationID != conversation.ID {
+		return r.SendErrorEnvelope(fasthttp.StatusNotFound, app.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.message}"), nil, envelope.NotFoundError)
+	}
+
+	newUUID, err := app.conversation.SplitMessage(uuid, user)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(map[string]string{
+		"uuid": newUUID,
+	})
+}
+
 // handleUpdateConversationCustomAttributes updates custom attributes of a conversation.
 func handleUpdateConversationCustomAttributes(r *fastglue.Request) error {
 	var (
@@ -545,7 +664,7 @@ func handleUpdateConversationCustomAttributes(r *fastglue.Request) error {
 	}
 
 	// Enforce conversation access.
-	user, err := app.user.GetAgent(auser.ID, "")
+	user, err := getAuthenticatedAgent(app, auser)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
@@ -575,7 +694,7 @@ func handleUpdateContactCustomAttributes(r *fastglue.Request) error {
 	}
 
 	// Enforce conversation access.
-	user, err := app.user.GetAgent(auser.ID, "")
+	user, err := getAuthenticatedAgent(app, auser)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
@@ -614,7 +733,7 @@ func handleRemoveUserAssignee(r *fastglue.Request) error {
 		uuid  = r.RequestCtx.UserValue("uuid").(string)
 		auser = r.RequestCtx.UserValue("user").(amodels.User)
 	)
-	user, err := app.user.GetAgent(auser.ID, "")
+	user, err := getAuthenticatedAgent(app, auser)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
@@ -635,7 +754,7 @@ func handleRemoveTeamAssignee(r *fastglue.Request) error {
 		uuid  = r.RequestCtx.UserValue("uuid").(string)
 		auser = r.RequestCtx.UserValue("user").(amodels.User)
 	)
-	user, err := app.user.GetAgent(auser.ID, "")
+	user, err := getAuthenticatedAgent(app, auser)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
@@ -691,7 +810,7 @@ func handleCreateConversation(r *fastglue.Request) error {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`contact_email`"), nil, envelope.InputError)
 	}
 
-	user, err := app.user.GetAgent(auser.ID, "")
+	user, err := getAuthenticatedAgent(app, auser)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
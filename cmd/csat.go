@@ -1,11 +1,40 @@
 package main
 
 import (
+	"regexp"
 	"strconv"
+	"strings"
 
+	"github.com/abhinavxd/libredesk/internal/csat/models"
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	"github.com/valyala/fasthttp"
 	"github.com/zerodha/fastglue"
 )
 
+const (
+	// csatAnswerFieldPrefix is the form field prefix of the answers to follow-up questions.
+	csatAnswerFieldPrefix = "q_"
+	maxCSATQuestions      = 10
+)
+
+var csatQuestionKeyRe = regexp.MustCompile(`^[a-z0-9_]{1,50}$`)
+
+// csatScaleOption is a selectable score on the public CSAT page.
+type csatScaleOption struct {
+	Value int
+	Label string
+	Emoji string
+}
+
+// csatStarOptions are the options of the star rating survey.
+var csatStarOptions = []csatScaleOption{
+	{Value: 1, Label: "Poor", Emoji: "😢"},
+	{Value: 2, Label: "Fair", Emoji: "😕"},
+	{Value: 3, Label: "Good", Emoji: "😊"},
+	{Value: 4, Label: "Great", Emoji: "😃"},
+	{Value: 5, Label: "Excellent", Emoji: "🤩"},
+}
+
 // handleShowCSAT renders the CSAT page for a given csat.
 func handleShowCSAT(r *fastglue.Request) error {
 	var (
@@ -40,11 +69,28 @@ func handleShowCSAT(r *fastglue.Request) error {
 		})
 	}
 
+	// Rating questions are rated 1-5.
+	questions := make([]models.SurveyQuestion, 0, len(csat.Questions))
+	for _, q := range csat.Questions {
+		if q.Type == models.QuestionTypeRating {
+			q.Options = []string{"1", "2", "3", "4", "5"}
+		}
+		questions = append(questions, q)
+	}
+
+	question, lowLabel, highLabel, options := csatScale(csat.SurveyType)
 	return app.tmpl.RenderWebPage(r.RequestCtx, "csat", map[string]interface{}{
 		"Data": map[string]interface{}{
-			"Title":    "Rate your interaction with us",
+			"Title": "Rate your interaction with us",
 			"CSAT": map[string]interface{}{
-				"UUID": csat.UUID,
+				"UUID":        csat.UUID,
+				"SurveyType":  csat.SurveyType,
+				"Question":    question,
+				"LowLabel":    lowLabel,
+				"HighLabel":   highLabel,
+				"Options":     options,
+				"Questions":   questions,
+				"FieldPrefix": csatAnswerFieldPrefix,
 			},
 			"Conversation": map[string]interface{}{
 				"Subject":         conversation.Subject.String,
@@ -61,6 +107,7 @@ func handleUpdateCSATResponse(r *fastglue.Request) error {
 		uuid     = r.RequestCtx.UserValue("uuid").(string)
 		rating   = r.RequestCtx.FormValue("rating")
 		feedback = string(r.RequestCtx.FormValue("feedback"))
+		answers  = make(map[string]string)
 	)
 
 	ratingI, err := strconv.Atoi(string(rating))
@@ -72,14 +119,6 @@ func handleUpdateCSATResponse(r *fastglue.Request) error {
 		})
 	}
 
-	if ratingI < 1 || ratingI > 5 {
-		return app.tmpl.RenderWebPage(r.RequestCtx, "error", map[string]interface{}{
-			"Data": map[string]interface{}{
-				"ErrorMessage": "Invalid `rating`",
-			},
-		})
-	}
-
 	if uuid == "" {
 		return app.tmpl.RenderWebPage(r.RequestCtx, "error", map[string]interface{}{
 			"Data": map[string]interface{}{
@@ -88,7 +127,15 @@ func handleUpdateCSATResponse(r *fastglue.Request) error {
 		})
 	}
 
-	if err := app.csat.UpdateResponse(uuid, ratingI, feedback); err != nil {
+	// Collect the answers to the follow-up questions.
+	r.RequestCtx.PostArgs().VisitAll(func(key, value []byte) {
+		if k, ok := strings.CutPrefix(string(key), csatAnswerFieldPrefix); ok && k != "" {
+			answers[k] = string(value)
+		}
+	})
+
+	// Rating range depends on the survey type and is validated by the manager.
+	if err := app.csat.UpdateResponse(uuid, ratingI, feedback, answers); err != nil {
 		return app.tmpl.RenderWebPage(r.RequestCtx, "error", map[string]interface{}{
 			"Data": map[string]interface{}{
 				"ErrorMessage": err.Error(),
@@ -103,3 +150,96 @@ func handleUpdateCSATResponse(r *fastglue.Request) error {
 		},
 	})
 }
+
+// handleGetCSATSurvey returns the CSAT survey of an inbox.
+func handleGetCSATSurvey(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		id, _ = strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	)
+	if id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+	survey, err := app.csat.GetSurvey(id)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(survey)
+}
+
+// handleUpdateCSATSurvey creates or updates the CSAT survey of an inbox.
+func handleUpdateCSATSurvey(r *fastglue.Request) error {
+	var (
+		app    = r.Context.(*App)
+		id, _  = strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+		survey = models.Survey{}
+	)
+	if id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+	if err := r.Decode(&survey, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil, envelope.InputError)
+	}
+	if _, err := app.inbox.GetDBRecord(id); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if err := validateCSATSurvey(app, survey); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	updated, err := app.csat.UpsertSurvey(id, survey.Type, survey.Questions)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(updated)
+}
+
+// validateCSATSurvey validates the survey type and follow-up questions of a CSAT survey.
+func validateCSATSurvey(app *App, survey models.Survey) error {
+	switch survey.Type {
+	case models.SurveyTypeStars, models.SurveyTypeNPS, models.SurveyTypeCES:
+	default:
+		return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.invalid", "name", "`type`"), nil)
+	}
+	if len(survey.Questions) > maxCSATQuestions {
+		return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.invalid", "name", "`questions`"), nil)
+	}
+	keys := make(map[string]struct{}, len(survey.Questions))
+	for _, q := range survey.Questions {
+		if _, ok := keys[q.Key]; ok || !csatQuestionKeyRe.MatchString(q.Key) {
+			return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.invalid", "name", "`key`"), nil)
+		}
+		keys[q.Key] = struct{}{}
+		if q.Label == "" {
+			return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.empty", "name", "`label`"), nil)
+		}
+		switch q.Type {
+		case models.QuestionTypeText, models.QuestionTypeRating:
+		case models.QuestionTypeChoice:
+			if len(q.Options) == 0 {
+				return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.empty", "name", "`options`"), nil)
+			}
+		default:
+			return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.invalid", "name", "`type`"), nil)
+		}
+	}
+	return nil
+}
+
+// csatScale returns the question, scale labels and options rendered on the public CSAT page for a survey type.
+func csatScale(surveyType string) (string, string, string, []csatScaleOption) {
+	switch surveyType {
+	case models.SurveyTypeNPS, models.SurveyTypeCES:
+		question, low, high := "How likely are you to recommend us to a friend or colleague?", "Not at all likely", "Extremely likely"
+		if surveyType == models.SurveyTypeCES {
+			question, low, high = "How easy was it to get your issue resolved?", "Very difficult", "Very easy"
+		}
+		minScore, maxScore := models.ScoreRange(surveyType)
+		options := make([]csatScaleOption, 0, maxScore-minScore+1)
+		for i := minScore; i <= maxScore; i++ {
+			options = append(options, csatScaleOption{Value: i, Label: strconv.Itoa(i)})
+		}
+		return question, low, high, options
+	default:
+		return "Rate your recent interaction", "", "", csatStarOptions
+	}
+}
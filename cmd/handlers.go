@@ -13,12 +13,18 @@ import (
 )
 
 // initHandlers initializes the HTTP routes and handlers for the application.
-func initHandlers(g *fastglue.Fastglue, hub *ws.Hub) {
+func initHandlers(g *fastglue.Fastglue, app *App, hub *ws.Hub) {
 	// Authentication.
 	g.POST("/api/v1/auth/login", handleLogin)
+	g.POST("/api/v1/auth/2fa/verify", handleTwoFactorLogin)
+	g.POST("/api/v1/auth/2fa/setup", handleTwoFactorLoginSetup)
+	g.POST("/api/v1/auth/2fa/setup/verify", handleTwoFactorLoginSetupVerify)
 	g.GET("/logout", auth(handleLogout))
 	g.GET("/api/v1/oidc/{id}/login", handleOIDCLogin)
 	g.GET("/api/v1/oidc/{id}/finish", handleOIDCCallback)
+	g.GET("/api/v1/saml/{id}/login", handleSAMLLogin)
+	g.GET("/api/v1/saml/{id}/metadata", handleSAMLMetadata)
+	g.POST("/api/v1/saml/{id}/acs", handleSAMLACS)
 
 	// i18n.
 	g.GET("/api/v1/lang/{lang}", handleGetI18nLang)
@@ -34,6 +40,8 @@ func initHandlers(g *fastglue.Fastglue, hub *ws.Hub) {
 	g.PUT("/api/v1/settings/notifications/email", perm(handleUpdateEmailNotificationSettings, "notification_settings:manage"))
 	g.GET("/api/v1/settings/article", handleGetArticleSettings)
 	g.PUT("/api/v1/settings/article", perm(handleUpdateArticleSettings, "article_setting:manage"))
+	g.GET("/api/v1/settings/ai", perm(handleGetAISettings, "ai:manage"))
+	g.PUT("/api/v1/settings/ai", perm(handleUpdateAISettings, "ai:manage"))
 
 	// OpenID connect single sign-on.
 	g.GET("/api/v1/oidc/enabled", handleGetAllEnabledOIDC)
@@ -43,6 +51,39 @@ func initHandlers(g *fastglue.Fastglue, hub *ws.Hub) {
 	g.PUT("/api/v1/oidc/{id}", perm(handleUpdateOIDC, "oidc:manage"))
 	g.DELETE("/api/v1/oidc/{id}", perm(handleDeleteOIDC, "oidc:manage"))
 
+	// SAML single sign-on.
+	g.GET("/api/v1/saml/enabled", handleGetAllEnabledSAML)
+	g.GET("/api/v1/saml", perm(handleGetAllSAML, "oidc:manage"))
+	g.POST("/api/v1/saml", perm(handleCreateSAML, "oidc:manage"))
+	g.GET("/api/v1/saml/{id}", perm(handleGetSAML, "oidc:manage"))
+	g.PUT("/api/v1/saml/{id}", perm(handleUpdateSAML, "oidc:manage"))
+	g.DELETE("/api/v1/saml/{id}", perm(handleDeleteSAML, "oidc:manage"))
+
+	// Password policy settings.
+	g.GET("/api/v1/settings/password-policy", perm(handleGetPasswordPolicy, "users:manage"))
+	g.PUT("/api/v1/settings/password-policy", perm(handleUpdatePasswordPolicy, "users:manage"))
+
+	// SCIM settings.
+	g.GET("/api/v1/settings/scim", perm(handleGetSCIMSettings, "users:manage"))
+	g.PUT("/api/v1/settings/scim", perm(handleUpdateSCIMSettings, "users:manage"))
+	g.POST("/api/v1/settings/scim/token", perm(handleGenerateSCIMToken, "users:manage"))
+	g.DELETE("/api/v1/settings/scim/token", perm(handleRevokeSCIMToken, "users:manage"))
+
+	// SCIM provisioning, authenticated with the SCIM bearer token.
+	g.GET("/scim/v2/ServiceProviderConfig", scimAuth(handleSCIMServiceProviderConfig))
+	g.GET("/scim/v2/Users", scimAuth(handleSCIMGetUsers))
+	g.POST("/scim/v2/Users", scimAuth(handleSCIMCreateUser))
+	g.GET("/scim/v2/Users/{id}", scimAuth(handleSCIMGetUser))
+	g.PUT("/scim/v2/Users/{id}", scimAuth(handleSCIMReplaceUser))
+	patch(g, app, "/scim/v2/Users/{id}", scimAuth(handleSCIMPatchUser))
+	g.DELETE("/scim/v2/Users/{id}", scimAuth(handleSCIMDeleteUser))
+	g.GET("/scim/v2/Groups", scimAuth(handleSCIMGetGroups))
+	g.POST("/scim/v2/Groups", scimAuth(handleSCIMCreateGroup))
+	g.GET("/scim/v2/Groups/{id}", scimAuth(handleSCIMGetGroup))
+	g.PUT("/scim/v2/Groups/{id}", scimAuth(handleSCIMReplaceGroup))
+	patch(g, app, "/scim/v2/Groups/{id}", scimAuth(handleSCIMPatchGroup))
+	g.DELETE("/scim/v2/Groups/{id}", scimAuth(handleSCIMDeleteGroup))
+
 	// Conversations.
 	g.GET("/api/v1/conversations/all", perm(handleGetAllConversations, "conversations:read_all"))
 	g.GET("/api/v1/conversations/unassigned", perm(handleGetUnassignedConversations, "conversations:read_unassigned"))
@@ -51,6 +92,7 @@ func initHandlers(g *fastglue.Fastglue, hub *ws.Hub) {
 	g.GET("/api/v1/views/{id}/conversations", perm(handleGetViewConversations, "conversations:read"))
 	g.GET("/api/v1/conversations/{uuid}", perm(handleGetConversation, "conversations:read"))
 	g.GET("/api/v1/conversations/{uuid}/participants", perm(handleGetConversationParticipants, "conversations:read"))
+	g.GET("/api/v1/conversations/{uuid}/sla/pauses", perm(handleGetConversationSLAPauses, "conversations:read"))
 	g.PUT("/api/v1/conversations/{uuid}/assignee/user", perm(handleUpdateUserAssignee, "conversations:update_user_assignee"))
 	g.PUT("/api/v1/conversations/{uuid}/assignee/team", perm(handleUpdateTeamAssignee, "conversations:update_team_assignee"))
 	g.PUT("/api/v1/conversations/{uuid}/assignee/user/remove", perm(handleRemoveUserAssignee, "conversations:update_user_assignee"))
@@ -63,9 +105,15 @@ func initHandlers(g *fastglue.Fastglue, hub *ws.Hub) {
 	g.GET("/api/v1/conversations/{uuid}/messages", perm(handleGetMessages, "messages:read"))
 	g.POST("/api/v1/conversations/{cuuid}/messages", perm(handleSendMessage, "messages:write"))
 	g.PUT("/api/v1/conversations/{cuuid}/messages/{uuid}/retry", perm(handleRetryMessage, "messages:write"))
+	g.PUT("/api/v1/conversations/{cuuid}/messages/{uuid}/cancel", perm(handleCancelMessage, "messages:write"))
+	g.PUT("/api/v1/conversations/{cuuid}/messages/{uuid}/schedule", perm(handleUpdateScheduledMessage, "messages:write"))
+	g.DELETE("/api/v1/conversations/{cuuid}/messages/{uuid}/schedule", perm(handleCancelScheduledMessage, "messages:write"))
 	g.POST("/api/v1/conversations", perm(handleCreateConversation, "conversations:write"))
-	g.PUT("/api/v1/conversations/{uuid}/custom-attributes", auth(handleUpdateConversationCustomAttributes))
-	g.PUT("/api/v1/conversations/{uuid}/contacts/custom-attributes", auth(handleUpdateContactCustomAttributes))
+	g.POST("/api/v1/conversations/bulk", auth(handleBulkConversationAction))
+	g.POST("/api/v1/conversations/{uuid}/merge", perm(handleMergeConversation, "conversations:merge"))
+	g.POST("/api/v1/conversations/{cuuid}/messages/{uuid}/split", perm(handleSplitMessage, "conversations:merge"))
+	g.PUT("/api/v1/conversations/{uuid}/custom-attributes", perm(handleUpdateConversationCustomAttributes, "conversations:update_custom_attributes"))
+	g.PUT("/api/v1/conversations/{uuid}/contacts/custom-attributes", perm(handleUpdateContactCustomAttributes, "contacts:update_custom_attributes"))
 
 	// Search.
 	g.GET("/api/v1/conversations/search", perm(handleSearchConversations, "conversations:read"))
@@ -86,18 +134,18 @@ func initHandlers(g *fastglue.Fastglue, hub *ws.Hub) {
 	g.GET("/api/v1/priorities", auth(handleGetPriorities))
 
 	// Tags.
-	g.GET("/api/v1/tags", auth(handleGetTags))
+	g.GET("/api/v1/tags", perm(handleGetTags, "tags:read"))
 	g.POST("/api/v1/tags", perm(handleCreateTag, "tags:manage"))
 	g.PUT("/api/v1/tags/{id}", perm(handleUpdateTag, "tags:manage"))
 	g.DELETE("/api/v1/tags/{id}", perm(handleDeleteTag, "tags:manage"))
 
 	// Macros.
-	g.GET("/api/v1/macros", auth(handleGetMacros))
+	g.GET("/api/v1/macros", perm(handleGetMacros, "macros:read"))
 	g.GET("/api/v1/macros/{id}", perm(handleGetMacro, "macros:manage"))
 	g.POST("/api/v1/macros", perm(handleCreateMacro, "macros:manage"))
 	g.PUT("/api/v1/macros/{id}", perm(handleUpdateMacro, "macros:manage"))
 	g.DELETE("/api/v1/macros/{id}", perm(handleDeleteMacro, "macros:manage"))
-	g.POST("/api/v1/conversations/{uuid}/macros/{id}/apply", auth(handleApplyMacro))
+	g.POST("/api/v1/conversations/{uuid}/macros/{id}/apply", perm(handleApplyMacro, "macros:apply"))
 
 	// Agents.
 	g.GET("/api/v1/agents/me", auth(handleGetCurrentAgent))
@@ -105,6 +153,11 @@ func initHandlers(g *fastglue.Fastglue, hub *ws.Hub) {
 	g.GET("/api/v1/agents/me/teams", auth(handleGetCurrentAgentTeams))
 	g.PUT("/api/v1/agents/me/availability", auth(handleUpdateAgentAvailability))
 	g.DELETE("/api/v1/agents/me/avatar", auth(handleDeleteCurrentAgentAvatar))
+	g.POST("/api/v1/agents/me/2fa", auth(handleGenerateTwoFactorSecret))
+	g.PUT("/api/v1/agents/me/2fa", auth(handleEnableTwoFactor))
+	g.POST("/api/v1/agents/me/2fa/disable", auth(handleDisableTwoFactor))
+	g.GET("/api/v1/agents/me/sessions", auth(handleGetCurrentAgentSessions))
+	g.DELETE("/api/v1/agents/me/sessions/{session_id}", auth(handleDeleteCurrentAgentSession))
 
 	g.GET("/api/v1/agents/compact", auth(handleGetAgentsCompact))
 	g.GET("/api/v1/agents", perm(handleGetAgents, "users:manage"))
@@ -112,8 +165,14 @@ func initHandlers(g *fastglue.Fastglue, hub *ws.Hub) {
 	g.POST("/api/v1/agents", perm(handleCreateAgent, "users:manage"))
 	g.PUT("/api/v1/agents/{id}", perm(handleUpdateAgent, "users:manage"))
 	g.DELETE("/api/v1/agents/{id}", perm(handleDeleteAgent, "users:manage"))
-	g.POST("/api/v1/agents/{id}/api-key", perm(handleGenerateAPIKey, "users:manage"))
-	g.DELETE("/api/v1/agents/{id}/api-key", perm(handleRevokeAPIKey, "users:manage"))
+	g.GET("/api/v1/agents/{id}/api-keys", perm(handleGetAPIKeys, "users:manage"))
+	g.POST("/api/v1/agents/{id}/api-keys", perm(handleCreateAPIKey, "users:manage"))
+	g.DELETE("/api/v1/agents/{id}/api-keys/{key_id}", perm(handleRevokeAPIKey, "users:manage"))
+	g.DELETE("/api/v1/agents/{id}/2fa", perm(handleResetTwoFactor, "users:manage"))
+	g.GET("/api/v1/agents/{id}/sessions", perm(handleGetAgentSessions, "users:manage"))
+	g.DELETE("/api/v1/agents/{id}/sessions", perm(handleDeleteAgentSessions, "users:manage"))
+	g.DELETE("/api/v1/agents/{id}/sessions/{session_id}", perm(handleDeleteAgentSession, "users:manage"))
+	g.DELETE("/api/v1/agents/{id}/lockout", perm(handleUnlockAgent, "users:manage"))
 	g.POST("/api/v1/agents/reset-password", tryAuth(handleResetPassword))
 	g.POST("/api/v1/agents/set-password", tryAuth(handleSetPassword))
 
@@ -147,11 +206,15 @@ func initHandlers(g *fastglue.Fastglue, hub *ws.Hub) {
 	g.DELETE("/api/v1/automations/rules/{id}", perm(handleDeleteAutomationRule, "automations:manage"))
 
 	// Inboxes.
-	g.GET("/api/v1/inboxes", auth(handleGetInboxes))
+	g.GET("/api/v1/inboxes", perm(handleGetInboxes, "inboxes:read"))
 	g.GET("/api/v1/inboxes/{id}", perm(handleGetInbox, "inboxes:manage"))
 	g.POST("/api/v1/inboxes", perm(handleCreateInbox, "inboxes:manage"))
 	g.PUT("/api/v1/inboxes/{id}/toggle", perm(handleToggleInbox, "inboxes:manage"))
 	g.PUT("/api/v1/inboxes/{id}", perm(handleUpdateInbox, "inboxes:manage"))
+	g.GET("/api/v1/inboxes/{id}/csat-survey", perm(handleGetCSATSurvey, "inboxes:manage"))
+	g.PUT("/api/v1/inboxes/{id}/csat-survey", perm(handleUpdateCSATSurvey, "inboxes:manage"))
+	g.GET("/api/v1/inboxes/{id}/ai-settings", perm(handleGetAIInboxSettings, "inboxes:manage"))
+	g.PUT("/api/v1/inboxes/{id}/ai-settings", perm(handleUpdateAIInboxSettings, "inboxes:manage"))
 	g.DELETE("/api/v1/inboxes/{id}", perm(handleDeleteInbox, "inboxes:manage"))
 
 	// Roles.
@@ -174,6 +237,10 @@ func initHandlers(g *fastglue.Fastglue, hub *ws.Hub) {
 	g.GET("/api/v1/reports/overview/sla", perm(handleOverviewSLA, "reports:manage"))
 	g.GET("/api/v1/reports/overview/counts", perm(handleOverviewCounts, "reports:manage"))
 	g.GET("/api/v1/reports/overview/charts", perm(handleOverviewCharts, "reports:manage"))
+	g.GET("/api/v1/reports/sla", perm(handleSLAComplianceReport, "reports:manage"))
+	g.GET("/api/v1/reports/sla/breaches", perm(handleSLABreachesReport, "reports:manage"))
+	g.GET("/api/v1/reports/csat", perm(handleCSATReport, "reports:manage"))
+	g.GET("/api/v1/reports/ai-usage", perm(handleAIUsageReport, "reports:manage"))
 
 	// Templates.
 	g.GET("/api/v1/templates", perm(handleGetTemplates, "templates:manage"))
@@ -186,6 +253,7 @@ func initHandlers(g *fastglue.Fastglue, hub *ws.Hub) {
 	g.GET("/api/v1/business-hours", auth(handleGetBusinessHours))
 	g.GET("/api/v1/business-hours/{id}", perm(handleGetBusinessHour, "business_hours:manage"))
 	g.POST("/api/v1/business-hours", perm(handleCreateBusinessHours, "business_hours:manage"))
+	g.POST("/api/v1/business-hours/holidays/import", perm(handleImportHolidays, "business_hours:manage"))
 	g.PUT("/api/v1/business-hours/{id}", perm(handleUpdateBusinessHours, "business_hours:manage"))
 	g.DELETE("/api/v1/business-hours/{id}", perm(handleDeleteBusinessHour, "business_hours:manage"))
 
@@ -197,9 +265,20 @@ func initHandlers(g *fastglue.Fastglue, hub *ws.Hub) {
 	g.DELETE("/api/v1/sla/{id}", perm(handleDeleteSLA, "sla:manage"))
 
 	// AI completions.
-	g.GET("/api/v1/ai/prompts", auth(handleGetAIPrompts))
-	g.POST("/api/v1/ai/completion", auth(handleAICompletion))
+	g.GET("/api/v1/ai/prompts", perm(handleGetAIPrompts, "ai:use"))
+	g.POST("/api/v1/ai/prompts", perm(handleCreateAIPrompt, "ai_prompts:manage"))
+	g.GET("/api/v1/ai/prompts/{id}", perm(handleGetAIPrompt, "ai_prompts:manage"))
+	g.PUT("/api/v1/ai/prompts/{id}", perm(handleUpdateAIPrompt, "ai_prompts:manage"))
+	g.DELETE("/api/v1/ai/prompts/{id}", perm(handleDeleteAIPrompt, "ai_prompts:manage"))
+	g.POST("/api/v1/ai/completion", perm(handleAICompletion, "ai:use"))
+	g.POST("/api/v1/conversations/{uuid}/ai/draft-reply", perm(handleAIDraftReply, "ai:use"))
 	g.PUT("/api/v1/ai/provider", perm(handleUpdateAIProvider, "ai:manage"))
+	g.GET("/api/v1/ai/providers", perm(handleGetAIProviders, "ai:manage"))
+	g.POST("/api/v1/ai/providers", perm(handleCreateAIProvider, "ai:manage"))
+	g.GET("/api/v1/ai/providers/{id}", perm(handleGetAIProvider, "ai:manage"))
+	g.PUT("/api/v1/ai/providers/{id}", perm(handleUpdateAIProviderByID, "ai:manage"))
+	g.DELETE("/api/v1/ai/providers/{id}", perm(handleDeleteAIProvider, "ai:manage"))
+	g.PUT("/api/v1/ai/providers/{id}/default", perm(handleSetDefaultAIProvider, "ai:manage"))
 
 	// Custom attributes.
 	g.GET("/api/v1/custom-attributes", auth(handleGetCustomAttributes))
@@ -263,6 +342,14 @@ func initHandlers(g *fastglue.Fastglue, hub *ws.Hub) {
 	g.GET("/health", handleHealthCheck)
 }
 
+// patch registers a PATCH route as fastglue has no wrapper for it, the handler gets the same request context
+// as the routes registered with fastglue.
+func patch(g *fastglue.Fastglue, app *App, path string, h fastglue.FastRequestHandler) {
+	g.Router.PATCH(path, func(ctx *fasthttp.RequestCtx) {
+		_ = h(&fastglue.Request{RequestCtx: ctx, Context: app})
+	})
+}
+
 // serveIndexPage serves the main index page of the application.
 func serveIndexPage(r *fastglue.Request) error {
 	app := r.Context.(*App)
@@ -6,8 +6,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"net"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"html/template"
@@ -21,6 +23,7 @@ import (
 	"github.com/abhinavxd/libredesk/internal/authz"
 	"github.com/abhinavxd/libredesk/internal/autoassigner"
 	"github.com/abhinavxd/libredesk/internal/automation"
+	"github.com/abhinavxd/libredesk/internal/bulk"
 	businesshours "github.com/abhinavxd/libredesk/internal/business_hours"
 	"github.com/abhinavxd/libredesk/internal/colorlog"
 	"github.com/abhinavxd/libredesk/internal/conversation"
@@ -40,6 +43,7 @@ import (
 	"github.com/abhinavxd/libredesk/internal/oidc"
 	"github.com/abhinavxd/libredesk/internal/report"
 	"github.com/abhinavxd/libredesk/internal/role"
+	"github.com/abhinavxd/libredesk/internal/saml"
 	"github.com/abhinavxd/libredesk/internal/search"
 	"github.com/abhinavxd/libredesk/internal/setting"
 	"github.com/abhinavxd/libredesk/internal/sla"
@@ -75,6 +79,8 @@ type constants struct {
 	UploadProvider              string
 	AllowedUploadFileExtensions []string
 	MaxFileUploadSizeMB         int
+	// TrustedProxies are the reverse proxies the X-Forwarded-For header is read from.
+	TrustedProxies []*net.IPNet
 }
 
 // Config loads config files into koanf.
@@ -129,9 +135,39 @@ func initConstants() *constants {
 		UploadProvider:              ko.MustString("upload.provider"),
 		AllowedUploadFileExtensions: ko.Strings("app.allowed_file_upload_extensions"),
 		MaxFileUploadSizeMB:         ko.Int("app.max_file_upload_size"),
+		TrustedProxies:              initTrustedProxies(),
 	}
 }
 
+// defaultTrustedProxies are trusted when the config doesn't set any, so reverse proxies on the same host or in a
+// docker network keep passing the client IP after upgrading from versions that always read the forwarded headers.
+var defaultTrustedProxies = []string{"127.0.0.0/8", "::1", "10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "fc00::/7"}
+
+// initTrustedProxies parses the IPs and CIDR ranges of the trusted reverse proxies.
+func initTrustedProxies() []*net.IPNet {
+	entries := defaultTrustedProxies
+	if ko.Exists("app.server.trusted_proxies") {
+		entries = ko.Strings("app.server.trusted_proxies")
+	}
+	var proxies []*net.IPNet
+	for _, p := range entries {
+		p = strings.TrimSpace(p)
+		if !strings.Contains(p, "/") {
+			if strings.Contains(p, ":") {
+				p += "/128"
+			} else {
+				p += "/32"
+			}
+		}
+		_, ipNet, err := net.ParseCIDR(p)
+		if err != nil {
+			log.Fatalf("invalid trusted proxy %q: %v", p, err)
+		}
+		proxies = append(proxies, ipNet)
+	}
+	return proxies
+}
+
 // initFS initializes the stuffbin FileSystem.
 func initFS() stuffbin.FileSystem {
 	var files = []string{
@@ -196,11 +232,11 @@ func initSettings(db *sqlx.DB) *setting.Manager {
 }
 
 // initUser inits user manager.
-func initUser(i18n *i18n.I18n, DB *sqlx.DB) *user.Manager {
+func initUser(i18n *i18n.I18n, DB *sqlx.DB, settings *setting.Manager) *user.Manager {
 	mgr, err := user.New(i18n, user.Opts{
 		DB: DB,
 		Lo: initLogger("user_manager"),
-	})
+	}, settings)
 	if err != nil {
 		log.Fatalf("error initializing user manager: %v", err)
 	}
@@ -225,12 +261,14 @@ func initConversations(
 	automationEngine *automation.Engine,
 	template *tmpl.Manager,
 	webhook *webhook.Manager,
+	ai *ai.Manager,
 ) *conversation.Manager {
-	c, err := conversation.New(hub, i18n, notif, sla, status, priority, inboxStore, userStore, teamStore, mediaStore, settings, csat, automationEngine, template, webhook, conversation.Opts{
+	c, err := conversation.New(hub, i18n, notif, sla, status, priority, inboxStore, userStore, teamStore, mediaStore, settings, csat, automationEngine, template, webhook, ai, conversation.Opts{
 		DB:                       db,
 		Lo:                       initLogger("conversation_manager"),
 		OutgoingMessageQueueSize: ko.MustInt("message.outgoing_queue_size"),
 		IncomingMessageQueueSize: ko.MustInt("message.incoming_queue_size"),
+		UndoSendWindow:           ko.Duration("message.undo_send_window"),
 	})
 	if err != nil {
 		log.Fatalf("error initializing conversation manager: %v", err)
@@ -658,16 +696,20 @@ func initAuthz(i18n *i18n.I18n) *authz.Enforcer {
 }
 
 // initAuth initializes the authentication manager.
-func initAuth(o *oidc.Manager, rd *redis.Client, i18n *i18n.I18n) *auth_.Auth {
+func initAuth(o *oidc.Manager, sm *saml.Manager, rd *redis.Client, i18n *i18n.I18n) *auth_.Auth {
 	lo := initLogger("auth")
 
 	providers, err := buildProviders(o)
 	if err != nil {
 		log.Fatalf("error initializing auth: %v", err)
 	}
+	samlProviders, err := buildSAMLProviders(sm)
+	if err != nil {
+		log.Fatalf("error initializing auth: %v", err)
+	}
 
 	secure := !ko.Bool("app.server.disable_secure_cookies")
-	auth, err := auth_.New(auth_.Config{Providers: providers, SecureCookies: secure}, i18n, rd, lo)
+	auth, err := auth_.New(auth_.Config{Providers: providers, SAMLProviders: samlProviders, SecureCookies: secure}, i18n, rd, lo)
 	if err != nil {
 		log.Fatalf("error initializing auth: %v", err)
 	}
@@ -682,7 +724,11 @@ func reloadAuth(app *App) error {
 	if err != nil {
 		log.Fatalf("error reloading auth: %v", err)
 	}
-	if err := app.auth.Reload(auth_.Config{Providers: providers}); err != nil {
+	samlProviders, err := buildSAMLProviders(app.saml)
+	if err != nil {
+		log.Fatalf("error reloading auth: %v", err)
+	}
+	if err := app.auth.Reload(auth_.Config{Providers: providers, SAMLProviders: samlProviders}); err != nil {
 		app.lo.Error("error reloading auth", "error", err)
 		return err
 	}
@@ -708,6 +754,34 @@ func buildProviders(o *oidc.Manager) ([]auth_.Provider, error) {
 			RedirectURL:  config.RedirectURI,
 			ClientID:     config.ClientID,
 			ClientSecret: config.ClientSecret,
+			GroupsClaim:  config.GroupsClaim,
+		})
+	}
+	return providers, nil
+}
+
+// buildSAMLProviders creates a list of enabled SAML providers from the SAML manager.
+func buildSAMLProviders(sm *saml.Manager) ([]auth_.SAMLProvider, error) {
+	samlConfigs, err := sm.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	providers := make([]auth_.SAMLProvider, 0, len(samlConfigs))
+	for _, config := range samlConfigs {
+		if !config.Enabled {
+			continue
+		}
+		providers = append(providers, auth_.SAMLProvider{
+			ID:                 config.ID,
+			EntityID:           config.EntityID,
+			ACSURL:             config.ACSURL,
+			IDPMetadata:        config.IDPMetadata,
+			SPCertificate:      config.SPCertificate,
+			SPPrivateKey:       config.SPPrivateKey,
+			EmailAttribute:     config.EmailAttribute,
+			FirstNameAttribute: config.FirstNameAttribute,
+			LastNameAttribute:  config.LastNameAttribute,
 		})
 	}
 	return providers, nil
@@ -727,6 +801,20 @@ func initOIDC(db *sqlx.DB, settings *setting.Manager, i18n *i18n.I18n) *oidc.Man
 	return o
 }
 
+// initSAML initializes the SAML identity provider config manager.
+func initSAML(db *sqlx.DB, settings *setting.Manager, i18n *i18n.I18n) *saml.Manager {
+	lo := initLogger("saml")
+	s, err := saml.New(saml.Opts{
+		DB:   db,
+		Lo:   lo,
+		I18n: i18n,
+	}, settings)
+	if err != nil {
+		log.Fatalf("error initializing saml: %v", err)
+	}
+	return s
+}
+
 // initI18n inits i18n.
 func initI18n(fs stuffbin.FileSystem) *i18n.I18n {
 	fileName := cmp.Or(ko.String("app.lang"), defLang)
@@ -820,9 +908,11 @@ func initPriority(db *sqlx.DB, i18n *i18n.I18n) *priority.Manager {
 func initAI(db *sqlx.DB, i18n *i18n.I18n) *ai.Manager {
 	lo := initLogger("ai")
 	m, err := ai.New(ai.Opts{
-		DB:   db,
-		Lo:   lo,
-		I18n: i18n,
+		DB:        db,
+		Lo:        lo,
+		I18n:      i18n,
+		Workers:   ko.Int("ai.workers"),
+		QueueSize: ko.Int("ai.queue_size"),
 	})
 	if err != nil {
 		log.Fatalf("error initializing AI manager: %v", err)
@@ -830,6 +920,16 @@ func initAI(db *sqlx.DB, i18n *i18n.I18n) *ai.Manager {
 	return m
 }
 
+// initBulk inits the bulk actions manager.
+func initBulk(conversation *conversation.Manager, enforcer *authz.Enforcer, macro *macro.Manager, hub *ws.Hub, i18n *i18n.I18n) *bulk.Manager {
+	return bulk.New(conversation, enforcer, macro, hub, bulk.Opts{
+		Lo:        initLogger("bulk"),
+		I18n:      i18n,
+		Workers:   ko.Int("bulk.workers"),
+		QueueSize: ko.Int("bulk.queue_size"),
+	})
+}
+
 // initSearch inits search manager.
 func initSearch(db *sqlx.DB, i18n *i18n.I18n) *search.Manager {
 	lo := initLogger("search")
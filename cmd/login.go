@@ -4,7 +4,6 @@ import (
 	amodels "github.com/abhinavxd/libredesk/internal/auth/models"
 	"github.com/abhinavxd/libredesk/internal/envelope"
 	umodels "github.com/abhinavxd/libredesk/internal/user/models"
-	realip "github.com/ferluci/fast-realip"
 	"github.com/valyala/fasthttp"
 	"github.com/zerodha/fastglue"
 )
@@ -14,11 +13,25 @@ type loginRequest struct {
 	Password string `json:"password"`
 }
 
+// twoFactorLoginResp is returned instead of the user when the login has to be completed with a second factor.
+type twoFactorLoginResp struct {
+	TwoFactorRequired bool `json:"two_factor_required"`
+	// SetupRequired is set if a role of the user requires two-factor authentication and it's not set up yet.
+	SetupRequired bool   `json:"setup_required"`
+	Token         string `json:"token"`
+}
+
+// passwordExpiredLoginResp is returned instead of the user when the password has to be reset with the token before logging in.
+type passwordExpiredLoginResp struct {
+	PasswordExpired bool   `json:"password_expired"`
+	Token           string `json:"token"`
+}
+
 // handleLogin logs in the user and returns the user.
 func handleLogin(r *fastglue.Request) error {
 	var (
 		app      = r.Context.(*App)
-		ip       = realip.FromRequest(r.RequestCtx)
+		ip       = clientIP(r)
 		loginReq loginRequest
 	)
 
@@ -31,9 +44,19 @@ func handleLogin(r *fastglue.Request) error {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.T("globals.messages.badRequest"), nil, envelope.InputError)
 	}
 
+	// Reject attempts while the account is locked out or the account or IP has to wait after failed logins.
+	if err := app.auth.CheckLoginAllowed(ip, loginReq.Email); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
 	// Verify email and password.
 	user, err := app.user.VerifyPassword(loginReq.Email, []byte(loginReq.Password))
 	if err != nil {
+		if e, ok := err.(envelope.Error); ok && e.ErrorType == envelope.InputError {
+			if lockErr := recordLoginFailure(app, ip, loginReq.Email, user); lockErr != nil {
+				return sendErrorEnvelope(r, lockErr)
+			}
+		}
 		return sendErrorEnvelope(r, err)
 	}
 
@@ -42,9 +65,86 @@ func handleLogin(r *fastglue.Request) error {
 		return sendErrorEnvelope(r, envelope.NewError(envelope.GeneralError, app.i18n.T("user.accountDisabled"), nil))
 	}
 
+	// Users with two-factor authentication enabled or required by a role complete the login with a code,
+	// an expired password is only checked after the code.
+	if user.TOTPEnabled || user.TOTPRequired {
+		token, err := app.auth.SavePartialSession(user.ID)
+		if err != nil {
+			return sendErrorEnvelope(r, envelope.NewError(envelope.GeneralError, app.i18n.Ts("globals.messages.errorSaving", "name", "{globals.terms.session}"), nil))
+		}
+		return r.SendEnvelope(twoFactorLoginResp{
+			TwoFactorRequired: true,
+			SetupRequired:     !user.TOTPEnabled,
+			Token:             token,
+		})
+	}
+
+	// Passwords older than the max age of the password policy have to be reset before logging in.
+	if resp, expired, err := passwordExpiredLogin(app, user); err != nil {
+		return sendErrorEnvelope(r, err)
+	} else if expired {
+		return r.SendEnvelope(resp)
+	}
+
+	if err := completeLogin(r, &user); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(user)
+}
+
+// passwordExpiredLogin returns the response with a reset token if the password of the user is older than the max age of the password policy.
+func passwordExpiredLogin(app *App, user umodels.User) (passwordExpiredLoginResp, bool, error) {
+	if !app.user.PasswordExpired(user) {
+		return passwordExpiredLoginResp{}, false, nil
+	}
+	token, err := app.user.SetResetPasswordToken(user.ID)
+	if err != nil {
+		return passwordExpiredLoginResp{}, false, err
+	}
+	return passwordExpiredLoginResp{
+		PasswordExpired: true,
+		Token:           token,
+	}, true, nil
+}
+
+// recordLoginFailure records a failed login for the email from the IP and returns the lockout error if the account got locked out.
+// user is the account of the email, unknown emails are counted the same but can't have activity logs.
+func recordLoginFailure(app *App, ip, email string, user umodels.User) error {
+	locked, err := app.auth.RecordLoginFailure(ip, email)
+	if err != nil {
+		app.lo.Error("error recording failed login", "email", email, "ip", ip, "error", err)
+		return nil
+	}
+
+	if user.ID > 0 {
+		if err := app.activityLog.LoginFailed(user.ID, user.Email.String, ip); err != nil {
+			app.lo.Error("error creating failed login activity log", "error", err)
+		}
+	}
+	if !locked {
+		return nil
+	}
+
+	app.lo.Warn("account locked out after failed logins", "email", email, "ip", ip)
+	if user.ID > 0 {
+		if err := app.activityLog.LockedOut(user.ID, user.Email.String, ip); err != nil {
+			app.lo.Error("error creating lockout activity log", "error", err)
+		}
+	}
+	return app.auth.CheckLoginAllowed(ip, email)
+}
+
+// completeLogin sets the user online, saves the session and records the login,
+// failed logins of the account are only cleared here once every factor has been checked.
+func completeLogin(r *fastglue.Request, user *umodels.User) error {
+	var (
+		app = r.Context.(*App)
+		ip  = clientIP(r)
+	)
+
 	// Set user availability status to online.
 	if err := app.user.UpdateAvailability(user.ID, umodels.Online); err != nil {
-		return sendErrorEnvelope(r, err)
+		return err
 	}
 	user.AvailabilityStatus = umodels.Online
 
@@ -53,27 +153,28 @@ func handleLogin(r *fastglue.Request) error {
 		Email:     user.Email.String,
 		FirstName: user.FirstName,
 		LastName:  user.LastName,
-	}, r); err != nil {
+	}, ip, r); err != nil {
 		app.lo.Error("error saving session", "error", err)
-		return sendErrorEnvelope(r, envelope.NewError(envelope.GeneralError, app.i18n.Ts("globals.messages.errorSaving", "name", "{globals.terms.session}"), nil))
+		return envelope.NewError(envelope.GeneralError, app.i18n.Ts("globals.messages.errorSaving", "name", "{globals.terms.session}"), nil)
 	}
 	// Set CSRF cookie if not already set.
 	if err := app.auth.SetCSRFCookie(r); err != nil {
 		app.lo.Error("error setting csrf cookie", "error", err)
-		return sendErrorEnvelope(r, envelope.NewError(envelope.GeneralError, app.i18n.Ts("globals.messages.errorSaving", "name", "{globals.terms.session}"), nil))
+		return envelope.NewError(envelope.GeneralError, app.i18n.Ts("globals.messages.errorSaving", "name", "{globals.terms.session}"), nil)
 	}
 
+	app.auth.ClearLoginFailures(user.Email.String)
+
 	// Update last login time.
 	if err := app.user.UpdateLastLoginAt(user.ID); err != nil {
-		return sendErrorEnvelope(r, err)
+		return err
 	}
 
 	// Insert activity log.
 	if err := app.activityLog.Login(user.ID, user.Email.String, ip); err != nil {
 		app.lo.Error("error creating login activity log", "error", err)
 	}
-
-	return r.SendEnvelope(user)
+	return nil
 }
 
 // handleLogout logs out the user and redirects to the dashboard.
@@ -81,7 +182,7 @@ func handleLogout(r *fastglue.Request) error {
 	var (
 		app   = r.Context.(*App)
 		auser = r.RequestCtx.UserValue("user").(amodels.User)
-		ip    = realip.FromRequest(r.RequestCtx)
+		ip    = clientIP(r)
 	)
 
 	// Insert activity log.
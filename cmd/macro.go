@@ -7,8 +7,11 @@ import (
 
 	amodels "github.com/abhinavxd/libredesk/internal/auth/models"
 	autoModels "github.com/abhinavxd/libredesk/internal/automation/models"
+	cmodels "github.com/abhinavxd/libredesk/internal/conversation/models"
 	"github.com/abhinavxd/libredesk/internal/envelope"
+	"github.com/abhinavxd/libredesk/internal/macro"
 	"github.com/abhinavxd/libredesk/internal/macro/models"
+	umodels "github.com/abhinavxd/libredesk/internal/user/models"
 	"github.com/valyala/fasthttp"
 	"github.com/zerodha/fastglue"
 )
@@ -140,7 +143,7 @@ func handleApplyMacro(r *fastglue.Request) error {
 		id, _            = strconv.Atoi(r.RequestCtx.UserValue("id").(string))
 		incomingActions  = []autoModels.RuleAction{}
 	)
-	user, err := app.user.GetAgent(auser.ID, "")
+	user, err := getAuthenticatedAgent(app, auser)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
@@ -165,36 +168,12 @@ func handleApplyMacro(r *fastglue.Request) error {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.macroAction}"), err.Error(), envelope.InputError)
 	}
 
-	// Make sure no duplicate action types are present.
-	actionTypes := make(map[string]bool, len(incomingActions))
-	for _, act := range incomingActions {
-		if actionTypes[act.Type] {
-			app.lo.Warn("duplicate action types found in macro apply apply request", "action", act.Type, "user_id", user.ID)
-			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.T("macro.duplicateActionsNotAllowed"), nil, envelope.InputError)
-		}
-		actionTypes[act.Type] = true
-	}
-
-	// Validate action permissions.
-	for _, act := range incomingActions {
-		if !isMacroActionAllowed(act.Type) {
-			app.lo.Warn("action not allowed in macro", "action", act.Type, "user_id", user.ID)
-			return r.SendErrorEnvelope(fasthttp.StatusForbidden, app.i18n.Ts("macro.actionNotAllowed", "name", act.Type), nil, envelope.PermissionError)
-		}
-		if !hasActionPermission(act.Type, user.Permissions) {
-			app.lo.Warn("no permission to execute macro action", "action", act.Type, "user_id", user.ID)
-			return r.SendErrorEnvelope(fasthttp.StatusForbidden, app.i18n.T("macro.permissionDenied"), nil, envelope.PermissionError)
-		}
+	if err := validateMacroActions(app, incomingActions, user); err != nil {
+		return sendErrorEnvelope(r, err)
 	}
 
 	// Apply actions.
-	successCount := 0
-	for _, act := range incomingActions {
-		if err := app.conversation.ApplyAction(act, conversation, user); err == nil {
-			successCount++
-		}
-	}
-
+	successCount := applyMacroActions(app, incomingActions, conversation, user)
 	if successCount == 0 {
 		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, app.i18n.T("macro.couldNotApply"), nil, envelope.GeneralError)
 	}
@@ -213,6 +192,56 @@ func handleApplyMacro(r *fastglue.Request) error {
 	})
 }
 
+// validateMacroActions checks that the actions are unique, allowed in a macro and that the user has the permissions to execute them.
+func validateMacroActions(app *App, actions []autoModels.RuleAction, user umodels.User) error {
+	// Make sure no duplicate action types are present.
+	actionTypes := make(map[string]bool, len(actions))
+	for _, act := range actions {
+		if actionTypes[act.Type] {
+			app.lo.Warn("duplicate action types found in macro apply apply request", "action", act.Type, "user_id", user.ID)
+			return envelope.NewError(envelope.InputError, app.i18n.T("macro.duplicateActionsNotAllowed"), nil)
+		}
+		actionTypes[act.Type] = true
+	}
+
+	// Validate action permissions.
+	for _, act := range actions {
+		if !isMacroActionAllowed(act.Type) {
+			app.lo.Warn("action not allowed in macro", "action", act.Type, "user_id", user.ID)
+			return envelope.NewError(envelope.PermissionError, app.i18n.Ts("macro.actionNotAllowed", "name", act.Type), nil)
+		}
+		if !hasActionPermission(act.Type, user.Permissions) {
+			app.lo.Warn("no permission to execute macro action", "action", act.Type, "user_id", user.ID)
+			return envelope.NewError(envelope.PermissionError, app.i18n.T("macro.permissionDenied"), nil)
+		}
+	}
+	return nil
+}
+
+// applyMacroActions applies the actions to a conversation in order and returns the number of actions applied,
+// the variables in the content of reply and private note actions are rendered for the conversation.
+func applyMacroActions(app *App, actions []autoModels.RuleAction, conversation cmodels.Conversation, user umodels.User) int {
+	successCount := 0
+	for _, act := range actions {
+		act, err := macro.RenderAction(act, conversation, user)
+		if err != nil {
+			app.lo.Error("error rendering macro action content", "action", act.Type, "uuid", conversation.UUID, "error", err)
+			continue
+		}
+		// The action's permission must be granted in the inbox and team of the conversation.
+		if !user.RoleScopes.For(autoModels.ActionPermissions[act.Type]).Allows(conversation.InboxID, conversation.AssignedTeamID.Int) {
+			app.lo.Warn("macro action not permitted in conversation scope", "action", act.Type, "uuid", conversation.UUID, "user_id", user.ID)
+			continue
+		}
+		if err := app.conversation.ApplyAction(act, conversation, user); err != nil {
+			app.lo.Error("error applying macro action", "action", act.Type, "uuid", conversation.UUID, "error", err)
+			continue
+		}
+		successCount++
+	}
+	return successCount
+}
+
 // hasActionPermission checks user permission for given action
 func hasActionPermission(action string, userPerms []string) bool {
 	requiredPerm, exists := autoModels.ActionPermissions[action]
@@ -271,23 +300,28 @@ func setDisplayValues(app *App, actions []autoModels.RuleAction) error {
 }
 
 // validateMacro validates an incoming macro.
-func validateMacro(app *App, macro models.Macro) error {
-	if macro.Name == "" {
+func validateMacro(app *App, m models.Macro) error {
+	if m.Name == "" {
 		return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.empty", "name", "`name`"), nil)
 	}
 
-	if len(macro.VisibleWhen) == 0 {
+	if len(m.VisibleWhen) == 0 {
 		return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.empty", "name", "`visible_when`"), nil)
 	}
 
 	var act []autoModels.RuleAction
-	if err := json.Unmarshal(macro.Actions, &act); err != nil {
+	if err := json.Unmarshal(m.Actions, &act); err != nil {
 		return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.macroAction}"), nil)
 	}
 	for _, a := range act {
 		if len(a.Value) == 0 {
 			return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.empty", "name", a.Type), nil)
 		}
+		if a.Type == autoModels.ActionReply || a.Type == autoModels.ActionSendPrivateNote {
+			if err := macro.ValidateContent(a.Value[0]); err != nil {
+				return envelope.NewError(envelope.InputError, app.i18n.Ts("macro.invalidContentVariables", "error", err.Error()), nil)
+			}
+		}
 	}
 	return nil
 }
@@ -295,9 +329,8 @@ func validateMacro(app *App, macro models.Macro) error {
 // isMacroActionAllowed returns true if the action is allowed in a macro.
 func isMacroActionAllowed(action string) bool {
 	switch action {
-	case autoModels.ActionSendPrivateNote, autoModels.ActionReply:
-		return false
-	case autoModels.ActionAssignTeam, autoModels.ActionAssignUser, autoModels.ActionSetStatus, autoModels.ActionSetPriority, autoModels.ActionAddTags, autoModels.ActionSetTags, autoModels.ActionRemoveTags:
+	case autoModels.ActionSendPrivateNote, autoModels.ActionReply,
+		autoModels.ActionAssignTeam, autoModels.ActionAssignUser, autoModels.ActionSetStatus, autoModels.ActionSetPriority, autoModels.ActionAddTags, autoModels.ActionSetTags, autoModels.ActionRemoveTags:
 		return true
 	default:
 		return false
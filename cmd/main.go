@@ -20,6 +20,7 @@ import (
 	"github.com/abhinavxd/libredesk/internal/article_section"
 	auth_ "github.com/abhinavxd/libredesk/internal/auth"
 	"github.com/abhinavxd/libredesk/internal/authz"
+	"github.com/abhinavxd/libredesk/internal/bulk"
 	businesshours "github.com/abhinavxd/libredesk/internal/business_hours"
 	"github.com/abhinavxd/libredesk/internal/colorlog"
 	"github.com/abhinavxd/libredesk/internal/csat"
@@ -39,12 +40,14 @@ import (
 	"github.com/abhinavxd/libredesk/internal/media"
 	"github.com/abhinavxd/libredesk/internal/oidc"
 	"github.com/abhinavxd/libredesk/internal/role"
+	"github.com/abhinavxd/libredesk/internal/saml"
 	"github.com/abhinavxd/libredesk/internal/setting"
 	"github.com/abhinavxd/libredesk/internal/tag"
 	"github.com/abhinavxd/libredesk/internal/team"
 	"github.com/abhinavxd/libredesk/internal/template"
 	"github.com/abhinavxd/libredesk/internal/user"
 	"github.com/abhinavxd/libredesk/internal/webhook"
+	"github.com/abhinavxd/libredesk/internal/ws"
 	"github.com/knadh/go-i18n"
 	"github.com/knadh/koanf/v2"
 	"github.com/knadh/stuffbin"
@@ -73,6 +76,7 @@ type App struct {
 	i18n             *i18n.I18n
 	lo               *logf.Logger
 	oidc             *oidc.Manager
+	saml             *saml.Manager
 	media            *media.Manager
 	setting          *setting.Manager
 	role             *role.Manager
@@ -91,6 +95,7 @@ type App struct {
 	csat             *csat.Manager
 	view             *view.Manager
 	ai               *ai.Manager
+	bulk             *bulk.Manager
 	search           *search.Manager
 	activityLog      *activitylog.Manager
 	notifier         *notifier.Service
@@ -100,6 +105,7 @@ type App struct {
 	article_category *article_category.Manager
 	article_section  *article_section.Manager
 	article          *article.Manager
+	wsHub            *ws.Hub
 	// Global state that stores data on an available app update.
 	update *AppUpdate
 	sync.Mutex
@@ -190,24 +196,31 @@ func main() {
 		i18n                        = initI18n(fs)
 		csat                        = initCSAT(db, i18n)
 		oidc                        = initOIDC(db, settings, i18n)
+		saml                        = initSAML(db, settings, i18n)
 		status                      = initStatus(db, i18n)
 		priority                    = initPriority(db, i18n)
-		auth                        = initAuth(oidc, rdb, i18n)
+		auth                        = initAuth(oidc, saml, rdb, i18n)
 		template                    = initTemplate(db, fs, constants, i18n)
 		media                       = initMedia(db, i18n)
 		inbox                       = initInbox(db, i18n)
 		team                        = initTeam(db, i18n)
 		businessHours               = initBusinessHours(db, i18n)
 		webhook                     = initWebhook(db, i18n)
-		user                        = initUser(i18n, db)
+		ai                          = initAI(db, i18n)
+		user                        = initUser(i18n, db, settings)
 		wsHub                       = initWS(user)
 		notifier                    = initNotifier()
 		automation                  = initAutomationEngine(db, i18n)
 		sla                         = initSLA(db, team, settings, businessHours, notifier, template, user, i18n)
-		conversation                = initConversations(i18n, sla, status, priority, wsHub, notifier, db, inbox, user, team, media, settings, csat, automation, template, webhook)
+		conversation                = initConversations(i18n, sla, status, priority, wsHub, notifier, db, inbox, user, team, media, settings, csat, automation, template, webhook, ai)
 		autoassigner                = initAutoAssigner(team, user, conversation)
+		authz                       = initAuthz(i18n)
+		macro                       = initMacro(db, i18n)
+		bulk                        = initBulk(conversation, authz, macro, wsHub, i18n)
 	)
 	automation.SetConversationStore(conversation)
+	sla.SetConversationStore(conversation)
+	ai.SetConversationStore(conversation)
 
 	startInboxes(ctx, inbox, conversation, user)
 	go automation.Run(ctx, automationWorkers)
@@ -215,6 +228,8 @@ func main() {
 	go conversation.Run(ctx, messageIncomingQWorkers, messageOutgoingQWorkers, messageOutgoingScanInterval)
 	go conversation.RunUnsnoozer(ctx, unsnoozeInterval)
 	go webhook.Run(ctx)
+	go ai.Run(ctx)
+	go bulk.Run(ctx)
 	go notifier.Run(ctx)
 	go sla.Run(ctx, slaEvaluationInterval)
 	go sla.SendNotifications(ctx)
@@ -226,6 +241,7 @@ func main() {
 		fs:               fs,
 		sla:              sla,
 		oidc:             oidc,
+		saml:             saml,
 		i18n:             i18n,
 		auth:             auth,
 		media:            media,
@@ -243,25 +259,27 @@ func main() {
 		businessHours:    businessHours,
 		activityLog:      initActivityLog(db, i18n),
 		customAttribute:  initCustomAttribute(db, i18n),
-		authz:            initAuthz(i18n),
+		authz:            authz,
 		view:             initView(db),
 		report:           initReport(db, i18n),
 		csat:             initCSAT(db, i18n),
 		search:           initSearch(db, i18n),
 		role:             initRole(db, i18n),
 		tag:              initTag(db, i18n),
-		macro:            initMacro(db, i18n),
-		ai:               initAI(db, i18n),
+		macro:            macro,
+		bulk:             bulk,
+		ai:               ai,
 		webhook:          webhook,
 		article_category: initArticleCategory(db, i18n),
 		article_section:  initArticleSection(db, i18n),
 		article:          initArticle(db, i18n),
+		wsHub:            wsHub,
 	}
 	app.consts.Store(constants)
 
 	g := fastglue.NewGlue()
 	g.SetContext(app)
-	initHandlers(g, wsHub)
+	initHandlers(g, app, wsHub)
 
 	s := &fasthttp.Server{
 		Name:                 appName,
@@ -301,6 +319,10 @@ func main() {
 	notifier.Close()
 	colorlog.Red("Shutting down webhook...")
 	webhook.Close()
+	colorlog.Red("Shutting down AI...")
+	ai.Close()
+	colorlog.Red("Shutting down bulk actions...")
+	bulk.Close()
 	colorlog.Red("Shutting down conversation...")
 	conversation.Close()
 	colorlog.Red("Shutting down SLA...")
@@ -2,6 +2,7 @@ package main
 
 import (
 	"strconv"
+	"time"
 
 	amodels "github.com/abhinavxd/libredesk/internal/auth/models"
 	"github.com/abhinavxd/libredesk/internal/envelope"
@@ -17,6 +18,13 @@ type messageReq struct {
 	To          []string `json:"to"`
 	CC          []string `json:"cc"`
 	BCC         []string `json:"bcc"`
+	// ScheduledAt is set to send the reply later.
+	ScheduledAt *time.Time `json:"scheduled_at"`
+}
+
+type scheduledMessageReq struct {
+	Message     string    `json:"message"`
+	ScheduledAt time.Time `json:"scheduled_at"`
 }
 
 // handleGetMessages returns messages for a conversation.
@@ -30,7 +38,7 @@ func handleGetMessages(r *fastglue.Request) error {
 		total       = 0
 	)
 
-	user, err := app.user.GetAgent(auser.ID, "")
+	user, err := getAuthenticatedAgent(app, auser)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
@@ -73,7 +81,7 @@ func handleGetMessage(r *fastglue.Request) error {
 		cuuid = r.RequestCtx.UserValue("cuuid").(string)
 		auser = r.RequestCtx.UserValue("user").(amodels.User)
 	)
-	user, err := app.user.GetAgent(auser.ID, "")
+	user, err := getAuthenticatedAgent(app, auser)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
@@ -108,7 +116,7 @@ func handleRetryMessage(r *fastglue.Request) error {
 		auser = r.RequestCtx.UserValue("user").(amodels.User)
 	)
 
-	user, err := app.user.GetAgent(auser.ID, "")
+	user, err := getAuthenticatedAgent(app, auser)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
@@ -134,7 +142,7 @@ func handleSendMessage(r *fastglue.Request) error {
 		req   = messageReq{}
 	)
 
-	user, err := app.user.GetAgent(auser.ID, "")
+	user, err := getAuthenticatedAgent(app, auser)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
@@ -165,10 +173,97 @@ func handleSendMessage(r *fastglue.Request) error {
 		if err := app.conversation.SendPrivateNote(media, user.ID, cuuid, req.Message); err != nil {
 			return sendErrorEnvelope(r, err)
 		}
+	} else if req.ScheduledAt != nil {
+		if err := app.conversation.ScheduleReply(media, conv.InboxID, user.ID, cuuid, req.Message, req.To, req.CC, req.BCC, map[string]any{} /**meta**/, *req.ScheduledAt); err != nil {
+			return sendErrorEnvelope(r, err)
+		}
 	} else {
-		if err := app.conversation.SendReply(media, conv.InboxID, user.ID, cuuid, req.Message, req.To, req.CC, req.BCC, map[string]any{} /**meta**/); err != nil {
+		if err := app.conversation.SendReplyWithUndo(media, conv.InboxID, user.ID, cuuid, req.Message, req.To, req.CC, req.BCC, map[string]any{} /**meta**/); err != nil {
 			return sendErrorEnvelope(r, err)
 		}
 	}
 	return r.SendEnvelope(true)
 }
+
+// handleUpdateScheduledMessage updates the content and scheduled time of a scheduled reply.
+func handleUpdateScheduledMessage(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+		uuid  = r.RequestCtx.UserValue("uuid").(string)
+		cuuid = r.RequestCtx.UserValue("cuuid").(string)
+		req   = scheduledMessageReq{}
+	)
+	if err := r.Decode(&req, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil, envelope.InputError)
+	}
+	if req.Message == "" {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.empty", "name", "`message`"), nil, envelope.InputError)
+	}
+
+	user, err := getAuthenticatedAgent(app, auser)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if _, err := enforceConversationAccess(app, cuuid, user); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	if err := app.conversation.UpdateScheduledMessage(cuuid, uuid, req.Message, req.ScheduledAt); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(true)
+}
+
+// handleCancelScheduledMessage cancels a scheduled reply.
+func handleCancelScheduledMessage(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+		uuid  = r.RequestCtx.UserValue("uuid").(string)
+		cuuid = r.RequestCtx.UserValue("cuuid").(string)
+	)
+	user, err := getAuthenticatedAgent(app, auser)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if _, err := enforceConversationAccess(app, cuuid, user); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	if err := app.conversation.CancelScheduledMessage(cuuid, uuid); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(true)
+}
+
+// handleCancelMessage cancels a reply sent by the user that is still in the undo send window.
+func handleCancelMessage(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+		uuid  = r.RequestCtx.UserValue("uuid").(string)
+		cuuid = r.RequestCtx.UserValue("cuuid").(string)
+	)
+	user, err := getAuthenticatedAgent(app, auser)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if _, err := enforceConversationAccess(app, cuuid, user); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	// Only the sender can undo a reply.
+	message, err := app.conversation.GetMessage(uuid)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if message.SenderID != user.ID {
+		return sendErrorEnvelope(r, envelope.NewError(envelope.PermissionError, app.i18n.Ts("globals.messages.denied", "name", "{globals.terms.permission}"), nil))
+	}
+
+	if err := app.conversation.CancelPendingMessage(cuuid, uuid); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(true)
+}
@@ -1,11 +1,14 @@
 package main
 
 import (
+	"net"
 	"net/http"
+	"slices"
 	"strings"
 
 	amodels "github.com/abhinavxd/libredesk/internal/auth/models"
 	"github.com/abhinavxd/libredesk/internal/envelope"
+	"github.com/abhinavxd/libredesk/internal/scim"
 	"github.com/abhinavxd/libredesk/internal/user/models"
 	"github.com/valyala/fasthttp"
 	"github.com/zerodha/fastglue"
@@ -21,7 +24,7 @@ func authenticateUser(r *fastglue.Request, app *App) (models.User, error) {
 	// Check for Authorization header first (API key authentication)
 	apiKey, apiSecret, err := r.ParseAuthHeader(fastglue.AuthBasic | fastglue.AuthToken)
 	if err == nil && len(apiKey) > 0 && len(apiSecret) > 0 {
-		user, err = app.user.ValidateAPIKey(string(apiKey), string(apiSecret))
+		user, err = app.user.ValidateAPIKey(string(apiKey), string(apiSecret), clientIP(r))
 		if err != nil {
 			return user, err
 		}
@@ -65,6 +68,35 @@ func authenticateUser(r *fastglue.Request, app *App) (models.User, error) {
 	return user, nil
 }
 
+// getAuthenticatedAgent loads the authenticated agent, the permissions of agents authenticated with an API key
+// are restricted to the key's permissions.
+func getAuthenticatedAgent(app *App, auser amodels.User) (models.User, error) {
+	user, err := app.user.GetAgent(auser.ID, "")
+	if err != nil {
+		return user, err
+	}
+	user.RoutePermission = auser.RoutePermission
+	if auser.APIKeyID > 0 {
+		user.APIKeyID = auser.APIKeyID
+		perms := make([]string, 0, len(user.Permissions))
+		for _, p := range user.Permissions {
+			if slices.Contains(auser.APIKeyPermissions, p) {
+				perms = append(perms, p)
+			}
+		}
+		user.Permissions = perms
+	}
+	return user, nil
+}
+
+// apiKeyPermissions returns the permissions of a user authenticated with an API key, nil otherwise.
+func apiKeyPermissions(user models.User) []string {
+	if user.APIKeyID == 0 {
+		return nil
+	}
+	return user.Permissions
+}
+
 // tryAuth attempts to authenticate the user and add them to the context but doesn't enforce authentication.
 // Handlers can check if user exists in context optionally.
 // Supports both API key authentication (Authorization header) and session-based authentication.
@@ -81,10 +113,12 @@ func tryAuth(handler fastglue.FastRequestHandler) fastglue.FastRequestHandler {
 
 		// Set user in context if authentication succeeded.
 		r.RequestCtx.SetUserValue("user", amodels.User{
-			ID:        user.ID,
-			Email:     user.Email.String,
-			FirstName: user.FirstName,
-			LastName:  user.LastName,
+			ID:                user.ID,
+			Email:             user.Email.String,
+			FirstName:         user.FirstName,
+			LastName:          user.LastName,
+			APIKeyID:          user.APIKeyID,
+			APIKeyPermissions: apiKeyPermissions(user),
 		})
 
 		return handler(r)
@@ -111,10 +145,12 @@ func auth(handler fastglue.FastRequestHandler) fastglue.FastRequestHandler {
 
 		// Set user in the request context.
 		r.RequestCtx.SetUserValue("user", amodels.User{
-			ID:        user.ID,
-			Email:     user.Email.String,
-			FirstName: user.FirstName,
-			LastName:  user.LastName,
+			ID:                user.ID,
+			Email:             user.Email.String,
+			FirstName:         user.FirstName,
+			LastName:          user.LastName,
+			APIKeyID:          user.APIKeyID,
+			APIKeyPermissions: apiKeyPermissions(user),
 		})
 
 		return handler(r)
@@ -155,16 +191,36 @@ func perm(handler fastglue.FastRequestHandler, perm string) fastglue.FastRequest
 
 		// Set user in the request context.
 		r.RequestCtx.SetUserValue("user", amodels.User{
-			ID:        user.ID,
-			Email:     user.Email.String,
-			FirstName: user.FirstName,
-			LastName:  user.LastName,
+			ID:                user.ID,
+			Email:             user.Email.String,
+			FirstName:         user.FirstName,
+			LastName:          user.LastName,
+			APIKeyID:          user.APIKeyID,
+			APIKeyPermissions: apiKeyPermissions(user),
+			RoutePermission:   perm,
 		})
 
 		return handler(r)
 	}
 }
 
+// scimAuth validates the SCIM bearer token of identity providers provisioning agents and teams.
+func scimAuth(handler fastglue.FastRequestHandler) fastglue.FastRequestHandler {
+	return func(r *fastglue.Request) error {
+		var app = r.Context.(*App)
+
+		token, _ := strings.CutPrefix(string(r.RequestCtx.Request.Header.Peek("Authorization")), "Bearer ")
+		ok, err := validSCIMToken(app, strings.TrimSpace(token))
+		if err != nil {
+			return sendSCIMError(r, err)
+		}
+		if !ok {
+			return sendSCIM(r, http.StatusUnauthorized, scim.NewError(http.StatusUnauthorized, "", app.i18n.Ts("globals.messages.invalid", "name", app.i18n.T("globals.terms.credential"))))
+		}
+		return handler(r)
+	}
+}
+
 // authPage ensures the user is logged in; otherwise, redirects to the login page.
 func authPage(handler fastglue.FastRequestHandler) fastglue.FastRequestHandler {
 	return func(r *fastglue.Request) error {
@@ -220,3 +276,36 @@ func notAuthPage(handler fastglue.FastRequestHandler) fastglue.FastRequestHandle
 		return handler(r)
 	}
 }
+
+// clientIP returns the IP of the client, read from the X-Forwarded-For header only if the request comes from a trusted proxy,
+// headers sent by clients directly are ignored so they can't spoof their IP.
+func clientIP(r *fastglue.Request) string {
+	var (
+		app     = r.Context.(*App)
+		proxies = app.consts.Load().(*constants).TrustedProxies
+		remote  = r.RequestCtx.RemoteIP()
+	)
+	if !isTrustedProxy(proxies, remote) {
+		return remote.String()
+	}
+
+	// Proxies append the address they received the request from, so the client is the last address that isn't a trusted proxy.
+	ip := remote
+	hops := strings.Split(string(r.RequestCtx.Request.Header.Peek(fasthttp.HeaderXForwardedFor)), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := net.ParseIP(strings.TrimSpace(hops[i]))
+		if hop == nil {
+			break
+		}
+		ip = hop
+		if !isTrustedProxy(proxies, hop) {
+			break
+		}
+	}
+	return ip.String()
+}
+
+// isTrustedProxy returns true if the IP is in one of the trusted proxy ranges.
+func isTrustedProxy(proxies []*net.IPNet, ip net.IP) bool {
+	return slices.ContainsFunc(proxies, func(p *net.IPNet) bool { return p.Contains(ip) })
+}
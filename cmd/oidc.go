@@ -1,6 +1,7 @@
 package main
 
 import (
+	"slices"
 	"strconv"
 	"strings"
 
@@ -64,6 +65,9 @@ func handleCreateOIDC(r *fastglue.Request) error {
 	if err := app.auth.TestProvider(req.ProviderURL); err != nil {
 		return sendErrorEnvelope(r, err)
 	}
+	if err := validateOIDCRoles(app, req); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
 
 	createdOIDC, err := app.oidc.Create(req)
 	if err != nil {
@@ -100,6 +104,9 @@ func handleUpdateOIDC(r *fastglue.Request) error {
 	if err := app.auth.TestProvider(req.ProviderURL); err != nil {
 		return sendErrorEnvelope(r, err)
 	}
+	if err := validateOIDCRoles(app, req); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
 
 	updatedOIDC, err := app.oidc.Update(id, req)
 	if err != nil {
@@ -117,6 +124,43 @@ func handleUpdateOIDC(r *fastglue.Request) error {
 	return r.SendEnvelope(updatedOIDC)
 }
 
+// validateOIDCRoles checks that the default role and the roles the groups map to exist.
+func validateOIDCRoles(app *App, o models.OIDC) error {
+	roles := make([]string, 0)
+	if o.DefaultRole != "" {
+		roles = append(roles, o.DefaultRole)
+	}
+	for _, m := range o.GroupMappings {
+		roles = append(roles, m.Roles...)
+	}
+	if len(roles) == 0 {
+		return nil
+	}
+	known, err := knownRoleNames(app)
+	if err != nil {
+		return err
+	}
+	for _, role := range roles {
+		if !slices.Contains(known, role) {
+			return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.role} `"+role+"`"), nil)
+		}
+	}
+	return nil
+}
+
+// knownRoleNames returns the names of all roles.
+func knownRoleNames(app *App) ([]string, error) {
+	roles, err := app.role.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(roles))
+	for _, role := range roles {
+		names = append(names, role.Name)
+	}
+	return names, nil
+}
+
 // handleDeleteOIDC deletes an OIDC record.
 func handleDeleteOIDC(r *fastglue.Request) error {
 	var app = r.Context.(*App)
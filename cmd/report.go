@@ -2,7 +2,12 @@ package main
 
 import (
 	"strconv"
+	"time"
 
+	amodels "github.com/abhinavxd/libredesk/internal/auth/models"
+	authzModels "github.com/abhinavxd/libredesk/internal/authz/models"
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	"github.com/abhinavxd/libredesk/internal/report"
 	"github.com/zerodha/fastglue"
 )
 
@@ -11,7 +16,11 @@ func handleOverviewCounts(r *fastglue.Request) error {
 	var (
 		app = r.Context.(*App)
 	)
-	counts, err := app.report.GetOverViewCounts()
+	scopes, err := reportScopes(r)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	counts, err := app.report.GetOverViewCounts(scopes)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
@@ -24,7 +33,11 @@ func handleOverviewCharts(r *fastglue.Request) error {
 		app     = r.Context.(*App)
 		days, _ = strconv.Atoi(string(r.RequestCtx.QueryArgs().Peek("days")))
 	)
-	charts, err := app.report.GetOverviewChart(days)
+	scopes, err := reportScopes(r)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	charts, err := app.report.GetOverviewChart(days, scopes)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
@@ -37,9 +50,171 @@ func handleOverviewSLA(r *fastglue.Request) error {
 		app     = r.Context.(*App)
 		days, _ = strconv.Atoi(string(r.RequestCtx.QueryArgs().Peek("days")))
 	)
-	sla, err := app.report.GetOverviewSLA(days)
+	scopes, err := reportScopes(r)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	sla, err := app.report.GetOverviewSLA(days, scopes)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
 	return r.SendEnvelope(sla)
 }
+
+// handleSLAComplianceReport retrieves SLA compliance per metric for a date range grouped by policy, team, agent or metric.
+func handleSLAComplianceReport(r *fastglue.Request) error {
+	var (
+		app     = r.Context.(*App)
+		groupBy = string(r.RequestCtx.QueryArgs().Peek("group_by"))
+	)
+	from, to, err := parseReportDateRange(r)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if groupBy == "" {
+		groupBy = report.SLAGroupByPolicy
+	}
+	scopes, err := reportScopes(r)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	compliance, err := app.report.GetSLACompliance(from, to, groupBy, scopes)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(compliance)
+}
+
+// handleSLABreachesReport retrieves the breached SLA metrics for a date range.
+func handleSLABreachesReport(r *fastglue.Request) error {
+	var (
+		app            = r.Context.(*App)
+		page, _        = strconv.Atoi(string(r.RequestCtx.QueryArgs().Peek("page")))
+		pageSize, _    = strconv.Atoi(string(r.RequestCtx.QueryArgs().Peek("page_size")))
+		slaPolicyID, _ = strconv.Atoi(string(r.RequestCtx.QueryArgs().Peek("sla_policy_id")))
+		teamID, _      = strconv.Atoi(string(r.RequestCtx.QueryArgs().Peek("team_id")))
+		agentID, _     = strconv.Atoi(string(r.RequestCtx.QueryArgs().Peek("agent_id")))
+		metric         = string(r.RequestCtx.QueryArgs().Peek("metric"))
+		total          = 0
+	)
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 100 {
+		pageSize = 100
+	}
+	from, to, err := parseReportDateRange(r)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	scopes, err := reportScopes(r)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	breaches, err := app.report.GetSLABreaches(from, to, report.SLABreachFilters{
+		Metric:      metric,
+		SLAPolicyID: slaPolicyID,
+		TeamID:      teamID,
+		AgentID:     agentID,
+	}, scopes, page, pageSize)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if len(breaches) > 0 {
+		total = breaches[0].Total
+	}
+	return r.SendEnvelope(envelope.PageResults{
+		Results:    breaches,
+		Total:      total,
+		PerPage:    pageSize,
+		TotalPages: (total + pageSize - 1) / pageSize,
+		Page:       page,
+	})
+}
+
+// reportScopes returns the inboxes and teams the reports of the authenticated user are restricted to.
+func reportScopes(r *fastglue.Request) (authzModels.Scopes, error) {
+	var (
+		app   = r.Context.(*App)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+	)
+	user, err := getAuthenticatedAgent(app, auser)
+	if err != nil {
+		return nil, err
+	}
+	return user.RoleScopes.For(authzModels.PermReportsManage), nil
+}
+
+// parseReportDateRange parses the `from` and `to` (YYYY-MM-DD) query params, defaulting to the last 30 days.
+func parseReportDateRange(r *fastglue.Request) (time.Time, time.Time, error) {
+	var (
+		app     = r.Context.(*App)
+		fromStr = string(r.RequestCtx.QueryArgs().Peek("from"))
+		toStr   = string(r.RequestCtx.QueryArgs().Peek("to"))
+		to      = time.Now()
+		from    = to.AddDate(0, 0, -30)
+		err     error
+	)
+	if fromStr != "" {
+		if from, err = time.Parse(time.DateOnly, fromStr); err != nil {
+			return from, to, envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.invalid", "name", "`from`"), nil)
+		}
+	}
+	if toStr != "" {
+		if to, err = time.Parse(time.DateOnly, toStr); err != nil {
+			return from, to, envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.invalid", "name", "`to`"), nil)
+		}
+	}
+	if from.After(to) {
+		return from, to, envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.invalid", "name", "`from`"), nil)
+	}
+	return from, to, nil
+}
+
+// handleCSATReport retrieves the CSAT, NPS and CES scores for a date range grouped by agent, team or inbox.
+func handleCSATReport(r *fastglue.Request) error {
+	var (
+		app     = r.Context.(*App)
+		groupBy = string(r.RequestCtx.QueryArgs().Peek("group_by"))
+	)
+	from, to, err := parseReportDateRange(r)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if groupBy == "" {
+		groupBy = report.CSATGroupByAgent
+	}
+	scopes, err := reportScopes(r)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	csat, err := app.report.GetCSATReport(from, to, groupBy, scopes)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(csat)
+}
+
+// handleAIUsageReport retrieves the AI requests, tokens and latency for a date range grouped by day, agent, prompt or provider.
+func handleAIUsageReport(r *fastglue.Request) error {
+	var (
+		app     = r.Context.(*App)
+		groupBy = string(r.RequestCtx.QueryArgs().Peek("group_by"))
+	)
+	from, to, err := parseReportDateRange(r)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if groupBy == "" {
+		groupBy = report.AIUsageGroupByDay
+	}
+	scopes, err := reportScopes(r)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	usage, err := app.report.GetAIUsageReport(from, to, groupBy, scopes)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(usage)
+}
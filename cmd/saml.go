@@ -0,0 +1,210 @@
+package main
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	"github.com/abhinavxd/libredesk/internal/saml/models"
+	"github.com/abhinavxd/libredesk/internal/stringutil"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+)
+
+// handleGetAllEnabledSAML returns all enabled SAML providers.
+func handleGetAllEnabledSAML(r *fastglue.Request) error {
+	app := r.Context.(*App)
+	out, err := app.saml.GetAllEnabled()
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(out)
+}
+
+// handleGetAllSAML returns all SAML providers.
+func handleGetAllSAML(r *fastglue.Request) error {
+	app := r.Context.(*App)
+	out, err := app.saml.GetAll()
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	for i := range out {
+		out[i].SPPrivateKey = ""
+		out[i].IDPMetadata = ""
+	}
+	return r.SendEnvelope(out)
+}
+
+// handleGetSAML returns a SAML provider by id.
+func handleGetSAML(r *fastglue.Request) error {
+	var app = r.Context.(*App)
+	id, err := strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	if err != nil || id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest,
+			app.i18n.Ts("globals.messages.invalid", "name", "SAML `id`"), nil, envelope.InputError)
+	}
+	s, err := app.saml.Get(id, false)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(s)
+}
+
+// handleCreateSAML creates a new SAML provider.
+func handleCreateSAML(r *fastglue.Request) error {
+	var (
+		app = r.Context.(*App)
+		req = models.SAML{}
+	)
+	if err := r.Decode(&req, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil, envelope.GeneralError)
+	}
+	if err := validateSAML(app, req); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	created, err := app.saml.Create(req)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	// Reload the auth manager to update the SAML providers.
+	if err := reloadAuth(app); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, app.i18n.Ts("globals.messages.couldNotReload", "name", "SAML"), nil, envelope.GeneralError)
+	}
+	return r.SendEnvelope(created)
+}
+
+// handleUpdateSAML updates a SAML provider.
+func handleUpdateSAML(r *fastglue.Request) error {
+	var (
+		app = r.Context.(*App)
+		req = models.SAML{}
+	)
+	id, err := strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	if err != nil || id == 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "SAML `id`"), nil, envelope.InputError)
+	}
+	if err := r.Decode(&req, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil, envelope.GeneralError)
+	}
+	if err := validateSAML(app, req); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	updated, err := app.saml.Update(id, req)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	// Reload the auth manager to update the SAML providers.
+	if err := reloadAuth(app); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, app.i18n.Ts("globals.messages.couldNotReload", "name", "SAML"), nil, envelope.GeneralError)
+	}
+	return r.SendEnvelope(updated)
+}
+
+// handleDeleteSAML deletes a SAML provider.
+func handleDeleteSAML(r *fastglue.Request) error {
+	var app = r.Context.(*App)
+	id, err := strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	if err != nil || id == 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "SAML `id`"), nil, envelope.InputError)
+	}
+	if err = app.saml.Delete(id); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	// Reload the auth manager to remove the SAML provider.
+	if err := reloadAuth(app); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, app.i18n.Ts("globals.messages.couldNotReload", "name", "SAML"), nil, envelope.GeneralError)
+	}
+	return r.SendEnvelope(true)
+}
+
+// handleSAMLLogin redirects to the SAML identity provider for login.
+func handleSAMLLogin(r *fastglue.Request) error {
+	var (
+		app             = r.Context.(*App)
+		providerID, err = strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+
+	loginURL, err := app.auth.SAMLLoginURL(providerID)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.Redirect(loginURL, fasthttp.StatusFound, nil, "")
+}
+
+// handleSAMLMetadata returns the service provider metadata to configure in the identity provider.
+func handleSAMLMetadata(r *fastglue.Request) error {
+	var (
+		app             = r.Context.(*App)
+		providerID, err = strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+
+	metadata, err := app.auth.SAMLMetadata(providerID)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	r.RequestCtx.SetContentType("application/samlmetadata+xml")
+	r.RequestCtx.SetBody(metadata)
+	return nil
+}
+
+// handleSAMLACS is the assertion consumer service, it receives the SAML response posted by the identity provider
+// and logs in the agent with the email of the assertion.
+func handleSAMLACS(r *fastglue.Request) error {
+	var (
+		app             = r.Context.(*App)
+		samlResponse    = string(r.RequestCtx.PostArgs().Peek("SAMLResponse"))
+		relayState      = string(r.RequestCtx.PostArgs().Peek("RelayState"))
+		providerID, err = strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+
+	claims, err := app.auth.ParseSAMLResponse(providerID, samlResponse, relayState)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	email := strings.TrimSpace(strings.ToLower(claims.Email))
+	if !stringutil.ValidEmail(email) {
+		app.lo.Error("invalid email in saml assertion", "provider_id", providerID, "email", claims.Email)
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`email`"), nil, envelope.InputError)
+	}
+
+	// Lookup the user by email and set the session.
+	user, err := app.user.GetAgent(0, email)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if !user.Enabled {
+		return sendErrorEnvelope(r, envelope.NewError(envelope.GeneralError, app.i18n.T("user.accountDisabled"), nil))
+	}
+	if err := completeLogin(r, &user); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.Redirect("/", fasthttp.StatusFound, nil, "")
+}
+
+// validateSAML validates a SAML provider request.
+func validateSAML(app *App, req models.SAML) error {
+	if strings.TrimSpace(req.Name) == "" {
+		return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.empty", "name", "`name`"), nil)
+	}
+	if req.IDPMetadataURL != "" {
+		if u, err := url.ParseRequestURI(req.IDPMetadataURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.invalid", "name", "`idp_metadata_url`"), nil)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,667 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	"github.com/abhinavxd/libredesk/internal/scim"
+	smodels "github.com/abhinavxd/libredesk/internal/setting/models"
+	"github.com/abhinavxd/libredesk/internal/stringutil"
+	tmodels "github.com/abhinavxd/libredesk/internal/team/models"
+	umodels "github.com/abhinavxd/libredesk/internal/user/models"
+	"github.com/valyala/fasthttp"
+	"github.com/volatiletech/null/v9"
+	"github.com/zerodha/fastglue"
+)
+
+const (
+	scimBasePath     = "/scim/v2"
+	scimTokenLength  = 64
+	scimDefaultCount = 100
+	scimMaxCount     = 1000
+	// scimTeamAssignmentType is the conversation assignment type of teams created over SCIM.
+	scimTeamAssignmentType = "Manual"
+)
+
+// handleGetSCIMSettings returns the SCIM settings, the token itself is only returned once when generated.
+func handleGetSCIMSettings(r *fastglue.Request) error {
+	var app = r.Context.(*App)
+	settings, err := getSCIMSettings(app)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	rootURL, err := app.setting.GetAppRootURL()
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(map[string]any{
+		"base_url":        rootURL + scimBasePath,
+		"default_role":    settings.DefaultRole,
+		"token_generated": settings.TokenHash != "",
+	})
+}
+
+// handleUpdateSCIMSettings updates the role of agents provisioned over SCIM without roles.
+func handleUpdateSCIMSettings(r *fastglue.Request) error {
+	var (
+		app = r.Context.(*App)
+		req = struct {
+			DefaultRole string `json:"default_role"`
+		}{}
+	)
+	if err := r.Decode(&req, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil, envelope.InputError)
+	}
+	if req.DefaultRole != "" {
+		roles, err := filterRoleNames(app, []string{req.DefaultRole})
+		if err != nil {
+			return sendErrorEnvelope(r, err)
+		}
+		if len(roles) == 0 {
+			return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`default_role`"), nil, envelope.InputError)
+		}
+	}
+	if err := app.setting.Update(map[string]any{"scim.default_role": req.DefaultRole}); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(true)
+}
+
+// handleGenerateSCIMToken generates a new SCIM bearer token replacing the existing one, only the hash is stored.
+func handleGenerateSCIMToken(r *fastglue.Request) error {
+	var app = r.Context.(*App)
+	token, err := stringutil.RandomAlphanumeric(scimTokenLength)
+	if err != nil {
+		app.lo.Error("error generating scim token", "error", err)
+		return sendErrorEnvelope(r, envelope.NewError(envelope.GeneralError, app.i18n.Ts("globals.messages.errorGenerating", "name", "{globals.terms.token}"), nil))
+	}
+	if err := app.setting.Update(map[string]any{"scim.token_hash": scim.HashToken(token)}); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(map[string]string{
+		"token": token,
+	})
+}
+
+// handleRevokeSCIMToken revokes the SCIM bearer token which disables SCIM.
+func handleRevokeSCIMToken(r *fastglue.Request) error {
+	var app = r.Context.(*App)
+	if err := app.setting.Update(map[string]any{"scim.token_hash": ""}); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(true)
+}
+
+// handleSCIMServiceProviderConfig returns the SCIM features supported.
+func handleSCIMServiceProviderConfig(r *fastglue.Request) error {
+	return sendSCIM(r, fasthttp.StatusOK, map[string]any{
+		"schemas":        []string{"urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"},
+		"patch":          map[string]bool{"supported": true},
+		"bulk":           map[string]any{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         map[string]any{"supported": true, "maxResults": scimMaxCount},
+		"changePassword": map[string]bool{"supported": false},
+		"sort":           map[string]bool{"supported": false},
+		"etag":           map[string]bool{"supported": false},
+		"authenticationSchemes": []map[string]any{{
+			"type":        "oauthbearertoken",
+			"name":        "OAuth Bearer Token",
+			"description": "Authentication with the SCIM token generated in the admin settings.",
+			"primary":     true,
+		}},
+	})
+}
+
+// handleSCIMGetUsers returns a page of agents, or the agent with the email of a `userName eq` filter.
+func handleSCIMGetUsers(r *fastglue.Request) error {
+	var (
+		app               = r.Context.(*App)
+		startIndex, count = scimPagination(r)
+		filter            = string(r.RequestCtx.QueryArgs().Peek("filter"))
+	)
+	rootURL, err := app.setting.GetAppRootURL()
+	if err != nil {
+		return sendSCIMError(r, err)
+	}
+
+	resources := make([]scim.User, 0)
+	if filter != "" {
+		attr, value, err := scim.ParseFilter(filter)
+		if err != nil || (attr != "username" && attr != "emails.value" && attr != "emails") {
+			return sendSCIM(r, fasthttp.StatusBadRequest, scim.NewError(fasthttp.StatusBadRequest, scim.ErrInvalidFilter, scim.ErrUnsupportedFilter.Error()))
+		}
+		agent, err := getSCIMAgent(app, 0, strings.ToLower(strings.TrimSpace(value)))
+		if err != nil {
+			if envErr, ok := err.(envelope.Error); !ok || envErr.ErrorType != envelope.NotFoundError {
+				return sendSCIMError(r, err)
+			}
+		} else {
+			resources = append(resources, scim.NewUser(agent, scimLocation(rootURL, scim.ResourceTypeUser, agent.ID)))
+		}
+		return sendSCIM(r, fasthttp.StatusOK, scim.NewListResponse(resources, len(resources), 1, len(resources)))
+	}
+
+	agents, total, err := app.user.GetAgentsRange(startIndex-1, count)
+	if err != nil {
+		return sendSCIMError(r, err)
+	}
+	for _, agent := range agents {
+		resources = append(resources, scim.NewUser(agent, scimLocation(rootURL, scim.ResourceTypeUser, agent.ID)))
+	}
+	return sendSCIM(r, fasthttp.StatusOK, scim.NewListResponse(resources, total, startIndex, len(resources)))
+}
+
+// handleSCIMGetUser returns an agent.
+func handleSCIMGetUser(r *fastglue.Request) error {
+	var app = r.Context.(*App)
+	agent, err := getSCIMAgent(app, scimResourceID(r), "")
+	if err != nil {
+		return sendSCIMError(r, err)
+	}
+	return sendSCIMUser(r, app, fasthttp.StatusOK, agent.ID)
+}
+
+// handleSCIMCreateUser creates an agent with the roles of the user that exist, or the default role.
+func handleSCIMCreateUser(r *fastglue.Request) error {
+	var (
+		app = r.Context.(*App)
+		req = scim.User{}
+	)
+	if err := json.Unmarshal(r.RequestCtx.PostBody(), &req); err != nil {
+		return sendSCIM(r, fasthttp.StatusBadRequest, scim.NewError(fasthttp.StatusBadRequest, scim.ErrInvalidSyntax, err.Error()))
+	}
+
+	var agent umodels.User
+	if err := applySCIMUser(app, req, &agent); err != nil {
+		return sendSCIMError(r, err)
+	}
+	if _, err := app.user.GetAgent(0, agent.Email.String); err == nil {
+		return sendSCIM(r, fasthttp.StatusConflict, scim.NewError(fasthttp.StatusConflict, scim.ErrUniqueness, app.i18n.T("user.sameEmailAlreadyExists")))
+	}
+	if len(agent.Roles) == 0 {
+		settings, err := getSCIMSettings(app)
+		if err != nil {
+			return sendSCIMError(r, err)
+		}
+		if agent.Roles, err = filterRoleNames(app, []string{settings.DefaultRole}); err != nil {
+			return sendSCIMError(r, err)
+		}
+		if len(agent.Roles) == 0 {
+			return sendSCIM(r, fasthttp.StatusBadRequest, scim.NewError(fasthttp.StatusBadRequest, scim.ErrInvalidValue, app.i18n.Ts("globals.messages.empty", "name", "`roles`")))
+		}
+	}
+
+	if err := app.user.CreateAgent(&agent); err != nil {
+		return sendSCIMError(r, err)
+	}
+	if req.Active != nil && !*req.Active {
+		if err := app.user.ToggleEnabled(agent.ID, umodels.UserTypeAgent, false); err != nil {
+			return sendSCIMError(r, err)
+		}
+	}
+	app.lo.Info("provisioned agent over scim", "user_id", agent.ID, "email", agent.Email.String, "roles", agent.Roles)
+	return sendSCIMUser(r, app, fasthttp.StatusCreated, agent.ID)
+}
+
+// handleSCIMReplaceUser replaces the name, email, roles and active status of an agent.
+func handleSCIMReplaceUser(r *fastglue.Request) error {
+	var (
+		app = r.Context.(*App)
+		req = scim.User{}
+	)
+	agent, err := getSCIMAgent(app, scimResourceID(r), "")
+	if err != nil {
+		return sendSCIMError(r, err)
+	}
+	if err := json.Unmarshal(r.RequestCtx.PostBody(), &req); err != nil {
+		return sendSCIM(r, fasthttp.StatusBadRequest, scim.NewError(fasthttp.StatusBadRequest, scim.ErrInvalidSyntax, err.Error()))
+	}
+	if err := applySCIMUser(app, req, &agent); err != nil {
+		return sendSCIMError(r, err)
+	}
+	if err := updateSCIMAgent(app, agent, req.Active); err != nil {
+		return sendSCIMError(r, err)
+	}
+	return sendSCIMUser(r, app, fasthttp.StatusOK, agent.ID)
+}
+
+// handleSCIMPatchUser applies patch operations to an agent, e.g. `active` set to false when deprovisioned.
+func handleSCIMPatchUser(r *fastglue.Request) error {
+	var (
+		app = r.Context.(*App)
+		req = scim.PatchRequest{}
+	)
+	agent, err := getSCIMAgent(app, scimResourceID(r), "")
+	if err != nil {
+		return sendSCIMError(r, err)
+	}
+	if err := json.Unmarshal(r.RequestCtx.PostBody(), &req); err != nil {
+		return sendSCIM(r, fasthttp.StatusBadRequest, scim.NewError(fasthttp.StatusBadRequest, scim.ErrInvalidSyntax, err.Error()))
+	}
+
+	user := scim.NewUser(agent, "")
+	if err := scim.ApplyUserPatch(&user, req.Operations); err != nil {
+		return sendSCIM(r, fasthttp.StatusBadRequest, scim.NewError(fasthttp.StatusBadRequest, scim.ErrInvalidValue, err.Error()))
+	}
+	if err := applySCIMUser(app, user, &agent); err != nil {
+		return sendSCIMError(r, err)
+	}
+	if err := updateSCIMAgent(app, agent, user.Active); err != nil {
+		return sendSCIMError(r, err)
+	}
+	return sendSCIMUser(r, app, fasthttp.StatusOK, agent.ID)
+}
+
+// handleSCIMDeleteUser soft deletes an agent and unassigns their open conversations.
+func handleSCIMDeleteUser(r *fastglue.Request) error {
+	var app = r.Context.(*App)
+	agent, err := getSCIMAgent(app, scimResourceID(r), "")
+	if err != nil {
+		return sendSCIMError(r, err)
+	}
+	if err := app.user.SoftDeleteAgent(agent.ID); err != nil {
+		return sendSCIMError(r, err)
+	}
+	if err := app.conversation.UnassignOpen(agent.ID); err != nil {
+		return sendSCIMError(r, err)
+	}
+	app.authz.InvalidateUserCache(agent.ID)
+	app.user.InvalidateAgentCache(agent.ID)
+	if err := logoutAgent(app, agent.ID); err != nil {
+		return sendSCIMError(r, err)
+	}
+	app.lo.Info("deprovisioned agent over scim", "user_id", agent.ID, "email", agent.Email.String)
+	r.RequestCtx.SetStatusCode(fasthttp.StatusNoContent)
+	return nil
+}
+
+// handleSCIMGetGroups returns a page of teams, or the team with the name of a `displayName eq` filter.
+func handleSCIMGetGroups(r *fastglue.Request) error {
+	var (
+		app               = r.Context.(*App)
+		startIndex, count = scimPagination(r)
+		filter            = string(r.RequestCtx.QueryArgs().Peek("filter"))
+		excludeMembers    = strings.Contains(strings.ToLower(string(r.RequestCtx.QueryArgs().Peek("excludedAttributes"))), "members")
+	)
+	rootURL, err := app.setting.GetAppRootURL()
+	if err != nil {
+		return sendSCIMError(r, err)
+	}
+	teams, err := app.team.GetAll()
+	if err != nil {
+		return sendSCIMError(r, err)
+	}
+	if filter != "" {
+		attr, value, err := scim.ParseFilter(filter)
+		if err != nil || attr != "displayname" {
+			return sendSCIM(r, fasthttp.StatusBadRequest, scim.NewError(fasthttp.StatusBadRequest, scim.ErrInvalidFilter, scim.ErrUnsupportedFilter.Error()))
+		}
+		teams = slices.DeleteFunc(teams, func(t tmodels.Team) bool {
+			return !strings.EqualFold(t.Name, value)
+		})
+	}
+
+	total := len(teams)
+	teams = teams[min(startIndex-1, total):min(startIndex-1+count, total)]
+	resources := make([]scim.Group, 0, len(teams))
+	for _, team := range teams {
+		var members []umodels.User
+		if !excludeMembers {
+			if members, err = app.team.GetAllMembers(team.ID); err != nil {
+				return sendSCIMError(r, err)
+			}
+		}
+		resources = append(resources, scim.NewGroup(team, members, scimLocation(rootURL, scim.ResourceTypeGroup, team.ID)))
+	}
+	return sendSCIM(r, fasthttp.StatusOK, scim.NewListResponse(resources, total, startIndex, len(resources)))
+}
+
+// handleSCIMGetGroup returns a team.
+func handleSCIMGetGroup(r *fastglue.Request) error {
+	var app = r.Context.(*App)
+	team, err := app.team.Get(scimResourceID(r))
+	if err != nil {
+		return sendSCIMError(r, err)
+	}
+	return sendSCIMGroup(r, app, fasthttp.StatusOK, team.ID)
+}
+
+// handleSCIMCreateGroup creates a team with manual conversation assignment and the workspace timezone.
+func handleSCIMCreateGroup(r *fastglue.Request) error {
+	var (
+		app = r.Context.(*App)
+		req = scim.Group{}
+	)
+	if err := json.Unmarshal(r.RequestCtx.PostBody(), &req); err != nil {
+		return sendSCIM(r, fasthttp.StatusBadRequest, scim.NewError(fasthttp.StatusBadRequest, scim.ErrInvalidSyntax, err.Error()))
+	}
+	name := strings.TrimSpace(req.DisplayName)
+	if name == "" {
+		return sendSCIM(r, fasthttp.StatusBadRequest, scim.NewError(fasthttp.StatusBadRequest, scim.ErrInvalidValue, app.i18n.Ts("globals.messages.empty", "name", "`displayName`")))
+	}
+
+	teams, err := app.team.GetAll()
+	if err != nil {
+		return sendSCIMError(r, err)
+	}
+	if slices.ContainsFunc(teams, func(t tmodels.Team) bool { return strings.EqualFold(t.Name, name) }) {
+		return sendSCIM(r, fasthttp.StatusConflict, scim.NewError(fasthttp.StatusConflict, scim.ErrUniqueness, app.i18n.Ts("globals.messages.errorAlreadyExists", "name", "{globals.terms.team}")))
+	}
+
+	var timezone string
+	if tz, err := app.setting.Get("app.timezone"); err == nil {
+		_ = json.Unmarshal(tz, &timezone)
+	}
+	team, err := app.team.Create(name, timezone, scimTeamAssignmentType, null.Int{}, null.Int{}, "", 0)
+	if err != nil {
+		return sendSCIMError(r, err)
+	}
+	if err := app.team.AddMembers(team.ID, scimMemberIDs(scim.Values(req.Members))); err != nil {
+		return sendSCIMError(r, err)
+	}
+	app.lo.Info("provisioned team over scim", "team_id", team.ID, "name", team.Name)
+	return sendSCIMGroup(r, app, fasthttp.StatusCreated, team.ID)
+}
+
+// handleSCIMReplaceGroup replaces the name and members of a team.
+func handleSCIMReplaceGroup(r *fastglue.Request) error {
+	var (
+		app = r.Context.(*App)
+		req = scim.Group{}
+	)
+	team, err := app.team.Get(scimResourceID(r))
+	if err != nil {
+		return sendSCIMError(r, err)
+	}
+	if err := json.Unmarshal(r.RequestCtx.PostBody(), &req); err != nil {
+		return sendSCIM(r, fasthttp.StatusBadRequest, scim.NewError(fasthttp.StatusBadRequest, scim.ErrInvalidSyntax, err.Error()))
+	}
+	if err := renameSCIMTeam(app, team, req.DisplayName); err != nil {
+		return sendSCIMError(r, err)
+	}
+	if err := setSCIMTeamMembers(app, team.ID, scimMemberIDs(scim.Values(req.Members))); err != nil {
+		return sendSCIMError(r, err)
+	}
+	return sendSCIMGroup(r, app, fasthttp.StatusOK, team.ID)
+}
+
+// handleSCIMPatchGroup applies patch operations to a team, usually adding or removing members.
+func handleSCIMPatchGroup(r *fastglue.Request) error {
+	var (
+		app = r.Context.(*App)
+		req = scim.PatchRequest{}
+	)
+	team, err := app.team.Get(scimResourceID(r))
+	if err != nil {
+		return sendSCIMError(r, err)
+	}
+	if err := json.Unmarshal(r.RequestCtx.PostBody(), &req); err != nil {
+		return sendSCIM(r, fasthttp.StatusBadRequest, scim.NewError(fasthttp.StatusBadRequest, scim.ErrInvalidSyntax, err.Error()))
+	}
+	patch, err := scim.ApplyGroupPatch(req.Operations)
+	if err != nil {
+		return sendSCIM(r, fasthttp.StatusBadRequest, scim.NewError(fasthttp.StatusBadRequest, scim.ErrInvalidValue, err.Error()))
+	}
+
+	if patch.DisplayName != "" {
+		if err := renameSCIMTeam(app, team, patch.DisplayName); err != nil {
+			return sendSCIMError(r, err)
+		}
+	}
+	if patch.ReplaceMembers {
+		if err := setSCIMTeamMembers(app, team.ID, scimMemberIDs(patch.Members)); err != nil {
+			return sendSCIMError(r, err)
+		}
+	}
+	if len(patch.Add) > 0 {
+		if err := app.team.AddMembers(team.ID, scimMemberIDs(patch.Add)); err != nil {
+			return sendSCIMError(r, err)
+		}
+	}
+	if len(patch.Remove) > 0 {
+		if err := app.team.RemoveMembers(team.ID, scimMemberIDs(patch.Remove)); err != nil {
+			return sendSCIMError(r, err)
+		}
+	}
+	return sendSCIMGroup(r, app, fasthttp.StatusOK, team.ID)
+}
+
+// handleSCIMDeleteGroup deletes a team.
+func handleSCIMDeleteGroup(r *fastglue.Request) error {
+	var app = r.Context.(*App)
+	team, err := app.team.Get(scimResourceID(r))
+	if err != nil {
+		return sendSCIMError(r, err)
+	}
+	if err := app.team.Delete(team.ID); err != nil {
+		return sendSCIMError(r, err)
+	}
+	app.lo.Info("deleted team over scim", "team_id", team.ID, "name", team.Name)
+	r.RequestCtx.SetStatusCode(fasthttp.StatusNoContent)
+	return nil
+}
+
+// validSCIMToken returns true if the token matches the SCIM bearer token, always false while no token is generated.
+func validSCIMToken(app *App, token string) (bool, error) {
+	settings, err := getSCIMSettings(app)
+	if err != nil {
+		return false, err
+	}
+	if settings.TokenHash == "" || token == "" {
+		return false, nil
+	}
+	return subtle.ConstantTimeCompare([]byte(scim.HashToken(token)), []byte(settings.TokenHash)) == 1, nil
+}
+
+// getSCIMSettings returns the SCIM settings.
+func getSCIMSettings(app *App) (smodels.SCIMSettings, error) {
+	var settings smodels.SCIMSettings
+	out, err := app.setting.GetByPrefix("scim.")
+	if err != nil {
+		return settings, err
+	}
+	if err := json.Unmarshal(out, &settings); err != nil {
+		app.lo.Error("error unmarshalling scim settings", "error", err)
+		return settings, envelope.NewError(envelope.GeneralError, app.i18n.Ts("globals.messages.errorFetching", "name", app.i18n.T("globals.terms.setting")), nil)
+	}
+	return settings, nil
+}
+
+// getSCIMAgent returns an agent by ID or email, the system user is never exposed over SCIM.
+func getSCIMAgent(app *App, id int, email string) (umodels.User, error) {
+	if id <= 0 && email == "" {
+		return umodels.User{}, envelope.NewError(envelope.NotFoundError, app.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.user}"), nil)
+	}
+	agent, err := app.user.GetAgent(id, email)
+	if err != nil {
+		return agent, err
+	}
+	if agent.Email.String == umodels.SystemUserEmail {
+		return umodels.User{}, envelope.NewError(envelope.NotFoundError, app.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.user}"), nil)
+	}
+	return agent, nil
+}
+
+// applySCIMUser sets the email, name and roles of an agent from a SCIM user, roles that don't exist are ignored
+// and the roles are left unchanged if none of them exist.
+func applySCIMUser(app *App, user scim.User, agent *umodels.User) error {
+	email := strings.ToLower(strings.TrimSpace(user.Email()))
+	if !stringutil.ValidEmail(email) {
+		return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.invalid", "name", "`userName`"), nil)
+	}
+
+	firstName, lastName := strings.TrimSpace(user.Name.GivenName), strings.TrimSpace(user.Name.FamilyName)
+	if firstName == "" {
+		firstName, lastName, _ = strings.Cut(strings.TrimSpace(user.DisplayName), " ")
+	}
+	if firstName == "" {
+		firstName, _, _ = strings.Cut(email, "@")
+	}
+
+	roles, err := filterRoleNames(app, user.RoleNames())
+	if err != nil {
+		return err
+	}
+	if len(roles) > 0 {
+		agent.Roles = roles
+	}
+	agent.Email = null.StringFrom(email)
+	agent.FirstName = firstName
+	agent.LastName = lastName
+	return nil
+}
+
+// updateSCIMAgent saves an agent and enables or disables it if the active status changed.
+func updateSCIMAgent(app *App, agent umodels.User, active *bool) error {
+	if err := app.user.UpdateAgent(agent.ID, agent); err != nil {
+		return err
+	}
+	app.authz.InvalidateUserCache(agent.ID)
+	if active == nil || *active == agent.Enabled {
+		return nil
+	}
+	if err := app.user.ToggleEnabled(agent.ID, umodels.UserTypeAgent, *active); err != nil {
+		return err
+	}
+	if !*active {
+		if err := logoutAgent(app, agent.ID); err != nil {
+			return err
+		}
+	}
+	app.lo.Info("toggled agent over scim", "user_id", agent.ID, "email", agent.Email.String, "enabled", *active)
+	return nil
+}
+
+// renameSCIMTeam renames a team keeping the rest of its settings.
+func renameSCIMTeam(app *App, team tmodels.Team, name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" || name == team.Name {
+		return nil
+	}
+	_, err := app.team.Update(team.ID, name, team.Timezone, team.ConversationAssignmentType, team.BusinessHoursID, team.SLAPolicyID, team.Emoji.String, team.MaxAutoAssignedConversations)
+	return err
+}
+
+// setSCIMTeamMembers replaces the members of a team.
+func setSCIMTeamMembers(app *App, teamID int, userIDs []int) error {
+	members, err := app.team.GetAllMembers(teamID)
+	if err != nil {
+		return err
+	}
+	var remove []int
+	for _, m := range members {
+		if !slices.Contains(userIDs, m.ID) {
+			remove = append(remove, m.ID)
+		}
+	}
+	if len(remove) > 0 {
+		if err := app.team.RemoveMembers(teamID, remove); err != nil {
+			return err
+		}
+	}
+	return app.team.AddMembers(teamID, userIDs)
+}
+
+// filterRoleNames returns the names that are existing roles.
+func filterRoleNames(app *App, names []string) ([]string, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	roles, err := app.role.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	var out []string
+	for _, role := range roles {
+		if slices.Contains(names, role.Name) {
+			out = append(out, role.Name)
+		}
+	}
+	return out, nil
+}
+
+// scimMemberIDs returns the agent IDs of group members, values that are not IDs are ignored.
+func scimMemberIDs(values []string) []int {
+	ids := make([]int, 0, len(values))
+	for _, v := range values {
+		if id, err := strconv.Atoi(v); err == nil && id > 0 {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// scimPagination returns the 1-based start index and the count of the list query parameters.
+func scimPagination(r *fastglue.Request) (int, int) {
+	var (
+		args          = r.RequestCtx.QueryArgs()
+		startIndex, _ = strconv.Atoi(string(args.Peek("startIndex")))
+		count         = scimDefaultCount
+	)
+	if args.Has("count") {
+		count, _ = strconv.Atoi(string(args.Peek("count")))
+	}
+	return max(startIndex, 1), min(max(count, 0), scimMaxCount)
+}
+
+// scimResourceID returns the ID of the resource in the path, 0 if it is not a number.
+func scimResourceID(r *fastglue.Request) int {
+	id, _ := strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	return id
+}
+
+// scimLocation returns the URL of a resource.
+func scimLocation(rootURL, resourceType string, id int) string {
+	return rootURL + scimBasePath + "/" + resourceType + "s/" + strconv.Itoa(id)
+}
+
+// sendSCIMUser sends the SCIM user of an agent.
+func sendSCIMUser(r *fastglue.Request, app *App, status, id int) error {
+	agent, err := app.user.GetAgent(id, "")
+	if err != nil {
+		return sendSCIMError(r, err)
+	}
+	rootURL, err := app.setting.GetAppRootURL()
+	if err != nil {
+		return sendSCIMError(r, err)
+	}
+	return sendSCIM(r, status, scim.NewUser(agent, scimLocation(rootURL, scim.ResourceTypeUser, agent.ID)))
+}
+
+// sendSCIMGroup sends the SCIM group of a team.
+func sendSCIMGroup(r *fastglue.Request, app *App, status, id int) error {
+	team, err := app.team.Get(id)
+	if err != nil {
+		return sendSCIMError(r, err)
+	}
+	members, err := app.team.GetAllMembers(id)
+	if err != nil {
+		return sendSCIMError(r, err)
+	}
+	rootURL, err := app.setting.GetAppRootURL()
+	if err != nil {
+		return sendSCIMError(r, err)
+	}
+	return sendSCIM(r, status, scim.NewGroup(team, members, scimLocation(rootURL, scim.ResourceTypeGroup, team.ID)))
+}
+
+// sendSCIM sends a SCIM response.
+func sendSCIM(r *fastglue.Request, status int, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusInternalServerError, err.Error(), nil, envelope.GeneralError)
+	}
+	return r.SendBytes(status, scim.ContentType, b)
+}
+
+// sendSCIMError sends an error as a SCIM error response.
+func sendSCIMError(r *fastglue.Request, err error) error {
+	status := fasthttp.StatusInternalServerError
+	if e, ok := err.(envelope.Error); ok {
+		status = e.Code
+	}
+	return sendSCIM(r, status, scim.NewError(status, "", err.Error()))
+}
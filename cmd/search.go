@@ -3,6 +3,8 @@ package main
 import (
 	"fmt"
 
+	amodels "github.com/abhinavxd/libredesk/internal/auth/models"
+	authzModels "github.com/abhinavxd/libredesk/internal/authz/models"
 	"github.com/abhinavxd/libredesk/internal/envelope"
 	"github.com/zerodha/fastglue"
 )
@@ -11,20 +13,28 @@ const (
 	minSearchQueryLength = 3
 )
 
-// handleSearchConversations searches conversations based on the query.
+// handleSearchConversations searches conversations based on the query in the inboxes and teams the user can read conversations of.
 func handleSearchConversations(r *fastglue.Request) error {
 	app := r.Context.(*App)
 	wrapper := func(query string) (interface{}, error) {
-		return app.search.Conversations(query)
+		user, err := getAuthenticatedAgent(app, r.RequestCtx.UserValue("user").(amodels.User))
+		if err != nil {
+			return nil, err
+		}
+		return app.search.Conversations(query, user.RoleScopes.For(authzModels.PermConversationsRead))
 	}
 	return handleSearch(r, wrapper)
 }
 
-// handleSearchMessages searches messages based on the query.
+// handleSearchMessages searches messages based on the query in the inboxes and teams the user can read messages of.
 func handleSearchMessages(r *fastglue.Request) error {
 	app := r.Context.(*App)
 	wrapper := func(query string) (interface{}, error) {
-		return app.search.Messages(query)
+		user, err := getAuthenticatedAgent(app, r.RequestCtx.UserValue("user").(amodels.User))
+		if err != nil {
+			return nil, err
+		}
+		return app.search.Messages(query, user.RoleScopes.For(authzModels.PermMessagesRead))
 	}
 	return handleSearch(r, wrapper)
 }
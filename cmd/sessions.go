@@ -0,0 +1,109 @@
+package main
+
+import (
+	"strconv"
+
+	amodels "github.com/abhinavxd/libredesk/internal/auth/models"
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+)
+
+// handleGetCurrentAgentSessions returns the active sessions of the current agent.
+func handleGetCurrentAgentSessions(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+	)
+	sessions, err := app.auth.GetUserSessions(auser.ID, currentSessionID(r, auser))
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(sessions)
+}
+
+// handleDeleteCurrentAgentSession logs the current agent out of one of their sessions.
+func handleDeleteCurrentAgentSession(r *fastglue.Request) error {
+	var (
+		app       = r.Context.(*App)
+		auser     = r.RequestCtx.UserValue("user").(amodels.User)
+		sessionID = r.RequestCtx.UserValue("session_id").(string)
+	)
+	if err := app.auth.DestroyUserSession(auser.ID, sessionID); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	app.wsHub.DisconnectSession(auser.ID, sessionID)
+	return r.SendEnvelope(true)
+}
+
+// handleGetAgentSessions returns the active sessions of an agent.
+func handleGetAgentSessions(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+		id, _ = strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	)
+	if id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+	sessions, err := app.auth.GetUserSessions(id, currentSessionID(r, auser))
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(sessions)
+}
+
+// handleDeleteAgentSession logs an agent out of one of their sessions.
+func handleDeleteAgentSession(r *fastglue.Request) error {
+	var (
+		app       = r.Context.(*App)
+		id, _     = strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+		sessionID = r.RequestCtx.UserValue("session_id").(string)
+	)
+	if id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+	if err := app.auth.DestroyUserSession(id, sessionID); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	app.wsHub.DisconnectSession(id, sessionID)
+	return r.SendEnvelope(true)
+}
+
+// handleDeleteAgentSessions logs an agent out of all their sessions.
+func handleDeleteAgentSessions(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		id, _ = strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	)
+	if id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+	if err := logoutAgent(app, id); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(true)
+}
+
+// logoutAgent logs an agent out of all sessions and disconnects their websocket clients,
+// e.g. when the agent is disabled or their password is reset.
+func logoutAgent(app *App, id int) error {
+	if err := app.auth.DestroyUserSessions(id); err != nil {
+		return err
+	}
+	app.wsHub.DisconnectUser(id)
+	return nil
+}
+
+// currentSessionID returns the ID of the session of the request, empty for requests authenticated with an API key.
+func currentSessionID(r *fastglue.Request, auser amodels.User) string {
+	if auser.APIKeyID > 0 {
+		return ""
+	}
+	app := r.Context.(*App)
+	id, err := app.auth.CurrentSessionID(r)
+	if err != nil {
+		app.lo.Error("error fetching session id", "user_id", auser.ID, "error", err)
+	}
+	return id
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/abhinavxd/libredesk/internal/envelope"
 	"github.com/abhinavxd/libredesk/internal/setting/models"
 	"github.com/abhinavxd/libredesk/internal/stringutil"
+	"github.com/abhinavxd/libredesk/internal/user"
 	"github.com/valyala/fasthttp"
 	"github.com/zerodha/fastglue"
 )
@@ -161,4 +162,83 @@ func handleUpdateArticleSettings(r *fastglue.Request) error {
 	}
 
 	return r.SendEnvelope(true)
-}
\ No newline at end of file
+}
+
+// handleGetAISettings fetches the AI daily token limits.
+func handleGetAISettings(r *fastglue.Request) error {
+	var (
+		app      = r.Context.(*App)
+		settings = models.AISettings{}
+	)
+	out, err := app.setting.GetByPrefix("ai.")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if err := json.Unmarshal(out, &settings); err != nil {
+		app.lo.Error("error unmarshalling AI settings", "err", err)
+		return sendErrorEnvelope(r, envelope.NewError(envelope.GeneralError, app.i18n.Ts("globals.messages.errorFetching", "name", app.i18n.T("globals.terms.setting")), nil))
+	}
+	return r.SendEnvelope(settings)
+}
+
+// handleUpdateAISettings updates the AI daily token limits.
+func handleUpdateAISettings(r *fastglue.Request) error {
+	var (
+		app = r.Context.(*App)
+		req = models.AISettings{}
+	)
+	if err := r.Decode(&req, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.T("globals.messages.badRequest"), nil, envelope.InputError)
+	}
+	if req.UserDailyTokenLimit < 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`ai.user_daily_token_limit`"), nil, envelope.InputError)
+	}
+	if req.WorkspaceDailyTokenLimit < 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`ai.workspace_daily_token_limit`"), nil, envelope.InputError)
+	}
+	if err := app.setting.Update(req); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(true)
+}
+
+// handleGetPasswordPolicy fetches the password policy.
+func handleGetPasswordPolicy(r *fastglue.Request) error {
+	var app = r.Context.(*App)
+	policy, err := app.user.GetPasswordPolicy()
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(policy)
+}
+
+// handleUpdatePasswordPolicy updates the password policy.
+func handleUpdatePasswordPolicy(r *fastglue.Request) error {
+	var (
+		app = r.Context.(*App)
+		req = models.PasswordPolicy{}
+	)
+	if err := r.Decode(&req, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.T("globals.messages.badRequest"), nil, envelope.InputError)
+	}
+	if req.MinLength < user.MinPolicyPasswordLength || req.MinLength > user.MaxPasswordLength {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`password.min_length`"), nil, envelope.InputError)
+	}
+	if req.HistoryCount < 0 || req.HistoryCount > user.MaxPolicyHistoryCount {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`password.history_count`"), nil, envelope.InputError)
+	}
+	if req.MaxAgeDays < 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`password.max_age_days`"), nil, envelope.InputError)
+	}
+	banned := make([]string, 0, len(req.BannedPasswords))
+	for _, p := range req.BannedPasswords {
+		if p = strings.TrimSpace(p); p != "" {
+			banned = append(banned, p)
+		}
+	}
+	req.BannedPasswords = banned
+	if err := app.setting.Update(req); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(true)
+}
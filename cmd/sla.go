@@ -4,7 +4,10 @@ import (
 	"strconv"
 	"time"
 
+	autoModels "github.com/abhinavxd/libredesk/internal/automation/models"
+	cmodels "github.com/abhinavxd/libredesk/internal/conversation/models"
 	"github.com/abhinavxd/libredesk/internal/envelope"
+	slapkg "github.com/abhinavxd/libredesk/internal/sla"
 	smodels "github.com/abhinavxd/libredesk/internal/sla/models"
 	"github.com/valyala/fasthttp"
 	"github.com/zerodha/fastglue"
@@ -54,7 +57,7 @@ func handleCreateSLA(r *fastglue.Request) error {
 		return sendErrorEnvelope(r, err)
 	}
 
-	createdSLA, err := app.sla.Create(sla.Name, sla.Description, sla.FirstResponseTime, sla.ResolutionTime, sla.NextResponseTime, sla.Notifications)
+	createdSLA, err := app.sla.Create(sla.Name, sla.Description, sla.FirstResponseTime, sla.ResolutionTime, sla.NextResponseTime, sla.Notifications, sla.PauseOnStatuses, sla.Escalations)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
@@ -82,7 +85,7 @@ func handleUpdateSLA(r *fastglue.Request) error {
 		return sendErrorEnvelope(r, err)
 	}
 
-	updatedSLA, err := app.sla.Update(id, sla.Name, sla.Description, sla.FirstResponseTime, sla.ResolutionTime, sla.NextResponseTime, sla.Notifications)
+	updatedSLA, err := app.sla.Update(id, sla.Name, sla.Description, sla.FirstResponseTime, sla.ResolutionTime, sla.NextResponseTime, sla.Notifications, sla.PauseOnStatuses, sla.Escalations)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
@@ -145,6 +148,42 @@ func validateSLA(app *App, sla *smodels.SLAPolicy) error {
 		}
 	}
 
+	// Validate escalations if any.
+	for _, e := range sla.Escalations {
+		switch e.Metric {
+		case slapkg.MetricFirstResponse, slapkg.MetricResolution, slapkg.MetricNextResponse, slapkg.MetricAll:
+		default:
+			return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.invalid", "name", "`metric`"), nil)
+		}
+		if len(e.Actions) == 0 {
+			return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.empty", "name", "`actions`"), nil)
+		}
+		for _, a := range e.Actions {
+			if !isSLAEscalationActionAllowed(a.Type) {
+				return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.invalid", "name", "`"+a.Type+"`"), nil)
+			}
+			if len(a.Value) == 0 || a.Value[0] == "" {
+				return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.empty", "name", "`value`"), nil)
+			}
+			if a.Type == autoModels.ActionSendWebhook {
+				webhookID, err := strconv.Atoi(a.Value[0])
+				if err != nil {
+					return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.invalid", "name", "`webhook`"), nil)
+				}
+				if _, err := app.webhook.Get(webhookID); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	// Validate pause statuses, SLAs stop on resolved / closed conversations so pausing on them is not allowed.
+	for _, status := range sla.PauseOnStatuses {
+		if status == "" || status == cmodels.StatusResolved || status == cmodels.StatusClosed {
+			return envelope.NewError(envelope.InputError, app.i18n.Ts("globals.messages.invalid", "name", "`pause_on_statuses`"), nil)
+		}
+	}
+
 	// Validate first response time duration string if not empty.
 	if sla.FirstResponseTime.String != "" {
 		frt, err := time.ParseDuration(sla.FirstResponseTime.String)
@@ -187,3 +226,13 @@ func validateSLA(app *App, sla *smodels.SLAPolicy) error {
 
 	return nil
 }
+
+// isSLAEscalationActionAllowed returns true if the action can be executed as an SLA breach escalation.
+func isSLAEscalationActionAllowed(action string) bool {
+	switch action {
+	case autoModels.ActionAssignTeam, autoModels.ActionSetPriority, autoModels.ActionAddTags, autoModels.ActionSendPrivateNote, autoModels.ActionSendWebhook:
+		return true
+	default:
+		return false
+	}
+}
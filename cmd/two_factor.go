@@ -0,0 +1,251 @@
+package main
+
+import (
+	"strconv"
+
+	amodels "github.com/abhinavxd/libredesk/internal/auth/models"
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	umodels "github.com/abhinavxd/libredesk/internal/user/models"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/fastglue"
+)
+
+// twoFactorReq is the request to complete a login or change two-factor authentication with a code.
+type twoFactorReq struct {
+	// Token is the partial session token returned by the login, unused once logged in.
+	Token string `json:"token"`
+	Code  string `json:"code"`
+}
+
+// handleTwoFactorLogin completes a login with a code from the authenticator app or a recovery code.
+func handleTwoFactorLogin(r *fastglue.Request) error {
+	var (
+		app = r.Context.(*App)
+		ip  = clientIP(r)
+		req = twoFactorReq{}
+	)
+	if err := r.Decode(&req, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil, envelope.InputError)
+	}
+	user, err := getPartialSessionUser(app, req.Token)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if err := app.auth.CheckLoginAllowed(ip, user.Email.String); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	if err := app.user.VerifyTOTP(user.ID, req.Code); err != nil {
+		return sendErrorEnvelope(r, recordTwoFactorFailure(app, req.Token, ip, user, err))
+	}
+	app.auth.DestroyPartialSession(req.Token)
+
+	// Passwords older than the max age of the password policy have to be reset before logging in.
+	if resp, expired, err := passwordExpiredLogin(app, user); err != nil {
+		return sendErrorEnvelope(r, err)
+	} else if expired {
+		return r.SendEnvelope(resp)
+	}
+
+	if err := completeLogin(r, &user); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(user)
+}
+
+// handleTwoFactorLoginSetup generates the secret for a user who has to set up two-factor authentication to log in.
+func handleTwoFactorLoginSetup(r *fastglue.Request) error {
+	var (
+		app = r.Context.(*App)
+		req = twoFactorReq{}
+	)
+	if err := r.Decode(&req, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil, envelope.InputError)
+	}
+	user, err := getPartialSessionUser(app, req.Token)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	enrollment, err := app.user.GenerateTOTPSecret(user.ID, app.consts.Load().(*constants).SiteName, user.Email.String)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(enrollment)
+}
+
+// handleTwoFactorLoginSetupVerify enables two-factor authentication with a code for the generated secret and completes the login,
+// the recovery codes are returned along with the user, or along with the reset token if the password has expired.
+func handleTwoFactorLoginSetupVerify(r *fastglue.Request) error {
+	var (
+		app = r.Context.(*App)
+		ip  = clientIP(r)
+		req = twoFactorReq{}
+	)
+	if err := r.Decode(&req, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil, envelope.InputError)
+	}
+	user, err := getPartialSessionUser(app, req.Token)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if err := app.auth.CheckLoginAllowed(ip, user.Email.String); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	codes, err := app.user.EnableTOTP(user.ID, req.Code)
+	if err != nil {
+		return sendErrorEnvelope(r, recordTwoFactorFailure(app, req.Token, ip, user, err))
+	}
+	app.auth.DestroyPartialSession(req.Token)
+	user.TOTPEnabled = true
+
+	if err := app.activityLog.TwoFactorEnabled(user.ID, user.Email.String, ip); err != nil {
+		app.lo.Error("error creating two-factor enabled activity log", "error", err)
+	}
+
+	// The recovery codes are still shown when the password has expired and has to be reset before logging in.
+	if resp, expired, err := passwordExpiredLogin(app, user); err != nil {
+		return sendErrorEnvelope(r, err)
+	} else if expired {
+		return r.SendEnvelope(map[string]any{
+			"password_expired": resp.PasswordExpired,
+			"token":            resp.Token,
+			"recovery_codes":   codes,
+		})
+	}
+
+	if err := completeLogin(r, &user); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(map[string]any{
+		"user":           user,
+		"recovery_codes": codes,
+	})
+}
+
+// handleGenerateTwoFactorSecret generates the secret for the current agent to set up two-factor authentication.
+func handleGenerateTwoFactorSecret(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+	)
+	enrollment, err := app.user.GenerateTOTPSecret(auser.ID, app.consts.Load().(*constants).SiteName, auser.Email)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(enrollment)
+}
+
+// handleEnableTwoFactor enables two-factor authentication for the current agent and returns the recovery codes.
+func handleEnableTwoFactor(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+		ip    = clientIP(r)
+		req   = twoFactorReq{}
+	)
+	if err := r.Decode(&req, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil, envelope.InputError)
+	}
+
+	codes, err := app.user.EnableTOTP(auser.ID, req.Code)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if err := app.activityLog.TwoFactorEnabled(auser.ID, auser.Email, ip); err != nil {
+		app.lo.Error("error creating two-factor enabled activity log", "error", err)
+	}
+	return r.SendEnvelope(map[string]any{
+		"recovery_codes": codes,
+	})
+}
+
+// handleDisableTwoFactor disables two-factor authentication for the current agent after validating a code,
+// not allowed if a role of the agent requires it.
+func handleDisableTwoFactor(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+		ip    = clientIP(r)
+		req   = twoFactorReq{}
+	)
+	if err := r.Decode(&req, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil, envelope.InputError)
+	}
+
+	user, err := getAuthenticatedAgent(app, auser)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if user.TOTPRequired {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.T("user.twoFactorRequiredByRole"), nil, envelope.InputError)
+	}
+	if err := app.user.VerifyTOTP(user.ID, req.Code); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if err := app.user.DisableTOTP(user.ID); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if err := app.activityLog.TwoFactorDisabled(user.ID, user.Email.String, ip); err != nil {
+		app.lo.Error("error creating two-factor disabled activity log", "error", err)
+	}
+	return r.SendEnvelope(true)
+}
+
+// handleResetTwoFactor resets the two-factor authentication of an agent, e.g. when the agent lost their device,
+// the agent sets it up again on the next login if a role requires it.
+func handleResetTwoFactor(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+		ip    = clientIP(r)
+		id, _ = strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	)
+	if id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+
+	agent, err := app.user.GetAgent(id, "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if err := app.user.DisableTOTP(agent.ID); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if err := app.activityLog.TwoFactorReset(auser.ID, auser.Email, ip, agent.ID, agent.Email.String); err != nil {
+		app.lo.Error("error creating two-factor reset activity log", "error", err)
+	}
+	return r.SendEnvelope(true)
+}
+
+// recordTwoFactorFailure records a wrong code for the partial session and counts it as a failed login of the account,
+// returns the lockout error if the account got locked out and the partial session is discarded, err otherwise.
+func recordTwoFactorFailure(app *App, token, ip string, user umodels.User, err error) error {
+	app.auth.RecordPartialSessionFailure(token)
+	if e, ok := err.(envelope.Error); !ok || e.ErrorType != envelope.InputError {
+		return err
+	}
+	if lockErr := recordLoginFailure(app, ip, user.Email.String, user); lockErr != nil {
+		app.auth.DestroyPartialSession(token)
+		return lockErr
+	}
+	return err
+}
+
+// getPartialSessionUser returns the enabled agent of a login waiting for the second factor.
+func getPartialSessionUser(app *App, token string) (umodels.User, error) {
+	userID, err := app.auth.GetPartialSession(token)
+	if err != nil {
+		return umodels.User{}, err
+	}
+	user, err := app.user.GetAgent(userID, "")
+	if err != nil {
+		return umodels.User{}, err
+	}
+	if !user.Enabled {
+		app.auth.DestroyPartialSession(token)
+		return umodels.User{}, envelope.NewError(envelope.GeneralError, app.i18n.T("user.accountDisabled"), nil)
+	}
+	return user, nil
+}
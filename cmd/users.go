@@ -16,7 +16,6 @@ import (
 	"github.com/abhinavxd/libredesk/internal/stringutil"
 	tmpl "github.com/abhinavxd/libredesk/internal/template"
 	"github.com/abhinavxd/libredesk/internal/user/models"
-	realip "github.com/ferluci/fast-realip"
 	"github.com/valyala/fasthttp"
 	"github.com/volatiletech/null/v9"
 	"github.com/zerodha/fastglue"
@@ -44,6 +43,14 @@ type SetPasswordRequest struct {
 	Password string `json:"password"`
 }
 
+// CreateAPIKeyRequest represents the request to create an API key
+type CreateAPIKeyRequest struct {
+	Name        string    `json:"name"`
+	Permissions []string  `json:"permissions"`
+	AllowedIPs  []string  `json:"allowed_ips"`
+	ExpiresAt   null.Time `json:"expires_at"`
+}
+
 // AvailabilityRequest represents the request to update agent availability
 type AvailabilityRequest struct {
 	Status string `json:"status"`
@@ -84,15 +91,43 @@ func handleGetAgent(r *fastglue.Request) error {
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
+	if until, locked := app.auth.LoginLockedUntil(agent.Email.String); locked {
+		agent.LockedUntil = null.TimeFrom(until)
+	}
 	return r.SendEnvelope(agent)
 }
 
+// handleUnlockAgent lifts the lockout of an agent locked out after too many failed login attempts.
+func handleUnlockAgent(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		auser = r.RequestCtx.UserValue("user").(amodels.User)
+		ip    = clientIP(r)
+		id, _ = strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	)
+	if id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+
+	agent, err := app.user.GetAgent(id, "")
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if err := app.auth.UnlockLogin(agent.Email.String); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	if err := app.activityLog.Unlocked(auser.ID, auser.Email, ip, agent.ID, agent.Email.String); err != nil {
+		app.lo.Error("error creating unlock activity log", "error", err)
+	}
+	return r.SendEnvelope(true)
+}
+
 // handleUpdateAgentAvailability updates the current agent availability.
 func handleUpdateAgentAvailability(r *fastglue.Request) error {
 	var (
 		app      = r.Context.(*App)
 		auser    = r.RequestCtx.UserValue("user").(amodels.User)
-		ip       = realip.FromRequest(r.RequestCtx)
+		ip       = clientIP(r)
 		availReq AvailabilityRequest
 	)
 
@@ -101,7 +136,7 @@ func handleUpdateAgentAvailability(r *fastglue.Request) error {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil, envelope.InputError)
 	}
 
-	agent, err := app.user.GetAgent(auser.ID, "")
+	agent, err := getAuthenticatedAgent(app, auser)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
@@ -132,7 +167,7 @@ func handleGetCurrentAgentTeams(r *fastglue.Request) error {
 		app   = r.Context.(*App)
 		auser = r.RequestCtx.UserValue("user").(amodels.User)
 	)
-	agent, err := app.user.GetAgent(auser.ID, "")
+	agent, err := getAuthenticatedAgent(app, auser)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
@@ -150,7 +185,7 @@ func handleUpdateCurrentAgent(r *fastglue.Request) error {
 		app   = r.Context.(*App)
 		auser = r.RequestCtx.UserValue("user").(amodels.User)
 	)
-	agent, err := app.user.GetAgent(auser.ID, "")
+	agent, err := getAuthenticatedAgent(app, auser)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
@@ -246,7 +281,7 @@ func handleUpdateAgent(r *fastglue.Request) error {
 		app   = r.Context.(*App)
 		user  = models.User{}
 		auser = r.RequestCtx.UserValue("user").(amodels.User)
-		ip    = realip.FromRequest(r.RequestCtx)
+		ip    = clientIP(r)
 		id, _ = strconv.Atoi(r.RequestCtx.UserValue("id").(string))
 	)
 	if id == 0 {
@@ -288,6 +323,13 @@ func handleUpdateAgent(r *fastglue.Request) error {
 	// Invalidate authz cache.
 	defer app.authz.InvalidateUserCache(id)
 
+	// Log the agent out of all sessions if disabled or the password was reset.
+	if (agent.Enabled && !user.Enabled) || user.NewPassword != "" {
+		if err := logoutAgent(app, id); err != nil {
+			return sendErrorEnvelope(r, err)
+		}
+	}
+
 	// Create activity log if user availability status changed.
 	if oldAvailabilityStatus != user.AvailabilityStatus {
 		if err := app.activityLog.UserAvailability(auser.ID, auser.Email, user.AvailabilityStatus, ip, user.Email.String, id); err != nil {
@@ -329,6 +371,10 @@ func handleDeleteAgent(r *fastglue.Request) error {
 		return sendErrorEnvelope(r, err)
 	}
 
+	if err := logoutAgent(app, id); err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
 	return r.SendEnvelope(true)
 }
 
@@ -338,7 +384,7 @@ func handleGetCurrentAgent(r *fastglue.Request) error {
 		app   = r.Context.(*App)
 		auser = r.RequestCtx.UserValue("user").(amodels.User)
 	)
-	u, err := app.user.GetAgent(auser.ID, "")
+	u, err := getAuthenticatedAgent(app, auser)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
@@ -353,7 +399,7 @@ func handleDeleteCurrentAgentAvatar(r *fastglue.Request) error {
 	)
 
 	// Get user
-	agent, err := app.user.GetAgent(auser.ID, "")
+	agent, err := getAuthenticatedAgent(app, auser)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
@@ -449,7 +495,13 @@ func handleSetPassword(r *fastglue.Request) error {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.empty", "name", "{globals.terms.password}"), nil, envelope.InputError)
 	}
 
-	if err := app.user.ResetPassword(req.Token, req.Password); err != nil {
+	userID, err := app.user.ResetPassword(req.Token, req.Password)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+
+	// Log the agent out of all sessions as the old password might have been compromised.
+	if err := logoutAgent(app, userID); err != nil {
 		return sendErrorEnvelope(r, err)
 	}
 
@@ -520,60 +572,70 @@ func uploadUserAvatar(r *fastglue.Request, user *models.User, files []*multipart
 	return nil
 }
 
-// handleGenerateAPIKey generates a new API key for a user
-func handleGenerateAPIKey(r *fastglue.Request) error {
+// handleGetAPIKeys returns the API keys of an agent.
+func handleGetAPIKeys(r *fastglue.Request) error {
+	var (
+		app   = r.Context.(*App)
+		id, _ = strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+	)
+	if id <= 0 {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
+	}
+
+	keys, err := app.user.GetAPIKeys(id)
+	if err != nil {
+		return sendErrorEnvelope(r, err)
+	}
+	return r.SendEnvelope(keys)
+}
+
+// handleCreateAPIKey creates a named API key for an agent, the secret is only returned once.
+func handleCreateAPIKey(r *fastglue.Request) error {
 	var (
 		app   = r.Context.(*App)
 		id, _ = strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+		req   = CreateAPIKeyRequest{}
 	)
 	if id <= 0 {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
 	}
 
+	if err := r.Decode(&req, "json"); err != nil {
+		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.errorParsing", "name", "{globals.terms.request}"), nil, envelope.InputError)
+	}
+
 	// Check if user exists
 	user, err := app.user.GetAgent(id, "")
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
 
-	// Generate API key and secret
-	apiKey, apiSecret, err := app.user.GenerateAPIKey(user.ID)
+	key, secret, err := app.user.CreateAPIKey(user, req.Name, req.Permissions, req.AllowedIPs, req.ExpiresAt)
 	if err != nil {
 		return sendErrorEnvelope(r, err)
 	}
 
-	// Return the API key and secret (only shown once)
-	response := struct {
-		APIKey    string `json:"api_key"`
+	return r.SendEnvelope(struct {
+		models.APIKey
 		APISecret string `json:"api_secret"`
-	}{
-		APIKey:    apiKey,
-		APISecret: apiSecret,
-	}
-
-	return r.SendEnvelope(response)
+	}{key, secret})
 }
 
-// handleRevokeAPIKey revokes a user's API key
+// handleRevokeAPIKey revokes an API key of an agent.
 func handleRevokeAPIKey(r *fastglue.Request) error {
 	var (
-		app   = r.Context.(*App)
-		id, _ = strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+		app      = r.Context.(*App)
+		id, _    = strconv.Atoi(r.RequestCtx.UserValue("id").(string))
+		keyID, _ = strconv.Atoi(r.RequestCtx.UserValue("key_id").(string))
 	)
-	if id <= 0 {
+	if id <= 0 || keyID <= 0 {
 		return r.SendErrorEnvelope(fasthttp.StatusBadRequest, app.i18n.Ts("globals.messages.invalid", "name", "`id`"), nil, envelope.InputError)
 	}
 
-	// Check if user exists
-	_, err := app.user.GetAgent(id, "")
-	if err != nil {
-		return sendErrorEnvelope(r, err)
-	}
-
-	// Revoke API key
-	if err := app.user.RevokeAPIKey(id); err != nil {
+	if err := app.user.RevokeAPIKey(id, keyID); err != nil {
 		return sendErrorEnvelope(r, err)
 	}
+	app.authz.RemoveAPIKey(keyID)
 
 	return r.SendEnvelope(true)
 }
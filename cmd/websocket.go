@@ -32,12 +32,15 @@ func handleWS(r *fastglue.Request, hub *ws.Hub) error {
 		auser = r.RequestCtx.UserValue("user").(amodels.User)
 		app   = r.Context.(*App)
 	)
+	// Session ID to disconnect the client when the session is revoked.
+	sessionID := currentSessionID(r, auser)
 	err := upgrader.Upgrade(r.RequestCtx, func(conn *websocket.Conn) {
 		c := ws.Client{
-			ID:   auser.ID,
-			Hub:  hub,
-			Conn: conn,
-			Send: make(chan wsmodels.WSMessage, 10000),
+			ID:        auser.ID,
+			SessionID: sessionID,
+			Hub:       hub,
+			Conn:      conn,
+			Send:      make(chan wsmodels.WSMessage, 10000),
 		}
 		hub.AddClient(&c)
 		go c.Listen()
@@ -100,6 +100,12 @@ const updateBusinessHours = (id, data) =>
     }
   })
 const deleteBusinessHours = (id) => http.delete(`/api/v1/business-hours/${id}`)
+const importHolidays = (data) =>
+  http.post('/api/v1/business-hours/holidays/import', data, {
+    headers: {
+      'Content-Type': 'multipart/form-data'
+    }
+  })
 
 const getAllSLAs = () => http.get('/api/v1/sla')
 const getSLA = (id) => http.get(`/api/v1/sla/${id}`)
@@ -132,6 +138,22 @@ const updateOIDC = (id, data) =>
     }
   })
 const deleteOIDC = (id) => http.delete(`/api/v1/oidc/${id}`)
+const createSAML = (data) =>
+  http.post('/api/v1/saml', data, {
+    headers: {
+      'Content-Type': 'application/json'
+    }
+  })
+const getAllEnabledSAML = () => http.get('/api/v1/saml/enabled')
+const getAllSAML = () => http.get('/api/v1/saml')
+const getSAML = (id) => http.get(`/api/v1/saml/${id}`)
+const updateSAML = (id, data) =>
+  http.put(`/api/v1/saml/${id}`, data, {
+    headers: {
+      'Content-Type': 'application/json'
+    }
+  })
+const deleteSAML = (id) => http.delete(`/api/v1/saml/${id}`)
 const updateSettings = (key, data) =>
   http.put(`/api/v1/settings/${key}`, data, {
     headers: {
@@ -144,6 +166,21 @@ const login = (data) => http.post(`/api/v1/auth/login`, data, {
     'Content-Type': 'application/json'
   }
 })
+const twoFactorLogin = (data) => http.post(`/api/v1/auth/2fa/verify`, data, {
+  headers: {
+    'Content-Type': 'application/json'
+  }
+})
+const twoFactorLoginSetup = (data) => http.post(`/api/v1/auth/2fa/setup`, data, {
+  headers: {
+    'Content-Type': 'application/json'
+  }
+})
+const twoFactorLoginSetupVerify = (data) => http.post(`/api/v1/auth/2fa/setup/verify`, data, {
+  headers: {
+    'Content-Type': 'application/json'
+  }
+})
 const getAutomationRules = (type) =>
   http.get(`/api/v1/automations/rules`, {
     params: { type: type }
@@ -306,6 +343,8 @@ const getConversationMessage = (cuuid, uuid) =>
   http.get(`/api/v1/conversations/${cuuid}/messages/${uuid}`)
 const retryMessage = (cuuid, uuid) =>
   http.put(`/api/v1/conversations/${cuuid}/messages/${uuid}/retry`)
+const cancelMessage = (cuuid, uuid) =>
+  http.put(`/api/v1/conversations/${cuuid}/messages/${uuid}/cancel`)
 const getConversationMessages = (uuid, params) =>
   http.get(`/api/v1/conversations/${uuid}/messages`, { params })
 const sendMessage = (uuid, data) =>
@@ -314,6 +353,14 @@ const sendMessage = (uuid, data) =>
       'Content-Type': 'application/json'
     }
   })
+const updateScheduledMessage = (cuuid, uuid, data) =>
+  http.put(`/api/v1/conversations/${cuuid}/messages/${uuid}/schedule`, data, {
+    headers: {
+      'Content-Type': 'application/json'
+    }
+  })
+const cancelScheduledMessage = (cuuid, uuid) =>
+  http.delete(`/api/v1/conversations/${cuuid}/messages/${uuid}/schedule`)
 const getConversation = (uuid) => http.get(`/api/v1/conversations/${uuid}`)
 const getConversationParticipants = (uuid) => http.get(`/api/v1/conversations/${uuid}/participants`)
 const getAllMacros = () => http.get('/api/v1/macros')
@@ -331,6 +378,20 @@ const updateMacro = (id, data) =>
     }
   })
 const deleteMacro = (id) => http.delete(`/api/v1/macros/${id}`)
+const mergeConversation = (uuid, data) =>
+  http.post(`/api/v1/conversations/${uuid}/merge`, data, {
+    headers: {
+      'Content-Type': 'application/json'
+    }
+  })
+const splitMessage = (cuuid, uuid) =>
+  http.post(`/api/v1/conversations/${cuuid}/messages/${uuid}/split`)
+const bulkUpdateConversations = (data) =>
+  http.post('/api/v1/conversations/bulk', data, {
+    headers: {
+      'Content-Type': 'application/json'
+    }
+  })
 const applyMacro = (uuid, id, data) =>
   http.post(`/api/v1/conversations/${uuid}/macros/${id}/apply`, data, {
     headers: {
@@ -354,6 +415,47 @@ const uploadMedia = (data) =>
 const getOverviewCounts = () => http.get('/api/v1/reports/overview/counts')
 const getOverviewCharts = (params) => http.get('/api/v1/reports/overview/charts', { params })
 const getOverviewSLA = (params) => http.get('/api/v1/reports/overview/sla', { params })
+const getSLAReport = (params) => http.get('/api/v1/reports/sla', { params })
+const getSLABreaches = (params) => http.get('/api/v1/reports/sla/breaches', { params })
+const getCSATReport = (params) => http.get('/api/v1/reports/csat', { params })
+const getAIUsageReport = (params) => http.get('/api/v1/reports/ai-usage', { params })
+const getAISettings = () => http.get('/api/v1/settings/ai')
+const updateAISettings = (data) =>
+  http.put('/api/v1/settings/ai', data, {
+    headers: {
+      'Content-Type': 'application/json'
+    }
+  })
+const getSCIMSettings = () => http.get('/api/v1/settings/scim')
+const updateSCIMSettings = (data) =>
+  http.put('/api/v1/settings/scim', data, {
+    headers: {
+      'Content-Type': 'application/json'
+    }
+  })
+const getPasswordPolicy = () => http.get('/api/v1/settings/password-policy')
+const updatePasswordPolicy = (data) =>
+  http.put('/api/v1/settings/password-policy', data, {
+    headers: {
+      'Content-Type': 'application/json'
+    }
+  })
+const generateSCIMToken = () => http.post('/api/v1/settings/scim/token')
+const revokeSCIMToken = () => http.delete('/api/v1/settings/scim/token')
+const getCSATSurvey = (inboxId) => http.get(`/api/v1/inboxes/${inboxId}/csat-survey`)
+const updateCSATSurvey = (inboxId, data) =>
+  http.put(`/api/v1/inboxes/${inboxId}/csat-survey`, data, {
+    headers: {
+      'Content-Type': 'application/json'
+    }
+  })
+const getAIInboxSettings = (inboxId) => http.get(`/api/v1/inboxes/${inboxId}/ai-settings`)
+const updateAIInboxSettings = (inboxId, data) =>
+  http.put(`/api/v1/inboxes/${inboxId}/ai-settings`, data, {
+    headers: {
+      'Content-Type': 'application/json'
+    }
+  })
 const getLanguage = (lang) => http.get(`/api/v1/lang/${lang}`)
 const createInbox = (data) =>
   http.post('/api/v1/inboxes', data, {
@@ -386,16 +488,46 @@ const updateView = (id, data) =>
   })
 const deleteView = (id) => http.delete(`/api/v1/views/me/${id}`)
 const getAiPrompts = () => http.get('/api/v1/ai/prompts')
+const getAiPrompt = (id) => http.get(`/api/v1/ai/prompts/${id}`)
+const createAiPrompt = (data) => http.post('/api/v1/ai/prompts', data, {
+  headers: {
+    'Content-Type': 'application/json'
+  }
+})
+const updateAiPrompt = (id, data) => http.put(`/api/v1/ai/prompts/${id}`, data, {
+  headers: {
+    'Content-Type': 'application/json'
+  }
+})
+const deleteAiPrompt = (id) => http.delete(`/api/v1/ai/prompts/${id}`)
 const aiCompletion = (data) => http.post('/api/v1/ai/completion', data, {
   headers: {
     'Content-Type': 'application/json'
   }
 })
+const aiDraftReply = (uuid, data) => http.post(`/api/v1/conversations/${uuid}/ai/draft-reply`, data, {
+  headers: {
+    'Content-Type': 'application/json'
+  }
+})
 const updateAIProvider = (data) => http.put('/api/v1/ai/provider', data, {
   headers: {
     'Content-Type': 'application/json'
   }
 })
+const getAIProviders = () => http.get('/api/v1/ai/providers')
+const createAIProvider = (data) => http.post('/api/v1/ai/providers', data, {
+  headers: {
+    'Content-Type': 'application/json'
+  }
+})
+const updateAIProviderByID = (id, data) => http.put(`/api/v1/ai/providers/${id}`, data, {
+  headers: {
+    'Content-Type': 'application/json'
+  }
+})
+const deleteAIProvider = (id) => http.delete(`/api/v1/ai/providers/${id}`)
+const setDefaultAIProvider = (id) => http.put(`/api/v1/ai/providers/${id}/default`)
 const getContactNotes = (id) => http.get(`/api/v1/contacts/${id}/notes`)
 const createContactNote = (id, data) => http.post(`/api/v1/contacts/${id}/notes`, data, {
   headers: {
@@ -422,14 +554,36 @@ const deleteWebhook = (id) => http.delete(`/api/v1/webhooks/${id}`)
 const toggleWebhook = (id) => http.put(`/api/v1/webhooks/${id}/toggle`)
 const testWebhook = (id) => http.post(`/api/v1/webhooks/${id}/test`)
 
-const generateAPIKey = (id) => 
-  http.post(`/api/v1/agents/${id}/api-key`, {}, {
+const getAPIKeys = (id) => http.get(`/api/v1/agents/${id}/api-keys`)
+const createAPIKey = (id, data) =>
+  http.post(`/api/v1/agents/${id}/api-keys`, data, {
+    headers: {
+      'Content-Type': 'application/json'
+    }
+  })
+const revokeAPIKey = (id, keyID) => http.delete(`/api/v1/agents/${id}/api-keys/${keyID}`)
+const resetTwoFactor = (id) => http.delete(`/api/v1/agents/${id}/2fa`)
+const getCurrentAgentSessions = () => http.get('/api/v1/agents/me/sessions')
+const revokeCurrentAgentSession = (sessionID) =>
+  http.delete(`/api/v1/agents/me/sessions/${sessionID}`)
+const getAgentSessions = (id) => http.get(`/api/v1/agents/${id}/sessions`)
+const revokeAgentSession = (id, sessionID) =>
+  http.delete(`/api/v1/agents/${id}/sessions/${sessionID}`)
+const revokeAgentSessions = (id) => http.delete(`/api/v1/agents/${id}/sessions`)
+const unlockAgent = (id) => http.delete(`/api/v1/agents/${id}/lockout`)
+const generateTwoFactorSecret = () => http.post('/api/v1/agents/me/2fa')
+const enableTwoFactor = (data) =>
+  http.put('/api/v1/agents/me/2fa', data, {
+    headers: {
+      'Content-Type': 'application/json'
+    }
+  })
+const disableTwoFactor = (data) =>
+  http.post('/api/v1/agents/me/2fa/disable', data, {
     headers: {
       'Content-Type': 'application/json'
     }
   })
-
-const revokeAPIKey = (id) => http.delete(`/api/v1/agents/${id}/api-key`)
 
 const getArticleCategories = () => http.get('/api/v1/article/categories')
 const createArticleCategory = (data) => http.post('/api/v1/article/category', data)
@@ -454,6 +608,9 @@ const unpublishArticle = (id) => http.put(`/api/v1/article/${id}/unpublish`)
 
 export default {
   login,
+  twoFactorLogin,
+  twoFactorLoginSetup,
+  twoFactorLoginSetupVerify,
   deleteUser,
   resetPassword,
   setPassword,
@@ -479,6 +636,7 @@ export default {
   createBusinessHours,
   updateBusinessHours,
   deleteBusinessHours,
+  importHolidays,
   getAllSLAs,
   getSLA,
   createSLA,
@@ -492,6 +650,22 @@ export default {
   getOverviewCharts,
   getOverviewCounts,
   getOverviewSLA,
+  getSLAReport,
+  getSLABreaches,
+  getCSATReport,
+  getAIUsageReport,
+  getAISettings,
+  updateAISettings,
+  getSCIMSettings,
+  updateSCIMSettings,
+  getPasswordPolicy,
+  updatePasswordPolicy,
+  generateSCIMToken,
+  revokeSCIMToken,
+  getCSATSurvey,
+  updateCSATSurvey,
+  getAIInboxSettings,
+  updateAIInboxSettings,
   getConversationParticipants,
   getConversationMessage,
   getConversationMessages,
@@ -503,6 +677,9 @@ export default {
   updateMacro,
   deleteMacro,
   applyMacro,
+  bulkUpdateConversations,
+  mergeConversation,
+  splitMessage,
   updateCurrentUser,
   updateAssignee,
   updateConversationStatus,
@@ -518,12 +695,20 @@ export default {
   updateAutomationRuleWeights,
   updateAutomationRulesExecutionMode,
   updateAIProvider,
+  getAIProviders,
+  createAIProvider,
+  updateAIProviderByID,
+  deleteAIProvider,
+  setDefaultAIProvider,
   createAutomationRule,
   toggleAutomationRule,
   deleteAutomationRule,
   createConversation,
   sendMessage,
+  updateScheduledMessage,
+  cancelScheduledMessage,
   retryMessage,
+  cancelMessage,
   createUser,
   createInbox,
   updateInbox,
@@ -539,6 +724,12 @@ export default {
   getOIDC,
   updateOIDC,
   deleteOIDC,
+  createSAML,
+  getAllSAML,
+  getAllEnabledSAML,
+  getSAML,
+  updateSAML,
+  deleteSAML,
   getTemplate,
   getTemplates,
   createTemplate,
@@ -562,7 +753,12 @@ export default {
   updateView,
   deleteView,
   getAiPrompts,
+  getAiPrompt,
+  createAiPrompt,
+  updateAiPrompt,
+  deleteAiPrompt,
   aiCompletion,
+  aiDraftReply,
   searchConversations,
   searchMessages,
   searchContacts,
@@ -587,8 +783,19 @@ export default {
   deleteWebhook,
   toggleWebhook,
   testWebhook,
-  generateAPIKey,
+  getAPIKeys,
+  createAPIKey,
   revokeAPIKey,
+  resetTwoFactor,
+  getCurrentAgentSessions,
+  revokeCurrentAgentSession,
+  getAgentSessions,
+  revokeAgentSession,
+  revokeAgentSessions,
+  unlockAgent,
+  generateTwoFactorSecret,
+  enableTwoFactor,
+  disableTwoFactor,
   getArticleCategories,
   createArticleCategory,
   updateArticleCategory,
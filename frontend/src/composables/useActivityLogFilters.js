@@ -34,6 +34,24 @@ export function useActivityLogFilters () {
             }, {
                 label: 'Agent online',
                 value: 'agent_online'
+            }, {
+                label: 'Agent 2FA enabled',
+                value: 'agent_2fa_enabled'
+            }, {
+                label: 'Agent 2FA disabled',
+                value: 'agent_2fa_disabled'
+            }, {
+                label: 'Agent 2FA reset',
+                value: 'agent_2fa_reset'
+            }, {
+                label: 'Agent login failed',
+                value: 'agent_login_failed'
+            }, {
+                label: 'Agent locked out',
+                value: 'agent_locked_out'
+            }, {
+                label: 'Agent unlocked',
+                value: 'agent_unlocked'
             }]
         },
     }))
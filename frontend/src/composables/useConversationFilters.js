@@ -300,6 +300,18 @@ export function useConversationFilters () {
             type: FIELD_TYPE.SELECT,
             options: cStore.priorityOptions
         },
+        send_private_note: {
+            label: t('globals.messages.send', {
+                name: t('globals.terms.privateNote').toLowerCase()
+            }),
+            type: FIELD_TYPE.RICHTEXT
+        },
+        send_reply: {
+            label: t('globals.messages.send', {
+                name: t('globals.terms.reply').toLowerCase()
+            }),
+            type: FIELD_TYPE.RICHTEXT
+        },
         add_tags: {
             label: t('globals.messages.add', {
                 name: t('globals.terms.tag', 2).toLowerCase()
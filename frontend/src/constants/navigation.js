@@ -3,6 +3,21 @@ export const reportsNavItems = [
     titleKey: 'globals.terms.overview',
     href: '/reports/overview',
     permission: 'reports:manage'
+  },
+  {
+    titleKey: 'globals.terms.sla',
+    href: '/reports/sla',
+    permission: 'reports:manage'
+  },
+  {
+    titleKey: 'globals.terms.csat',
+    href: '/reports/csat',
+    permission: 'reports:manage'
+  },
+  {
+    titleKey: 'report.aiUsage.title',
+    href: '/reports/ai-usage',
+    permission: 'reports:manage'
   }
 ]
 
@@ -129,6 +144,21 @@ export const adminNavItems = [
         titleKey: 'globals.terms.sso',
         href: '/admin/sso',
         permission: 'oidc:manage'
+      },
+      {
+        titleKey: 'globals.terms.saml',
+        href: '/admin/saml',
+        permission: 'oidc:manage'
+      },
+      {
+        titleKey: 'globals.terms.scim',
+        href: '/admin/scim',
+        permission: 'users:manage'
+      },
+      {
+        titleKey: 'globals.terms.passwordPolicy',
+        href: '/admin/password-policy',
+        permission: 'users:manage'
       }
     ]
   },
@@ -140,6 +170,16 @@ export const adminNavItems = [
         titleKey: 'globals.terms.webhook',
         href: '/admin/webhooks',
         permission: 'webhooks:manage'
+      },
+      {
+        titleKey: 'globals.terms.aiProvider',
+        href: '/admin/ai-providers',
+        permission: 'ai:manage'
+      },
+      {
+        titleKey: 'globals.terms.prompt',
+        href: '/admin/ai-prompts',
+        permission: 'ai_prompts:manage'
       }
     ]
   }
@@ -149,6 +189,10 @@ export const accountNavItems = [
   {
     titleKey: 'globals.terms.profile',
     href: '/account/profile'
+  },
+  {
+    titleKey: 'globals.terms.security',
+    href: '/account/security'
   }
 ]
 
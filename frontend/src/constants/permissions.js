@@ -10,6 +10,8 @@ export const permissions = {
   CONVERSATIONS_UPDATE_PRIORITY: 'conversations:update_priority',
   CONVERSATIONS_UPDATE_STATUS: 'conversations:update_status',
   CONVERSATIONS_UPDATE_TAGS: 'conversations:update_tags',
+  CONVERSATIONS_MERGE: 'conversations:merge',
+  CONVERSATIONS_UPDATE_CUSTOM_ATTRIBUTES: 'conversations:update_custom_attributes',
   MESSAGES_READ: 'messages:read',
   MESSAGES_WRITE: 'messages:write',
   VIEW_MANAGE: 'view:manage',
@@ -18,22 +20,29 @@ export const permissions = {
   STATUS_MANAGE: 'status:manage',
   OIDC_MANAGE: 'oidc:manage',
   TAGS_MANAGE: 'tags:manage',
+  TAGS_READ: 'tags:read',
   MACROS_MANAGE: 'macros:manage',
+  MACROS_READ: 'macros:read',
+  MACROS_APPLY: 'macros:apply',
   USERS_MANAGE: 'users:manage',
   TEAMS_MANAGE: 'teams:manage',
   AUTOMATIONS_MANAGE: 'automations:manage',
   INBOXES_MANAGE: 'inboxes:manage',
+  INBOXES_READ: 'inboxes:read',
   ROLES_MANAGE: 'roles:manage',
   TEMPLATES_MANAGE: 'templates:manage',
   REPORTS_MANAGE: 'reports:manage',
   BUSINESS_HOURS_MANAGE: 'business_hours:manage',
   SLA_MANAGE: 'sla:manage',
   AI_MANAGE: 'ai:manage',
+  AI_PROMPTS_MANAGE: 'ai_prompts:manage',
+  AI_USE: 'ai:use',
   CUSTOM_ATTRIBUTES_MANAGE: 'custom_attributes:manage',
   CONTACTS_READ_ALL: 'contacts:read_all',
   CONTACTS_READ: 'contacts:read',
   CONTACTS_WRITE: 'contacts:write',
   CONTACTS_BLOCK: 'contacts:block',
+  CONTACTS_UPDATE_CUSTOM_ATTRIBUTES: 'contacts:update_custom_attributes',
   CONTACT_NOTES_READ: 'contact_notes:read',
   CONTACT_NOTES_WRITE: 'contact_notes:write',
   CONTACT_NOTES_DELETE: 'contact_notes:delete',
@@ -2,4 +2,5 @@ export const WS_EVENT = {
     NEW_MESSAGE: 'new_message',
     MESSAGE_PROP_UPDATE: 'message_prop_update',
     CONVERSATION_PROP_UPDATE: 'conversation_prop_update',
+    BULK_ACTION_PROGRESS: 'bulk_action_progress',
 }
\ No newline at end of file
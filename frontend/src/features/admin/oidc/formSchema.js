@@ -21,4 +21,17 @@ export const createFormSchema = (t) => z.object({
   }),
   redirect_uri: z.string().readonly().optional(),
   enabled: z.boolean().default(true).optional(),
+  provision_users: z.boolean().default(false).optional(),
+  default_role: z.string().optional(),
+  groups_claim: z.string().optional(),
+  group_mappings: z
+    .array(
+      z.object({
+        group: z.string().min(1, { message: t('globals.messages.required') }),
+        roles: z.array(z.string()).default([]),
+        teams: z.array(z.string()).default([])
+      })
+    )
+    .default([])
+    .optional(),
 })
@@ -22,5 +22,8 @@ export const createFormSchema = (t) => z.object({
     .max(300, {
       message: t('form.error.minmax', { min: 2, max: 300 })
     }),
-  permissions: z.array(z.string()).optional()
+  permissions: z.array(z.string()).optional(),
+  inbox_ids: z.array(z.string()).default([]),
+  team_ids: z.array(z.string()).default([]),
+  require_two_factor: z.boolean().default(false)
 })
@@ -0,0 +1,46 @@
+import { h } from 'vue'
+import dropdown from './dataTableDropdown.vue'
+import { format } from 'date-fns'
+
+export const createColumns = (t) => [
+  {
+    accessorKey: 'name',
+    header: function () {
+      return h('div', { class: 'text-center' }, t('globals.terms.name'))
+    },
+    cell: function ({ row }) {
+      return h('div', { class: 'text-center font-medium' }, row.getValue('name'))
+    }
+  },
+  {
+    accessorKey: 'enabled',
+    header: () => h('div', { class: 'text-center' }, t('globals.terms.enabled')),
+    cell: ({ row }) => {
+      const enabled = row.getValue('enabled')
+      return h('div', { class: 'text-center' }, enabled ? t('globals.messages.yes') : t('globals.messages.no'))
+    }
+  },
+  {
+    accessorKey: 'updated_at',
+    header: function () {
+      return h('div', { class: 'text-center' }, t('globals.terms.updatedAt'))
+    },
+    cell: function ({ row }) {
+      return h('div', { class: 'text-center' }, format(row.getValue('updated_at'), 'PPpp'))
+    }
+  },
+  {
+    id: 'actions',
+    enableHiding: false,
+    cell: ({ row }) => {
+      const provider = row.original
+      return h(
+        'div',
+        { class: 'relative' },
+        h(dropdown, {
+          provider
+        })
+      )
+    }
+  }
+]
@@ -0,0 +1,27 @@
+import * as z from 'zod'
+
+export const createFormSchema = (t) =>
+  z
+    .object({
+      name: z.string({
+        required_error: t('globals.messages.required')
+      }),
+      idp_metadata_url: z
+        .string()
+        .url({
+          message: t('form.error.validUrl')
+        })
+        .optional()
+        .or(z.literal('')),
+      idp_metadata: z.string().optional(),
+      email_attribute: z.string().optional(),
+      first_name_attribute: z.string().optional(),
+      last_name_attribute: z.string().optional(),
+      entity_id: z.string().readonly().optional(),
+      acs_url: z.string().readonly().optional(),
+      enabled: z.boolean().default(true).optional()
+    })
+    .refine((data) => data.idp_metadata_url || data.idp_metadata, {
+      message: t('admin.saml.metadataRequired'),
+      path: ['idp_metadata_url']
+    })
@@ -21,6 +21,29 @@ export const createFormSchema = (t) =>
             next_response_time: z.string().nullable().optional().refine(val => !val || isGoHourMinuteDuration(val), {
                 message: t('globals.messages.goHourMinuteDuration'),
             }),
+            pause_on_statuses: z.array(z.string()).optional().default([]),
+            escalations: z
+                .array(
+                    z.object({
+                        metric: z.enum(['first_response', 'resolution', 'next_response', 'all']),
+                        actions: z
+                            .array(
+                                z.object({
+                                    type: z.enum(['assign_team', 'set_priority', 'add_tags', 'send_private_note', 'send_webhook']),
+                                    value: z.array(z.string()).refine(val => val.length > 0 && val[0] !== '', {
+                                        message: t('globals.messages.required'),
+                                    }),
+                                })
+                            )
+                            .min(1, {
+                                message: t('globals.messages.selectAtLeastOne', {
+                                    name: t('globals.terms.action').toLowerCase(),
+                                })
+                            }),
+                    })
+                )
+                .optional()
+                .default([]),
             notifications: z
                 .array(
                     z
@@ -57,6 +57,24 @@ const routes = [
             name: 'overview',
             component: () => import('@/views/reports/OverviewView.vue'),
             meta: { title: 'Overview' }
+          },
+          {
+            path: 'sla',
+            name: 'sla-report',
+            component: () => import('@/views/reports/SLAReportView.vue'),
+            meta: { title: 'SLA' }
+          },
+          {
+            path: 'csat',
+            name: 'csat-report',
+            component: () => import('@/views/reports/CSATReportView.vue'),
+            meta: { title: 'CSAT' }
+          },
+          {
+            path: 'ai-usage',
+            name: 'ai-usage-report',
+            component: () => import('@/views/reports/AIUsageReportView.vue'),
+            meta: { title: 'AI usage' }
           }
         ]
       },
@@ -160,6 +178,12 @@ const routes = [
             name: 'profile',
             component: () => import('@/views/account/profile/ProfileEditView.vue'),
             meta: { title: 'Edit Profile' }
+          },
+          {
+            path: 'security',
+            name: 'security',
+            component: () => import('@/views/account/security/SecurityView.vue'),
+            meta: { title: 'Security' }
           }
         ]
       },
@@ -419,6 +443,56 @@ const routes = [
               }
             ]
           },
+          {
+            path: 'saml',
+            component: () => import('@/views/admin/saml/SAML.vue'),
+            name: 'saml',
+            meta: { title: 'SAML' },
+            children: [
+              {
+                path: '',
+                name: 'saml-list',
+                component: () => import('@/views/admin/saml/SAMLList.vue')
+              },
+              {
+                path: ':id/edit',
+                props: true,
+                name: 'edit-saml',
+                component: () => import('@/views/admin/saml/CreateEditSAML.vue'),
+                meta: { title: 'Edit SAML' }
+              },
+              {
+                path: 'new',
+                name: 'new-saml',
+                component: () => import('@/views/admin/saml/CreateEditSAML.vue'),
+                meta: { title: 'New SAML' }
+              }
+            ]
+          },
+          {
+            path: 'scim',
+            name: 'scim',
+            component: () => import('@/views/admin/scim/SCIM.vue'),
+            meta: { title: 'SCIM' }
+          },
+          {
+            path: 'password-policy',
+            name: 'password-policy',
+            component: () => import('@/views/admin/password-policy/PasswordPolicy.vue'),
+            meta: { title: 'Password Policy' }
+          },
+          {
+            path: 'ai-providers',
+            name: 'ai-providers',
+            component: () => import('@/views/admin/ai/AIProvidersView.vue'),
+            meta: { title: 'AI Providers' }
+          },
+          {
+            path: 'ai-prompts',
+            name: 'ai-prompts',
+            component: () => import('@/views/admin/ai/AIPromptsView.vue'),
+            meta: { title: 'AI Prompts' }
+          },
           {
             path: 'webhooks',
             component: () => import('@/views/admin/webhooks/Webhooks.vue'),
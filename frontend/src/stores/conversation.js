@@ -17,6 +17,8 @@ export const useConversationStore = defineStore('conversation', () => {
   const currentBCC = ref([])
   const currentCC = ref([])
   const macros = ref({})
+  const selectedUUIDs = ref([])
+  const bulkProgress = ref(null)
 
   // Options for select fields
   const priorityOptions = computed(() => {
@@ -607,7 +609,12 @@ export const useConversationStore = defineStore('conversation', () => {
   function updateMessageProp (message) {
     const exists = messages.data.hasMessage(message.conversation_uuid, message.uuid)
     if (exists) {
-      messages.data.updateMessageField(message.conversation_uuid, message.uuid, message.prop, message.value)
+      // Cancelled messages are deleted.
+      if (message.prop === 'status' && message.value === 'cancelled') {
+        messages.data.removeMessage(message.conversation_uuid, message.uuid)
+      } else {
+        messages.data.updateMessageField(message.conversation_uuid, message.uuid, message.prop, message.value)
+      }
       incrementMessageVersion()
     }
   }
@@ -678,6 +685,21 @@ export const useConversationStore = defineStore('conversation', () => {
     macros.value = { ...macros.value, [context]: {} }
   }
 
+  /** Conversations selected in the list for bulk actions **/
+  function toggleSelected (uuid) {
+    selectedUUIDs.value = selectedUUIDs.value.includes(uuid)
+      ? selectedUUIDs.value.filter(u => u !== uuid)
+      : [...selectedUUIDs.value, uuid]
+  }
+
+  function clearSelected () {
+    selectedUUIDs.value = []
+  }
+
+  function updateBulkProgress (progress) {
+    bulkProgress.value = progress
+  }
+
   return {
     conversations,
     conversation,
@@ -720,6 +742,11 @@ export const useConversationStore = defineStore('conversation', () => {
     getMacro,
     setMacro,
     resetMacro,
+    selectedUUIDs,
+    bulkProgress,
+    updateBulkProgress,
+    toggleSelected,
+    clearSelected,
     removeAssignee,
     getListSortField,
     getListStatus,
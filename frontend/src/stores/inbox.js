@@ -3,17 +3,20 @@ import { defineStore } from 'pinia'
 import { handleHTTPError } from '@/utils/http'
 import { useEmitter } from '@/composables/useEmitter'
 import { EMITTER_EVENTS } from '@/constants/emitterEvents'
+import { useUserStore } from './user'
+import { permissions as perms } from '@/constants/permissions.js'
 import api from '@/api'
 
 export const useInboxStore = defineStore('inbox', () => {
   const inboxes = ref([])
   const emitter = useEmitter()
+  const userStore = useUserStore()
   const options = computed(() => inboxes.value.map(inb => ({
     label: inb.name,
     value: String(inb.id)
   })))
   const fetchInboxes = async () => {
-    if (inboxes.value.length) return
+    if (inboxes.value.length || !userStore.can(perms.INBOXES_READ)) return
     try {
       const response = await api.getInboxes()
       inboxes.value = response?.data?.data || []
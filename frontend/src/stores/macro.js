@@ -27,6 +27,9 @@ export const useMacroStore = defineStore('macroStore', () => {
     }
 
     const macroOptions = computed(() => {
+        // Macros can't be applied without the permission.
+        if (!userStore.can(perms.MACROS_APPLY)) return []
+
         // Filter macros based on visibility set.
         const userTeams = userStore.teams.map(team => String(team.id))
         let filtered = macroList.value.filter(macro =>
@@ -62,7 +65,7 @@ export const useMacroStore = defineStore('macroStore', () => {
     })
 
     const loadMacros = async () => {
-        if (macroList.value.length) return
+        if (macroList.value.length || !userStore.can(perms.MACROS_READ)) return
         try {
             const response = await api.getAllMacros()
             macroList.value = response?.data?.data || []
@@ -3,11 +3,14 @@ import { defineStore } from 'pinia'
 import { handleHTTPError } from '@/utils/http'
 import { useEmitter } from '@/composables/useEmitter'
 import { EMITTER_EVENTS } from '@/constants/emitterEvents'
+import { useUserStore } from './user'
+import { permissions as perms } from '@/constants/permissions.js'
 import api from '@/api'
 
 export const useTagStore = defineStore('tags', () => {
     const tags = ref([])
     const emitter = useEmitter()
+    const userStore = useUserStore()
     const tagNames = computed(() => tags.value.map(tag => tag.name))
     const tagOptions = computed(() => tags.value.map(tag => ({
         label: tag.name,
@@ -15,7 +18,7 @@ export const useTagStore = defineStore('tags', () => {
     })))
 
     const fetchTags = async () => {
-        if (tags.value.length) return
+        if (tags.value.length || !userStore.can(perms.TAGS_READ)) return
         try {
             const response = await api.getTags()
             tags.value = response?.data?.data || []
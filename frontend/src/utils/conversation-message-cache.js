@@ -121,6 +121,17 @@ export default class MessageCache {
         })
     }
 
+    /**
+     * Removes a message from a conversation
+     */
+    removeMessage (convId, msgId) {
+        const conv = this.cache.get(convId)
+        if (!conv) return
+        conv.pages.forEach((msgs, page) => {
+            conv.pages.set(page, msgs.filter(m => m.uuid !== msgId))
+        })
+    }
+
     /**
      * Checks if conversation has more pages to fetch
      */
@@ -61,7 +61,8 @@ export class WebSocketClient {
           this.convStore.updateConversationMessage(data.data)
         },
         [WS_EVENT.MESSAGE_PROP_UPDATE]: () => this.convStore.updateMessageProp(data.data),
-        [WS_EVENT.CONVERSATION_PROP_UPDATE]: () => this.convStore.updateConversationProp(data.data)
+        [WS_EVENT.CONVERSATION_PROP_UPDATE]: () => this.convStore.updateConversationProp(data.data),
+        [WS_EVENT.BULK_ACTION_PROGRESS]: () => this.convStore.updateBulkProgress(data.data)
       }
 
       const handler = handlers[data.type]
@@ -115,6 +115,78 @@ func (al *Manager) Logout(userID int, email, ip string) error {
 	)
 }
 
+// TwoFactorEnabled records the given user enabling two-factor authentication.
+func (al *Manager) TwoFactorEnabled(userID int, email, ip string) error {
+	return al.Create(
+		models.Agent2FAEnabled,
+		fmt.Sprintf("%s (#%d) enabled two-factor authentication", email, userID),
+		userID,
+		umodels.UserModel,
+		userID,
+		ip,
+	)
+}
+
+// TwoFactorDisabled records the given user disabling two-factor authentication.
+func (al *Manager) TwoFactorDisabled(userID int, email, ip string) error {
+	return al.Create(
+		models.Agent2FADisabled,
+		fmt.Sprintf("%s (#%d) disabled two-factor authentication", email, userID),
+		userID,
+		umodels.UserModel,
+		userID,
+		ip,
+	)
+}
+
+// TwoFactorReset records an admin resetting the two-factor authentication of the target user.
+func (al *Manager) TwoFactorReset(actorID int, actorEmail, ip string, targetID int, targetEmail string) error {
+	return al.Create(
+		models.Agent2FAReset,
+		fmt.Sprintf("%s (#%d) reset two-factor authentication of %s (#%d)", actorEmail, actorID, targetEmail, targetID),
+		actorID,
+		umodels.UserModel,
+		targetID,
+		ip,
+	)
+}
+
+// LoginFailed records a failed login attempt for the given user.
+func (al *Manager) LoginFailed(userID int, email, ip string) error {
+	return al.Create(
+		models.AgentLoginFailed,
+		fmt.Sprintf("%s (#%d) failed to log in", email, userID),
+		userID,
+		umodels.UserModel,
+		userID,
+		ip,
+	)
+}
+
+// LockedOut records the given user being locked out after too many failed login attempts.
+func (al *Manager) LockedOut(userID int, email, ip string) error {
+	return al.Create(
+		models.AgentLockedOut,
+		fmt.Sprintf("%s (#%d) was locked out after too many failed login attempts", email, userID),
+		userID,
+		umodels.UserModel,
+		userID,
+		ip,
+	)
+}
+
+// Unlocked records an admin lifting the login lockout of the target user.
+func (al *Manager) Unlocked(actorID int, actorEmail, ip string, targetID int, targetEmail string) error {
+	return al.Create(
+		models.AgentUnlocked,
+		fmt.Sprintf("%s (#%d) unlocked %s (#%d)", actorEmail, actorID, targetEmail, targetID),
+		actorID,
+		umodels.UserModel,
+		targetID,
+		ip,
+	)
+}
+
 // Away records an away event for the given user.
 func (al *Manager) Away(actorID int, actorEmail, ip string, targetID int, targetEmail string) error {
 	var description string
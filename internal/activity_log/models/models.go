@@ -10,6 +10,12 @@ const (
 	AgentAway           = "agent_away"
 	AgentAwayReassigned = "agent_away_reassigned"
 	AgentOnline         = "agent_online"
+	Agent2FAEnabled     = "agent_2fa_enabled"
+	Agent2FADisabled    = "agent_2fa_disabled"
+	Agent2FAReset       = "agent_2fa_reset"
+	AgentLoginFailed    = "agent_login_failed"
+	AgentLockedOut      = "agent_locked_out"
+	AgentUnlocked       = "agent_unlocked"
 )
 
 type ActivityLog struct {
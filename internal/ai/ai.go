@@ -4,12 +4,15 @@ package ai
 import (
 	"database/sql"
 	"embed"
-	"encoding/json"
 	"errors"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/abhinavxd/libredesk/internal/ai/models"
 	"github.com/abhinavxd/libredesk/internal/dbutil"
 	"github.com/abhinavxd/libredesk/internal/envelope"
+	"github.com/abhinavxd/libredesk/internal/stringutil"
 	"github.com/jmoiron/sqlx"
 	"github.com/knadh/go-i18n"
 	"github.com/zerodha/logf"
@@ -23,10 +26,20 @@ var (
 	ErrApiKeyNotSet  = errors.New("api Key not set")
 )
 
+// maskedAPIKey replaces API keys in responses, an update with a masked key keeps the stored key.
+var maskedAPIKey = strings.Repeat(stringutil.PasswordDummy, 10)
+
 type Manager struct {
-	q    queries
-	lo   *logf.Logger
-	i18n *i18n.I18n
+	q                 queries
+	lo                *logf.Logger
+	i18n              *i18n.I18n
+	db                *sqlx.DB
+	conversationStore conversationStore
+	insightsQueue     chan string
+	insightsWorkers   int
+	closed            bool
+	closedMu          sync.RWMutex
+	wg                sync.WaitGroup
 }
 
 // Opts contains options for initializing the Manager.
@@ -34,14 +47,36 @@ type Opts struct {
 	DB   *sqlx.DB
 	I18n *i18n.I18n
 	Lo   *logf.Logger
+	// Workers and QueueSize of the queue of resolved conversations to summarize and tag.
+	Workers   int
+	QueueSize int
 }
 
 // queries contains prepared SQL queries.
 type queries struct {
-	GetDefaultProvider *sqlx.Stmt `query:"get-default-provider"`
-	GetPrompt          *sqlx.Stmt `query:"get-prompt"`
-	GetPrompts         *sqlx.Stmt `query:"get-prompts"`
-	SetOpenAIKey       *sqlx.Stmt `query:"set-openai-key"`
+	GetDefaultProvider   *sqlx.Stmt `query:"get-default-provider"`
+	GetProviders         *sqlx.Stmt `query:"get-providers"`
+	GetProvider          *sqlx.Stmt `query:"get-provider"`
+	InsertProvider       *sqlx.Stmt `query:"insert-provider"`
+	UpdateProvider       *sqlx.Stmt `query:"update-provider"`
+	DeleteProvider       *sqlx.Stmt `query:"delete-provider"`
+	UnsetDefaultProvider *sqlx.Stmt `query:"unset-default-provider"`
+	SetDefaultProvider   *sqlx.Stmt `query:"set-default-provider"`
+	GetPrompt            *sqlx.Stmt `query:"get-prompt"`
+	GetPrompts           *sqlx.Stmt `query:"get-prompts"`
+	GetPromptByID        *sqlx.Stmt `query:"get-prompt-by-id"`
+	InsertPrompt         *sqlx.Stmt `query:"insert-prompt"`
+	UpdatePrompt         *sqlx.Stmt `query:"update-prompt"`
+	DeletePrompt         *sqlx.Stmt `query:"delete-prompt"`
+
+	GetInboxSettings               *sqlx.Stmt `query:"get-inbox-settings"`
+	UpsertInboxSettings            *sqlx.Stmt `query:"upsert-inbox-settings"`
+	GetTagNames                    *sqlx.Stmt `query:"get-tag-names"`
+	GetConversationAttributeValues *sqlx.Stmt `query:"get-conversation-attribute-values"`
+	SetProviderAPIKey              *sqlx.Stmt `query:"set-provider-api-key"`
+
+	InsertUsage   *sqlx.Stmt `query:"insert-usage"`
+	GetDailyUsage *sqlx.Stmt `query:"get-daily-usage"`
 }
 
 // New creates and returns a new instance of the Manager.
@@ -50,46 +85,74 @@ func New(opts Opts) (*Manager, error) {
 	if err := dbutil.ScanSQLFile("queries.sql", &q, opts.DB, efs); err != nil {
 		return nil, err
 	}
+	if opts.Workers <= 0 {
+		opts.Workers = defaultInsightsWorkers
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = defaultInsightsQueueSize
+	}
 	return &Manager{
-		q:    q,
-		lo:   opts.Lo,
-		i18n: opts.I18n,
+		q:               q,
+		lo:              opts.Lo,
+		i18n:            opts.I18n,
+		db:              opts.DB,
+		insightsQueue:   make(chan string, opts.QueueSize),
+		insightsWorkers: opts.Workers,
 	}, nil
 }
 
-// Completion sends a prompt to the default provider and returns the response.
-func (m *Manager) Completion(k string, prompt string) (string, error) {
-	systemPrompt, err := m.getPrompt(k)
+// Completion renders the prompt with the given variables, sends it to the default provider on behalf of the user and returns the response,
+// conversationID is the conversation the prompt is used in, 0 if none.
+func (m *Manager) Completion(userID, conversationID int, k string, prompt string, vars PromptVars) (string, error) {
+	content, err := m.getPrompt(k)
 	if err != nil {
 		return "", err
 	}
 
-	client, err := m.getDefaultProviderClient()
+	systemPrompt, err := RenderPrompt(content, vars)
 	if err != nil {
-		m.lo.Error("error getting provider client", "error", err)
-		return "", envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", m.i18n.Ts("globals.terms.provider")), nil)
+		m.lo.Error("error rendering prompt", "key", k, "error", err)
+		return "", envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.template}"), nil)
 	}
 
-	payload := PromptPayload{
+	return m.sendPrompt(PromptPayload{
 		SystemPrompt: systemPrompt,
 		UserPrompt:   prompt,
+	}, usageMeta{PromptKey: k, UserID: userID, ConversationID: conversationID})
+}
+
+// sendPrompt sends a payload to the default provider within the daily token limits and records its usage.
+func (m *Manager) sendPrompt(payload PromptPayload, meta usageMeta) (string, error) {
+	client, provider, err := m.getDefaultProviderClient()
+	if err != nil {
+		m.lo.Error("error getting provider client", "error", err)
+		return "", envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", m.i18n.Ts("globals.terms.provider")), nil)
 	}
 
+	if err := m.checkUsageLimits(meta.UserID); err != nil {
+		m.recordUsage(meta, provider, PromptResponse{}, 0, UsageOutcomeRateLimited)
+		return "", err
+	}
+
+	start := time.Now()
 	response, err := client.SendPrompt(payload)
+	latency := time.Since(start)
 	if err != nil {
+		m.recordUsage(meta, provider, response, latency, UsageOutcomeError)
 		if errors.Is(err, ErrInvalidAPIKey) {
 			m.lo.Error("error invalid API key", "error", err)
-			return "", envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.invalid", "name", "OpenAI API Key"), nil)
+			return "", envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.invalid", "name", provider.Name+" API Key"), nil)
 		}
 		if errors.Is(err, ErrApiKeyNotSet) {
 			m.lo.Error("error API key not set", "error", err)
-			return "", envelope.NewError(envelope.InputError, m.i18n.Ts("ai.apiKeyNotSet", "provider", "OpenAI"), nil)
+			return "", envelope.NewError(envelope.InputError, m.i18n.Ts("ai.apiKeyNotSet", "provider", provider.Name), nil)
 		}
 		m.lo.Error("error sending prompt to provider", "error", err)
 		return "", envelope.NewError(envelope.GeneralError, err.Error(), nil)
 	}
+	m.recordUsage(meta, provider, response, latency, UsageOutcomeSuccess)
 
-	return response, nil
+	return response.Content, nil
 }
 
 // GetPrompts returns a list of prompts from the database.
@@ -102,26 +165,139 @@ func (m *Manager) GetPrompts() ([]models.Prompt, error) {
 	return prompts, nil
 }
 
-// UpdateProvider updates a provider.
-func (m *Manager) UpdateProvider(provider, apiKey string) error {
-	switch ProviderType(provider) {
-	case ProviderOpenAI:
-		return m.setOpenAIAPIKey(apiKey)
-	default:
+// SetProviderAPIKey sets the API key of the default provider of the given provider type.
+func (m *Manager) SetProviderAPIKey(provider, apiKey string) error {
+	if !IsValidProvider(provider) {
 		m.lo.Error("unsupported provider type", "provider", provider)
-		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.invalid", "name", m.i18n.Ts("globals.terms.provider")), nil)
+		return envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.invalid", "name", m.i18n.Ts("globals.terms.provider")), nil)
+	}
+	if _, err := m.q.SetProviderAPIKey.Exec(apiKey, provider); err != nil {
+		m.lo.Error("error setting provider API key", "provider", provider, "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorUpdating", "name", "API Key"), nil)
+	}
+	return nil
+}
+
+// GetProviders returns all providers with their API keys masked.
+func (m *Manager) GetProviders() ([]models.Provider, error) {
+	var providers = make([]models.Provider, 0)
+	if err := m.q.GetProviders.Select(&providers); err != nil {
+		m.lo.Error("error fetching providers", "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", m.i18n.Ts("globals.terms.provider")), nil)
+	}
+	for i := range providers {
+		maskAPIKey(&providers[i])
+	}
+	return providers, nil
+}
+
+// GetProvider returns a provider with its API key masked.
+func (m *Manager) GetProvider(id int) (models.Provider, error) {
+	provider, err := m.getProvider(id)
+	if err != nil {
+		return provider, err
+	}
+	maskAPIKey(&provider)
+	return provider, nil
+}
+
+// CreateProvider creates a new provider.
+func (m *Manager) CreateProvider(name, provider string, config models.ProviderConfig) (models.Provider, error) {
+	var p models.Provider
+	if err := m.q.InsertProvider.Get(&p, name, provider, config); err != nil {
+		if dbutil.IsUniqueViolationError(err) {
+			return p, envelope.NewError(envelope.ConflictError, m.i18n.Ts("globals.messages.errorAlreadyExists", "name", m.i18n.Ts("globals.terms.provider")), nil)
+		}
+		m.lo.Error("error inserting provider", "error", err)
+		return p, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorCreating", "name", m.i18n.Ts("globals.terms.provider")), nil)
+	}
+	maskAPIKey(&p)
+	return p, nil
+}
+
+// UpdateProvider updates a provider, a masked or empty API key keeps the stored API key.
+func (m *Manager) UpdateProvider(id int, name, provider string, config models.ProviderConfig) (models.Provider, error) {
+	existing, err := m.getProvider(id)
+	if err != nil {
+		return existing, err
+	}
+	if config.APIKey == "" || config.APIKey == maskedAPIKey {
+		config.APIKey = existing.Config.APIKey
+	}
+
+	var p models.Provider
+	if err := m.q.UpdateProvider.Get(&p, id, name, provider, config); err != nil {
+		if dbutil.IsUniqueViolationError(err) {
+			return p, envelope.NewError(envelope.ConflictError, m.i18n.Ts("globals.messages.errorAlreadyExists", "name", m.i18n.Ts("globals.terms.provider")), nil)
+		}
+		m.lo.Error("error updating provider", "error", err)
+		return p, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorUpdating", "name", m.i18n.Ts("globals.terms.provider")), nil)
 	}
+	maskAPIKey(&p)
+	return p, nil
 }
 
-// setOpenAIAPIKey sets the OpenAI API key in the database.
-func (m *Manager) setOpenAIAPIKey(apiKey string) error {
-	if _, err := m.q.SetOpenAIKey.Exec(apiKey); err != nil {
-		m.lo.Error("error setting OpenAI API key", "error", err)
-		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorUpdating", "name", "OpenAI API Key"), nil)
+// DeleteProvider deletes a provider, the default provider cannot be deleted.
+func (m *Manager) DeleteProvider(id int) error {
+	res, err := m.q.DeleteProvider.Exec(id)
+	if err != nil {
+		m.lo.Error("error deleting provider", "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorDeleting", "name", m.i18n.Ts("globals.terms.provider")), nil)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return envelope.NewError(envelope.InputError, m.i18n.T("ai.cannotDeleteDefaultProvider"), nil)
+	}
+	return nil
+}
+
+// SetDefaultProvider makes a provider the default provider.
+func (m *Manager) SetDefaultProvider(id int) error {
+	if _, err := m.getProvider(id); err != nil {
+		return err
+	}
+
+	tx, err := m.db.Beginx()
+	if err != nil {
+		m.lo.Error("error starting db txn", "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorUpdating", "name", m.i18n.Ts("globals.terms.provider")), nil)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Stmtx(m.q.UnsetDefaultProvider).Exec(id); err != nil {
+		m.lo.Error("error unsetting default provider", "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorUpdating", "name", m.i18n.Ts("globals.terms.provider")), nil)
+	}
+	if _, err := tx.Stmtx(m.q.SetDefaultProvider).Exec(id); err != nil {
+		m.lo.Error("error setting default provider", "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorUpdating", "name", m.i18n.Ts("globals.terms.provider")), nil)
+	}
+	if err := tx.Commit(); err != nil {
+		m.lo.Error("error committing db txn", "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorUpdating", "name", m.i18n.Ts("globals.terms.provider")), nil)
 	}
 	return nil
 }
 
+// getProvider returns a provider including its API key.
+func (m *Manager) getProvider(id int) (models.Provider, error) {
+	var p models.Provider
+	if err := m.q.GetProvider.Get(&p, id); err != nil {
+		if err == sql.ErrNoRows {
+			return p, envelope.NewError(envelope.NotFoundError, m.i18n.Ts("globals.messages.notFound", "name", m.i18n.Ts("globals.terms.provider")), nil)
+		}
+		m.lo.Error("error fetching provider", "error", err)
+		return p, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", m.i18n.Ts("globals.terms.provider")), nil)
+	}
+	return p, nil
+}
+
+// maskAPIKey masks the API key of a provider.
+func maskAPIKey(p *models.Provider) {
+	if p.Config.APIKey != "" {
+		p.Config.APIKey = maskedAPIKey
+	}
+}
+
 // getPrompt returns a prompt from the database.
 func (m *Manager) getPrompt(k string) (string, error) {
 	var p models.Prompt
@@ -137,26 +313,18 @@ func (m *Manager) getPrompt(k string) (string, error) {
 }
 
 // getDefaultProviderClient returns a ProviderClient for the default provider.
-func (m *Manager) getDefaultProviderClient() (ProviderClient, error) {
+func (m *Manager) getDefaultProviderClient() (ProviderClient, models.Provider, error) {
 	var p models.Provider
 
 	if err := m.q.GetDefaultProvider.Get(&p); err != nil {
 		m.lo.Error("error fetching provider details", "error", err)
-		return nil, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", m.i18n.Ts("globals.terms.provider")), nil)
+		return nil, p, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", m.i18n.Ts("globals.terms.provider")), nil)
 	}
 
-	switch ProviderType(p.Provider) {
-	case ProviderOpenAI:
-		config := struct {
-			APIKey string `json:"api_key"`
-		}{}
-		if err := json.Unmarshal([]byte(p.Config), &config); err != nil {
-			m.lo.Error("error parsing provider config", "error", err)
-			return nil, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorParsing", "name", m.i18n.Ts("globals.terms.provider")), nil)
-		}
-		return NewOpenAIClient(config.APIKey, m.lo), nil
-	default:
+	client, ok := newProviderClient(p.Provider, p.Config, m.lo)
+	if !ok {
 		m.lo.Error("unsupported provider type", "provider", p.Provider)
-		return nil, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.invalid", "name", m.i18n.Ts("globals.terms.provider")), nil)
+		return nil, p, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.invalid", "name", m.i18n.Ts("globals.terms.provider")), nil)
 	}
+	return client, p, nil
 }
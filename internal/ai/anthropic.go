@@ -0,0 +1,111 @@
+package ai
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/abhinavxd/libredesk/internal/ai/models"
+	"github.com/valyala/fasthttp"
+	"github.com/zerodha/logf"
+)
+
+const anthropicVersion = "2023-06-01"
+
+// AnthropicClient talks to the Anthropic Messages API.
+type AnthropicClient struct {
+	cfg    models.ProviderConfig
+	lo     *logf.Logger
+	client *http.Client
+}
+
+func NewAnthropicClient(cfg models.ProviderConfig, lo *logf.Logger) *AnthropicClient {
+	return &AnthropicClient{
+		cfg:    cfg,
+		lo:     lo,
+		client: &http.Client{Timeout: timeout(cfg)},
+	}
+}
+
+// SendPrompt sends a prompt to the Anthropic Messages API and returns the response text and token usage.
+func (a *AnthropicClient) SendPrompt(payload PromptPayload) (PromptResponse, error) {
+	if a.cfg.APIKey == "" {
+		return PromptResponse{}, ErrApiKeyNotSet
+	}
+
+	apiURL := strings.TrimRight(a.cfg.BaseURL, "/") + "/messages"
+	requestBody := map[string]interface{}{
+		"model":  a.cfg.Model,
+		"system": payload.SystemPrompt,
+		"messages": []map[string]string{
+			{"role": "user", "content": payload.UserPrompt},
+		},
+		"max_tokens":  a.cfg.MaxTokens,
+		"temperature": *a.cfg.Temperature,
+	}
+
+	bodyBytes, err := json.Marshal(requestBody)
+	if err != nil {
+		a.lo.Error("error marshalling request body", "error", err)
+		return PromptResponse{}, fmt.Errorf("marshalling request body: %w", err)
+	}
+
+	req, err := http.NewRequest(fasthttp.MethodPost, apiURL, bytes.NewBuffer(bodyBytes))
+	if err != nil {
+		a.lo.Error("error creating request", "error", err)
+		return PromptResponse{}, fmt.Errorf("error creating request: %w", err)
+	}
+
+	req.Header.Set("x-api-key", a.cfg.APIKey)
+	req.Header.Set("anthropic-version", anthropicVersion)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		a.lo.Error("error making HTTP request", "error", err)
+		return PromptResponse{}, fmt.Errorf("making HTTP request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return PromptResponse{}, ErrInvalidAPIKey
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		a.lo.Error("non-ok response received from anthropic API", "status", resp.Status, "code", resp.StatusCode, "response_text", body)
+		return PromptResponse{}, fmt.Errorf("API error: %s, body: %s", resp.Status, body)
+	}
+
+	var responseBody struct {
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+		Usage struct {
+			InputTokens  int `json:"input_tokens"`
+			OutputTokens int `json:"output_tokens"`
+		} `json:"usage"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&responseBody); err != nil {
+		return PromptResponse{}, fmt.Errorf("decoding response body: %w", err)
+	}
+
+	var text strings.Builder
+	for _, block := range responseBody.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+	if text.Len() > 0 {
+		return PromptResponse{
+			Content:      text.String(),
+			InputTokens:  responseBody.Usage.InputTokens,
+			OutputTokens: responseBody.Usage.OutputTokens,
+		}, nil
+	}
+	return PromptResponse{}, fmt.Errorf("no response found")
+}
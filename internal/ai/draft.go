@@ -0,0 +1,115 @@
+package ai
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/abhinavxd/libredesk/internal/ai/models"
+)
+
+const (
+	// maxDraftArticleChars is the max length of an article's content included in a draft prompt.
+	maxDraftArticleChars = 4000
+	// maxDraftMessageChars is the max length of a message included in a draft prompt.
+	maxDraftMessageChars = 2000
+)
+
+const draftSystemPrompt = `You are a customer support agent drafting a reply to the latest customer message in a support conversation.
+- Reply in the language of the customer and address the customer by name when known.
+- Use only the numbered knowledge base articles and the conversation for facts, do not make up policies, prices or steps.
+- When a sentence relies on an article, cite it by appending its number in square brackets e.g. [1].
+- If the articles and conversation do not answer the question, say that you will look into it instead of guessing.
+- Return only the reply body, without a subject line, greeting placeholders or signature.`
+
+var citationRe = regexp.MustCompile(`\s?\[(\d+)\]`)
+
+// DraftReply drafts a reply to a conversation for the user grounded in the given conversation history and knowledge base articles.
+func (m *Manager) DraftReply(userID, conversationID int, dc models.DraftContext) (models.Draft, error) {
+	response, err := m.sendPrompt(PromptPayload{
+		SystemPrompt: draftSystemPrompt,
+		UserPrompt:   buildDraftPrompt(dc),
+	}, usageMeta{PromptKey: draftPromptKey, UserID: userID, ConversationID: conversationID})
+	if err != nil {
+		return models.Draft{}, err
+	}
+	return parseDraft(response, dc.Articles), nil
+}
+
+// buildDraftPrompt builds the user prompt of a draft from the draft context.
+func buildDraftPrompt(dc models.DraftContext) string {
+	var b strings.Builder
+
+	b.WriteString("# Customer\n")
+	if dc.Contact.Name != "" {
+		fmt.Fprintf(&b, "Name: %s\n", dc.Contact.Name)
+	}
+	if dc.Contact.Email != "" {
+		fmt.Fprintf(&b, "Email: %s\n", dc.Contact.Email)
+	}
+
+	b.WriteString("\n# Knowledge base articles\n")
+	if len(dc.Articles) == 0 {
+		b.WriteString("None.\n")
+	}
+	for i, a := range dc.Articles {
+		fmt.Fprintf(&b, "[%d] %s\n%s\n\n", i+1, a.Title, truncate(a.Content, maxDraftArticleChars))
+	}
+
+	b.WriteString("\n# Conversation\n")
+	if dc.Subject != "" {
+		fmt.Fprintf(&b, "Subject: %s\n\n", dc.Subject)
+	}
+	for _, msg := range dc.Messages {
+		sender := "Agent"
+		if msg.FromContact {
+			sender = "Customer"
+		}
+		fmt.Fprintf(&b, "%s: %s\n\n", sender, truncate(msg.Content, maxDraftMessageChars))
+	}
+
+	if dc.Instructions != "" {
+		fmt.Fprintf(&b, "\n# Instructions from the agent\n%s\n", dc.Instructions)
+	}
+	return b.String()
+}
+
+// parseDraft strips the citation markers from a provider response and returns the cited articles in order of first citation.
+func parseDraft(response string, articles []models.DraftArticle) models.Draft {
+	var (
+		draft = models.Draft{Citations: make([]models.Citation, 0)}
+		cited = make(map[int]struct{})
+	)
+	for _, match := range citationRe.FindAllStringSubmatch(response, -1) {
+		n, err := strconv.Atoi(match[1])
+		if err != nil || n < 1 || n > len(articles) {
+			continue
+		}
+		if _, ok := cited[n]; ok {
+			continue
+		}
+		cited[n] = struct{}{}
+		draft.Citations = append(draft.Citations, models.Citation{
+			ArticleID: articles[n-1].ID,
+			Title:     articles[n-1].Title,
+		})
+	}
+	draft.Content = strings.TrimSpace(citationRe.ReplaceAllStringFunc(response, func(marker string) string {
+		n, _ := strconv.Atoi(citationRe.FindStringSubmatch(marker)[1])
+		if n < 1 || n > len(articles) {
+			return marker
+		}
+		return ""
+	}))
+	return draft
+}
+
+// truncate truncates s to n runes.
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n]) + "…"
+}
@@ -0,0 +1,60 @@
+package ai
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/abhinavxd/libredesk/internal/ai/models"
+)
+
+func TestParseDraft(t *testing.T) {
+	articles := []models.DraftArticle{
+		{ID: 10, Title: "Resetting your password"},
+		{ID: 20, Title: "Billing FAQ"},
+	}
+
+	tests := []struct {
+		name     string
+		response string
+		expected models.Draft
+	}{
+		{
+			name:     "no citations",
+			response: "  Thanks for reaching out, we will look into it.\n",
+			expected: models.Draft{
+				Content:   "Thanks for reaching out, we will look into it.",
+				Citations: []models.Citation{},
+			},
+		},
+		{
+			name:     "citations are stripped and deduplicated in order of first use",
+			response: "Refunds take 5 days [2]. You can reset your password from the login page [1]. See billing [2].",
+			expected: models.Draft{
+				Content: "Refunds take 5 days. You can reset your password from the login page. See billing.",
+				Citations: []models.Citation{
+					{ArticleID: 20, Title: "Billing FAQ"},
+					{ArticleID: 10, Title: "Resetting your password"},
+				},
+			},
+		},
+		{
+			name:     "unknown article numbers are kept as is",
+			response: "Use option [3] in the menu [1].",
+			expected: models.Draft{
+				Content: "Use option [3] in the menu.",
+				Citations: []models.Citation{
+					{ArticleID: 10, Title: "Resetting your password"},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseDraft(tt.response, articles)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("parseDraft() = %+v, want %+v", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,271 @@
+package ai
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"html"
+	"slices"
+	"strings"
+
+	"github.com/abhinavxd/libredesk/internal/ai/models"
+	amodels "github.com/abhinavxd/libredesk/internal/automation/models"
+	cmodels "github.com/abhinavxd/libredesk/internal/conversation/models"
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	"github.com/abhinavxd/libredesk/internal/stringutil"
+	umodels "github.com/abhinavxd/libredesk/internal/user/models"
+	"github.com/lib/pq"
+)
+
+const (
+	defaultInsightsWorkers   = 2
+	defaultInsightsQueueSize = 1000
+	// maxInsightsMessages is the number of latest messages a resolved conversation is summarized from.
+	maxInsightsMessages = 50
+	// maxInsightsTags is the max number of tags applied to a conversation.
+	maxInsightsTags = 3
+)
+
+const insightsSystemPrompt = `You analyse resolved customer support conversations.
+Respond with only a JSON object, without markdown, in this format:
+{"summary": "", "tags": [], "category": ""}
+- summary: a short summary of the customer's issue, what was done and the outcome, in at most 5 sentences. Empty if not requested.
+- tags: at most 3 tags from the allowed tags that describe the conversation. Empty if not requested or none apply.
+- category: exactly one of the allowed categories that best describes the conversation. Empty if not requested or none apply.`
+
+type conversationStore interface {
+	GetConversation(id int, uuid string) (cmodels.Conversation, error)
+	GetConversationMessages(uuid string, page, pageSize int) ([]cmodels.Message, int, error)
+	ApplyAction(action amodels.RuleAction, conversation cmodels.Conversation, user umodels.User) error
+	UpdateConversationCustomAttributes(uuid string, customAttributes map[string]any) error
+}
+
+// insights is the response of the provider for a resolved conversation.
+type insights struct {
+	Summary  string   `json:"summary"`
+	Tags     []string `json:"tags"`
+	Category string   `json:"category"`
+}
+
+// SetConversationStore sets the conversation store.
+func (m *Manager) SetConversationStore(store conversationStore) {
+	m.conversationStore = store
+}
+
+// EnqueueResolvedConversation enqueues a resolved conversation for summarization and tagging as per its inbox settings.
+func (m *Manager) EnqueueResolvedConversation(conversationUUID string) {
+	m.closedMu.RLock()
+	defer m.closedMu.RUnlock()
+	if m.closed {
+		return
+	}
+	select {
+	case m.insightsQueue <- conversationUUID:
+	default:
+		m.lo.Warn("AI insights queue is full, skipping conversation", "uuid", conversationUUID, "queue_size", len(m.insightsQueue))
+	}
+}
+
+// Run starts the worker pool that summarizes and tags resolved conversations.
+func (m *Manager) Run(ctx context.Context) {
+	for i := 0; i < m.insightsWorkers; i++ {
+		m.wg.Add(1)
+		go func() {
+			defer m.wg.Done()
+			m.insightsWorker(ctx)
+		}()
+	}
+}
+
+// Close signals the manager to stop processing and waits for all workers to finish.
+func (m *Manager) Close() {
+	m.closedMu.Lock()
+	defer m.closedMu.Unlock()
+	if m.closed {
+		return
+	}
+	m.closed = true
+	close(m.insightsQueue)
+	m.wg.Wait()
+}
+
+// GetInboxSettings returns the AI settings of an inbox.
+func (m *Manager) GetInboxSettings(inboxID int) (models.InboxSettings, error) {
+	var s models.InboxSettings
+	if err := m.q.GetInboxSettings.Get(&s, inboxID); err != nil {
+		if err == sql.ErrNoRows {
+			return models.InboxSettings{InboxID: inboxID}, nil
+		}
+		m.lo.Error("error fetching AI inbox settings", "inbox_id", inboxID, "error", err)
+		return s, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.setting}"), nil)
+	}
+	return s, nil
+}
+
+// UpsertInboxSettings creates or updates the AI settings of an inbox.
+func (m *Manager) UpsertInboxSettings(s models.InboxSettings) (models.InboxSettings, error) {
+	var out models.InboxSettings
+	if err := m.q.UpsertInboxSettings.Get(&out, s.InboxID, s.SummarizeOnResolve, s.AutoTagOnResolve, s.CategoryAttributeKey); err != nil {
+		m.lo.Error("error upserting AI inbox settings", "inbox_id", s.InboxID, "error", err)
+		return out, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.setting}"), nil)
+	}
+	return out, nil
+}
+
+// insightsWorker processes resolved conversations from the queue.
+func (m *Manager) insightsWorker(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case uuid, ok := <-m.insightsQueue:
+			if !ok {
+				return
+			}
+			if err := m.processResolvedConversation(uuid); err != nil {
+				m.lo.Error("error processing AI insights for conversation", "uuid", uuid, "error", err)
+			}
+		}
+	}
+}
+
+// processResolvedConversation summarizes, tags and categorizes a resolved conversation as per its inbox settings.
+func (m *Manager) processResolvedConversation(uuid string) error {
+	conv, err := m.conversationStore.GetConversation(0, uuid)
+	if err != nil {
+		return fmt.Errorf("fetching conversation: %w", err)
+	}
+
+	settings, err := m.GetInboxSettings(conv.InboxID)
+	if err != nil {
+		return fmt.Errorf("fetching inbox settings: %w", err)
+	}
+	if !settings.Enabled() {
+		return nil
+	}
+
+	var (
+		tags       []string
+		categories pq.StringArray
+	)
+	if settings.AutoTagOnResolve {
+		if err := m.q.GetTagNames.Select(&tags); err != nil {
+			return fmt.Errorf("fetching tags: %w", err)
+		}
+	}
+	if settings.CategoryAttributeKey.Valid {
+		if err := m.q.GetConversationAttributeValues.Get(&categories, settings.CategoryAttributeKey.String); err != nil && err != sql.ErrNoRows {
+			return fmt.Errorf("fetching category attribute: %w", err)
+		}
+	}
+
+	messages, _, err := m.conversationStore.GetConversationMessages(uuid, 1, maxInsightsMessages)
+	if err != nil {
+		return fmt.Errorf("fetching messages: %w", err)
+	}
+	transcript := buildTranscript(messages)
+	if transcript == "" {
+		return nil
+	}
+
+	var prompt strings.Builder
+	fmt.Fprintf(&prompt, "Summary requested: %t\n", settings.SummarizeOnResolve)
+	fmt.Fprintf(&prompt, "Allowed tags: %s\n", strings.Join(tags, ", "))
+	fmt.Fprintf(&prompt, "Allowed categories: %s\n\n", strings.Join(categories, ", "))
+	fmt.Fprintf(&prompt, "# Conversation\nSubject: %s\n\n%s", conv.Subject.String, transcript)
+
+	response, err := m.sendPrompt(PromptPayload{
+		SystemPrompt: insightsSystemPrompt,
+		UserPrompt:   prompt.String(),
+	}, usageMeta{PromptKey: insightsPromptKey, ConversationID: conv.ID})
+	if err != nil {
+		return fmt.Errorf("sending prompt: %w", err)
+	}
+	result, err := parseInsights(response)
+	if err != nil {
+		return err
+	}
+
+	// Actions are applied as the system user.
+	if settings.SummarizeOnResolve && result.Summary != "" {
+		note := fmt.Sprintf("<p><strong>%s</strong></p><p>%s</p>", html.EscapeString(m.i18n.T("ai.summaryNoteTitle")), strings.ReplaceAll(html.EscapeString(result.Summary), "\n", "<br>"))
+		if err := m.conversationStore.ApplyAction(amodels.RuleAction{Type: amodels.ActionSendPrivateNote, Value: []string{note}}, conv, umodels.User{}); err != nil {
+			m.lo.Error("error adding AI summary note", "uuid", uuid, "error", err)
+		}
+	}
+	if tags := filterAllowed(result.Tags, tags, maxInsightsTags); len(tags) > 0 {
+		if err := m.conversationStore.ApplyAction(amodels.RuleAction{Type: amodels.ActionAddTags, Value: tags}, conv, umodels.User{}); err != nil {
+			m.lo.Error("error adding AI suggested tags", "uuid", uuid, "error", err)
+		}
+	}
+	if category := filterAllowed([]string{result.Category}, categories, 1); len(category) > 0 {
+		attrs := map[string]any{}
+		if len(conv.CustomAttributes) > 0 {
+			if err := json.Unmarshal(conv.CustomAttributes, &attrs); err != nil {
+				return fmt.Errorf("unmarshalling custom attributes: %w", err)
+			}
+		}
+		attrs[settings.CategoryAttributeKey.String] = category[0]
+		if err := m.conversationStore.UpdateConversationCustomAttributes(uuid, attrs); err != nil {
+			m.lo.Error("error setting AI suggested category", "uuid", uuid, "error", err)
+		}
+	}
+	return nil
+}
+
+// buildTranscript returns the transcript of the public messages of a conversation, oldest first.
+func buildTranscript(messages []cmodels.Message) string {
+	var b strings.Builder
+	for i := len(messages) - 1; i >= 0; i-- {
+		msg := messages[i]
+		if msg.Private || (msg.Type != cmodels.MessageIncoming && msg.Type != cmodels.MessageOutgoing) {
+			continue
+		}
+		content := msg.TextContent
+		if content == "" {
+			content = stringutil.HTML2Text(msg.Content)
+		}
+		if strings.TrimSpace(content) == "" {
+			continue
+		}
+		sender := "Agent"
+		if msg.Type == cmodels.MessageIncoming {
+			sender = "Customer"
+		}
+		fmt.Fprintf(&b, "%s: %s\n\n", sender, truncate(content, maxDraftMessageChars))
+	}
+	return b.String()
+}
+
+// parseInsights parses the JSON response of the provider, ignoring any markdown code fence around it.
+func parseInsights(response string) (insights, error) {
+	var out insights
+	response = strings.TrimSpace(response)
+	if start, end := strings.Index(response, "{"), strings.LastIndex(response, "}"); start >= 0 && end > start {
+		response = response[start : end+1]
+	}
+	if err := json.Unmarshal([]byte(response), &out); err != nil {
+		return out, fmt.Errorf("parsing provider response: %w", err)
+	}
+	out.Summary = strings.TrimSpace(out.Summary)
+	return out, nil
+}
+
+// filterAllowed returns up to max values that are in the allowed list, matched case-insensitively and returned as in the allowed list.
+func filterAllowed(values, allowed []string, max int) []string {
+	out := make([]string, 0, max)
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		for _, a := range allowed {
+			if strings.EqualFold(v, a) && !slices.Contains(out, a) {
+				out = append(out, a)
+				break
+			}
+		}
+		if len(out) == max {
+			break
+		}
+	}
+	return out
+}
@@ -0,0 +1,65 @@
+package ai
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseInsights(t *testing.T) {
+	response := "```json\n{\"summary\": \" Customer could not log in, password was reset. \", \"tags\": [\"login\"], \"category\": \"Account\"}\n```"
+	got, err := parseInsights(response)
+	if err != nil {
+		t.Fatalf("parseInsights() error = %v", err)
+	}
+	expected := insights{
+		Summary:  "Customer could not log in, password was reset.",
+		Tags:     []string{"login"},
+		Category: "Account",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("parseInsights() = %+v, want %+v", got, expected)
+	}
+
+	if _, err := parseInsights("I could not summarize this conversation."); err == nil {
+		t.Error("parseInsights() expected error for a non JSON response")
+	}
+}
+
+func TestFilterAllowed(t *testing.T) {
+	allowed := []string{"Billing", "Login", "Refund", "Bug"}
+
+	tests := []struct {
+		name     string
+		values   []string
+		max      int
+		expected []string
+	}{
+		{
+			name:     "unknown values are dropped and known ones returned as allowed",
+			values:   []string{"billing", "shipping", " LOGIN "},
+			max:      3,
+			expected: []string{"Billing", "Login"},
+		},
+		{
+			name:     "duplicates are removed",
+			values:   []string{"Bug", "bug"},
+			max:      3,
+			expected: []string{"Bug"},
+		},
+		{
+			name:     "results are capped at max",
+			values:   []string{"Bug", "Refund", "Login", "Billing"},
+			max:      2,
+			expected: []string{"Bug", "Refund"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := filterAllowed(tt.values, allowed, tt.max)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("filterAllowed() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
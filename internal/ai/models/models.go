@@ -1,15 +1,53 @@
 package models
 
-import "time"
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/volatiletech/null/v9"
+)
 
 type Provider struct {
-	ID        string    `db:"id"`
-	CreatedAt time.Time `db:"created_at"`
-	UpdatedAt time.Time `db:"updated_at"`
-	Name      string    `db:"name"`
-	Provider  string    `db:"provider"`
-	Config    string    `db:"config"`
-	IsDefault bool      `db:"is_default"`
+	ID        int            `db:"id" json:"id"`
+	CreatedAt time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time      `db:"updated_at" json:"updated_at"`
+	Name      string         `db:"name" json:"name"`
+	Provider  string         `db:"provider" json:"provider"`
+	Config    ProviderConfig `db:"config" json:"config"`
+	IsDefault bool           `db:"is_default" json:"is_default"`
+}
+
+// ProviderConfig is the configuration of a provider, unset values fall back to the provider defaults.
+type ProviderConfig struct {
+	APIKey      string   `json:"api_key"`
+	BaseURL     string   `json:"base_url"`
+	Model       string   `json:"model"`
+	Temperature *float64 `json:"temperature"`
+	MaxTokens   int      `json:"max_tokens"`
+	// Timeout is a duration string e.g. 30s.
+	Timeout string `json:"timeout"`
+}
+
+// Value implements the driver.Valuer interface.
+func (pc ProviderConfig) Value() (driver.Value, error) {
+	return json.Marshal(pc)
+}
+
+// Scan implements the sql.Scanner interface.
+func (pc *ProviderConfig) Scan(src any) error {
+	var data []byte
+
+	switch v := src.(type) {
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		return fmt.Errorf("unsupported type: %T", src)
+	}
+	return json.Unmarshal(data, pc)
 }
 
 type Prompt struct {
@@ -20,3 +58,68 @@ type Prompt struct {
 	Key       string    `db:"key" json:"key"`
 	Content   string    `db:"content" json:"content,omitempty"`
 }
+
+// DraftContext is the context a reply is drafted from.
+type DraftContext struct {
+	Subject      string
+	Contact      DraftContact
+	Messages     []DraftMessage
+	Articles     []DraftArticle
+	Instructions string
+}
+
+// DraftContact holds the contact details shared with the provider.
+type DraftContact struct {
+	Name  string
+	Email string
+}
+
+// DraftMessage is a conversation message, oldest first.
+type DraftMessage struct {
+	FromContact bool
+	Content     string
+}
+
+// DraftArticle is a knowledge base article the draft can be grounded in.
+type DraftArticle struct {
+	ID      int
+	Title   string
+	Content string
+}
+
+// Draft is a drafted reply with the articles it cites.
+type Draft struct {
+	Content   string     `json:"content"`
+	Citations []Citation `json:"citations"`
+}
+
+// Citation is an article cited by a draft.
+type Citation struct {
+	ArticleID int    `json:"article_id"`
+	Title     string `json:"title"`
+}
+
+// InboxSettings are the AI settings of an inbox, applied in the background when a conversation is resolved.
+type InboxSettings struct {
+	ID                 int       `db:"id" json:"id"`
+	CreatedAt          time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt          time.Time `db:"updated_at" json:"updated_at"`
+	InboxID            int       `db:"inbox_id" json:"inbox_id"`
+	SummarizeOnResolve bool      `db:"summarize_on_resolve" json:"summarize_on_resolve"`
+	AutoTagOnResolve   bool      `db:"auto_tag_on_resolve" json:"auto_tag_on_resolve"`
+	// CategoryAttributeKey is the key of a conversation list custom attribute set to the suggested category.
+	CategoryAttributeKey null.String `db:"category_attribute_key" json:"category_attribute_key"`
+}
+
+// Enabled returns true if any of the settings is enabled.
+func (s InboxSettings) Enabled() bool {
+	return s.SummarizeOnResolve || s.AutoTagOnResolve || s.CategoryAttributeKey.Valid
+}
+
+// DailyUsage is the number of tokens used today by a user and the workspace along with their daily limits, a limit of 0 is unlimited.
+type DailyUsage struct {
+	UserTokens          int `db:"user_tokens" json:"user_tokens"`
+	WorkspaceTokens     int `db:"workspace_tokens" json:"workspace_tokens"`
+	UserTokenLimit      int `db:"user_token_limit" json:"user_token_limit"`
+	WorkspaceTokenLimit int `db:"workspace_token_limit" json:"workspace_token_limit"`
+}
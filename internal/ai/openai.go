@@ -6,73 +6,75 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"time"
+	"strings"
 
+	"github.com/abhinavxd/libredesk/internal/ai/models"
 	"github.com/valyala/fasthttp"
 	"github.com/zerodha/logf"
 )
 
+// OpenAIClient talks to the OpenAI chat completions API or any OpenAI-compatible server e.g. a self-hosted one.
 type OpenAIClient struct {
-	apikey string
+	cfg    models.ProviderConfig
 	lo     *logf.Logger
 	client *http.Client
 }
 
-func NewOpenAIClient(apiKey string, lo *logf.Logger) *OpenAIClient {
+func NewOpenAIClient(cfg models.ProviderConfig, lo *logf.Logger) *OpenAIClient {
 	return &OpenAIClient{
-		apikey: apiKey,
+		cfg:    cfg,
 		lo:     lo,
-		client: &http.Client{Timeout: 10 * time.Second},
+		client: &http.Client{Timeout: timeout(cfg)},
 	}
 }
 
-// SendPrompt sends a prompt to the OpenAI API and returns the response text.
-func (o *OpenAIClient) SendPrompt(payload PromptPayload) (string, error) {
-	if o.apikey == "" {
-		return "", ErrApiKeyNotSet
+// SendPrompt sends a prompt to the OpenAI API and returns the response text and token usage.
+func (o *OpenAIClient) SendPrompt(payload PromptPayload) (PromptResponse, error) {
+	if o.cfg.APIKey == "" {
+		return PromptResponse{}, ErrApiKeyNotSet
 	}
 
-	apiURL := "https://api.openai.com/v1/chat/completions"
+	apiURL := strings.TrimRight(o.cfg.BaseURL, "/") + "/chat/completions"
 	requestBody := map[string]interface{}{
-		"model": "gpt-4o-mini",
+		"model": o.cfg.Model,
 		"messages": []map[string]string{
 			{"role": "system", "content": payload.SystemPrompt},
 			{"role": "user", "content": payload.UserPrompt},
 		},
-		"max_tokens":  1024,
-		"temperature": 0.7,
+		"max_tokens":  o.cfg.MaxTokens,
+		"temperature": *o.cfg.Temperature,
 	}
 
 	bodyBytes, err := json.Marshal(requestBody)
 	if err != nil {
 		o.lo.Error("error marshalling request body", "error", err)
-		return "", fmt.Errorf("marshalling request body: %w", err)
+		return PromptResponse{}, fmt.Errorf("marshalling request body: %w", err)
 	}
 
 	req, err := http.NewRequest(fasthttp.MethodPost, apiURL, bytes.NewBuffer(bodyBytes))
 	if err != nil {
 		o.lo.Error("error creating request", "error", err)
-		return "", fmt.Errorf("error creating request: %w", err)
+		return PromptResponse{}, fmt.Errorf("error creating request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+o.apikey)
+	req.Header.Set("Authorization", "Bearer "+o.cfg.APIKey)
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := o.client.Do(req)
 	if err != nil {
 		o.lo.Error("error making HTTP request", "error", err)
-		return "", fmt.Errorf("making HTTP request: %w", err)
+		return PromptResponse{}, fmt.Errorf("making HTTP request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode == http.StatusUnauthorized {
-		return "", ErrInvalidAPIKey
+		return PromptResponse{}, ErrInvalidAPIKey
 	}
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
 		o.lo.Error("non-ok response received from openai API", "status", resp.Status, "code", resp.StatusCode, "response_text", body)
-		return "", fmt.Errorf("API error: %s, body: %s", resp.Status, body)
+		return PromptResponse{}, fmt.Errorf("API error: %s, body: %s", resp.Status, body)
 	}
 
 	var responseBody struct {
@@ -81,13 +83,21 @@ func (o *OpenAIClient) SendPrompt(payload PromptPayload) (string, error) {
 				Content string `json:"content"`
 			} `json:"message"`
 		} `json:"choices"`
+		Usage struct {
+			PromptTokens     int `json:"prompt_tokens"`
+			CompletionTokens int `json:"completion_tokens"`
+		} `json:"usage"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&responseBody); err != nil {
-		return "", fmt.Errorf("decoding response body: %w", err)
+		return PromptResponse{}, fmt.Errorf("decoding response body: %w", err)
 	}
 
 	if len(responseBody.Choices) > 0 {
-		return responseBody.Choices[0].Message.Content, nil
+		return PromptResponse{
+			Content:      responseBody.Choices[0].Message.Content,
+			InputTokens:  responseBody.Usage.PromptTokens,
+			OutputTokens: responseBody.Usage.CompletionTokens,
+		}, nil
 	}
-	return "", fmt.Errorf("no response found")
+	return PromptResponse{}, fmt.Errorf("no response found")
 }
@@ -0,0 +1,116 @@
+package ai
+
+import (
+	"database/sql"
+	"strings"
+	"text/template"
+
+	"github.com/abhinavxd/libredesk/internal/ai/models"
+	"github.com/abhinavxd/libredesk/internal/dbutil"
+	"github.com/abhinavxd/libredesk/internal/envelope"
+)
+
+// PromptVars are the variables a prompt can reference e.g. {{ .Contact.FirstName }} or {{ .Conversation.Subject }}.
+type PromptVars map[string]any
+
+// promptVarFields are the variables available to prompts by group, unset variables render as empty strings.
+var promptVarFields = map[string][]string{
+	"Conversation": {"ReferenceNumber", "Subject", "Priority", "Status", "UUID"},
+	"Contact":      {"FirstName", "LastName", "FullName", "Email"},
+	"Agent":        {"FirstName", "LastName", "FullName", "Email"},
+}
+
+// RenderPrompt renders the variables in a prompt, missing variables render as empty strings.
+func RenderPrompt(content string, vars PromptVars) (string, error) {
+	tmpl, err := parsePrompt(content)
+	if err != nil {
+		return "", err
+	}
+	data := make(PromptVars, len(vars)+len(promptVarFields))
+	for k, v := range vars {
+		data[k] = v
+	}
+	for g, fields := range promptVarFields {
+		group := make(map[string]any, len(fields))
+		for _, f := range fields {
+			group[f] = ""
+		}
+		if set, ok := vars[g].(map[string]any); ok {
+			for k, v := range set {
+				group[k] = v
+			}
+		}
+		data[g] = group
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// ValidatePrompt returns an error if the prompt has invalid variable syntax.
+func ValidatePrompt(content string) error {
+	_, err := parsePrompt(content)
+	return err
+}
+
+func parsePrompt(content string) (*template.Template, error) {
+	return template.New("prompt").Option("missingkey=zero").Parse(content)
+}
+
+// GetPrompt returns a prompt by ID.
+func (m *Manager) GetPrompt(id int) (models.Prompt, error) {
+	var p models.Prompt
+	if err := m.q.GetPromptByID.Get(&p, id); err != nil {
+		if err == sql.ErrNoRows {
+			return p, envelope.NewError(envelope.NotFoundError, m.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.prompt}"), nil)
+		}
+		m.lo.Error("error fetching prompt", "error", err)
+		return p, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.prompt}"), nil)
+	}
+	return p, nil
+}
+
+// CreatePrompt creates a prompt.
+func (m *Manager) CreatePrompt(key, title, content string) (models.Prompt, error) {
+	var p models.Prompt
+	if err := m.q.InsertPrompt.Get(&p, key, title, content); err != nil {
+		if dbutil.IsUniqueViolationError(err) {
+			return p, envelope.NewError(envelope.ConflictError, m.i18n.Ts("globals.messages.errorAlreadyExists", "name", "{globals.terms.prompt}"), nil)
+		}
+		m.lo.Error("error inserting prompt", "error", err)
+		return p, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorCreating", "name", "{globals.terms.prompt}"), nil)
+	}
+	return p, nil
+}
+
+// UpdatePrompt updates a prompt.
+func (m *Manager) UpdatePrompt(id int, key, title, content string) (models.Prompt, error) {
+	var p models.Prompt
+	if err := m.q.UpdatePrompt.Get(&p, id, key, title, content); err != nil {
+		if err == sql.ErrNoRows {
+			return p, envelope.NewError(envelope.NotFoundError, m.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.prompt}"), nil)
+		}
+		if dbutil.IsUniqueViolationError(err) {
+			return p, envelope.NewError(envelope.ConflictError, m.i18n.Ts("globals.messages.errorAlreadyExists", "name", "{globals.terms.prompt}"), nil)
+		}
+		m.lo.Error("error updating prompt", "error", err)
+		return p, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.prompt}"), nil)
+	}
+	return p, nil
+}
+
+// DeletePrompt deletes a prompt.
+func (m *Manager) DeletePrompt(id int) error {
+	res, err := m.q.DeletePrompt.Exec(id)
+	if err != nil {
+		m.lo.Error("error deleting prompt", "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorDeleting", "name", "{globals.terms.prompt}"), nil)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return envelope.NewError(envelope.NotFoundError, m.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.prompt}"), nil)
+	}
+	return nil
+}
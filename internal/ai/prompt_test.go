@@ -0,0 +1,55 @@
+package ai
+
+import "testing"
+
+func TestRenderPrompt(t *testing.T) {
+	vars := PromptVars{
+		"Contact": map[string]any{"FirstName": "Jane"},
+		"Agent":   map[string]any{"FullName": "John Doe"},
+	}
+
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "no variables",
+			content:  "Translate the text to Spanish.",
+			expected: "Translate the text to Spanish.",
+		},
+		{
+			name:     "variables are rendered",
+			content:  "Apologise to {{ .Contact.FirstName }} on behalf of {{ .Agent.FullName }}.",
+			expected: "Apologise to Jane on behalf of John Doe.",
+		},
+		{
+			name:     "missing variables render empty",
+			content:  "Subject: {{ .Conversation.Subject }}, email: {{ .Contact.Email }}",
+			expected: "Subject: , email: ",
+		},
+		{
+			name:     "literal text is kept",
+			content:  "Reply with <no value> if unsure, {{ .Contact.FirstName }}.",
+			expected: "Reply with <no value> if unsure, Jane.",
+		},
+		{
+			name:    "invalid syntax",
+			content: "Hello {{ .Contact.FirstName",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RenderPrompt(tt.content, vars)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RenderPrompt() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.expected {
+				t.Errorf("RenderPrompt() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
@@ -1,20 +1,108 @@
 package ai
 
+import (
+	"time"
+
+	"github.com/abhinavxd/libredesk/internal/ai/models"
+	"github.com/zerodha/logf"
+)
+
 // ProviderClient is the interface all providers should implement.
 type ProviderClient interface {
-	SendPrompt(payload PromptPayload) (string, error)
+	SendPrompt(payload PromptPayload) (PromptResponse, error)
 }
 
 // ProviderType is an enum-like type for different providers.
 type ProviderType string
 
 const (
-	ProviderOpenAI ProviderType = "openai"
-	ProviderClaude ProviderType = "claude"
+	ProviderOpenAI    ProviderType = "openai"
+	ProviderAnthropic ProviderType = "anthropic"
+)
+
+const (
+	defaultTemperature = 0.7
+	defaultMaxTokens   = 1024
+	defaultTimeout     = 30 * time.Second
 )
 
+// providerFactory creates a ProviderClient from a provider config with defaults applied.
+type providerFactory func(cfg models.ProviderConfig, lo *logf.Logger) ProviderClient
+
+// providerDefaults holds the default base URL and model of a provider.
+type providerDefaults struct {
+	BaseURL string
+	Model   string
+}
+
+// providerRegistry holds the supported providers, a provider is added by implementing ProviderClient and registering its factory here.
+var providerRegistry = map[ProviderType]struct {
+	defaults providerDefaults
+	factory  providerFactory
+}{
+	ProviderOpenAI: {
+		defaults: providerDefaults{BaseURL: "https://api.openai.com/v1", Model: "gpt-4o-mini"},
+		factory:  func(cfg models.ProviderConfig, lo *logf.Logger) ProviderClient { return NewOpenAIClient(cfg, lo) },
+	},
+	ProviderAnthropic: {
+		defaults: providerDefaults{BaseURL: "https://api.anthropic.com/v1", Model: "claude-3-5-haiku-latest"},
+		factory:  func(cfg models.ProviderConfig, lo *logf.Logger) ProviderClient { return NewAnthropicClient(cfg, lo) },
+	},
+}
+
+// IsValidProvider returns true if the provider type is supported.
+func IsValidProvider(provider string) bool {
+	_, ok := providerRegistry[ProviderType(provider)]
+	return ok
+}
+
+// newProviderClient returns a ProviderClient for the provider type with the unset config values defaulted.
+func newProviderClient(provider string, cfg models.ProviderConfig, lo *logf.Logger) (ProviderClient, bool) {
+	p, ok := providerRegistry[ProviderType(provider)]
+	if !ok {
+		return nil, false
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = p.defaults.BaseURL
+	}
+	if cfg.Model == "" {
+		cfg.Model = p.defaults.Model
+	}
+	if cfg.Temperature == nil {
+		t := defaultTemperature
+		cfg.Temperature = &t
+	}
+	if cfg.MaxTokens <= 0 {
+		cfg.MaxTokens = defaultMaxTokens
+	}
+	return p.factory(cfg, lo), true
+}
+
+// providerModel returns the model of a provider, defaulting to the model of its provider type.
+func providerModel(p models.Provider) string {
+	if p.Config.Model != "" {
+		return p.Config.Model
+	}
+	return providerRegistry[ProviderType(p.Provider)].defaults.Model
+}
+
+// timeout returns the request timeout of a provider config.
+func timeout(cfg models.ProviderConfig) time.Duration {
+	if d, err := time.ParseDuration(cfg.Timeout); err == nil && d > 0 {
+		return d
+	}
+	return defaultTimeout
+}
+
 // PromptPayload represents the structured input for an LLM provider.
 type PromptPayload struct {
 	SystemPrompt string `json:"system_prompt"`
 	UserPrompt   string `json:"user_prompt"`
 }
+
+// PromptResponse represents the response of an LLM provider along with the tokens used.
+type PromptResponse struct {
+	Content      string
+	InputTokens  int
+	OutputTokens int
+}
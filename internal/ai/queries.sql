@@ -1,5 +1,34 @@
 -- name: get-default-provider
-SELECT id, name, provider, config, is_default FROM ai_providers where is_default is true;
+SELECT id, created_at, updated_at, name, provider, config, is_default FROM ai_providers where is_default is true;
+
+-- name: get-providers
+SELECT id, created_at, updated_at, name, provider, config, is_default FROM ai_providers ORDER BY name;
+
+-- name: get-provider
+SELECT id, created_at, updated_at, name, provider, config, is_default FROM ai_providers WHERE id = $1;
+
+-- name: insert-provider
+INSERT INTO ai_providers (name, provider, config)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: update-provider
+UPDATE ai_providers
+SET name = $2,
+    provider = $3,
+    config = $4,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: delete-provider
+DELETE FROM ai_providers WHERE id = $1 AND is_default = false;
+
+-- name: unset-default-provider
+UPDATE ai_providers SET is_default = false, updated_at = NOW() WHERE is_default = true AND id != $1;
+
+-- name: set-default-provider
+UPDATE ai_providers SET is_default = true, updated_at = NOW() WHERE id = $1;
 
 -- name: get-prompt
 SELECT id, key, title, content FROM ai_prompts where key = $1;
@@ -7,11 +36,68 @@ SELECT id, key, title, content FROM ai_prompts where key = $1;
 -- name: get-prompts
 SELECT id, key, title FROM ai_prompts order by title;
 
--- name: set-openai-key
+-- name: get-prompt-by-id
+SELECT id, created_at, updated_at, key, title, content FROM ai_prompts WHERE id = $1;
+
+-- name: insert-prompt
+INSERT INTO ai_prompts (key, title, content)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: update-prompt
+UPDATE ai_prompts
+SET key = $2,
+    title = $3,
+    content = $4,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: delete-prompt
+DELETE FROM ai_prompts WHERE id = $1;
+
+-- name: set-provider-api-key
+-- Sets the API key of the default provider of the type, or the first one if none of them is the default.
 UPDATE ai_providers 
 SET config = jsonb_set(
     COALESCE(config, '{}'::jsonb),
     '{api_key}', 
     to_jsonb($1::text)
-) 
-WHERE provider = 'openai';
+),
+updated_at = NOW()
+WHERE id = (
+    SELECT id FROM ai_providers WHERE provider = $2::ai_provider ORDER BY is_default DESC, id LIMIT 1
+);
+
+-- name: get-inbox-settings
+SELECT id, created_at, updated_at, inbox_id, summarize_on_resolve, auto_tag_on_resolve, category_attribute_key FROM ai_inbox_settings WHERE inbox_id = $1;
+
+-- name: upsert-inbox-settings
+INSERT INTO ai_inbox_settings (inbox_id, summarize_on_resolve, auto_tag_on_resolve, category_attribute_key)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (inbox_id) DO UPDATE
+SET summarize_on_resolve = EXCLUDED.summarize_on_resolve,
+    auto_tag_on_resolve = EXCLUDED.auto_tag_on_resolve,
+    category_attribute_key = EXCLUDED.category_attribute_key,
+    updated_at = NOW()
+RETURNING id, created_at, updated_at, inbox_id, summarize_on_resolve, auto_tag_on_resolve, category_attribute_key;
+
+-- name: get-tag-names
+SELECT name FROM tags ORDER BY name;
+
+-- name: get-conversation-attribute-values
+SELECT values FROM custom_attribute_definitions WHERE key = $1 AND applies_to = 'conversation';
+
+-- name: insert-usage
+INSERT INTO ai_usage (user_id, provider_id, model, prompt_key, input_tokens, output_tokens, latency_ms, outcome, conversation_id)
+VALUES (NULLIF($1, 0), NULLIF($2, 0), $3, $4, $5, $6, $7, $8, NULLIF($9, 0));
+
+-- name: get-daily-usage
+-- Tokens used today by the user and the workspace along with the daily token limits, a limit of 0 is unlimited.
+SELECT
+    COALESCE(SUM(input_tokens + output_tokens) FILTER (WHERE user_id = $1), 0) AS user_tokens,
+    COALESCE(SUM(input_tokens + output_tokens), 0) AS workspace_tokens,
+    COALESCE((SELECT (value #>> '{}')::INT FROM settings WHERE key = 'ai.user_daily_token_limit'), 0) AS user_token_limit,
+    COALESCE((SELECT (value #>> '{}')::INT FROM settings WHERE key = 'ai.workspace_daily_token_limit'), 0) AS workspace_token_limit
+FROM ai_usage
+WHERE created_at >= date_trunc('day', NOW());
@@ -0,0 +1,54 @@
+package ai
+
+import (
+	"time"
+
+	"github.com/abhinavxd/libredesk/internal/ai/models"
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	"github.com/valyala/fasthttp"
+)
+
+// Usage outcomes of a prompt.
+const (
+	UsageOutcomeSuccess     = "success"
+	UsageOutcomeError       = "error"
+	UsageOutcomeRateLimited = "rate_limited"
+)
+
+// Prompt keys recorded in the usage of the built-in prompts.
+const (
+	draftPromptKey    = "draft_reply"
+	insightsPromptKey = "conversation_insights"
+)
+
+// usageMeta identifies the source of a prompt in the usage log.
+type usageMeta struct {
+	PromptKey string
+	// UserID is the agent the prompt is sent for, 0 for prompts sent by the system e.g. on resolve.
+	UserID int
+	// ConversationID is the conversation the prompt is sent in, 0 if it isn't sent in a conversation.
+	ConversationID int
+}
+
+// checkUsageLimits returns an error if the workspace or the user has used up their daily tokens.
+func (m *Manager) checkUsageLimits(userID int) error {
+	var usage models.DailyUsage
+	if err := m.q.GetDailyUsage.Get(&usage, userID); err != nil {
+		m.lo.Error("error fetching AI daily usage", "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.usage}"), nil)
+	}
+	if usage.WorkspaceTokenLimit > 0 && usage.WorkspaceTokens >= usage.WorkspaceTokenLimit {
+		return envelope.NewErrorWithCode(envelope.InputError, fasthttp.StatusTooManyRequests, m.i18n.T("ai.workspaceDailyLimitReached"), nil)
+	}
+	if userID > 0 && usage.UserTokenLimit > 0 && usage.UserTokens >= usage.UserTokenLimit {
+		return envelope.NewErrorWithCode(envelope.InputError, fasthttp.StatusTooManyRequests, m.i18n.T("ai.userDailyLimitReached"), nil)
+	}
+	return nil
+}
+
+// recordUsage records the tokens, latency and outcome of a prompt, errors are logged as usage is best effort.
+func (m *Manager) recordUsage(meta usageMeta, provider models.Provider, resp PromptResponse, latency time.Duration, outcome string) {
+	if _, err := m.q.InsertUsage.Exec(meta.UserID, provider.ID, providerModel(provider), meta.PromptKey, resp.InputTokens, resp.OutputTokens, latency.Milliseconds(), outcome, meta.ConversationID); err != nil {
+		m.lo.Error("error recording AI usage", "prompt_key", meta.PromptKey, "user_id", meta.UserID, "error", err)
+	}
+}
@@ -3,6 +3,8 @@ package article
 
 import (
 	"embed"
+	"strings"
+	"unicode"
 
 	"github.com/abhinavxd/libredesk/internal/article/models"
 	"github.com/abhinavxd/libredesk/internal/dbutil"
@@ -17,6 +19,9 @@ var (
 	efs embed.FS
 )
 
+// maxSearchKeywords is the max number of keywords of a text used to search articles.
+const maxSearchKeywords = 30
+
 type Manager struct {
 	q    queries
 	lo   *logf.Logger
@@ -32,14 +37,15 @@ type Opts struct {
 
 // queries contains prepared SQL queries.
 type queries struct {
-	InsertArticle         *sqlx.Stmt `query:"insert-article"`
-	DeleteArticle         *sqlx.Stmt `query:"delete-article"`
-	UpdateArticle         *sqlx.Stmt `query:"update-article"`
-	GetAllArticles        *sqlx.Stmt `query:"get-all-articles"`
-	GetArticleByID        *sqlx.Stmt `query:"get-article-by-id"`
-	GetArticlesBySectionID *sqlx.Stmt `query:"get-articles-by-section-id"`
-	PublishArticle        *sqlx.Stmt `query:"publish-article"`
-	UnpublishArticle      *sqlx.Stmt `query:"unpublish-article"`
+	InsertArticle           *sqlx.Stmt `query:"insert-article"`
+	DeleteArticle           *sqlx.Stmt `query:"delete-article"`
+	UpdateArticle           *sqlx.Stmt `query:"update-article"`
+	GetAllArticles          *sqlx.Stmt `query:"get-all-articles"`
+	GetArticleByID          *sqlx.Stmt `query:"get-article-by-id"`
+	GetArticlesBySectionID  *sqlx.Stmt `query:"get-articles-by-section-id"`
+	PublishArticle          *sqlx.Stmt `query:"publish-article"`
+	UnpublishArticle        *sqlx.Stmt `query:"unpublish-article"`
+	SearchPublishedArticles *sqlx.Stmt `query:"search-published-articles"`
 }
 
 // New creates and returns a new instance of the Manager.
@@ -131,4 +137,43 @@ func (m *Manager) Unpublish(id int) error {
 		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.article}"), nil)
 	}
 	return nil
-}
\ No newline at end of file
+}
+
+// SearchPublished returns the published articles most relevant to the given text.
+func (m *Manager) SearchPublished(text string, limit int) ([]models.Article, error) {
+	var articles = make([]models.Article, 0)
+	query := keywordsQuery(text)
+	if query == "" {
+		return articles, nil
+	}
+	if err := m.q.SearchPublishedArticles.Select(&articles, query, limit); err != nil {
+		m.lo.Error("error searching articles", "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", m.i18n.P("globals.terms.article")), nil)
+	}
+	return articles, nil
+}
+
+// keywordsQuery returns a tsquery that matches any of the keywords in the text.
+func keywordsQuery(text string) string {
+	var (
+		seen     = make(map[string]struct{})
+		keywords = make([]string, 0, maxSearchKeywords)
+	)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, w := range words {
+		if len([]rune(w)) < 3 {
+			continue
+		}
+		if _, ok := seen[w]; ok {
+			continue
+		}
+		seen[w] = struct{}{}
+		keywords = append(keywords, w)
+		if len(keywords) == maxSearchKeywords {
+			break
+		}
+	}
+	return strings.Join(keywords, " | ")
+}
@@ -95,4 +95,24 @@ SET
     published_at = NULL,
     updated_at = now()
 WHERE
-    id = $1;
\ No newline at end of file
+    id = $1;
+
+-- name: search-published-articles
+-- $1 is a tsquery of OR-ed keywords.
+SELECT
+    id,
+    created_at,
+    updated_at,
+    title,
+    content,
+    section_id,
+    published_at,
+    is_published
+FROM
+    articles
+WHERE
+    is_published = true
+    AND to_tsvector('english', title || ' ' || COALESCE(content, '')) @@ to_tsquery('english', $1)
+ORDER BY
+    ts_rank(to_tsvector('english', title || ' ' || COALESCE(content, '')), to_tsquery('english', $1)) DESC
+LIMIT $2;
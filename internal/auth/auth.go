@@ -1,4 +1,4 @@
-// Package auth implements OIDC multi-provider authentication and session management
+// Package auth implements OIDC and SAML multi-provider authentication and session management
 package auth
 
 import (
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
@@ -27,12 +28,25 @@ import (
 	"golang.org/x/oauth2"
 )
 
+const (
+	// partialSessionKey is the Redis key of a login that is waiting for the second factor.
+	partialSessionKey = "libredesk:auth:partial_session:%s"
+	partialSessionTTL = 5 * time.Minute
+	// maxPartialSessionAttempts is the number of invalid second factor codes after which the login has to start over.
+	maxPartialSessionAttempts = 5
+)
+
 // OIDCclaim holds OIDC token claims data
 type OIDCclaim struct {
 	Email         string `json:"email"`
 	EmailVerified bool   `json:"email_verified"`
 	Sub           string `json:"sub"`
 	Picture       string `json:"picture"`
+	Name          string `json:"name"`
+	GivenName     string `json:"given_name"`
+	FamilyName    string `json:"family_name"`
+	// Groups is read from the groups claim configured for the provider.
+	Groups []string `json:"-"`
 }
 
 // Provider defines an OIDC provider configuration
@@ -43,11 +57,13 @@ type Provider struct {
 	RedirectURL  string
 	ClientID     string
 	ClientSecret string
+	GroupsClaim  string
 }
 
-// Config holds OIDC providers and cookies security settings
+// Config holds OIDC and SAML providers and cookies security settings
 type Config struct {
 	Providers     []Provider
+	SAMLProviders []SAMLProvider
 	SecureCookies bool
 }
 
@@ -58,9 +74,11 @@ type Auth struct {
 	i18n      *i18n.I18n
 	oauthCfgs map[int]oauth2.Config
 	verifiers map[int]*oidc.IDTokenVerifier
-	sess      *simplesessions.Manager
-	logger    *logf.Logger
-	rd        *redis.Client
+	// samlProviders are the SAML service providers by identity provider ID.
+	samlProviders map[int]samlProvider
+	sess          *simplesessions.Manager
+	logger        *logf.Logger
+	rd            *redis.Client
 }
 
 // New creates an Auth service with configured OIDC providers
@@ -89,6 +107,16 @@ func New(cfg Config, i18n *i18n.I18n, rd *redis.Client, logger *logf.Logger) (*A
 		verifiers[provider.ID] = verifier
 	}
 
+	samlProviders := make(map[int]samlProvider)
+	for _, provider := range cfg.SAMLProviders {
+		p, err := newSAMLProvider(provider)
+		if err != nil {
+			logger.Error("error initializing saml provider", "error", err, "provider_id", provider.ID)
+			continue
+		}
+		samlProviders[provider.ID] = p
+	}
+
 	sess := simplesessions.New(simplesessions.Options{
 		EnableAutoCreate: true,
 		SessionIDLength:  64,
@@ -96,22 +124,25 @@ func New(cfg Config, i18n *i18n.I18n, rd *redis.Client, logger *logf.Logger) (*A
 			Name:       "libredesk_session",
 			IsHTTPOnly: true,
 			IsSecure:   cfg.SecureCookies,
-			MaxAge:     time.Hour * 9,
+			MaxAge:     sessionTTL,
 		},
 	})
 
 	st := sessredisstore.New(context.TODO(), rd)
+	st.SetPrefix(sessionPrefix)
+	st.SetTTL(sessionTTL, false)
 	sess.UseStore(st)
 	sess.SetCookieHooks(simpleSessGetCookieCB, simpleSessSetCookieCB)
 
 	return &Auth{
-		cfg:       cfg,
-		i18n:      i18n,
-		oauthCfgs: oauthCfgs,
-		verifiers: verifiers,
-		sess:      sess,
-		logger:    logger,
-		rd:        rd,
+		cfg:           cfg,
+		i18n:          i18n,
+		oauthCfgs:     oauthCfgs,
+		verifiers:     verifiers,
+		samlProviders: samlProviders,
+		sess:          sess,
+		logger:        logger,
+		rd:            rd,
 	}, nil
 }
 
@@ -154,9 +185,20 @@ func (a *Auth) Reload(cfg Config) error {
 		verifiers[provider.ID] = verifier
 	}
 
+	samlProviders := make(map[int]samlProvider)
+	for _, provider := range cfg.SAMLProviders {
+		p, err := newSAMLProvider(provider)
+		if err != nil {
+			a.logger.Error("error initializing saml provider", "provider_id", provider.ID, "error", err)
+			continue
+		}
+		samlProviders[provider.ID] = p
+	}
+
 	a.cfg = cfg
 	a.oauthCfgs = oauthCfgs
 	a.verifiers = verifiers
+	a.samlProviders = samlProviders
 
 	return nil
 }
@@ -208,11 +250,55 @@ func (a *Auth) ExchangeOIDCToken(ctx context.Context, providerID int, code strin
 	if err := idTk.Claims(&claims); err != nil {
 		return "", OIDCclaim{}, errors.New("error getting user from OIDC")
 	}
+
+	// Read the groups from the configured claim.
+	var raw map[string]any
+	if err := idTk.Claims(&raw); err != nil {
+		return "", OIDCclaim{}, errors.New("error getting user from OIDC")
+	}
+	for _, p := range a.cfg.Providers {
+		if p.ID == providerID {
+			claims.Groups = groupsFromClaims(raw, p.GroupsClaim)
+			break
+		}
+	}
 	return rawIDTk, claims, nil
 }
 
-// SaveSession creates and sets a session (post successful login/auth).
-func (a *Auth) SaveSession(user amodels.User, r *fastglue.Request) error {
+// groupsFromClaims returns the groups in the claim at the given dot separated path,
+// the claim can be a list of strings or a single string.
+func groupsFromClaims(claims map[string]any, path string) []string {
+	if path == "" {
+		return nil
+	}
+	var val any = claims
+	for _, key := range strings.Split(path, ".") {
+		m, ok := val.(map[string]any)
+		if !ok {
+			return nil
+		}
+		if val, ok = m[key]; !ok {
+			return nil
+		}
+	}
+
+	switch v := val.(type) {
+	case string:
+		return []string{v}
+	case []any:
+		groups := make([]string, 0, len(v))
+		for _, g := range v {
+			if s, ok := g.(string); ok {
+				groups = append(groups, s)
+			}
+		}
+		return groups
+	}
+	return nil
+}
+
+// SaveSession creates and sets a session (post successful login/auth), ip is the client IP stored with the session.
+func (a *Auth) SaveSession(user amodels.User, ip string, r *fastglue.Request) error {
 	a.mu.RLock()
 	defer a.mu.RUnlock()
 
@@ -222,18 +308,94 @@ func (a *Auth) SaveSession(user amodels.User, r *fastglue.Request) error {
 		return err
 	}
 
-	if err := sess.SetMulti(map[string]interface{}{
-		"id":         user.ID,
-		"email":      user.Email,
-		"first_name": user.FirstName,
-		"last_name":  user.LastName,
-	}); err != nil {
+	values := sessionMeta(r, ip)
+	values["id"] = user.ID
+	values["email"] = user.Email
+	values["first_name"] = user.FirstName
+	values["last_name"] = user.LastName
+	if err := sess.SetMulti(values); err != nil {
 		a.logger.Error("error setting login session", "error", err)
 		return err
 	}
+
+	// Index the session so that it can be listed and revoked.
+	if err := a.indexSession(user.ID, sess.ID()); err != nil {
+		a.logger.Error("error indexing login session", "error", err)
+		return err
+	}
 	return nil
 }
 
+// SavePartialSession stores a login that passed the password check and is waiting for the second factor,
+// returns the token to complete the login with.
+func (a *Auth) SavePartialSession(userID int) (string, error) {
+	token, err := stringutil.RandomAlphanumeric(64)
+	if err != nil {
+		a.logger.Error("error generating partial session token", "error", err)
+		return "", err
+	}
+	var (
+		ctx = context.Background()
+		key = fmt.Sprintf(partialSessionKey, token)
+	)
+	if _, err := a.rd.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.HSet(ctx, key, "user_id", userID, "attempts", 0)
+		p.Expire(ctx, key, partialSessionTTL)
+		return nil
+	}); err != nil {
+		a.logger.Error("error saving partial session", "error", err)
+		return "", err
+	}
+	return token, nil
+}
+
+// GetPartialSession returns the user ID of a login waiting for the second factor.
+func (a *Auth) GetPartialSession(token string) (int, error) {
+	if token == "" {
+		return 0, envelope.NewError(envelope.UnauthorizedError, a.i18n.T("auth.twoFactorSessionExpired"), nil)
+	}
+	userID, err := a.rd.HGet(context.Background(), fmt.Sprintf(partialSessionKey, token), "user_id").Int()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			a.logger.Error("error fetching partial session", "error", err)
+		}
+		return 0, envelope.NewError(envelope.UnauthorizedError, a.i18n.T("auth.twoFactorSessionExpired"), nil)
+	}
+	return userID, nil
+}
+
+// RecordPartialSessionFailure counts an invalid second factor code and destroys the partial session
+// once the max attempts are reached.
+func (a *Auth) RecordPartialSessionFailure(token string) {
+	var (
+		ctx = context.Background()
+		key = fmt.Sprintf(partialSessionKey, token)
+	)
+	var (
+		attempts *redis.IntCmd
+		exists   *redis.BoolCmd
+	)
+	if _, err := a.rd.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		attempts = p.HIncrBy(ctx, key, "attempts", 1)
+		exists = p.HExists(ctx, key, "user_id")
+		return nil
+	}); err != nil {
+		a.logger.Error("error recording partial session failure", "error", err)
+		return
+	}
+	// The increment recreates an expired session without a TTL, delete it.
+	if !exists.Val() || attempts.Val() >= maxPartialSessionAttempts {
+		a.DestroyPartialSession(token)
+	}
+}
+
+// DestroyPartialSession deletes a partial session.
+func (a *Auth) DestroyPartialSession(token string) {
+	if err := a.rd.Del(context.Background(), fmt.Sprintf(partialSessionKey, token)).Err(); err != nil {
+		a.logger.Error("error deleting partial session", "error", err)
+	}
+}
+
 // SetSessionValues sets passed values in the session.
 func (a *Auth) SetSessionValues(r *fastglue.Request, values map[string]interface{}) error {
 	a.mu.RLock()
@@ -305,19 +467,30 @@ func (a *Auth) ValidateSession(r *fastglue.Request) (models.User, error) {
 		return models.User{}, err
 	}
 
-	sessVals, err := sess.GetMulti("id", "email", "first_name", "last_name")
+	sessVals, err := sess.GetMulti("id", "email", "first_name", "last_name", "last_seen_at")
 	if err != nil {
 		a.logger.Error("error fetching session variables", "error", err)
 		return models.User{}, err
 	}
 
 	var (
-		userID, _    = sess.Int(sessVals["id"], nil)
-		email, _     = sess.String(sessVals["email"], nil)
-		firstName, _ = sess.String(sessVals["first_name"], nil)
-		lastName, _  = sess.String(sessVals["last_name"], nil)
+		userID, _     = sess.Int(sessVals["id"], nil)
+		email, _      = sess.String(sessVals["email"], nil)
+		firstName, _  = sess.String(sessVals["first_name"], nil)
+		lastName, _   = sess.String(sessVals["last_name"], nil)
+		lastSeenAt, _ = sess.Int64(sessVals["last_seen_at"], nil)
 	)
 
+	// Update the last seen time of the session, this also indexes sessions created before sessions were indexed.
+	if userID > 0 && time.Since(time.Unix(lastSeenAt, 0)) > lastSeenInterval {
+		if err := sess.Set("last_seen_at", time.Now().Unix()); err != nil {
+			a.logger.Error("error updating session last seen", "error", err)
+		}
+		if err := a.indexSession(userID, sess.ID()); err != nil {
+			a.logger.Error("error indexing session", "error", err)
+		}
+	}
+
 	return models.User{
 		ID:        userID,
 		Email:     null.NewString(email, email != ""),
@@ -336,10 +509,14 @@ func (a *Auth) DestroySession(r *fastglue.Request) error {
 		a.logger.Error("error acquiring session", "error", err)
 		return err
 	}
+	userID, _ := sess.Int(sess.Get("id"))
 	if err := sess.Destroy(); err != nil {
 		a.logger.Error("error clearing session", "error", err)
 		return err
 	}
+	if userID > 0 {
+		a.unindexSession(userID, sess.ID())
+	}
 	return nil
 }
 
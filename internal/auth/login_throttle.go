@@ -0,0 +1,189 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	"github.com/redis/go-redis/v9"
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	// loginFailuresKey counts the failed logins of an account or IP within loginFailuresWindow.
+	loginFailuresKey    = "libredesk:auth:login_failures:%s:%s"
+	loginFailuresWindow = 15 * time.Minute
+	// loginDelayKey exists while an account or IP has to wait before the next login attempt.
+	loginDelayKey = "libredesk:auth:login_delay:%s:%s"
+	// loginLockoutKey exists while an account is locked out.
+	loginLockoutKey      = "libredesk:auth:login_lockout:%s"
+	loginLockoutDuration = 15 * time.Minute
+
+	// maxAccountLoginFailures is the number of failed logins after which an account is locked out.
+	maxAccountLoginFailures = 5
+	// maxIPLoginFailures is the number of failed logins after which an IP is blocked until the failures expire.
+	maxIPLoginFailures = 20
+	// freeLoginFailures is the number of failed logins before subsequent attempts are delayed.
+	freeLoginFailures = 2
+	maxLoginDelay     = 30 * time.Second
+
+	loginScopeAccount = "account"
+	loginScopeIP      = "ip"
+)
+
+// loginDelay returns how long to wait before the next login attempt after the given number of failed logins,
+// doubling with every failure after the first free ones.
+func loginDelay(failures int) time.Duration {
+	if failures <= freeLoginFailures {
+		return 0
+	}
+	delay := time.Second
+	for i := freeLoginFailures + 1; i < failures && delay < maxLoginDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxLoginDelay)
+}
+
+// normalizeLoginEmail returns the email that failed logins of an account are counted by.
+func normalizeLoginEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// CheckLoginAllowed returns an error if login attempts for the email from the IP are locked out or have to be delayed.
+func (a *Auth) CheckLoginAllowed(ip, email string) error {
+	ctx := context.Background()
+	email = normalizeLoginEmail(email)
+
+	if until, locked := a.LoginLockedUntil(email); locked {
+		return a.lockedOutError(time.Until(until))
+	}
+
+	var (
+		ipFailures *redis.StringCmd
+		delays     []*redis.DurationCmd
+	)
+	if _, err := a.rd.Pipelined(ctx, func(p redis.Pipeliner) error {
+		ipFailures = p.Get(ctx, fmt.Sprintf(loginFailuresKey, loginScopeIP, ip))
+		delays = append(delays,
+			p.PTTL(ctx, fmt.Sprintf(loginDelayKey, loginScopeAccount, email)),
+			p.PTTL(ctx, fmt.Sprintf(loginDelayKey, loginScopeIP, ip)),
+		)
+		return nil
+	}); err != nil && err != redis.Nil {
+		// Logins are not blocked if Redis is unavailable.
+		a.logger.Error("error fetching login attempts", "error", err)
+		return nil
+	}
+
+	if n, _ := ipFailures.Int(); n >= maxIPLoginFailures {
+		ttl, _ := a.rd.PTTL(ctx, fmt.Sprintf(loginFailuresKey, loginScopeIP, ip)).Result()
+		return a.tooManyAttemptsError(ttl)
+	}
+	for _, d := range delays {
+		if ttl := d.Val(); ttl > 0 {
+			return a.tooManyAttemptsError(ttl)
+		}
+	}
+	return nil
+}
+
+// RecordLoginFailure records a failed login for the email from the IP and returns true if the account got locked out.
+func (a *Auth) RecordLoginFailure(ip, email string) (bool, error) {
+	email = normalizeLoginEmail(email)
+	var (
+		ctx        = context.Background()
+		accountKey = fmt.Sprintf(loginFailuresKey, loginScopeAccount, email)
+		ipKey      = fmt.Sprintf(loginFailuresKey, loginScopeIP, ip)
+		accountCmd *redis.IntCmd
+		ipCmd      *redis.IntCmd
+	)
+	if _, err := a.rd.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		accountCmd = p.Incr(ctx, accountKey)
+		p.Expire(ctx, accountKey, loginFailuresWindow)
+		ipCmd = p.Incr(ctx, ipKey)
+		p.Expire(ctx, ipKey, loginFailuresWindow)
+		return nil
+	}); err != nil {
+		a.logger.Error("error recording failed login", "error", err)
+		return false, err
+	}
+
+	var (
+		accountFailures = int(accountCmd.Val())
+		locked          = accountFailures >= maxAccountLoginFailures
+	)
+	if _, err := a.rd.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		if d := loginDelay(accountFailures); d > 0 {
+			p.Set(ctx, fmt.Sprintf(loginDelayKey, loginScopeAccount, email), 1, d)
+		}
+		if d := loginDelay(int(ipCmd.Val())); d > 0 {
+			p.Set(ctx, fmt.Sprintf(loginDelayKey, loginScopeIP, ip), 1, d)
+		}
+		if locked {
+			p.Set(ctx, fmt.Sprintf(loginLockoutKey, email), 1, loginLockoutDuration)
+			// Failures start over once the lockout ends.
+			p.Del(ctx, accountKey)
+		}
+		return nil
+	}); err != nil {
+		a.logger.Error("error recording failed login", "error", err)
+		return false, err
+	}
+	return locked, nil
+}
+
+// ClearLoginFailures clears the failed logins of an account after a successful login.
+// Failures of the IP are kept so that a valid account can't be used to reset them.
+func (a *Auth) ClearLoginFailures(email string) {
+	email = normalizeLoginEmail(email)
+	if err := a.rd.Del(context.Background(),
+		fmt.Sprintf(loginFailuresKey, loginScopeAccount, email),
+		fmt.Sprintf(loginDelayKey, loginScopeAccount, email),
+	).Err(); err != nil {
+		a.logger.Error("error clearing failed logins", "error", err)
+	}
+}
+
+// LoginLockedUntil returns the time until which an account is locked out, and whether it's locked out.
+func (a *Auth) LoginLockedUntil(email string) (time.Time, bool) {
+	ttl, err := a.rd.PTTL(context.Background(), fmt.Sprintf(loginLockoutKey, normalizeLoginEmail(email))).Result()
+	if err != nil {
+		a.logger.Error("error fetching login lockout", "error", err)
+		return time.Time{}, false
+	}
+	if ttl <= 0 {
+		return time.Time{}, false
+	}
+	return time.Now().Add(ttl), true
+}
+
+// UnlockLogin lifts the lockout of an account and clears its failed logins.
+func (a *Auth) UnlockLogin(email string) error {
+	email = normalizeLoginEmail(email)
+	if err := a.rd.Del(context.Background(),
+		fmt.Sprintf(loginLockoutKey, email),
+		fmt.Sprintf(loginFailuresKey, loginScopeAccount, email),
+		fmt.Sprintf(loginDelayKey, loginScopeAccount, email),
+	).Err(); err != nil {
+		a.logger.Error("error unlocking login", "error", err)
+		return envelope.NewError(envelope.GeneralError, a.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.user}"), nil)
+	}
+	return nil
+}
+
+// lockedOutError returns the error for logins to a locked out account.
+func (a *Auth) lockedOutError(ttl time.Duration) error {
+	minutes := int(math.Ceil(ttl.Minutes()))
+	return envelope.NewErrorWithCode(envelope.PermissionError, fasthttp.StatusTooManyRequests,
+		a.i18n.Ts("user.accountLocked", "minutes", fmt.Sprintf("%d", max(minutes, 1))), nil)
+}
+
+// tooManyAttemptsError returns the error for login attempts that have to wait.
+func (a *Auth) tooManyAttemptsError(ttl time.Duration) error {
+	seconds := int(math.Ceil(ttl.Seconds()))
+	return envelope.NewErrorWithCode(envelope.InputError, fasthttp.StatusTooManyRequests,
+		a.i18n.Ts("user.tooManyLoginAttempts", "seconds", fmt.Sprintf("%d", max(seconds, 1))), nil)
+}
@@ -0,0 +1,26 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoginDelay(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{freeLoginFailures, 0},
+		{freeLoginFailures + 1, time.Second},
+		{freeLoginFailures + 2, 2 * time.Second},
+		{freeLoginFailures + 4, 8 * time.Second},
+		{freeLoginFailures + 10, maxLoginDelay},
+		{1000, maxLoginDelay},
+	}
+	for _, tt := range tests {
+		if got := loginDelay(tt.failures); got != tt.want {
+			t.Errorf("loginDelay(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
@@ -1,9 +1,31 @@
 package models
 
+import "time"
+
 // User represents an authenticated user.
 type User struct {
 	ID        int    `json:"id"`
 	FirstName string `json:"first_name"`
 	LastName  string `json:"last_name"`
 	Email     string `json:"email,omitempty"`
+
+	// APIKeyID and APIKeyPermissions are set when the user is authenticated with an API key,
+	// the user's permissions are restricted to APIKeyPermissions.
+	APIKeyID          int      `json:"-"`
+	APIKeyPermissions []string `json:"-"`
+
+	// RoutePermission is the permission the request was authorized with by the perm middleware.
+	RoutePermission string `json:"-"`
+}
+
+// Session is an active login session of a user.
+type Session struct {
+	// ID identifies the session without exposing the session cookie.
+	ID         string    `json:"id"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"user_agent"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	// Current is set for the session of the request listing the sessions.
+	Current bool `json:"current"`
 }
@@ -0,0 +1,217 @@
+package auth
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	"github.com/abhinavxd/libredesk/internal/stringutil"
+	"github.com/crewjam/saml"
+	"github.com/crewjam/saml/samlsp"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// samlRequestKey is the Redis key of a pending SAML authentication request by its relay state.
+	samlRequestKey = "libredesk:auth:saml_request:%s"
+	samlRequestTTL = 10 * time.Minute
+)
+
+// SAMLProvider defines a SAML identity provider configuration.
+type SAMLProvider struct {
+	ID                 int
+	EntityID           string
+	ACSURL             string
+	IDPMetadata        string
+	SPCertificate      string
+	SPPrivateKey       string
+	EmailAttribute     string
+	FirstNameAttribute string
+	LastNameAttribute  string
+}
+
+// SAMLclaim holds the user attributes of a validated SAML assertion.
+type SAMLclaim struct {
+	Email     string
+	FirstName string
+	LastName  string
+}
+
+// samlProvider is a configured service provider along with the attribute mapping of the identity provider.
+type samlProvider struct {
+	sp  *saml.ServiceProvider
+	cfg SAMLProvider
+}
+
+// newSAMLProvider creates the service provider for an identity provider.
+func newSAMLProvider(p SAMLProvider) (samlProvider, error) {
+	md, err := samlsp.ParseMetadata([]byte(p.IDPMetadata))
+	if err != nil {
+		return samlProvider{}, fmt.Errorf("parsing idp metadata: %w", err)
+	}
+
+	certBlock, _ := pem.Decode([]byte(p.SPCertificate))
+	if certBlock == nil {
+		return samlProvider{}, errors.New("invalid sp certificate")
+	}
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return samlProvider{}, fmt.Errorf("parsing sp certificate: %w", err)
+	}
+	keyBlock, _ := pem.Decode([]byte(p.SPPrivateKey))
+	if keyBlock == nil {
+		return samlProvider{}, errors.New("invalid sp private key")
+	}
+	key, err := x509.ParsePKCS1PrivateKey(keyBlock.Bytes)
+	if err != nil {
+		return samlProvider{}, fmt.Errorf("parsing sp private key: %w", err)
+	}
+
+	metadataURL, err := url.Parse(p.EntityID)
+	if err != nil {
+		return samlProvider{}, fmt.Errorf("parsing metadata url: %w", err)
+	}
+	acsURL, err := url.Parse(p.ACSURL)
+	if err != nil {
+		return samlProvider{}, fmt.Errorf("parsing acs url: %w", err)
+	}
+
+	return samlProvider{
+		sp: &saml.ServiceProvider{
+			EntityID:    p.EntityID,
+			Key:         key,
+			Certificate: cert,
+			MetadataURL: *metadataURL,
+			AcsURL:      *acsURL,
+			IDPMetadata: md,
+		},
+		cfg: p,
+	}, nil
+}
+
+// SAMLLoginURL returns the identity provider URL to start a login with, the request is saved to validate the response.
+func (a *Auth) SAMLLoginURL(providerID int) (string, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	p, ok := a.samlProviders[providerID]
+	if !ok {
+		return "", envelope.NewError(envelope.InputError, a.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.provider}"), nil)
+	}
+
+	req, err := p.sp.MakeAuthenticationRequest(p.sp.GetSSOBindingLocation(saml.HTTPRedirectBinding), saml.HTTPRedirectBinding, saml.HTTPPostBinding)
+	if err != nil {
+		a.logger.Error("error creating saml authentication request", "provider_id", providerID, "error", err)
+		return "", envelope.NewError(envelope.GeneralError, a.i18n.Ts("globals.messages.errorCreating", "name", "{globals.terms.request}"), nil)
+	}
+
+	// The relay state is echoed back by the identity provider and identifies the request, session cookies are not sent on the cross-site POST.
+	relayState, err := stringutil.RandomAlphanumeric(64)
+	if err != nil {
+		a.logger.Error("error generating saml relay state", "error", err)
+		return "", envelope.NewError(envelope.GeneralError, a.i18n.Ts("globals.messages.errorCreating", "name", "{globals.terms.request}"), nil)
+	}
+	if err := a.rd.Set(context.Background(), fmt.Sprintf(samlRequestKey, relayState), req.ID, samlRequestTTL).Err(); err != nil {
+		a.logger.Error("error saving saml request", "error", err)
+		return "", envelope.NewError(envelope.GeneralError, a.i18n.Ts("globals.messages.errorSaving", "name", "{globals.terms.request}"), nil)
+	}
+
+	u, err := req.Redirect(relayState, p.sp)
+	if err != nil {
+		a.logger.Error("error creating saml redirect url", "provider_id", providerID, "error", err)
+		return "", envelope.NewError(envelope.GeneralError, a.i18n.Ts("globals.messages.errorCreating", "name", "{globals.terms.request}"), nil)
+	}
+	return u.String(), nil
+}
+
+// SAMLMetadata returns the service provider metadata XML for the identity provider.
+func (a *Auth) SAMLMetadata(providerID int) ([]byte, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	p, ok := a.samlProviders[providerID]
+	if !ok {
+		return nil, envelope.NewError(envelope.NotFoundError, a.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.provider}"), nil)
+	}
+	b, err := xml.MarshalIndent(p.sp.Metadata(), "", "  ")
+	if err != nil {
+		a.logger.Error("error marshalling saml metadata", "provider_id", providerID, "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, a.i18n.Ts("globals.messages.errorFetching", "name", "metadata"), nil)
+	}
+	return b, nil
+}
+
+// ParseSAMLResponse validates the signed SAML response posted to the assertion consumer service for a request started with
+// SAMLLoginURL and returns the mapped user attributes. Each request can only be used once.
+func (a *Auth) ParseSAMLResponse(providerID int, samlResponse, relayState string) (SAMLclaim, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	p, ok := a.samlProviders[providerID]
+	if !ok {
+		return SAMLclaim{}, envelope.NewError(envelope.InputError, a.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.provider}"), nil)
+	}
+
+	if relayState == "" {
+		return SAMLclaim{}, envelope.NewError(envelope.UnauthorizedError, a.i18n.T("auth.samlRequestExpired"), nil)
+	}
+	requestID, err := a.rd.GetDel(context.Background(), fmt.Sprintf(samlRequestKey, relayState)).Result()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			a.logger.Error("error fetching saml request", "error", err)
+		}
+		return SAMLclaim{}, envelope.NewError(envelope.UnauthorizedError, a.i18n.T("auth.samlRequestExpired"), nil)
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(samlResponse)
+	if err != nil {
+		return SAMLclaim{}, envelope.NewError(envelope.InputError, a.i18n.T("auth.invalidSAMLResponse"), nil)
+	}
+	assertion, err := p.sp.ParseXMLResponse(raw, []string{requestID}, p.sp.AcsURL)
+	if err != nil {
+		var invalidErr *saml.InvalidResponseError
+		if errors.As(err, &invalidErr) {
+			err = invalidErr.PrivateErr
+		}
+		a.logger.Error("error validating saml response", "provider_id", providerID, "error", err)
+		return SAMLclaim{}, envelope.NewError(envelope.UnauthorizedError, a.i18n.T("auth.invalidSAMLResponse"), nil)
+	}
+
+	claims := SAMLclaim{
+		Email:     assertionAttribute(assertion, p.cfg.EmailAttribute),
+		FirstName: assertionAttribute(assertion, p.cfg.FirstNameAttribute),
+		LastName:  assertionAttribute(assertion, p.cfg.LastNameAttribute),
+	}
+	if claims.Email == "" && assertion.Subject != nil && assertion.Subject.NameID != nil {
+		claims.Email = assertion.Subject.NameID.Value
+	}
+	return claims, nil
+}
+
+// assertionAttribute returns the first value of the attribute with the given name or friendly name.
+func assertionAttribute(assertion *saml.Assertion, name string) string {
+	if name == "" {
+		return ""
+	}
+	for _, stmt := range assertion.AttributeStatements {
+		for _, attr := range stmt.Attributes {
+			if attr.Name != name && attr.FriendlyName != name {
+				continue
+			}
+			for _, v := range attr.Values {
+				if val := strings.TrimSpace(v.Value); val != "" {
+					return val
+				}
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,180 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	amodels "github.com/abhinavxd/libredesk/internal/auth/models"
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	"github.com/redis/go-redis/v9"
+	"github.com/zerodha/fastglue"
+)
+
+const (
+	// sessionPrefix is the Redis key prefix of sessions in the session store.
+	sessionPrefix = "session:"
+	sessionTTL    = time.Hour * 9
+	// userSessionsKey is the Redis hash of a user's sessions, mapping session IDs to session cookie values.
+	userSessionsKey = "libredesk:auth:user_sessions:%d"
+	// lastSeenInterval is how often the last seen time of a session is updated.
+	lastSeenInterval = time.Minute
+)
+
+// sessionID returns the ID of a session that is safe to expose, as the session cookie value can be used to log in.
+func sessionID(cookieID string) string {
+	h := sha256.Sum256([]byte(cookieID))
+	return hex.EncodeToString(h[:16])
+}
+
+// sessionMeta returns the metadata stored along with a new session, ip is the client IP the session is created from.
+func sessionMeta(r *fastglue.Request, ip string) map[string]interface{} {
+	now := time.Now().Unix()
+	return map[string]interface{}{
+		"ip":           ip,
+		"user_agent":   string(r.RequestCtx.UserAgent()),
+		"created_at":   now,
+		"last_seen_at": now,
+	}
+}
+
+// indexSession adds a session to the sessions of a user.
+func (a *Auth) indexSession(userID int, cookieID string) error {
+	var (
+		ctx = context.Background()
+		key = fmt.Sprintf(userSessionsKey, userID)
+	)
+	_, err := a.rd.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.HSet(ctx, key, sessionID(cookieID), cookieID)
+		p.Expire(ctx, key, sessionTTL)
+		return nil
+	})
+	return err
+}
+
+// unindexSession removes a session from the sessions of a user.
+func (a *Auth) unindexSession(userID int, cookieID string) {
+	if err := a.rd.HDel(context.Background(), fmt.Sprintf(userSessionsKey, userID), sessionID(cookieID)).Err(); err != nil {
+		a.logger.Error("error removing user session", "user_id", userID, "error", err)
+	}
+}
+
+// CurrentSessionID returns the ID of the session of the request.
+func (a *Auth) CurrentSessionID(r *fastglue.Request) (string, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	sess, err := a.sess.Acquire(r.RequestCtx, r, r)
+	if err != nil {
+		return "", err
+	}
+	return sessionID(sess.ID()), nil
+}
+
+// GetUserSessions returns the active sessions of a user, most recently seen first. currentID marks the session of the request.
+func (a *Auth) GetUserSessions(userID int, currentID string) ([]amodels.Session, error) {
+	var (
+		ctx      = context.Background()
+		key      = fmt.Sprintf(userSessionsKey, userID)
+		sessions = make([]amodels.Session, 0)
+	)
+	index, err := a.rd.HGetAll(ctx, key).Result()
+	if err != nil {
+		a.logger.Error("error fetching user sessions", "user_id", userID, "error", err)
+		return sessions, envelope.NewError(envelope.GeneralError, a.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.session}"), nil)
+	}
+
+	cmds := make(map[string]*redis.MapStringStringCmd, len(index))
+	if _, err := a.rd.Pipelined(ctx, func(p redis.Pipeliner) error {
+		for id, cookieID := range index {
+			cmds[id] = p.HGetAll(ctx, sessionPrefix+cookieID)
+		}
+		return nil
+	}); err != nil {
+		a.logger.Error("error fetching user sessions", "user_id", userID, "error", err)
+		return sessions, envelope.NewError(envelope.GeneralError, a.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.session}"), nil)
+	}
+
+	var stale []string
+	for id, cmd := range cmds {
+		vals := cmd.Val()
+		// Sessions that expired or were logged out, or belong to another user after the index expired.
+		if len(vals) == 0 || vals["id"] != strconv.Itoa(userID) {
+			stale = append(stale, id)
+			continue
+		}
+		createdAt, _ := strconv.ParseInt(vals["created_at"], 10, 64)
+		lastSeenAt, _ := strconv.ParseInt(vals["last_seen_at"], 10, 64)
+		sessions = append(sessions, amodels.Session{
+			ID:         id,
+			IP:         vals["ip"],
+			UserAgent:  vals["user_agent"],
+			CreatedAt:  time.Unix(createdAt, 0),
+			LastSeenAt: time.Unix(lastSeenAt, 0),
+			Current:    id == currentID,
+		})
+	}
+	if len(stale) > 0 {
+		if err := a.rd.HDel(ctx, key, stale...).Err(); err != nil {
+			a.logger.Error("error removing stale user sessions", "user_id", userID, "error", err)
+		}
+	}
+
+	slices.SortFunc(sessions, func(x, y amodels.Session) int {
+		return y.LastSeenAt.Compare(x.LastSeenAt)
+	})
+	return sessions, nil
+}
+
+// DestroyUserSession logs a user out of a session.
+func (a *Auth) DestroyUserSession(userID int, id string) error {
+	var (
+		ctx = context.Background()
+		key = fmt.Sprintf(userSessionsKey, userID)
+	)
+	cookieID, err := a.rd.HGet(ctx, key, id).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return envelope.NewError(envelope.NotFoundError, a.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.session}"), nil)
+		}
+		a.logger.Error("error fetching user session", "user_id", userID, "error", err)
+		return envelope.NewError(envelope.GeneralError, a.i18n.Ts("globals.messages.errorDestroying", "name", "{globals.terms.session}"), nil)
+	}
+	if _, err := a.rd.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.Del(ctx, sessionPrefix+cookieID)
+		p.HDel(ctx, key, id)
+		return nil
+	}); err != nil {
+		a.logger.Error("error destroying user session", "user_id", userID, "error", err)
+		return envelope.NewError(envelope.GeneralError, a.i18n.Ts("globals.messages.errorDestroying", "name", "{globals.terms.session}"), nil)
+	}
+	return nil
+}
+
+// DestroyUserSessions logs a user out of all sessions.
+func (a *Auth) DestroyUserSessions(userID int) error {
+	var (
+		ctx = context.Background()
+		key = fmt.Sprintf(userSessionsKey, userID)
+	)
+	index, err := a.rd.HGetAll(ctx, key).Result()
+	if err != nil {
+		a.logger.Error("error fetching user sessions", "user_id", userID, "error", err)
+		return envelope.NewError(envelope.GeneralError, a.i18n.Ts("globals.messages.errorDestroying", "name", "{globals.terms.session}"), nil)
+	}
+	if _, err := a.rd.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		for _, cookieID := range index {
+			p.Del(ctx, sessionPrefix+cookieID)
+		}
+		p.Del(ctx, key)
+		return nil
+	}); err != nil {
+		a.logger.Error("error destroying user sessions", "user_id", userID, "error", err)
+		return envelope.NewError(envelope.GeneralError, a.i18n.Ts("globals.messages.errorDestroying", "name", "{globals.terms.session}"), nil)
+	}
+	return nil
+}
@@ -20,8 +20,8 @@ import (
 // Enforcer is a wrapper around Casbin enforcer.
 type Enforcer struct {
 	enforcer *casbin.SyncedEnforcer
-	// User permissions cache to avoid loading policies into Casbin every time.
-	permsCache   map[int][]string
+	// Policies cache by subject to avoid loading policies into Casbin every time.
+	permsCache   map[string][]string
 	permsCacheMu sync.RWMutex
 	lo           *logf.Logger
 	i18n         *i18n.I18n
@@ -29,18 +29,22 @@ type Enforcer struct {
 
 const casbinModel = `
 	[request_definition]
-	r = sub, obj, act
+	r = sub, obj, act, inbox, team
 
 	[policy_definition]
-	p = sub, obj, act
+	p = sub, obj, act, inbox, team
 
 	[policy_effect]
 	e = some(where (p.eft == allow))
 
 	[matchers]
-	m = r.sub == p.sub && r.obj == p.obj && r.act == p.act
+	m = r.sub == p.sub && r.obj == p.obj && r.act == p.act && (r.inbox == "*" || p.inbox == "*" || r.inbox == p.inbox) && (r.team == "*" || p.team == "*" || r.team == p.team)
 `
 
+// anyScope matches policies of all inboxes and teams, policies of permissions that aren't restricted
+// by role scopes apply to all inboxes and teams.
+const anyScope = "*"
+
 // NewEnforcer initializes a new Enforcer with the hardcoded model
 func NewEnforcer(lo *logf.Logger, i18n *i18n.I18n) (*Enforcer, error) {
 	m, err := model.NewModelFromString(casbinModel)
@@ -54,64 +58,142 @@ func NewEnforcer(lo *logf.Logger, i18n *i18n.I18n) (*Enforcer, error) {
 
 	return &Enforcer{
 		enforcer:   e,
-		permsCache: make(map[int][]string),
+		permsCache: make(map[string][]string),
 		lo:         lo,
 		i18n:       i18n,
 	}, nil
 }
 
+// subject returns the Casbin subject of a user, users authenticated with an API key get a subject per key
+// as the key's permissions can be a subset of the user's permissions.
+func subject(user umodels.User) string {
+	if user.APIKeyID > 0 {
+		return "api_key:" + strconv.Itoa(user.APIKeyID)
+	}
+	return strconv.Itoa(user.ID)
+}
+
 // LoadPermissions syncs user permissions with Casbin enforcer by removing existing policies and adding current permissions as new policies.
 func (e *Enforcer) LoadPermissions(user umodels.User) error {
+	sub := subject(user)
+
+	// Build all policies, permissions restricted by role scopes get a policy per inbox and team they're granted in.
+	policies, err := userPolicies(sub, user)
+	if err != nil {
+		return err
+	}
+	cacheKey := make([]string, len(policies))
+	for i, p := range policies {
+		cacheKey[i] = strings.Join(p, ",")
+	}
+
 	e.permsCacheMu.RLock()
-	cached, exists := e.permsCache[user.ID]
+	cached, exists := e.permsCache[sub]
 	e.permsCacheMu.RUnlock()
 
-	if exists && slices.Equal(cached, user.Permissions) {
+	if exists && slices.Equal(cached, cacheKey) {
 		return nil
 	}
 
-	e.lo.Debug("loading user permissions in enforcer cache", "user_id", user.ID, "permissions", user.Permissions)
+	e.lo.Debug("loading user permissions in enforcer cache", "user_id", user.ID, "subject", sub, "permissions", user.Permissions)
 
-	// Build all policies and add them to the enforcer.
-	var policies [][]string
-	userID := strconv.Itoa(user.ID)
-	for _, perm := range user.Permissions {
-		parts := strings.Split(perm, ":")
-		if len(parts) != 2 {
-			return fmt.Errorf("invalid permission format: %s", perm)
-		}
-		policies = append(policies, []string{userID, parts[0], parts[1]})
-	}
-
-	_, err := e.enforcer.RemoveFilteredPolicy(0, userID)
-	if err != nil {
+	if _, err := e.enforcer.RemoveFilteredPolicy(0, sub); err != nil {
 		return fmt.Errorf("failed to remove policies: %v", err)
 	}
 
 	if len(policies) > 0 {
-		_, err = e.enforcer.AddPolicies(policies)
-		if err != nil {
+		if _, err := e.enforcer.AddPolicies(policies); err != nil {
 			return fmt.Errorf("failed to add policies: %v", err)
 		}
 	}
 
-	// Update permsCache with the latest permissions
+	// Update permsCache with the latest policies
 	e.permsCacheMu.Lock()
-	e.permsCache[user.ID] = slices.Clone(user.Permissions)
+	e.permsCache[sub] = cacheKey
 	e.permsCacheMu.Unlock()
 
 	return nil
 }
 
+// userPolicies returns the Casbin policies of the permissions of a user.
+func userPolicies(sub string, user umodels.User) ([][]string, error) {
+	var (
+		policies [][]string
+		seen     = make(map[string]struct{})
+	)
+	add := func(obj, act, inbox, team string) {
+		key := strings.Join([]string{obj, act, inbox, team}, ",")
+		if _, ok := seen[key]; ok {
+			return
+		}
+		seen[key] = struct{}{}
+		policies = append(policies, []string{sub, obj, act, inbox, team})
+	}
+
+	for _, perm := range user.Permissions {
+		parts := strings.Split(perm, ":")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid permission format: %s", perm)
+		}
+		scopes := user.RoleScopes.For(perm)
+		if len(scopes) == 0 {
+			add(parts[0], parts[1], anyScope, anyScope)
+			continue
+		}
+		for _, scope := range scopes {
+			inboxes, teams := scopeValues(scope.InboxIDs), scopeValues(scope.TeamIDs)
+			for _, inbox := range inboxes {
+				for _, team := range teams {
+					add(parts[0], parts[1], inbox, team)
+				}
+			}
+		}
+	}
+	return policies, nil
+}
+
+// scopeValues returns the policy values of the inbox or team IDs of a scope, anyScope if there are none.
+func scopeValues(ids []int) []string {
+	if len(ids) == 0 {
+		return []string{anyScope}
+	}
+	values := make([]string, len(ids))
+	for i, id := range ids {
+		values[i] = strconv.Itoa(id)
+	}
+	return values
+}
+
 // InvalidateUserCache removes user from permsCache to be called when user permissions change.
 func (e *Enforcer) InvalidateUserCache(userID int) {
 	e.permsCacheMu.Lock()
-	delete(e.permsCache, userID)
+	delete(e.permsCache, strconv.Itoa(userID))
+	e.permsCacheMu.Unlock()
+}
+
+// RemoveAPIKey removes the policies and cached permissions of a revoked API key.
+func (e *Enforcer) RemoveAPIKey(keyID int) {
+	sub := subject(umodels.User{APIKeyID: keyID})
+	if _, err := e.enforcer.RemoveFilteredPolicy(0, sub); err != nil {
+		e.lo.Error("error removing api key policies", "api_key_id", keyID, "error", err)
+	}
+	e.permsCacheMu.Lock()
+	delete(e.permsCache, sub)
 	e.permsCacheMu.Unlock()
 }
 
-// Enforce checks if a user has permission to perform an action on an object.
+// Enforce checks if a user has permission to perform an action on an object in any inbox or team.
 func (e *Enforcer) Enforce(user umodels.User, obj, act string) (bool, error) {
+	return e.enforce(user, obj, act, anyScope, anyScope)
+}
+
+// EnforceInScope checks if a user has permission to perform an action on an object of a conversation
+// in the inbox and assigned to the team, 0 for none.
+func (e *Enforcer) EnforceInScope(user umodels.User, obj, act string, inboxID, teamID int) (bool, error) {
+	return e.enforce(user, obj, act, strconv.Itoa(inboxID), strconv.Itoa(teamID))
+}
+
+func (e *Enforcer) enforce(user umodels.User, obj, act, inbox, team string) (bool, error) {
 	// Load permissions before enforcing as user perjissions might have changed.
 	err := e.LoadPermissions(user)
 	if err != nil {
@@ -119,7 +201,7 @@ func (e *Enforcer) Enforce(user umodels.User, obj, act string) (bool, error) {
 		return false, err
 	}
 	// Check if the user has the required permission
-	allowed, err := e.enforcer.Enforce(strconv.Itoa(user.ID), obj, act)
+	allowed, err := e.enforcer.Enforce(subject(user), obj, act, inbox, team)
 	if err != nil {
 		e.lo.Error("error checking permission", "user_id", user.ID, "object", obj, "action", act, "error", err)
 		return false, fmt.Errorf("error checking permission: %v", err)
@@ -133,19 +215,31 @@ func (e *Enforcer) Enforce(user umodels.User, obj, act string) (bool, error) {
 // 2. User has the "read_assigned" permission and is the assigned user.
 // 3. User has the "read_team_inbox" permission and is part of the assigned team, with the conversation NOT assigned to any user.
 // 4. User has the "read_unassigned" permission and the conversation is not assigned to any user or team.
+// Permissions are checked in the scope of the conversation's inbox and team, and the permission the request
+// was authorized with must be granted in that scope too.
 // Returns true if access is granted, false otherwise. In case of an error while checking permissions returns false and the error.
 func (e *Enforcer) EnforceConversationAccess(user umodels.User, conversation cmodels.Conversation) (bool, error) {
-	checkPermission := func(action string) (bool, error) {
-		allowed, err := e.Enforce(user, "conversations", action)
+	checkScopedPermission := func(obj, action string) (bool, error) {
+		allowed, err := e.EnforceInScope(user, obj, action, conversation.InboxID, conversation.AssignedTeamID.Int)
 		if err != nil {
 			e.lo.Error("error enforcing permission", "user_id", user.ID, "conversation_id", conversation.ID, "error", err)
 			return false, envelope.NewError(envelope.GeneralError, e.i18n.Ts("globals.messages.errorChecking", "name", "{globals.terms.permission}"), nil)
 		}
 		if !allowed {
-			e.lo.Debug("permission denied", "user_id", user.ID, "action", action, "conversation_id", conversation.ID)
+			e.lo.Debug("permission denied", "user_id", user.ID, "object", obj, "action", action, "conversation_id", conversation.ID)
 		}
 		return allowed, nil
 	}
+	checkPermission := func(action string) (bool, error) {
+		return checkScopedPermission("conversations", action)
+	}
+
+	// Check the permission of the request in the scope of the conversation.
+	if obj, act, ok := strings.Cut(user.RoutePermission, ":"); ok {
+		if allowed, err := checkScopedPermission(obj, act); err != nil || !allowed {
+			return allowed, err
+		}
+	}
 
 	// Check `read` permission
 	if allowed, err := checkPermission("read"); err != nil || !allowed {
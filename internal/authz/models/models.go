@@ -2,19 +2,21 @@ package models
 
 const (
 	// Conversation
-	PermConversationsReadAll            = "conversations:read_all"
-	PermConversationsReadUnassigned     = "conversations:read_unassigned"
-	PermConversationsReadAssigned       = "conversations:read_assigned"
-	PermConversationsReadTeamInbox      = "conversations:read_team_inbox"
-	PermConversationsRead               = "conversations:read"
-	PermConversationsUpdateUserAssignee = "conversations:update_user_assignee"
-	PermConversationsUpdateTeamAssignee = "conversations:update_team_assignee"
-	PermConversationsUpdatePriority     = "conversations:update_priority"
-	PermConversationsUpdateStatus       = "conversations:update_status"
-	PermConversationsUpdateTags         = "conversations:update_tags"
-	PermConversationWrite               = "conversations:write"
-	PermMessagesRead                    = "messages:read"
-	PermMessagesWrite                   = "messages:write"
+	PermConversationsReadAll                = "conversations:read_all"
+	PermConversationsReadUnassigned         = "conversations:read_unassigned"
+	PermConversationsReadAssigned           = "conversations:read_assigned"
+	PermConversationsReadTeamInbox          = "conversations:read_team_inbox"
+	PermConversationsRead                   = "conversations:read"
+	PermConversationsUpdateUserAssignee     = "conversations:update_user_assignee"
+	PermConversationsUpdateTeamAssignee     = "conversations:update_team_assignee"
+	PermConversationsUpdatePriority         = "conversations:update_priority"
+	PermConversationsUpdateStatus           = "conversations:update_status"
+	PermConversationsUpdateTags             = "conversations:update_tags"
+	PermConversationsMerge                  = "conversations:merge"
+	PermConversationsUpdateCustomAttributes = "conversations:update_custom_attributes"
+	PermConversationWrite                   = "conversations:write"
+	PermMessagesRead                        = "messages:read"
+	PermMessagesWrite                       = "messages:write"
 
 	// View
 	PermViewManage = "view:manage"
@@ -24,9 +26,12 @@ const (
 
 	// Tags
 	PermTagsManage = "tags:manage"
+	PermTagsRead   = "tags:read"
 
 	// Macros
 	PermMacrosManage = "macros:manage"
+	PermMacrosRead   = "macros:read"
+	PermMacrosApply  = "macros:apply"
 
 	// Users
 	PermUsersManage = "users:manage"
@@ -39,6 +44,7 @@ const (
 
 	// Inboxes
 	PermInboxesManage = "inboxes:manage"
+	PermInboxesRead   = "inboxes:read"
 
 	// Roles
 	PermRolesManage = "roles:manage"
@@ -68,13 +74,16 @@ const (
 	PermOIDCManage = "oidc:manage"
 
 	// AI
-	PermAIManage = "ai:manage"
+	PermAIManage        = "ai:manage"
+	PermAIPromptsManage = "ai_prompts:manage"
+	PermAIUse           = "ai:use"
 
 	// Contacts
-	PermContactsReadAll = "contacts:read_all"
-	PermContactsRead    = "contacts:read"
-	PermContactsWrite   = "contacts:write"
-	PermContactsBlock   = "contacts:block"
+	PermContactsReadAll                = "contacts:read_all"
+	PermContactsRead                   = "contacts:read"
+	PermContactsWrite                  = "contacts:write"
+	PermContactsBlock                  = "contacts:block"
+	PermContactsUpdateCustomAttributes = "contacts:update_custom_attributes"
 
 	// Contact Notes
 	PermContactNotesRead   = "contact_notes:read"
@@ -89,46 +98,55 @@ const (
 )
 
 var validPermissions = map[string]struct{}{
-	PermConversationsReadAll:            {},
-	PermConversationsReadUnassigned:     {},
-	PermConversationsReadAssigned:       {},
-	PermConversationsReadTeamInbox:      {},
-	PermConversationsRead:               {},
-	PermConversationsUpdateUserAssignee: {},
-	PermConversationsUpdateTeamAssignee: {},
-	PermConversationsUpdatePriority:     {},
-	PermConversationsUpdateStatus:       {},
-	PermConversationsUpdateTags:         {},
-	PermConversationWrite:               {},
-	PermMessagesRead:                    {},
-	PermMessagesWrite:                   {},
-	PermViewManage:                      {},
-	PermStatusManage:                    {},
-	PermTagsManage:                      {},
-	PermMacrosManage:                    {},
-	PermUsersManage:                     {},
-	PermTeamsManage:                     {},
-	PermAutomationsManage:               {},
-	PermInboxesManage:                   {},
-	PermRolesManage:                     {},
-	PermTemplatesManage:                 {},
-	PermReportsManage:                   {},
-	PermBusinessHoursManage:             {},
-	PermSLAManage:                       {},
-	PermGeneralSettingsManage:           {},
-	PermNotificationSettingsManage:      {},
-	PermOIDCManage:                      {},
-	PermAIManage:                        {},
-	PermCustomAttributesManage:          {},
-	PermContactsReadAll:                 {},
-	PermContactsRead:                    {},
-	PermContactsWrite:                   {},
-	PermContactsBlock:                   {},
-	PermContactNotesRead:                {},
-	PermContactNotesWrite:               {},
-	PermContactNotesDelete:              {},
-	PermActivityLogsManage:              {},
-	PermWebhooksManage:                  {},
+	PermConversationsReadAll:                {},
+	PermConversationsReadUnassigned:         {},
+	PermConversationsReadAssigned:           {},
+	PermConversationsReadTeamInbox:          {},
+	PermConversationsRead:                   {},
+	PermConversationsUpdateUserAssignee:     {},
+	PermConversationsUpdateTeamAssignee:     {},
+	PermConversationsUpdatePriority:         {},
+	PermConversationsUpdateStatus:           {},
+	PermConversationsUpdateTags:             {},
+	PermConversationsMerge:                  {},
+	PermConversationsUpdateCustomAttributes: {},
+	PermConversationWrite:                   {},
+	PermMessagesRead:                        {},
+	PermMessagesWrite:                       {},
+	PermViewManage:                          {},
+	PermStatusManage:                        {},
+	PermTagsManage:                          {},
+	PermTagsRead:                            {},
+	PermMacrosManage:                        {},
+	PermMacrosRead:                          {},
+	PermMacrosApply:                         {},
+	PermUsersManage:                         {},
+	PermTeamsManage:                         {},
+	PermAutomationsManage:                   {},
+	PermInboxesManage:                       {},
+	PermInboxesRead:                         {},
+	PermRolesManage:                         {},
+	PermTemplatesManage:                     {},
+	PermReportsManage:                       {},
+	PermBusinessHoursManage:                 {},
+	PermSLAManage:                           {},
+	PermGeneralSettingsManage:               {},
+	PermNotificationSettingsManage:          {},
+	PermOIDCManage:                          {},
+	PermAIManage:                            {},
+	PermAIPromptsManage:                     {},
+	PermAIUse:                               {},
+	PermCustomAttributesManage:              {},
+	PermContactsReadAll:                     {},
+	PermContactsRead:                        {},
+	PermContactsWrite:                       {},
+	PermContactsBlock:                       {},
+	PermContactsUpdateCustomAttributes:      {},
+	PermContactNotesRead:                    {},
+	PermContactNotesWrite:                   {},
+	PermContactNotesDelete:                  {},
+	PermActivityLogsManage:                  {},
+	PermWebhooksManage:                      {},
 }
 
 // PermissionExists returns true if the permission exists else false
@@ -0,0 +1,118 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// scopedObjects are the objects whose permissions can be restricted to inboxes and teams by the scope of a role.
+var scopedObjects = []string{"conversations", "messages", "reports"}
+
+// Scope restricts the conversation, message and report permissions of a role to conversations in its inboxes
+// and assigned to its teams, empty IDs don't restrict.
+type Scope struct {
+	Permissions []string `json:"permissions"`
+	InboxIDs    []int    `json:"inbox_ids"`
+	TeamIDs     []int    `json:"team_ids"`
+}
+
+// Scopes are the scopes of the roles of a user.
+type Scopes []Scope
+
+// Scan implements the sql.Scanner interface for Scopes.
+func (s *Scopes) Scan(src interface{}) error {
+	if src == nil {
+		*s = nil
+		return nil
+	}
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, s)
+	default:
+		return fmt.Errorf("unsupported type for Scopes: %T", src)
+	}
+}
+
+// IsScopable returns true if the permission can be restricted by the scope of a role.
+func IsScopable(permission string) bool {
+	object, _, _ := strings.Cut(permission, ":")
+	return slices.Contains(scopedObjects, object)
+}
+
+// Unrestricted returns true if the scope applies to all conversations.
+func (s Scope) Unrestricted() bool {
+	return len(s.InboxIDs) == 0 && len(s.TeamIDs) == 0
+}
+
+// Allows returns true if a conversation in the inbox and assigned to the team, 0 for none, is in the scope.
+func (s Scope) Allows(inboxID, teamID int) bool {
+	return (len(s.InboxIDs) == 0 || slices.Contains(s.InboxIDs, inboxID)) &&
+		(len(s.TeamIDs) == 0 || slices.Contains(s.TeamIDs, teamID))
+}
+
+// For returns the scopes a permission is restricted to, nil if the permission isn't restricted
+// because it can't be scoped or one of the roles granting it applies to all conversations.
+func (s Scopes) For(permission string) Scopes {
+	if !IsScopable(permission) {
+		return nil
+	}
+	var scopes Scopes
+	for _, scope := range s {
+		if !slices.Contains(scope.Permissions, permission) {
+			continue
+		}
+		if scope.Unrestricted() {
+			return nil
+		}
+		scopes = append(scopes, scope)
+	}
+	return scopes
+}
+
+// Allows returns true if the scopes are unrestricted or any of them allows a conversation in the inbox and assigned to the team.
+func (s Scopes) Allows(inboxID, teamID int) bool {
+	if len(s) == 0 {
+		return true
+	}
+	for _, scope := range s {
+		if scope.Allows(inboxID, teamID) {
+			return true
+		}
+	}
+	return false
+}
+
+// Condition returns an SQL condition matching conversations in any of the scopes, TRUE if the scopes are unrestricted.
+// inboxCol and teamCol are the inbox and assigned team columns of the conversations.
+func (s Scopes) Condition(inboxCol, teamCol string) string {
+	if len(s) == 0 {
+		return "TRUE"
+	}
+	conditions := make([]string, 0, len(s))
+	for _, scope := range s {
+		if scope.Unrestricted() {
+			return "TRUE"
+		}
+		var parts []string
+		if len(scope.InboxIDs) > 0 {
+			parts = append(parts, fmt.Sprintf("%s IN (%s)", inboxCol, joinIDs(scope.InboxIDs)))
+		}
+		if len(scope.TeamIDs) > 0 {
+			parts = append(parts, fmt.Sprintf("%s IN (%s)", teamCol, joinIDs(scope.TeamIDs)))
+		}
+		conditions = append(conditions, "("+strings.Join(parts, " AND ")+")")
+	}
+	return "(" + strings.Join(conditions, " OR ") + ")"
+}
+
+// joinIDs joins IDs into a comma separated list.
+func joinIDs(ids []int) string {
+	s := make([]string, len(ids))
+	for i, id := range ids {
+		s[i] = strconv.Itoa(id)
+	}
+	return strings.Join(s, ",")
+}
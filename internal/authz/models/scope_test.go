@@ -0,0 +1,60 @@
+package models
+
+import "testing"
+
+func TestScopesFor(t *testing.T) {
+	billing := Scope{Permissions: []string{PermConversationsReadAll, PermMessagesWrite, PermTagsManage}, InboxIDs: []int{3}}
+	support := Scope{Permissions: []string{PermConversationsReadAll}, TeamIDs: []int{5}}
+	agent := Scope{Permissions: []string{PermConversationsRead, PermMessagesWrite}}
+	scopes := Scopes{billing, support, agent}
+
+	if got := scopes.For(PermConversationsReadAll); len(got) != 2 {
+		t.Errorf("For(read_all) = %v, want the billing and support scopes", got)
+	}
+	if got := scopes.For(PermMessagesWrite); got != nil {
+		t.Errorf("For(messages:write) = %v, want nil as the agent role is unrestricted", got)
+	}
+	if got := scopes.For(PermTagsManage); got != nil {
+		t.Errorf("For(tags:manage) = %v, want nil as the permission can't be scoped", got)
+	}
+}
+
+func TestScopesAllows(t *testing.T) {
+	scopes := Scopes{
+		{InboxIDs: []int{3}},
+		{InboxIDs: []int{4}, TeamIDs: []int{5, 6}},
+	}
+	tests := []struct {
+		inboxID, teamID int
+		want            bool
+	}{
+		{3, 0, true},
+		{3, 9, true},
+		{4, 6, true},
+		{4, 0, false},
+		{4, 7, false},
+		{1, 5, false},
+	}
+	for _, tt := range tests {
+		if got := scopes.Allows(tt.inboxID, tt.teamID); got != tt.want {
+			t.Errorf("Allows(%d, %d) = %v, want %v", tt.inboxID, tt.teamID, got, tt.want)
+		}
+	}
+	if !Scopes(nil).Allows(1, 0) {
+		t.Error("nil scopes should allow all conversations")
+	}
+}
+
+func TestScopesCondition(t *testing.T) {
+	if got := Scopes(nil).Condition("c.inbox_id", "c.assigned_team_id"); got != "TRUE" {
+		t.Errorf("Condition() = %q, want TRUE", got)
+	}
+	scopes := Scopes{
+		{InboxIDs: []int{3}},
+		{InboxIDs: []int{4}, TeamIDs: []int{5, 6}},
+	}
+	want := "((c.inbox_id IN (3)) OR (c.inbox_id IN (4) AND c.assigned_team_id IN (5,6)))"
+	if got := scopes.Condition("c.inbox_id", "c.assigned_team_id"); got != want {
+		t.Errorf("Condition() = %q, want %q", got, want)
+	}
+}
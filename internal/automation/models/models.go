@@ -20,6 +20,7 @@ const (
 	ActionSetTags         = "set_tags"
 	ActionRemoveTags      = "remove_tags"
 	ActionSendCSAT        = "send_csat"
+	ActionSendWebhook     = "send_webhook"
 
 	OperatorAnd = "AND"
 	OperatorOR  = "OR"
@@ -0,0 +1,243 @@
+// Package bulk applies actions to many conversations at once in the background,
+// reporting the progress to the requesting user over the WebSocket.
+package bulk
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	amodels "github.com/abhinavxd/libredesk/internal/automation/models"
+	cmodels "github.com/abhinavxd/libredesk/internal/conversation/models"
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	"github.com/abhinavxd/libredesk/internal/macro"
+	umodels "github.com/abhinavxd/libredesk/internal/user/models"
+	wsmodels "github.com/abhinavxd/libredesk/internal/ws/models"
+	"github.com/google/uuid"
+	"github.com/knadh/go-i18n"
+	"github.com/zerodha/logf"
+)
+
+const (
+	defaultWorkers   = 2
+	defaultQueueSize = 100
+	// progressInterval is the number of processed conversations after which progress is reported.
+	progressInterval = 10
+)
+
+type conversationStore interface {
+	GetConversation(id int, uuid string) (cmodels.Conversation, error)
+	ApplyAction(action amodels.RuleAction, conversation cmodels.Conversation, user umodels.User) error
+}
+
+type enforcer interface {
+	EnforceConversationAccess(user umodels.User, conversation cmodels.Conversation) (bool, error)
+}
+
+type macroStore interface {
+	IncrementUsageCount(id int) error
+}
+
+type broadcaster interface {
+	BroadcastMessage(msg wsmodels.BroadcastMessage)
+}
+
+// Manager processes bulk action jobs.
+type Manager struct {
+	lo                *logf.Logger
+	i18n              *i18n.I18n
+	conversationStore conversationStore
+	enforcer          enforcer
+	macroStore        macroStore
+	broadcaster       broadcaster
+	queue             chan Job
+	workers           int
+	closed            bool
+	closedMu          sync.RWMutex
+	wg                sync.WaitGroup
+}
+
+// Opts contains options for initializing the Manager.
+type Opts struct {
+	Lo        *logf.Logger
+	I18n      *i18n.I18n
+	Workers   int
+	QueueSize int
+}
+
+// Job is a set of actions to apply to conversations on behalf of a user.
+type Job struct {
+	ID                string
+	User              umodels.User
+	Actions           []amodels.RuleAction
+	MacroID           int
+	ConversationUUIDs []string
+}
+
+// Progress is the progress of a job sent to the user that created it.
+type Progress struct {
+	JobID     string   `json:"job_id"`
+	Total     int      `json:"total"`
+	Processed int      `json:"processed"`
+	Succeeded int      `json:"succeeded"`
+	Failed    []string `json:"failed"`
+	Done      bool     `json:"done"`
+}
+
+// New creates and returns a new instance of the Manager.
+func New(conversationStore conversationStore, enforcer enforcer, macroStore macroStore, broadcaster broadcaster, opts Opts) *Manager {
+	if opts.Workers <= 0 {
+		opts.Workers = defaultWorkers
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = defaultQueueSize
+	}
+	return &Manager{
+		lo:                opts.Lo,
+		i18n:              opts.I18n,
+		conversationStore: conversationStore,
+		enforcer:          enforcer,
+		macroStore:        macroStore,
+		broadcaster:       broadcaster,
+		queue:             make(chan Job, opts.QueueSize),
+		workers:           opts.Workers,
+	}
+}
+
+// Enqueue assigns an ID to the job, queues it for processing and returns the ID.
+func (m *Manager) Enqueue(job Job) (string, error) {
+	m.closedMu.RLock()
+	defer m.closedMu.RUnlock()
+	if m.closed {
+		return "", envelope.NewError(envelope.GeneralError, m.i18n.T("bulk.queueFull"), nil)
+	}
+	job.ID = uuid.NewString()
+	select {
+	case m.queue <- job:
+		return job.ID, nil
+	default:
+		m.lo.Warn("bulk action queue is full, rejecting job", "user_id", job.User.ID, "queue_size", len(m.queue))
+		return "", envelope.NewError(envelope.GeneralError, m.i18n.T("bulk.queueFull"), nil)
+	}
+}
+
+// Run starts the worker pool that processes the jobs.
+func (m *Manager) Run(ctx context.Context) {
+	for i := 0; i < m.workers; i++ {
+		m.wg.Add(1)
+		go func() {
+			defer m.wg.Done()
+			m.worker(ctx)
+		}()
+	}
+}
+
+// Close signals the manager to stop processing and waits for all workers to finish.
+func (m *Manager) Close() {
+	m.closedMu.Lock()
+	defer m.closedMu.Unlock()
+	if m.closed {
+		return
+	}
+	m.closed = true
+	close(m.queue)
+	m.wg.Wait()
+}
+
+// worker processes jobs from the queue.
+func (m *Manager) worker(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job, ok := <-m.queue:
+			if !ok {
+				return
+			}
+			m.process(ctx, job)
+		}
+	}
+}
+
+// process applies the job actions to each conversation the user has access to, a conversation fails if any of the actions fail.
+func (m *Manager) process(ctx context.Context, job Job) {
+	progress := Progress{
+		JobID:  job.ID,
+		Total:  len(job.ConversationUUIDs),
+		Failed: make([]string, 0),
+	}
+	for _, uuid := range job.ConversationUUIDs {
+		if ctx.Err() != nil {
+			break
+		}
+		if m.apply(job, uuid) {
+			progress.Succeeded++
+		} else {
+			progress.Failed = append(progress.Failed, uuid)
+		}
+		progress.Processed++
+		if progress.Processed%progressInterval == 0 && progress.Processed < progress.Total {
+			m.sendProgress(job.User.ID, progress)
+		}
+	}
+
+	if job.MacroID > 0 && progress.Succeeded > 0 {
+		if err := m.macroStore.IncrementUsageCount(job.MacroID); err != nil {
+			m.lo.Error("error incrementing macro usage count", "macro_id", job.MacroID, "error", err)
+		}
+	}
+
+	progress.Done = true
+	m.sendProgress(job.User.ID, progress)
+	m.lo.Info("bulk action job done", "job_id", job.ID, "user_id", job.User.ID, "total", progress.Total, "succeeded", progress.Succeeded, "failed", len(progress.Failed))
+}
+
+// apply applies the job actions to a conversation and returns true if all of them were applied,
+// the variables in the content of reply and private note actions are rendered for the conversation.
+func (m *Manager) apply(job Job, uuid string) bool {
+	conversation, err := m.conversationStore.GetConversation(0, uuid)
+	if err != nil {
+		return false
+	}
+	if allowed, err := m.enforcer.EnforceConversationAccess(job.User, conversation); err != nil || !allowed {
+		m.lo.Warn("skipping conversation in bulk action, access denied", "uuid", uuid, "user_id", job.User.ID, "job_id", job.ID)
+		return false
+	}
+
+	ok := true
+	for _, act := range job.Actions {
+		act, err := macro.RenderAction(act, conversation, job.User)
+		if err != nil {
+			m.lo.Error("error rendering bulk action content", "action", act.Type, "uuid", uuid, "error", err)
+			ok = false
+			continue
+		}
+		// The action's permission must be granted in the inbox and team of the conversation.
+		if !job.User.RoleScopes.For(amodels.ActionPermissions[act.Type]).Allows(conversation.InboxID, conversation.AssignedTeamID.Int) {
+			m.lo.Warn("bulk action not permitted in conversation scope", "action", act.Type, "uuid", uuid, "job_id", job.ID)
+			ok = false
+			continue
+		}
+		if err := m.conversationStore.ApplyAction(act, conversation, job.User); err != nil {
+			m.lo.Error("error applying bulk action", "action", act.Type, "uuid", uuid, "job_id", job.ID, "error", err)
+			ok = false
+		}
+	}
+	return ok
+}
+
+// sendProgress sends the job progress to the user over the WebSocket.
+func (m *Manager) sendProgress(userID int, progress Progress) {
+	b, err := json.Marshal(wsmodels.Message{
+		Type: wsmodels.MessageTypeBulkActionProgress,
+		Data: progress,
+	})
+	if err != nil {
+		m.lo.Error("error marshalling bulk action progress", "error", err)
+		return
+	}
+	m.broadcaster.BroadcastMessage(wsmodels.BroadcastMessage{
+		Data:  b,
+		Users: []int{userID},
+	})
+}
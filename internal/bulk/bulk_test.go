@@ -0,0 +1,97 @@
+package bulk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	amodels "github.com/abhinavxd/libredesk/internal/automation/models"
+	cmodels "github.com/abhinavxd/libredesk/internal/conversation/models"
+	umodels "github.com/abhinavxd/libredesk/internal/user/models"
+	wsmodels "github.com/abhinavxd/libredesk/internal/ws/models"
+	"github.com/zerodha/logf"
+)
+
+type fakeConversationStore struct {
+	applied []string
+}
+
+func (f *fakeConversationStore) GetConversation(id int, uuid string) (cmodels.Conversation, error) {
+	if uuid == "missing" {
+		return cmodels.Conversation{}, errors.New("not found")
+	}
+	return cmodels.Conversation{UUID: uuid}, nil
+}
+
+func (f *fakeConversationStore) ApplyAction(action amodels.RuleAction, conversation cmodels.Conversation, user umodels.User) error {
+	f.applied = append(f.applied, conversation.UUID+":"+action.Type)
+	return nil
+}
+
+type fakeEnforcer struct{}
+
+func (fakeEnforcer) EnforceConversationAccess(user umodels.User, conversation cmodels.Conversation) (bool, error) {
+	return conversation.UUID != "denied", nil
+}
+
+type fakeMacroStore struct {
+	incremented int
+}
+
+func (f *fakeMacroStore) IncrementUsageCount(id int) error {
+	f.incremented++
+	return nil
+}
+
+type fakeBroadcaster struct {
+	messages []wsmodels.BroadcastMessage
+}
+
+func (f *fakeBroadcaster) BroadcastMessage(msg wsmodels.BroadcastMessage) {
+	f.messages = append(f.messages, msg)
+}
+
+func TestProcess(t *testing.T) {
+	var (
+		lo     = logf.New(logf.Opts{})
+		convs  = &fakeConversationStore{}
+		macros = &fakeMacroStore{}
+		ws     = &fakeBroadcaster{}
+		m      = New(convs, fakeEnforcer{}, macros, ws, Opts{Lo: &lo})
+	)
+
+	m.process(context.Background(), Job{
+		ID:                "job",
+		User:              umodels.User{ID: 7},
+		Actions:           []amodels.RuleAction{{Type: amodels.ActionSetStatus, Value: []string{"2"}}},
+		MacroID:           1,
+		ConversationUUIDs: []string{"a", "denied", "missing", "b"},
+	})
+
+	if expected := []string{"a:set_status", "b:set_status"}; !reflect.DeepEqual(convs.applied, expected) {
+		t.Errorf("applied = %v, want %v", convs.applied, expected)
+	}
+	if macros.incremented != 1 {
+		t.Errorf("macro usage incremented %d times, want 1", macros.incremented)
+	}
+	if len(ws.messages) != 1 {
+		t.Fatalf("got %d progress messages, want 1", len(ws.messages))
+	}
+	if !reflect.DeepEqual(ws.messages[0].Users, []int{7}) {
+		t.Errorf("progress sent to %v, want [7]", ws.messages[0].Users)
+	}
+
+	var msg struct {
+		Type string   `json:"type"`
+		Data Progress `json:"data"`
+	}
+	if err := json.Unmarshal(ws.messages[0].Data, &msg); err != nil {
+		t.Fatalf("unmarshalling progress: %v", err)
+	}
+	expected := Progress{JobID: "job", Total: 4, Processed: 4, Succeeded: 2, Failed: []string{"denied", "missing"}, Done: true}
+	if msg.Type != wsmodels.MessageTypeBulkActionProgress || !reflect.DeepEqual(msg.Data, expected) {
+		t.Errorf("progress = %s %+v, want %+v", msg.Type, msg.Data, expected)
+	}
+}
@@ -80,9 +80,9 @@ func (m *Manager) GetAll() ([]models.BusinessHours, error) {
 }
 
 // Create creates new business hours.
-func (m *Manager) Create(name string, description null.String, isAlwaysOpen bool, workingHrs, holidays types.JSONText) (models.BusinessHours, error) {
+func (m *Manager) Create(name string, description null.String, isAlwaysOpen bool, workingHrs, holidays, specialHours types.JSONText) (models.BusinessHours, error) {
 	var result models.BusinessHours
-	if err := m.q.InsertBusinessHours.Get(&result, name, description, isAlwaysOpen, workingHrs, holidays); err != nil {
+	if err := m.q.InsertBusinessHours.Get(&result, name, description, isAlwaysOpen, workingHrs, holidays, specialHours); err != nil {
 		m.lo.Error("error inserting business hours", "error", err)
 		return models.BusinessHours{}, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorCreating", "name", "{globals.terms.businessHour}"), nil)
 	}
@@ -102,9 +102,9 @@ func (m *Manager) Delete(id int) error {
 }
 
 // Update updates business hours by ID.
-func (m *Manager) Update(id int, name string, description null.String, isAlwaysOpen bool, workingHrs, holidays types.JSONText) (models.BusinessHours, error) {
+func (m *Manager) Update(id int, name string, description null.String, isAlwaysOpen bool, workingHrs, holidays, specialHours types.JSONText) (models.BusinessHours, error) {
 	var result models.BusinessHours
-	if err := m.q.UpdateBusinessHours.Get(&result, id, name, description, isAlwaysOpen, workingHrs, holidays); err != nil {
+	if err := m.q.UpdateBusinessHours.Get(&result, id, name, description, isAlwaysOpen, workingHrs, holidays, specialHours); err != nil {
 		m.lo.Error("error updating business hours", "error", err)
 		return models.BusinessHours{}, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.businessHour}"), nil)
 	}
@@ -0,0 +1,157 @@
+package businesshours
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/abhinavxd/libredesk/internal/business_hours/models"
+)
+
+const (
+	// maxICSEventDays is the maximum number of days a single all-day event is expanded to.
+	maxICSEventDays = 31
+	icsDateLayout   = "20060102"
+)
+
+var ErrInvalidICS = errors.New("invalid iCalendar file")
+
+// icsEvent holds the properties of a VEVENT that are relevant for holidays.
+type icsEvent struct {
+	summary   string
+	start     string
+	end       string
+	endIsDate bool
+	recurring bool
+}
+
+// ParseICS parses holidays from an iCalendar (RFC 5545) stream. Every VEVENT becomes a holiday on its start date,
+// all-day events spanning several days are expanded to one holiday per day and events with a yearly RRULE are marked as recurring.
+func ParseICS(r io.Reader) ([]models.Holiday, error) {
+	var (
+		holidays = make([]models.Holiday, 0)
+		lines    = make([]string, 0)
+		scanner  = bufio.NewScanner(r)
+		foundCal bool
+		event    *icsEvent
+	)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	// Unfold lines, continuation lines start with a space or a tab.
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, line := range lines {
+		name, params, value, ok := parseICSLine(line)
+		if !ok {
+			continue
+		}
+		switch {
+		case name == "BEGIN" && value == "VCALENDAR":
+			foundCal = true
+		case name == "BEGIN" && value == "VEVENT":
+			event = &icsEvent{}
+		case name == "END" && value == "VEVENT":
+			if event == nil {
+				continue
+			}
+			evHolidays, err := event.holidays()
+			if err != nil {
+				return nil, err
+			}
+			holidays = append(holidays, evHolidays...)
+			event = nil
+		case event == nil:
+			continue
+		case name == "SUMMARY":
+			event.summary = unescapeICSText(value)
+		case name == "DTSTART":
+			event.start = value
+		case name == "DTEND":
+			event.end = value
+			event.endIsDate = strings.Contains(strings.ToUpper(params), "VALUE=DATE") && !strings.Contains(strings.ToUpper(params), "VALUE=DATE-TIME")
+		case name == "RRULE":
+			event.recurring = strings.Contains(strings.ToUpper(value), "FREQ=YEARLY")
+		}
+	}
+
+	if !foundCal {
+		return nil, ErrInvalidICS
+	}
+	return holidays, nil
+}
+
+// holidays returns the holidays for the event.
+func (e *icsEvent) holidays() ([]models.Holiday, error) {
+	start, err := parseICSDate(e.start)
+	if err != nil {
+		return nil, err
+	}
+
+	// DTEND of all-day events is exclusive, expand the event to every day it covers.
+	days := 1
+	if e.end != "" && e.endIsDate {
+		end, err := parseICSDate(e.end)
+		if err != nil {
+			return nil, err
+		}
+		if d := int(end.Sub(start).Hours() / 24); d > 1 {
+			days = min(d, maxICSEventDays)
+		}
+	}
+
+	holidays := make([]models.Holiday, 0, days)
+	for i := 0; i < days; i++ {
+		holidays = append(holidays, models.Holiday{
+			Name:      e.summary,
+			Date:      start.AddDate(0, 0, i).Format(time.DateOnly),
+			Recurring: e.recurring,
+		})
+	}
+	return holidays, nil
+}
+
+// parseICSLine splits a content line into its name, parameters and value.
+func parseICSLine(line string) (string, string, string, bool) {
+	colon := strings.Index(line, ":")
+	if colon <= 0 {
+		return "", "", "", false
+	}
+	var (
+		key    = line[:colon]
+		value  = strings.TrimSpace(line[colon+1:])
+		params string
+	)
+	if semi := strings.Index(key, ";"); semi >= 0 {
+		key, params = key[:semi], key[semi+1:]
+	}
+	return strings.ToUpper(strings.TrimSpace(key)), params, value, true
+}
+
+// parseICSDate parses the date part of a DATE or DATE-TIME value, the time of day is irrelevant for holidays.
+func parseICSDate(value string) (time.Time, error) {
+	if len(value) < len(icsDateLayout) {
+		return time.Time{}, ErrInvalidICS
+	}
+	t, err := time.Parse(icsDateLayout, value[:len(icsDateLayout)])
+	if err != nil {
+		return time.Time{}, ErrInvalidICS
+	}
+	return t, nil
+}
+
+// unescapeICSText unescapes a TEXT value.
+func unescapeICSText(s string) string {
+	return strings.NewReplacer(`\n`, " ", `\N`, " ", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(s)
+}
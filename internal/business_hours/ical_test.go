@@ -0,0 +1,72 @@
+package businesshours
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/abhinavxd/libredesk/internal/business_hours/models"
+)
+
+func TestParseICS(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []models.Holiday
+		wantErr  bool
+	}{
+		{
+			name:  "single all-day event",
+			input: "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nBEGIN:VEVENT\r\nDTSTART;VALUE=DATE:20241225\r\nDTEND;VALUE=DATE:20241226\r\nSUMMARY:Christmas Day\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n",
+			expected: []models.Holiday{
+				{Name: "Christmas Day", Date: "2024-12-25"},
+			},
+		},
+		{
+			name:  "multi-day event is expanded",
+			input: "BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART;VALUE=DATE:20241230\nDTEND;VALUE=DATE:20250102\nSUMMARY:Year end\nEND:VEVENT\nEND:VCALENDAR\n",
+			expected: []models.Holiday{
+				{Name: "Year end", Date: "2024-12-30"},
+				{Name: "Year end", Date: "2024-12-31"},
+				{Name: "Year end", Date: "2025-01-01"},
+			},
+		},
+		{
+			name:  "yearly recurring event with folded and escaped summary",
+			input: "BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART:20240101T000000Z\nRRULE:FREQ=YEARLY\nSUMMARY:New Year\\, \n observed\nEND:VEVENT\nEND:VCALENDAR\n",
+			expected: []models.Holiday{
+				{Name: "New Year, observed", Date: "2024-01-01", Recurring: true},
+			},
+		},
+		{
+			name:     "calendar without events",
+			input:    "BEGIN:VCALENDAR\nEND:VCALENDAR\n",
+			expected: []models.Holiday{},
+		},
+		{
+			name:    "not a calendar",
+			input:   "hello world",
+			wantErr: true,
+		},
+		{
+			name:    "invalid start date",
+			input:   "BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART:2024\nEND:VEVENT\nEND:VCALENDAR\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseICS(strings.NewReader(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseICS() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("ParseICS() = %+v, want %+v", got, tt.expected)
+			}
+		})
+	}
+}
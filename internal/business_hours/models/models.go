@@ -17,17 +17,28 @@ type BusinessHours struct {
 	Description  null.String    `db:"description" json:"description"`
 	IsAlwaysOpen bool           `db:"is_always_open" json:"is_always_open"`
 	Holidays     types.JSONText `db:"holidays" json:"holidays"`
+	SpecialHours types.JSONText `db:"special_hours" json:"special_hours"`
 	Hours        types.JSONText `db:"hours" json:"hours"`
 }
 
 // WorkingHours represents the working hours for a specific day.
 type WorkingHours struct {
-	Open         string `json:"open"`
-	Close        string `json:"close"`
+	Open  string `json:"open"`
+	Close string `json:"close"`
 }
 
-// Holiday represents a holiday.
+// Holiday represents a holiday, recurring holidays repeat every year on the same month and day.
 type Holiday struct {
-	Name string `json:"name"`
-	Date string `json:"date"`
+	Name      string `json:"name"`
+	Date      string `json:"date"`
+	Recurring bool   `json:"recurring"`
+}
+
+// SpecialHours represents working hours that replace the regular working hours for a date e.g. a half-day.
+type SpecialHours struct {
+	Name      string `json:"name"`
+	Date      string `json:"date"`
+	Open      string `json:"open"`
+	Close     string `json:"close"`
+	Recurring bool   `json:"recurring"`
 }
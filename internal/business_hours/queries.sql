@@ -6,7 +6,8 @@ SELECT id,
     description,
     is_always_open,
     hours,
-    holidays
+    holidays,
+    special_hours
 FROM business_hours
 WHERE id = $1;
 
@@ -25,9 +26,10 @@ INSERT INTO business_hours (
         description,
         is_always_open,
         hours,
-        holidays
+        holidays,
+        special_hours
     )
-VALUES ($1, $2, $3, $4, $5)
+VALUES ($1, $2, $3, $4, $5, $6)
 RETURNING *;
 
 -- name: delete-business-hours
@@ -41,6 +43,7 @@ SET "name" = $2,
     is_always_open = $4,
     hours = $5,
     holidays = $6,
+    special_hours = $7,
     updated_at = NOW()
 WHERE id = $1
 RETURNING *;
\ No newline at end of file
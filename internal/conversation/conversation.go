@@ -15,6 +15,7 @@ import (
 	"sync"
 	"time"
 
+	authzModels "github.com/abhinavxd/libredesk/internal/authz/models"
 	"github.com/abhinavxd/libredesk/internal/automation"
 	amodels "github.com/abhinavxd/libredesk/internal/automation/models"
 	"github.com/abhinavxd/libredesk/internal/conversation/models"
@@ -54,6 +55,14 @@ const (
 	conversationsListMaxPageSize = 100
 )
 
+// listPermissions maps the conversation list types to the permissions granting access to them.
+var listPermissions = map[string]string{
+	models.AllConversations:            authzModels.PermConversationsReadAll,
+	models.AssignedConversations:       authzModels.PermConversationsReadAssigned,
+	models.UnassignedConversations:     authzModels.PermConversationsReadUnassigned,
+	models.TeamUnassignedConversations: authzModels.PermConversationsReadTeamInbox,
+}
+
 // Manager handles the operations related to conversations
 type Manager struct {
 	q                          queries
@@ -67,6 +76,7 @@ type Manager struct {
 	settingsStore              settingsStore
 	csatStore                  csatStore
 	webhookStore               webhookStore
+	aiStore                    aiStore
 	notifier                   *notifier.Service
 	lo                         *logf.Logger
 	db                         *sqlx.DB
@@ -77,6 +87,7 @@ type Manager struct {
 	incomingMessageQueue       chan models.IncomingMessage
 	outgoingMessageQueue       chan models.Message
 	outgoingProcessingMessages sync.Map
+	undoSendWindow             time.Duration
 	closed                     bool
 	closedMu                   sync.RWMutex
 	wg                         sync.WaitGroup
@@ -86,6 +97,7 @@ type slaStore interface {
 	ApplySLA(startTime time.Time, conversationID, assignedTeamID, slaID int) (slaModels.SLAPolicy, error)
 	CreateNextResponseSLAEvent(conversationID, appliedSLAID, slaPolicyID, assignedTeamID int) (time.Time, error)
 	SetLatestSLAEventMetAt(appliedSLAID int, metric string) (time.Time, error)
+	SyncPause(appliedSLAID int) error
 }
 
 type statusStore interface {
@@ -132,6 +144,12 @@ type csatStore interface {
 
 type webhookStore interface {
 	TriggerEvent(event wmodels.WebhookEvent, data any)
+	TriggerWebhook(id int, event wmodels.WebhookEvent, data any)
+	Get(id int) (wmodels.Webhook, error)
+}
+
+type aiStore interface {
+	EnqueueResolvedConversation(conversationUUID string)
 }
 
 // Opts holds the options for creating a new Manager.
@@ -140,6 +158,8 @@ type Opts struct {
 	Lo                       *logf.Logger
 	OutgoingMessageQueueSize int
 	IncomingMessageQueueSize int
+	// UndoSendWindow is how long a pending outgoing message waits before it is sent and can be cancelled.
+	UndoSendWindow time.Duration
 }
 
 // New initializes a new conversation Manager.
@@ -159,6 +179,7 @@ func New(
 	automation *automation.Engine,
 	template *template.Manager,
 	webhook webhookStore,
+	ai aiStore,
 	opts Opts) (*Manager, error) {
 
 	var q queries
@@ -178,6 +199,7 @@ func New(
 		settingsStore:              settingsStore,
 		csatStore:                  csatStore,
 		webhookStore:               webhook,
+		aiStore:                    ai,
 		slaStore:                   slaStore,
 		statusStore:                statusStore,
 		priorityStore:              priorityStore,
@@ -187,6 +209,7 @@ func New(
 		lo:                         opts.Lo,
 		incomingMessageQueue:       make(chan models.IncomingMessage, opts.IncomingMessageQueueSize),
 		outgoingMessageQueue:       make(chan models.Message, opts.OutgoingMessageQueueSize),
+		undoSendWindow:             opts.UndoSendWindow,
 		outgoingProcessingMessages: sync.Map{},
 	}
 
@@ -200,7 +223,7 @@ type queries struct {
 	GetConversationsCreatedAfter       *sqlx.Stmt `query:"get-conversations-created-after"`
 	GetUnassignedConversations         *sqlx.Stmt `query:"get-unassigned-conversations"`
 	GetConversations                   string     `query:"get-conversations"`
-	GetContactConversations            *sqlx.Stmt `query:"get-contact-conversations"`
+	GetContactConversations            string     `query:"get-contact-conversations"`
 	GetConversationParticipants        *sqlx.Stmt `query:"get-conversation-participants"`
 	GetUserActiveConversationsCount    *sqlx.Stmt `query:"get-user-active-conversations-count"`
 	UpdateConversationFirstReplyAt     *sqlx.Stmt `query:"update-conversation-first-reply-at"`
@@ -225,6 +248,11 @@ type queries struct {
 	DeleteConversation                 *sqlx.Stmt `query:"delete-conversation"`
 	RemoveConversationAssignee         *sqlx.Stmt `query:"remove-conversation-assignee"`
 	GetLatestMessage                   *sqlx.Stmt `query:"get-latest-message"`
+	MoveConversationMessages           *sqlx.Stmt `query:"move-conversation-messages"`
+	CopyConversationParticipants       *sqlx.Stmt `query:"copy-conversation-participants"`
+	CopyConversationTags               *sqlx.Stmt `query:"copy-conversation-tags"`
+	InsertSplitConversation            *sqlx.Stmt `query:"insert-split-conversation"`
+	RefreshConversationLastMessage     *sqlx.Stmt `query:"refresh-conversation-last-message"`
 
 	// Message queries.
 	GetMessage                         *sqlx.Stmt `query:"get-message"`
@@ -234,6 +262,10 @@ type queries struct {
 	GetConversationUUIDFromMessageUUID *sqlx.Stmt `query:"get-conversation-uuid-from-message-uuid"`
 	InsertMessage                      *sqlx.Stmt `query:"insert-message"`
 	UpdateMessageStatus                *sqlx.Stmt `query:"update-message-status"`
+	MoveMessage                        *sqlx.Stmt `query:"move-message"`
+	PromoteDueScheduledMessages        *sqlx.Stmt `query:"promote-due-scheduled-messages"`
+	UpdateScheduledMessage             *sqlx.Stmt `query:"update-scheduled-message"`
+	DeleteUnsentMessage                *sqlx.Stmt `query:"delete-unsent-message"`
 	MessageExistsBySourceID            *sqlx.Stmt `query:"message-exists-by-source-id"`
 	GetConversationByMessageID         *sqlx.Stmt `query:"get-conversation-by-message-id"`
 }
@@ -280,10 +312,13 @@ func (c *Manager) GetConversation(id int, uuid string) (models.Conversation, err
 	return conversation, nil
 }
 
-// GetContactConversations retrieves conversations for a contact.
-func (c *Manager) GetContactConversations(contactID int) ([]models.Conversation, error) {
-	var conversations = make([]models.Conversation, 0)
-	if err := c.q.GetContactConversations.Select(&conversations, contactID); err != nil {
+// GetContactConversations retrieves conversations for a contact, restricted to conversations in the scopes.
+func (c *Manager) GetContactConversations(contactID int, scopes authzModels.Scopes) ([]models.Conversation, error) {
+	var (
+		conversations = make([]models.Conversation, 0)
+		scope         = scopes.Condition("c.inbox_id", "c.assigned_team_id")
+	)
+	if err := c.db.Select(&conversations, fmt.Sprintf(c.q.GetContactConversations, scope), contactID); err != nil {
 		c.lo.Error("error fetching conversations", "error", err)
 		return conversations, envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.conversation}"), nil)
 	}
@@ -348,35 +383,36 @@ func (c *Manager) GetConversationUUID(id int) (string, error) {
 }
 
 // GetAllConversationsList retrieves all conversations with optional filtering, ordering, and pagination.
-func (c *Manager) GetAllConversationsList(order, orderBy, filters string, page, pageSize int) ([]models.Conversation, error) {
-	return c.GetConversations(0, []int{}, []string{models.AllConversations}, order, orderBy, filters, page, pageSize)
+func (c *Manager) GetAllConversationsList(user umodels.User, order, orderBy, filters string, page, pageSize int) ([]models.Conversation, error) {
+	return c.GetConversations(user, []int{}, []string{models.AllConversations}, order, orderBy, filters, page, pageSize)
 }
 
 // GetAssignedConversationsList retrieves conversations assigned to a specific user with optional filtering, ordering, and pagination.
-func (c *Manager) GetAssignedConversationsList(userID int, order, orderBy, filters string, page, pageSize int) ([]models.Conversation, error) {
-	return c.GetConversations(userID, []int{}, []string{models.AssignedConversations}, order, orderBy, filters, page, pageSize)
+func (c *Manager) GetAssignedConversationsList(user umodels.User, order, orderBy, filters string, page, pageSize int) ([]models.Conversation, error) {
+	return c.GetConversations(user, []int{}, []string{models.AssignedConversations}, order, orderBy, filters, page, pageSize)
 }
 
 // GetUnassignedConversationsList retrieves conversations assigned to a team the user is part of with optional filtering, ordering, and pagination.
-func (c *Manager) GetUnassignedConversationsList(order, orderBy, filters string, page, pageSize int) ([]models.Conversation, error) {
-	return c.GetConversations(0, []int{}, []string{models.UnassignedConversations}, order, orderBy, filters, page, pageSize)
+func (c *Manager) GetUnassignedConversationsList(user umodels.User, order, orderBy, filters string, page, pageSize int) ([]models.Conversation, error) {
+	return c.GetConversations(user, []int{}, []string{models.UnassignedConversations}, order, orderBy, filters, page, pageSize)
 }
 
 // GetTeamUnassignedConversationsList retrieves conversations assigned to a team with optional filtering, ordering, and pagination.
-func (c *Manager) GetTeamUnassignedConversationsList(teamID int, order, orderBy, filters string, page, pageSize int) ([]models.Conversation, error) {
-	return c.GetConversations(0, []int{teamID}, []string{models.TeamUnassignedConversations}, order, orderBy, filters, page, pageSize)
+func (c *Manager) GetTeamUnassignedConversationsList(user umodels.User, teamID int, order, orderBy, filters string, page, pageSize int) ([]models.Conversation, error) {
+	return c.GetConversations(user, []int{teamID}, []string{models.TeamUnassignedConversations}, order, orderBy, filters, page, pageSize)
 }
 
-func (c *Manager) GetViewConversationsList(userID int, teamIDs []int, listType []string, order, orderBy, filters string, page, pageSize int) ([]models.Conversation, error) {
-	return c.GetConversations(userID, teamIDs, listType, order, orderBy, filters, page, pageSize)
+func (c *Manager) GetViewConversationsList(user umodels.User, listType []string, order, orderBy, filters string, page, pageSize int) ([]models.Conversation, error) {
+	return c.GetConversations(user, user.Teams.IDs(), listType, order, orderBy, filters, page, pageSize)
 }
 
-// GetConversations retrieves conversations list based on user ID, type, and optional filtering, ordering, and pagination.
-func (c *Manager) GetConversations(userID int, teamIDs []int, listTypes []string, order, orderBy, filters string, page, pageSize int) ([]models.Conversation, error) {
+// GetConversations retrieves conversations list based on user, type, and optional filtering, ordering, and pagination.
+// Conversations are restricted to the inboxes and teams the user's permissions for the list types are scoped to.
+func (c *Manager) GetConversations(user umodels.User, teamIDs []int, listTypes []string, order, orderBy, filters string, page, pageSize int) ([]models.Conversation, error) {
 	var conversations = make([]models.Conversation, 0)
 
 	// Make the query.
-	query, qArgs, err := c.makeConversationsListQuery(userID, teamIDs, listTypes, c.q.GetConversations, order, orderBy, page, pageSize, filters)
+	query, qArgs, err := c.makeConversationsListQuery(user, teamIDs, listTypes, c.q.GetConversations, order, orderBy, page, pageSize, filters)
 	if err != nil {
 		c.lo.Error("error making conversations query", "error", err)
 		return conversations, envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.conversation}"), nil)
@@ -685,6 +721,13 @@ func (c *Manager) UpdateConversationStatus(uuid string, statusID int, status, sn
 	// Broadcast updates using websocket.
 	c.BroadcastConversationUpdate(uuid, "status", status)
 
+	// Pause or resume the SLA clock as per the new status.
+	if conversationBeforeChange.AppliedSLAID.Valid {
+		if err := c.slaStore.SyncPause(conversationBeforeChange.AppliedSLAID.Int); err != nil {
+			c.lo.Error("error syncing SLA pause", "uuid", uuid, "error", err)
+		}
+	}
+
 	// Evaluate automation rules.
 	conversation, err := c.GetConversation(0, uuid)
 	if err != nil {
@@ -701,6 +744,9 @@ func (c *Manager) UpdateConversationStatus(uuid string, statusID int, status, sn
 			resolvedAt = time.Now()
 		}
 		c.BroadcastConversationUpdate(uuid, "resolved_at", resolvedAt.Format(time.RFC3339))
+
+		// Summarize and tag the conversation in the background as per the inbox AI settings.
+		c.aiStore.EnqueueResolvedConversation(uuid)
 	}
 	return nil
 }
@@ -953,6 +999,28 @@ func (m *Manager) ApplyAction(action amodels.RuleAction, conv models.Conversatio
 	}
 }
 
+// ApplyEscalationAction applies an SLA breach escalation action to a conversation.
+// The `send_webhook` action is only available to escalations and is recorded in the conversation activity like the other actions,
+// other actions are applied with ApplyAction.
+func (m *Manager) ApplyEscalationAction(action amodels.RuleAction, conv models.Conversation, user umodels.User) error {
+	if action.Type != amodels.ActionSendWebhook {
+		return m.ApplyAction(action, conv, user)
+	}
+	if len(action.Value) == 0 {
+		return fmt.Errorf("empty value for action %s", action.Type)
+	}
+	webhookID, err := strconv.Atoi(action.Value[0])
+	if err != nil {
+		return fmt.Errorf("invalid webhook ID %q: %w", action.Value[0], err)
+	}
+	webhook, err := m.webhookStore.Get(webhookID)
+	if err != nil {
+		return err
+	}
+	m.webhookStore.TriggerWebhook(webhook.ID, wmodels.EventConversationEscalated, conv)
+	return m.InsertConversationActivity(models.ActivityWebhookSent, conv.UUID, webhook.Name, user)
+}
+
 // RemoveConversationAssignee removes the assignee from the conversation.
 func (m *Manager) RemoveConversationAssignee(uuid, typ string, actor umodels.User) error {
 	if _, err := m.q.RemoveConversationAssignee.Exec(uuid, typ); err != nil {
@@ -1042,7 +1110,7 @@ func (c *Manager) getConversationTags(uuid string) ([]string, error) {
 }
 
 // makeConversationsListQuery prepares a SQL query string for conversations list
-func (c *Manager) makeConversationsListQuery(userID int, teamIDs []int, listTypes []string, baseQuery, order, orderBy string, page, pageSize int, filtersJSON string) (string, []interface{}, error) {
+func (c *Manager) makeConversationsListQuery(user umodels.User, teamIDs []int, listTypes []string, baseQuery, order, orderBy string, page, pageSize int, filtersJSON string) (string, []interface{}, error) {
 	var qArgs []interface{}
 
 	// Set defaults
@@ -1068,37 +1136,37 @@ func (c *Manager) makeConversationsListQuery(userID int, teamIDs []int, listType
 		return "", nil, fmt.Errorf("no conversation list types specified")
 	}
 
-	// Prepare the conditions based on the list types.
+	// Prepare the conditions based on the list types, each restricted to the scopes of the permission granting the list.
 	conditions := []string{}
 	for _, lt := range listTypes {
+		var condition string
 		switch lt {
 		case models.AssignedConversations:
-			conditions = append(conditions, fmt.Sprintf("conversations.assigned_user_id = $%d", len(qArgs)+1))
-			qArgs = append(qArgs, userID)
+			condition = fmt.Sprintf("conversations.assigned_user_id = $%d", len(qArgs)+1)
+			qArgs = append(qArgs, user.ID)
 		case models.UnassignedConversations:
-			conditions = append(conditions, "conversations.assigned_user_id IS NULL AND conversations.assigned_team_id IS NULL")
+			condition = "conversations.assigned_user_id IS NULL AND conversations.assigned_team_id IS NULL"
 		case models.TeamUnassignedConversations:
 			placeholders := make([]string, len(teamIDs))
 			for i := range teamIDs {
 				placeholders[i] = fmt.Sprintf("$%d", len(qArgs)+i+1)
 			}
-			conditions = append(conditions, fmt.Sprintf("(conversations.assigned_team_id IN (%s) AND conversations.assigned_user_id IS NULL)", strings.Join(placeholders, ",")))
+			condition = fmt.Sprintf("(conversations.assigned_team_id IN (%s) AND conversations.assigned_user_id IS NULL)", strings.Join(placeholders, ","))
 			for _, id := range teamIDs {
 				qArgs = append(qArgs, id)
 			}
 		case models.AllConversations:
 			// No conditions needed for all conversations.
+			condition = "TRUE"
 		default:
 			return "", nil, fmt.Errorf("unknown conversation type: %s", lt)
 		}
+		scope := user.RoleScopes.For(listPermissions[lt]).Condition("conversations.inbox_id", "conversations.assigned_team_id")
+		conditions = append(conditions, fmt.Sprintf("(%s AND %s)", condition, scope))
 	}
 
-	if len(conditions) > 0 {
-		baseQuery = fmt.Sprintf(baseQuery, "AND ("+strings.Join(conditions, " OR ")+")")
-	} else {
-		// Replace the `%s` in the base query with an empty string.
-		baseQuery = fmt.Sprintf(baseQuery, "")
-	}
+	readScope := user.RoleScopes.For(authzModels.PermConversationsRead).Condition("conversations.inbox_id", "conversations.assigned_team_id")
+	baseQuery = fmt.Sprintf(baseQuery, "AND ("+strings.Join(conditions, " OR ")+") AND "+readScope)
 
 	return dbutil.BuildPaginatedQuery(baseQuery, qArgs, dbutil.PaginationOptions{
 		Order:    order,
@@ -0,0 +1,130 @@
+package conversation
+
+import (
+	"github.com/abhinavxd/libredesk/internal/conversation/models"
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	umodels "github.com/abhinavxd/libredesk/internal/user/models"
+)
+
+// MergeConversations merges the source conversation into the target conversation and closes the source.
+// Messages move along with their media and email source IDs so future replies to the source thread land in the target,
+// participants and tags are copied over.
+func (c *Manager) MergeConversations(sourceUUID, targetUUID string, actor umodels.User) error {
+	if sourceUUID == targetUUID {
+		return envelope.NewError(envelope.InputError, c.i18n.T("conversation.cannotMergeIntoItself"), nil)
+	}
+	source, err := c.GetConversation(0, sourceUUID)
+	if err != nil {
+		return err
+	}
+	target, err := c.GetConversation(0, targetUUID)
+	if err != nil {
+		return err
+	}
+	if source.InboxID != target.InboxID || source.ContactID != target.ContactID {
+		return envelope.NewError(envelope.InputError, c.i18n.T("conversation.mergeMismatch"), nil)
+	}
+
+	tx, err := c.db.Beginx()
+	if err != nil {
+		c.lo.Error("error starting db txn", "error", err)
+		return envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.conversation}"), nil)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Stmtx(c.q.MoveConversationMessages).Exec(source.ID, target.ID); err != nil {
+		c.lo.Error("error moving conversation messages", "source", sourceUUID, "target", targetUUID, "error", err)
+		return envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.conversation}"), nil)
+	}
+	if _, err := tx.Stmtx(c.q.CopyConversationParticipants).Exec(source.ID, target.ID); err != nil {
+		c.lo.Error("error copying conversation participants", "source", sourceUUID, "target", targetUUID, "error", err)
+		return envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.conversation}"), nil)
+	}
+	if _, err := tx.Stmtx(c.q.CopyConversationTags).Exec(source.ID, target.ID); err != nil {
+		c.lo.Error("error copying conversation tags", "source", sourceUUID, "target", targetUUID, "error", err)
+		return envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.conversation}"), nil)
+	}
+	if _, err := tx.Stmtx(c.q.RefreshConversationLastMessage).Exec(target.ID); err != nil {
+		c.lo.Error("error refreshing conversation last message", "uuid", targetUUID, "error", err)
+		return envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.conversation}"), nil)
+	}
+	if err := tx.Commit(); err != nil {
+		c.lo.Error("error committing db txn", "error", err)
+		return envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.conversation}"), nil)
+	}
+
+	if source.Status.String != models.StatusClosed {
+		if err := c.UpdateConversationStatus(sourceUUID, 0, models.StatusClosed, "", actor); err != nil {
+			c.lo.Error("error closing merged conversation", "uuid", sourceUUID, "error", err)
+		}
+	}
+	if err := c.InsertConversationActivity(models.ActivityMergedInto, sourceUUID, target.ReferenceNumber, actor); err != nil {
+		c.lo.Error("error recording merge activity", "uuid", sourceUUID, "error", err)
+	}
+	if err := c.InsertConversationActivity(models.ActivityMerged, targetUUID, source.ReferenceNumber, actor); err != nil {
+		c.lo.Error("error recording merge activity", "uuid", targetUUID, "error", err)
+	}
+	return nil
+}
+
+// SplitMessage moves an incoming or outgoing message into a new conversation with the same contact, inbox, subject and tags,
+// the email source ID moves along so future replies to the message land in the new conversation. Returns the new conversation UUID.
+func (c *Manager) SplitMessage(messageUUID string, actor umodels.User) (string, error) {
+	message, err := c.GetMessage(messageUUID)
+	if err != nil {
+		return "", err
+	}
+	if message.Type != models.MessageIncoming && message.Type != models.MessageOutgoing {
+		return "", envelope.NewError(envelope.InputError, c.i18n.T("conversation.cannotSplitMessage"), nil)
+	}
+	source, err := c.GetConversation(message.ConversationID, "")
+	if err != nil {
+		return "", err
+	}
+
+	tx, err := c.db.Beginx()
+	if err != nil {
+		c.lo.Error("error starting db txn", "error", err)
+		return "", envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorCreating", "name", "{globals.terms.conversation}"), nil)
+	}
+	defer tx.Rollback()
+
+	var (
+		newID   int
+		newUUID string
+	)
+	if err := tx.Stmtx(c.q.InsertSplitConversation).QueryRow(source.ID, models.StatusOpen).Scan(&newID, &newUUID); err != nil {
+		c.lo.Error("error inserting split conversation", "source", source.UUID, "error", err)
+		return "", envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorCreating", "name", "{globals.terms.conversation}"), nil)
+	}
+	if _, err := tx.Stmtx(c.q.MoveMessage).Exec(messageUUID, newID); err != nil {
+		c.lo.Error("error moving message", "uuid", messageUUID, "error", err)
+		return "", envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorCreating", "name", "{globals.terms.conversation}"), nil)
+	}
+	if _, err := tx.Stmtx(c.q.CopyConversationTags).Exec(source.ID, newID); err != nil {
+		c.lo.Error("error copying conversation tags", "source", source.UUID, "error", err)
+		return "", envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorCreating", "name", "{globals.terms.conversation}"), nil)
+	}
+	for _, id := range []int{source.ID, newID} {
+		if _, err := tx.Stmtx(c.q.RefreshConversationLastMessage).Exec(id); err != nil {
+			c.lo.Error("error refreshing conversation last message", "id", id, "error", err)
+			return "", envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorCreating", "name", "{globals.terms.conversation}"), nil)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		c.lo.Error("error committing db txn", "error", err)
+		return "", envelope.NewError(envelope.GeneralError, c.i18n.Ts("globals.messages.errorCreating", "name", "{globals.terms.conversation}"), nil)
+	}
+
+	split, err := c.GetConversation(newID, "")
+	if err != nil {
+		return newUUID, nil
+	}
+	if err := c.InsertConversationActivity(models.ActivitySplit, source.UUID, split.ReferenceNumber, actor); err != nil {
+		c.lo.Error("error recording split activity", "uuid", source.UUID, "error", err)
+	}
+	if err := c.InsertConversationActivity(models.ActivitySplitFrom, newUUID, source.ReferenceNumber, actor); err != nil {
+		c.lo.Error("error recording split activity", "uuid", newUUID, "error", err)
+	}
+	return newUUID, nil
+}
@@ -58,6 +58,9 @@ func (m *Manager) Run(ctx context.Context, incomingQWorkers, outgoingQWorkers, s
 		case <-ctx.Done():
 			return
 		case <-dbScanner.C:
+			// Scheduled messages that are due become pending and are sent in this scan.
+			m.promoteDueScheduledMessages()
+
 			var (
 				pendingMessages = []models.Message{}
 				messageIDs      = m.getOutgoingProcessingMessageIDs()
@@ -374,13 +377,126 @@ func (m *Manager) SendPrivateNote(media []mmodels.Media, senderID int, conversat
 
 // SendReply inserts a reply message in a conversation.
 func (m *Manager) SendReply(media []mmodels.Media, inboxID, senderID int, conversationUUID, content string, to, cc, bcc []string, meta map[string]interface{}) error {
+	message, err := m.newReply(media, inboxID, senderID, conversationUUID, content, to, cc, bcc, meta)
+	if err != nil {
+		return err
+	}
+	return m.InsertMessage(&message)
+}
+
+// SendReplyWithUndo inserts a reply message in a conversation that is sent once the undo send window ends,
+// until then the sender can cancel it.
+func (m *Manager) SendReplyWithUndo(media []mmodels.Media, inboxID, senderID int, conversationUUID, content string, to, cc, bcc []string, meta map[string]interface{}) error {
+	message, err := m.newReply(media, inboxID, senderID, conversationUUID, content, to, cc, bcc, meta)
+	if err != nil {
+		return err
+	}
+	if m.undoSendWindow > 0 {
+		message.ScheduledAt = null.TimeFrom(time.Now().Add(m.undoSendWindow))
+	}
+	return m.InsertMessage(&message)
+}
+
+// ScheduleReply inserts a reply message in a conversation that is sent at the scheduled time.
+func (m *Manager) ScheduleReply(media []mmodels.Media, inboxID, senderID int, conversationUUID, content string, to, cc, bcc []string, meta map[string]interface{}, scheduledAt time.Time) error {
+	if !scheduledAt.After(time.Now()) {
+		return envelope.NewError(envelope.InputError, m.i18n.T("message.scheduledAtInPast"), nil)
+	}
+	message, err := m.newReply(media, inboxID, senderID, conversationUUID, content, to, cc, bcc, meta)
+	if err != nil {
+		return err
+	}
+	message.Status = models.MessageStatusScheduled
+	message.ScheduledAt = null.TimeFrom(scheduledAt)
+	return m.InsertMessage(&message)
+}
+
+// UpdateScheduledMessage updates the content and the scheduled time of a message that is still scheduled.
+func (m *Manager) UpdateScheduledMessage(conversationUUID, uuid, content string, scheduledAt time.Time) error {
+	if !scheduledAt.After(time.Now()) {
+		return envelope.NewError(envelope.InputError, m.i18n.T("message.scheduledAtInPast"), nil)
+	}
+	res, err := m.q.UpdateScheduledMessage.Exec(uuid, content, stringutil.HTML2Text(content), scheduledAt, conversationUUID)
+	if err != nil {
+		m.lo.Error("error updating scheduled message", "uuid", uuid, "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.message}"), nil)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return envelope.NewError(envelope.InputError, m.i18n.T("message.notScheduled"), nil)
+	}
+	m.BroadcastMessageUpdate(conversationUUID, uuid, "content", content)
+	m.BroadcastMessageUpdate(conversationUUID, uuid, "scheduled_at", scheduledAt.Format(time.RFC3339))
+	return nil
+}
+
+// CancelScheduledMessage deletes a message that is still scheduled.
+func (m *Manager) CancelScheduledMessage(conversationUUID, uuid string) error {
+	return m.cancelUnsentMessage(conversationUUID, uuid, []string{models.MessageStatusScheduled}, false, "message.notScheduled")
+}
+
+// CancelPendingMessage deletes a pending outgoing message that is still in the undo send window.
+func (m *Manager) CancelPendingMessage(conversationUUID, uuid string) error {
+	if m.undoSendWindow <= 0 {
+		return envelope.NewError(envelope.InputError, m.i18n.T("message.cannotUndoSend"), nil)
+	}
+	return m.cancelUnsentMessage(conversationUUID, uuid, []string{models.MessageStatusPending}, true, "message.cannotUndoSend")
+}
+
+// cancelUnsentMessage deletes a message if it is in one of the given statuses and, if undoWindow is set, still in its undo send window.
+// The cancellation is broadcast and the last message of the conversation is refreshed.
+func (m *Manager) cancelUnsentMessage(conversationUUID, uuid string, statuses []string, undoWindow bool, notFoundKey string) error {
+	var conversationID int
+	if err := m.q.DeleteUnsentMessage.Get(&conversationID, uuid, pq.Array(statuses), conversationUUID, undoWindow); err != nil {
+		m.lo.Error("error deleting unsent message", "uuid", uuid, "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorDeleting", "name", "{globals.terms.message}"), nil)
+	}
+	if conversationID == 0 {
+		return envelope.NewError(envelope.InputError, m.i18n.T(notFoundKey), nil)
+	}
+	m.BroadcastMessageUpdate(conversationUUID, uuid, "status", models.MessageStatusCancelled)
+	if _, err := m.q.RefreshConversationLastMessage.Exec(conversationID); err != nil {
+		m.lo.Error("error refreshing conversation last message", "uuid", conversationUUID, "error", err)
+	}
+	return nil
+}
+
+// promoteDueScheduledMessages marks the scheduled messages that are due as pending and ends the undo send window of replies,
+// updating the last message of their conversations and triggering the message created webhook.
+func (m *Manager) promoteDueScheduledMessages() {
+	var due []struct {
+		UUID             string `db:"uuid"`
+		ConversationID   int    `db:"conversation_id"`
+		ConversationUUID string `db:"conversation_uuid"`
+		TextContent      string `db:"text_content"`
+		SenderType       string `db:"sender_type"`
+		Status           string `db:"status"`
+	}
+	if err := m.q.PromoteDueScheduledMessages.Select(&due); err != nil {
+		m.lo.Error("error promoting due scheduled messages", "error", err)
+		return
+	}
+	for _, d := range due {
+		m.BroadcastMessageUpdate(d.ConversationUUID, d.UUID, "status", d.Status)
+		m.UpdateConversationLastMessage(d.ConversationID, d.ConversationUUID, d.TextContent, d.SenderType, time.Now())
+
+		message, err := m.GetMessage(d.UUID)
+		if err != nil {
+			m.lo.Error("error fetching scheduled message for webhook event", "uuid", d.UUID, "error", err)
+			continue
+		}
+		m.webhookStore.TriggerEvent(wmodels.EventMessageCreated, message)
+	}
+}
+
+// newReply returns a pending reply message with the recipients in meta and a unique source ID.
+func (m *Manager) newReply(media []mmodels.Media, inboxID, senderID int, conversationUUID, content string, to, cc, bcc []string, meta map[string]interface{}) (models.Message, error) {
 	// Save to, cc and bcc in meta.
 	to = stringutil.RemoveEmpty(to)
 	cc = stringutil.RemoveEmpty(cc)
 	bcc = stringutil.RemoveEmpty(bcc)
 
 	if len(to) == 0 {
-		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.empty", "name", "`to`"), nil)
+		return models.Message{}, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.empty", "name", "`to`"), nil)
 	}
 	meta["to"] = to
 
@@ -393,22 +509,21 @@ func (m *Manager) SendReply(media []mmodels.Media, inboxID, senderID int, conver
 
 	metaJSON, err := json.Marshal(meta)
 	if err != nil {
-		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorMarshalling", "name", "{globals.terms.meta}"), nil)
+		return models.Message{}, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorMarshalling", "name", "{globals.terms.meta}"), nil)
 	}
 
 	// Generage unique source ID i.e. message-id for email.
 	inbox, err := m.inboxStore.GetDBRecord(inboxID)
 	if err != nil {
-		return err
+		return models.Message{}, err
 	}
 	sourceID, err := stringutil.GenerateEmailMessageID(conversationUUID, inbox.From)
 	if err != nil {
 		m.lo.Error("error generating source message id", "error", err)
-		return envelope.NewError(envelope.GeneralError, m.i18n.T("conversation.errorGeneratingMessageID"), nil)
+		return models.Message{}, envelope.NewError(envelope.GeneralError, m.i18n.T("conversation.errorGeneratingMessageID"), nil)
 	}
 
-	// Insert Message.
-	message := models.Message{
+	return models.Message{
 		ConversationUUID: conversationUUID,
 		SenderID:         senderID,
 		Type:             models.MessageOutgoing,
@@ -420,8 +535,7 @@ func (m *Manager) SendReply(media []mmodels.Media, inboxID, senderID int, conver
 		Media:            media,
 		Meta:             metaJSON,
 		SourceID:         null.StringFrom(sourceID),
-	}
-	return m.InsertMessage(&message)
+	}, nil
 }
 
 // InsertMessage inserts a message and attaches the media to the message.
@@ -440,7 +554,7 @@ func (m *Manager) InsertMessage(message *models.Message) error {
 
 	// Insert Message.
 	if err := m.q.InsertMessage.QueryRow(message.Type, message.Status, message.ConversationID, message.ConversationUUID, message.Content, message.TextContent, message.SenderID, message.SenderType,
-		message.Private, message.ContentType, message.SourceID, message.Meta).Scan(&message.ID, &message.UUID, &message.CreatedAt); err != nil {
+		message.Private, message.ContentType, message.SourceID, message.Meta, message.ScheduledAt).Scan(&message.ID, &message.UUID, &message.CreatedAt); err != nil {
 		m.lo.Error("error inserting message in db", "error", err)
 		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorInserting", "name", "{globals.terms.message}"), nil)
 	}
@@ -453,6 +567,13 @@ func (m *Manager) InsertMessage(message *models.Message) error {
 	// Add this user as a participant.
 	m.addConversationParticipant(message.SenderID, message.ConversationUUID)
 
+	// Scheduled messages and replies in their undo send window only update the last message and trigger the webhook
+	// once they are due, see promoteDueScheduledMessages.
+	if message.ScheduledAt.Valid {
+		m.BroadcastNewMessage(message)
+		return nil
+	}
+
 	// Hide CSAT message content as it contains a public link to the survey.
 	lastMessage := message.TextContent
 	if message.HasCSAT() {
@@ -581,6 +702,16 @@ func (m *Manager) getMessageActivityContent(activityType, newValue, actorName st
 		content = fmt.Sprintf("%s removed tag %s", actorName, newValue)
 	case models.ActivitySLASet:
 		content = fmt.Sprintf("%s set %s SLA policy", actorName, newValue)
+	case models.ActivityMerged:
+		content = fmt.Sprintf("%s merged conversation #%s into this conversation", actorName, newValue)
+	case models.ActivityMergedInto:
+		content = fmt.Sprintf("%s merged this conversation into #%s", actorName, newValue)
+	case models.ActivitySplit:
+		content = fmt.Sprintf("%s split a message into conversation #%s", actorName, newValue)
+	case models.ActivitySplitFrom:
+		content = fmt.Sprintf("%s split this conversation from #%s", actorName, newValue)
+	case models.ActivityWebhookSent:
+		content = fmt.Sprintf("%s sent the conversation to the %s webhook", actorName, newValue)
 	default:
 		return "", fmt.Errorf("invalid activity type %s", activityType)
 	}
@@ -658,6 +789,13 @@ func (m *Manager) processIncomingMessage(in models.IncomingMessage) error {
 		// Trigger automations on incoming message event.
 		m.automation.EvaluateConversationUpdateRules(conversation, amodels.EventConversationMessageIncoming)
 
+		// Conversation was possibly reopened, resume the SLA clock if it was paused.
+		if conversation.AppliedSLAID.Valid {
+			if err := m.slaStore.SyncPause(conversation.AppliedSLAID.Int); err != nil {
+				m.lo.Error("error syncing SLA pause", "conversation_id", conversation.ID, "error", err)
+			}
+		}
+
 		if conversation.SLAPolicyID.Int == 0 {
 			m.lo.Info("no SLA policy applied to conversation, skipping next response SLA event creation")
 			return nil
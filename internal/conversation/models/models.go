@@ -34,10 +34,13 @@ var (
 	SenderTypeAgent   = "agent"
 	SenderTypeContact = "contact"
 
-	MessageStatusPending  = "pending"
-	MessageStatusSent     = "sent"
-	MessageStatusFailed   = "failed"
-	MessageStatusReceived = "received"
+	MessageStatusPending   = "pending"
+	MessageStatusSent      = "sent"
+	MessageStatusFailed    = "failed"
+	MessageStatusReceived  = "received"
+	MessageStatusScheduled = "scheduled"
+	// MessageStatusCancelled is only broadcast when an unsent message is cancelled and deleted, it is never stored.
+	MessageStatusCancelled = "cancelled"
 
 	ActivityStatusChange       = "status_change"
 	ActivityPriorityChange     = "priority_change"
@@ -47,6 +50,11 @@ var (
 	ActivityTagAdded           = "tag_added"
 	ActivityTagRemoved         = "tag_removed"
 	ActivitySLASet             = "sla_set"
+	ActivityMerged             = "merged"
+	ActivityMergedInto         = "merged_into"
+	ActivitySplit              = "split"
+	ActivitySplitFrom          = "split_from"
+	ActivityWebhookSent        = "webhook_sent"
 
 	ContentTypeText = "text"
 	ContentTypeHTML = "html"
@@ -133,6 +141,7 @@ type Message struct {
 	SenderType       string                 `db:"sender_type" json:"sender_type"`
 	InboxID          int                    `db:"inbox_id" json:"-"`
 	Meta             json.RawMessage        `db:"meta" json:"meta"`
+	ScheduledAt      null.Time              `db:"scheduled_at" json:"scheduled_at"`
 	Attachments      attachment.Attachments `db:"attachments" json:"attachments"`
 	ConversationUUID string                 `db:"conversation_uuid" json:"-"`
 	From             string                 `db:"from"  json:"-"`
@@ -193,7 +193,7 @@ SELECT
     c.last_message_at
 FROM users u
 JOIN conversations c ON c.contact_id = u.id
-WHERE c.contact_id = $1
+WHERE c.contact_id = $1 AND %s
 ORDER BY c.created_at DESC
 LIMIT 10;
 
@@ -387,6 +387,54 @@ WHERE m.id = $1;
 -- name: delete-conversation
 DELETE FROM conversations WHERE uuid = $1;
 
+-- name: move-conversation-messages
+UPDATE conversation_messages SET conversation_id = $2, updated_at = NOW() WHERE conversation_id = $1;
+
+-- name: copy-conversation-participants
+INSERT INTO conversation_participants (user_id, conversation_id)
+SELECT user_id, $2 FROM conversation_participants WHERE conversation_id = $1
+ON CONFLICT (conversation_id, user_id) DO NOTHING;
+
+-- name: copy-conversation-tags
+INSERT INTO conversation_tags (tag_id, conversation_id)
+SELECT tag_id, $2 FROM conversation_tags WHERE conversation_id = $1
+ON CONFLICT (conversation_id, tag_id) DO NOTHING;
+
+-- name: insert-split-conversation
+-- Creates a conversation with the same contact, channel, inbox and subject as the given conversation.
+WITH reference_number AS (
+   SELECT generate_reference_number('') AS reference_number
+)
+INSERT INTO conversations
+(contact_id, contact_channel_id, status_id, inbox_id, subject, reference_number)
+SELECT
+   contact_id,
+   contact_channel_id,
+   (SELECT id FROM conversation_statuses WHERE name = $2),
+   inbox_id,
+   subject,
+   (SELECT reference_number FROM reference_number)
+FROM conversations
+WHERE id = $1
+RETURNING id, uuid;
+
+-- name: refresh-conversation-last-message
+-- Sets the last message of a conversation from its latest non activity message,
+-- scheduled messages and replies in their undo send window are not sent yet.
+UPDATE conversations c
+SET last_message = m.text_content,
+    last_message_sender = m.sender_type,
+    last_message_at = m.created_at,
+    updated_at = NOW()
+FROM (
+    SELECT text_content, sender_type, created_at
+    FROM conversation_messages
+    WHERE conversation_id = $1 AND type != 'activity' AND status != 'scheduled' AND scheduled_at IS NULL
+    ORDER BY created_at DESC
+    LIMIT 1
+) m
+WHERE c.id = $1;
+
 -- MESSAGE queries.
 -- name: get-message-source-ids
 SELECT 
@@ -421,6 +469,8 @@ FROM conversation_messages m
 INNER JOIN conversations c ON c.id = m.conversation_id
 WHERE m.status = 'pending' AND m.type = 'outgoing' AND m.private = false
 AND NOT(m.id = ANY($1::INT[]))
+-- Replies in the undo send window are sent after it ends.
+AND (m.scheduled_at IS NULL OR m.scheduled_at <= NOW())
 
 -- name: get-message
 SELECT
@@ -438,6 +488,7 @@ SELECT
     m.sender_type,
     m.sender_id,
     m.meta,
+    m.scheduled_at,
     COALESCE(
         json_agg(
             json_build_object(
@@ -471,6 +522,7 @@ SELECT
    m.sender_id,
    m.sender_type,
    m.meta,
+   m.scheduled_at,
    COALESCE(
      (SELECT json_agg(
        json_build_object(
@@ -503,11 +555,11 @@ inserted_msg AS (
    INSERT INTO conversation_messages (
        "type", status, conversation_id, "content", 
        text_content, sender_id, sender_type, private,
-       content_type, source_id, meta
+       content_type, source_id, meta, scheduled_at
    )
    VALUES (
        $1, $2, (SELECT id FROM conversation_id),
-       $5, $6, $7, $8, $9, $10, $11, $12
+       $5, $6, $7, $8, $9, $10, $11, $12, $13
    )
    RETURNING id, uuid, created_at, conversation_id
 )
@@ -518,6 +570,41 @@ SELECT conversation_id
 FROM conversation_messages
 WHERE source_id = ANY($1::text []);
 
+-- name: promote-due-scheduled-messages
+-- Marks scheduled messages that are due as pending so they are picked up by the outgoing message scan,
+-- and ends the undo send window of pending replies. The scheduled time is cleared so each message is only promoted once.
+UPDATE conversation_messages m
+SET status = CASE WHEN m.status = 'scheduled' THEN 'pending'::message_status ELSE m.status END,
+    scheduled_at = NULL, updated_at = NOW()
+FROM conversations c
+WHERE c.id = m.conversation_id AND m.scheduled_at <= NOW()
+RETURNING m.uuid, m.conversation_id, c.uuid AS conversation_uuid, m.text_content, m.sender_type, m.status;
+
+-- name: update-scheduled-message
+UPDATE conversation_messages
+SET content = $2, text_content = $3, scheduled_at = $4, updated_at = NOW()
+WHERE uuid = $1 AND status = 'scheduled'
+AND conversation_id = (SELECT id FROM conversations WHERE uuid = $5);
+
+-- name: delete-unsent-message
+-- Deletes a message that is not sent yet and returns its conversation ID, or 0 if no message was deleted.
+-- If $4 is true, only messages that are still in their undo send window are deleted. Media is unlinked to be cleaned up.
+WITH deleted AS (
+    DELETE FROM conversation_messages
+    WHERE uuid = $1 AND status = ANY($2::message_status[])
+    AND conversation_id = (SELECT id FROM conversations WHERE uuid = $3)
+    AND (NOT $4::BOOLEAN OR scheduled_at > NOW())
+    RETURNING id, conversation_id
+),
+unlinked AS (
+    UPDATE media SET model_id = NULL
+    WHERE model_type = 'messages' AND model_id IN (SELECT id FROM deleted)
+)
+SELECT COALESCE(MAX(conversation_id), 0) FROM deleted;
+
+-- name: move-message
+UPDATE conversation_messages SET conversation_id = $2, updated_at = NOW() WHERE uuid = $1;
+
 -- name: update-message-status
 update conversation_messages set status = $1, updated_at = NOW() where uuid = $2;
 
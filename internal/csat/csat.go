@@ -6,6 +6,7 @@ import (
 	"embed"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/abhinavxd/libredesk/internal/csat/models"
 	"github.com/abhinavxd/libredesk/internal/dbutil"
@@ -30,6 +31,7 @@ type Manager struct {
 	q    queries
 	lo   *logf.Logger
 	i18n *i18n.I18n
+	db   *sqlx.DB
 }
 
 // Opts contains options for initializing the Manager.
@@ -41,9 +43,12 @@ type Opts struct {
 
 // queries contains prepared SQL queries.
 type queries struct {
-	Insert *sqlx.Stmt `query:"insert"`
-	Get    *sqlx.Stmt `query:"get"`
-	Update *sqlx.Stmt `query:"update"`
+	Insert       *sqlx.Stmt `query:"insert"`
+	Get          *sqlx.Stmt `query:"get"`
+	Update       *sqlx.Stmt `query:"update"`
+	InsertAnswer *sqlx.Stmt `query:"insert-answer"`
+	GetSurvey    *sqlx.Stmt `query:"get-survey"`
+	UpsertSurvey *sqlx.Stmt `query:"upsert-survey"`
 }
 
 // New creates and returns a new instance of the Manager.
@@ -56,6 +61,7 @@ func New(opts Opts) (*Manager, error) {
 		q:    q,
 		lo:   opts.Lo,
 		i18n: opts.I18n,
+		db:   opts.DB,
 	}, nil
 }
 
@@ -86,25 +92,117 @@ func (m *Manager) Get(uuid string) (models.CSATResponse, error) {
 	return csat, nil
 }
 
-// UpdateResponse updates the CSAT response for the given csat.
-func (m *Manager) UpdateResponse(uuid string, score int, feedback string) error {
+// UpdateResponse updates the CSAT response for the given csat along with the answers to the survey's follow-up questions keyed by question key.
+func (m *Manager) UpdateResponse(uuid string, score int, feedback string, answers map[string]string) error {
 	csat, err := m.Get(uuid)
 	if err != nil {
 		return err
 	}
 
-	if csat.Score > 0 || !csat.ResponseTimestamp.IsZero() {
+	if csat.ResponseTimestamp.Valid {
 		return envelope.NewError(envelope.InputError, m.i18n.T("csat.alreadySubmitted"), nil)
 	}
 
-	_, err = m.q.Update.Exec(uuid, score, feedback)
+	if minScore, maxScore := models.ScoreRange(csat.SurveyType); score < minScore || score > maxScore {
+		return envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.invalid", "name", "`rating`"), nil)
+	}
+
+	// Validate answers against the survey questions.
+	for _, question := range csat.Questions {
+		answer := strings.TrimSpace(answers[question.Key])
+		if answer == "" {
+			if question.Required {
+				return envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.required", "name", question.Label), nil)
+			}
+			continue
+		}
+		if !isValidAnswer(question, answer) {
+			return envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.invalid", "name", question.Label), nil)
+		}
+	}
+
+	tx, err := m.db.Beginx()
+	if err != nil {
+		m.lo.Error("error starting db txn", "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorSaving", "name", "{globals.terms.csatResponse}"), nil)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Stmtx(m.q.Update).Exec(uuid, score, feedback)
 	if err != nil {
 		m.lo.Error("error updating CSAT", "error", err)
 		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorSaving", "name", "{globals.terms.csatResponse}"), nil)
 	}
+	// Response submitted concurrently.
+	if n, _ := res.RowsAffected(); n == 0 {
+		return envelope.NewError(envelope.InputError, m.i18n.T("csat.alreadySubmitted"), nil)
+	}
+
+	for _, question := range csat.Questions {
+		answer := strings.TrimSpace(answers[question.Key])
+		if answer == "" {
+			continue
+		}
+		if _, err := tx.Stmtx(m.q.InsertAnswer).Exec(csat.ID, question.Key, question.Label, answer); err != nil {
+			m.lo.Error("error inserting CSAT answer", "error", err)
+			return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorSaving", "name", "{globals.terms.csatResponse}"), nil)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		m.lo.Error("error committing db txn", "error", err)
+		return envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorSaving", "name", "{globals.terms.csatResponse}"), nil)
+	}
 	return nil
 }
 
+// GetSurvey returns the CSAT survey of an inbox, inboxes without a survey use a star rating survey without follow-up questions.
+func (m *Manager) GetSurvey(inboxID int) (models.Survey, error) {
+	var survey models.Survey
+	if err := m.q.GetSurvey.Get(&survey, inboxID); err != nil {
+		if err == sql.ErrNoRows {
+			return models.Survey{
+				InboxID:   inboxID,
+				Type:      models.SurveyTypeStars,
+				Questions: models.SurveyQuestions{},
+			}, nil
+		}
+		m.lo.Error("error fetching CSAT survey", "inbox_id", inboxID, "error", err)
+		return survey, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.csatSurvey}"), nil)
+	}
+	return survey, nil
+}
+
+// UpsertSurvey creates or updates the CSAT survey of an inbox.
+func (m *Manager) UpsertSurvey(inboxID int, surveyType string, questions models.SurveyQuestions) (models.Survey, error) {
+	var survey models.Survey
+	if questions == nil {
+		questions = models.SurveyQuestions{}
+	}
+	if err := m.q.UpsertSurvey.Get(&survey, inboxID, surveyType, questions); err != nil {
+		m.lo.Error("error upserting CSAT survey", "inbox_id", inboxID, "error", err)
+		return survey, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorSaving", "name", "{globals.terms.csatSurvey}"), nil)
+	}
+	return survey, nil
+}
+
+// isValidAnswer returns true if the answer is valid for the question.
+func isValidAnswer(question models.SurveyQuestion, answer string) bool {
+	switch question.Type {
+	case models.QuestionTypeChoice:
+		for _, option := range question.Options {
+			if option == answer {
+				return true
+			}
+		}
+		return false
+	case models.QuestionTypeRating:
+		return len(answer) == 1 && answer[0] >= '1' && answer[0] <= '5'
+	default:
+		return len(answer) <= 1000
+	}
+}
+
 // MakePublicURL returns the public URL for the given CSAT UUID.
 func (m *Manager) MakePublicURL(appBaseURL, uuid string) string {
 	return fmt.Sprintf(csatURL, appBaseURL, uuid)
@@ -2,19 +2,101 @@
 package models
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/volatiletech/null/v9"
 )
 
+const (
+	// SurveyTypeStars is a 1-5 star rating.
+	SurveyTypeStars = "stars"
+	// SurveyTypeNPS is a Net Promoter Score survey rated 0-10.
+	SurveyTypeNPS = "nps"
+	// SurveyTypeCES is a Customer Effort Score survey rated 1-7.
+	SurveyTypeCES = "ces"
+
+	QuestionTypeText   = "text"
+	QuestionTypeChoice = "choice"
+	QuestionTypeRating = "rating"
+)
+
+// ScoreRange returns the minimum and maximum score of a survey type.
+func ScoreRange(surveyType string) (int, int) {
+	switch surveyType {
+	case SurveyTypeNPS:
+		return 0, 10
+	case SurveyTypeCES:
+		return 1, 7
+	default:
+		return 1, 5
+	}
+}
+
 // CSATResponse represents a customer satisfaction survey response.
 type CSATResponse struct {
-	ID                int         `db:"id"`
-	UUID              string      `db:"uuid"`
-	CreatedAt         time.Time   `db:"created_at"`
-	UpdatedAt         time.Time   `db:"updated_at"`
-	ConversationID    int         `db:"conversation_id"`
-	Score             int         `db:"rating"`
-	Feedback          null.String `db:"feedback"`
-	ResponseTimestamp null.Time   `db:"response_timestamp"`
+	ID                int             `db:"id"`
+	UUID              string          `db:"uuid"`
+	CreatedAt         time.Time       `db:"created_at"`
+	UpdatedAt         time.Time       `db:"updated_at"`
+	ConversationID    int             `db:"conversation_id"`
+	SurveyID          null.Int        `db:"survey_id"`
+	SurveyType        string          `db:"survey_type"`
+	Questions         SurveyQuestions `db:"questions"`
+	Score             int             `db:"rating"`
+	Feedback          null.String     `db:"feedback"`
+	ResponseTimestamp null.Time       `db:"response_timestamp"`
+}
+
+// Survey represents the CSAT survey definition of an inbox.
+type Survey struct {
+	ID        int             `db:"id" json:"id"`
+	CreatedAt time.Time       `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time       `db:"updated_at" json:"updated_at"`
+	InboxID   int             `db:"inbox_id" json:"inbox_id"`
+	Type      string          `db:"type" json:"type"`
+	Questions SurveyQuestions `db:"questions" json:"questions"`
+}
+
+type SurveyQuestions []SurveyQuestion
+
+// Value implements the driver.Valuer interface.
+func (sq SurveyQuestions) Value() (driver.Value, error) {
+	return json.Marshal(sq)
+}
+
+// Scan implements the sql.Scanner interface.
+func (sq *SurveyQuestions) Scan(src any) error {
+	var data []byte
+
+	switch v := src.(type) {
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		return fmt.Errorf("unsupported type: %T", src)
+	}
+	return json.Unmarshal(data, sq)
+}
+
+// SurveyQuestion represents an optional follow-up question of a survey.
+type SurveyQuestion struct {
+	Key      string   `json:"key"`
+	Label    string   `json:"label"`
+	Type     string   `json:"type"`
+	Options  []string `json:"options"`
+	Required bool     `json:"required"`
+}
+
+// Answer represents the answer to a follow-up question of a CSAT response.
+type Answer struct {
+	ID             int       `db:"id" json:"id"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+	CSATResponseID int       `db:"csat_response_id" json:"csat_response_id"`
+	QuestionKey    string    `db:"question_key" json:"question_key"`
+	Question       string    `db:"question" json:"question"`
+	Value          string    `db:"value" json:"value"`
 }
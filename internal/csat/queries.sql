@@ -1,25 +1,53 @@
 -- name: insert
+-- The survey type of the conversation's inbox is copied to the response so that it can be rendered and reported on even if the survey changes later.
 INSERT INTO csat_responses (
-        conversation_id
+        conversation_id,
+        survey_id,
+        survey_type
     )
-VALUES ($1)
+SELECT c.id, s.id, COALESCE(s.type, 'stars'::csat_survey_type)
+FROM conversations c
+LEFT JOIN csat_surveys s ON s.inbox_id = c.inbox_id
+WHERE c.id = $1
 RETURNING uuid;
 
 -- name: get
-SELECT id,
-    uuid,
-    created_at,
-    updated_at,
-    conversation_id,
-    rating,
-    feedback,
-    response_timestamp
-FROM csat_responses
-WHERE uuid = $1;
+SELECT r.id,
+    r.uuid,
+    r.created_at,
+    r.updated_at,
+    r.conversation_id,
+    r.survey_id,
+    r.survey_type,
+    COALESCE(s.questions, '[]'::jsonb) AS questions,
+    r.rating,
+    r.feedback,
+    r.response_timestamp
+FROM csat_responses r
+LEFT JOIN csat_surveys s ON s.id = r.survey_id
+WHERE r.uuid = $1;
 
 -- name: update
 UPDATE csat_responses
 SET rating = $2,
     feedback = $3,
     response_timestamp = NOW()
-WHERE uuid = $1;
+WHERE uuid = $1 AND response_timestamp IS NULL;
+
+-- name: insert-answer
+INSERT INTO csat_response_answers (csat_response_id, question_key, question, value)
+VALUES ($1, $2, $3, $4);
+
+-- name: get-survey
+SELECT id, created_at, updated_at, inbox_id, type, questions
+FROM csat_surveys
+WHERE inbox_id = $1;
+
+-- name: upsert-survey
+INSERT INTO csat_surveys (inbox_id, type, questions)
+VALUES ($1, $2, $3)
+ON CONFLICT (inbox_id) DO UPDATE SET
+    type = EXCLUDED.type,
+    questions = EXCLUDED.questions,
+    updated_at = NOW()
+RETURNING *;
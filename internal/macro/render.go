@@ -0,0 +1,85 @@
+package macro
+
+import (
+	"encoding/json"
+	"html/template"
+	"strings"
+
+	amodels "github.com/abhinavxd/libredesk/internal/automation/models"
+	cmodels "github.com/abhinavxd/libredesk/internal/conversation/models"
+	umodels "github.com/abhinavxd/libredesk/internal/user/models"
+)
+
+// RenderContent renders the variables e.g. {{ .Contact.FirstName }} in the HTML content of a reply or private note action,
+// variable values are HTML escaped and missing variables render as empty strings.
+func RenderContent(content string, data map[string]any) (string, error) {
+	tmpl, err := parseContent(content)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// RenderAction returns the action with the variables in the content of a reply or private note rendered for the conversation,
+// other actions are returned as is.
+func RenderAction(action amodels.RuleAction, conv cmodels.Conversation, agent umodels.User) (amodels.RuleAction, error) {
+	if action.Type != amodels.ActionReply && action.Type != amodels.ActionSendPrivateNote || len(action.Value) == 0 {
+		return action, nil
+	}
+	content, err := RenderContent(action.Value[0], TemplateData(conv, agent))
+	if err != nil {
+		return action, err
+	}
+	action.Value = []string{content}
+	return action, nil
+}
+
+// ValidateContent returns an error if the content has invalid variable syntax.
+func ValidateContent(content string) error {
+	_, err := parseContent(content)
+	return err
+}
+
+// TemplateData returns the variables available to the content of a reply or private note action applied on a conversation by an agent.
+func TemplateData(conv cmodels.Conversation, agent umodels.User) map[string]any {
+	return map[string]any{
+		"Conversation": map[string]any{
+			"ReferenceNumber":  conv.ReferenceNumber,
+			"Subject":          conv.Subject.String,
+			"Priority":         conv.Priority.String,
+			"Status":           conv.Status.String,
+			"UUID":             conv.UUID,
+			"CustomAttributes": customAttributes(conv.CustomAttributes),
+		},
+		"Contact": map[string]any{
+			"FirstName":        conv.Contact.FirstName,
+			"LastName":         conv.Contact.LastName,
+			"FullName":         conv.Contact.FullName(),
+			"Email":            conv.Contact.Email.String,
+			"CustomAttributes": customAttributes(conv.Contact.CustomAttributes),
+		},
+		"Agent": map[string]any{
+			"FirstName": agent.FirstName,
+			"LastName":  agent.LastName,
+			"FullName":  agent.FullName(),
+			"Email":     agent.Email.String,
+		},
+	}
+}
+
+func parseContent(content string) (*template.Template, error) {
+	return template.New("content").Option("missingkey=zero").Parse(content)
+}
+
+// customAttributes unmarshals custom attributes, invalid or empty attributes return an empty map.
+func customAttributes(b json.RawMessage) map[string]any {
+	attrs := map[string]any{}
+	if len(b) > 0 {
+		_ = json.Unmarshal(b, &attrs)
+	}
+	return attrs
+}
@@ -0,0 +1,59 @@
+package macro
+
+import (
+	"encoding/json"
+	"testing"
+
+	cmodels "github.com/abhinavxd/libredesk/internal/conversation/models"
+	umodels "github.com/abhinavxd/libredesk/internal/user/models"
+)
+
+func TestRenderContent(t *testing.T) {
+	conv := cmodels.Conversation{
+		ReferenceNumber:  "100",
+		CustomAttributes: json.RawMessage(`{"plan": "Pro"}`),
+		Contact: umodels.User{
+			FirstName: "<b>Jane</b>",
+			LastName:  "Doe",
+		},
+	}
+	agent := umodels.User{FirstName: "John", LastName: "Smith"}
+
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			name:     "variables are rendered and escaped",
+			content:  "<p>Hi {{ .Contact.FirstName }}, ref #{{ .Conversation.ReferenceNumber }}</p><p>{{ .Agent.FullName }}</p>",
+			expected: "<p>Hi &lt;b&gt;Jane&lt;/b&gt;, ref #100</p><p>John Smith</p>",
+		},
+		{
+			name:     "custom attributes",
+			content:  "<p>Plan: {{ .Conversation.CustomAttributes.plan }}</p>",
+			expected: "<p>Plan: Pro</p>",
+		},
+		{
+			name:     "missing variables render as empty strings",
+			content:  "<p>{{ .Contact.CustomAttributes.company }}{{ .Contact.Nickname }}</p>",
+			expected: "<p></p>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RenderContent(tt.content, TemplateData(conv, agent))
+			if err != nil {
+				t.Fatalf("RenderContent() error = %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("RenderContent() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+
+	if err := ValidateContent("<p>{{ .Contact.FirstName </p>"); err == nil {
+		t.Error("ValidateContent() expected error for unclosed action")
+	}
+}
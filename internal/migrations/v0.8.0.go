@@ -0,0 +1,453 @@
+package migrations
+
+import (
+	"github.com/jmoiron/sqlx"
+	"github.com/knadh/koanf/v2"
+	"github.com/knadh/stuffbin"
+)
+
+// V0_8_0 updates the database schema to v0.8.0.
+func V0_8_0(db *sqlx.DB, fs stuffbin.FileSystem, ko *koanf.Koanf) error {
+	// Add statuses that pause the SLA clock to SLA policies
+	_, err := db.Exec(`
+		ALTER TABLE sla_policies ADD COLUMN IF NOT EXISTS pause_on_statuses TEXT[] DEFAULT '{}'::TEXT[] NOT NULL;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create applied_sla_pauses table if it doesn't exist
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS applied_sla_pauses (
+			id BIGSERIAL PRIMARY KEY,
+			created_at TIMESTAMPTZ DEFAULT NOW(),
+			applied_sla_id BIGINT REFERENCES applied_slas(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+			status TEXT NOT NULL,
+			paused_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+			resumed_at TIMESTAMPTZ NULL,
+			paused_minutes INT DEFAULT 0 NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS index_applied_sla_pauses_on_applied_sla_id ON applied_sla_pauses(applied_sla_id);
+		CREATE UNIQUE INDEX IF NOT EXISTS index_unique_open_applied_sla_pauses ON applied_sla_pauses(applied_sla_id) WHERE resumed_at IS NULL;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Add breach escalations to SLA policies
+	_, err = db.Exec(`
+		ALTER TABLE sla_policies ADD COLUMN IF NOT EXISTS escalations JSONB DEFAULT '[]'::jsonb NOT NULL;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Add special hours e.g. half-days to business hours
+	_, err = db.Exec(`
+		ALTER TABLE business_hours ADD COLUMN IF NOT EXISTS special_hours JSONB DEFAULT '[]'::jsonb NOT NULL;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create csat_survey_type enum if not exists
+	_, err = db.Exec(`
+		DO $$
+		BEGIN
+			IF NOT EXISTS (
+				SELECT 1 FROM pg_type WHERE typname = 'csat_survey_type'
+			) THEN
+				CREATE TYPE csat_survey_type AS ENUM ('stars', 'nps', 'ces');
+			END IF;
+		END
+		$$;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create csat_surveys table if not exists
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS csat_surveys (
+			id SERIAL PRIMARY KEY,
+			created_at TIMESTAMPTZ DEFAULT NOW(),
+			updated_at TIMESTAMPTZ DEFAULT NOW(),
+			inbox_id INT REFERENCES inboxes(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL UNIQUE,
+			type csat_survey_type DEFAULT 'stars' NOT NULL,
+			questions JSONB DEFAULT '[]'::jsonb NOT NULL
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Add survey columns to csat_responses and allow NPS ratings (0-10)
+	_, err = db.Exec(`
+		ALTER TABLE csat_responses ADD COLUMN IF NOT EXISTS survey_id INT REFERENCES csat_surveys(id) ON DELETE SET NULL ON UPDATE CASCADE NULL;
+		ALTER TABLE csat_responses ADD COLUMN IF NOT EXISTS survey_type csat_survey_type DEFAULT 'stars' NOT NULL;
+		ALTER TABLE csat_responses DROP CONSTRAINT IF EXISTS constraint_csat_responses_on_rating;
+		ALTER TABLE csat_responses ADD CONSTRAINT constraint_csat_responses_on_rating CHECK (rating >= 0 AND rating <= 10);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create csat_response_answers table if not exists
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS csat_response_answers (
+			id BIGSERIAL PRIMARY KEY,
+			created_at TIMESTAMPTZ DEFAULT NOW(),
+			csat_response_id INT REFERENCES csat_responses(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+			question_key TEXT NOT NULL,
+			question TEXT NOT NULL,
+			value TEXT NOT NULL,
+			CONSTRAINT constraint_csat_response_answers_on_value CHECK (length(value) <= 1000)
+		);
+		CREATE INDEX IF NOT EXISTS index_csat_response_answers_on_csat_response_id ON csat_response_answers(csat_response_id);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Add anthropic to ai_provider enum
+	_, err = db.Exec(`
+		DO $$
+		BEGIN
+			IF NOT EXISTS (
+				SELECT 1 FROM pg_enum e
+				JOIN pg_type t ON t.oid = e.enumtypid
+				WHERE t.typname = 'ai_provider'
+				AND e.enumlabel = 'anthropic'
+			) THEN
+				ALTER TYPE ai_provider ADD VALUE 'anthropic';
+			END IF;
+		END
+		$$;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Admin role gets the AI prompts permission.
+	_, err = db.Exec(`
+		UPDATE roles
+		SET permissions = array_append(permissions, 'ai_prompts:manage')
+		WHERE name = 'Admin' AND NOT ('ai_prompts:manage' = ANY(permissions));
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create ai_inbox_settings table if not exists
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS ai_inbox_settings (
+			id SERIAL PRIMARY KEY,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			inbox_id INT REFERENCES inboxes(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL UNIQUE,
+			summarize_on_resolve BOOLEAN NOT NULL DEFAULT FALSE,
+			auto_tag_on_resolve BOOLEAN NOT NULL DEFAULT FALSE,
+			category_attribute_key TEXT NULL,
+			CONSTRAINT constraint_ai_inbox_settings_on_category_attribute_key CHECK (length(category_attribute_key) <= 140)
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create ai_usage_outcome enum if not exists
+	_, err = db.Exec(`
+		DO $$
+		BEGIN
+			IF NOT EXISTS (
+				SELECT 1 FROM pg_type WHERE typname = 'ai_usage_outcome'
+			) THEN
+				CREATE TYPE ai_usage_outcome AS ENUM ('success', 'error', 'rate_limited');
+			END IF;
+		END
+		$$;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Create ai_usage table if not exists
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS ai_usage (
+			id BIGSERIAL PRIMARY KEY,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+			user_id INT REFERENCES users(id) ON DELETE SET NULL ON UPDATE CASCADE NULL,
+			provider_id INT REFERENCES ai_providers(id) ON DELETE SET NULL ON UPDATE CASCADE NULL,
+			model TEXT NOT NULL,
+			prompt_key TEXT NOT NULL,
+			input_tokens INT NOT NULL DEFAULT 0,
+			output_tokens INT NOT NULL DEFAULT 0,
+			latency_ms INT NOT NULL DEFAULT 0,
+			outcome ai_usage_outcome NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS index_ai_usage_on_created_at ON ai_usage USING btree (created_at);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Add AI daily token limit settings
+	_, err = db.Exec(`
+		INSERT INTO settings (key, value)
+		VALUES
+			('ai.user_daily_token_limit', '0'::jsonb),
+			('ai.workspace_daily_token_limit', '0'::jsonb)
+		ON CONFLICT (key) DO NOTHING;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Agent and Admin roles get the permission to merge and split conversations.
+	_, err = db.Exec(`
+		UPDATE roles
+		SET permissions = array_append(permissions, 'conversations:merge')
+		WHERE name IN ('Agent', 'Admin') AND NOT ('conversations:merge' = ANY(permissions));
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Add scheduled message status
+	_, err = db.Exec(`
+		DO $$
+		BEGIN
+			IF NOT EXISTS (
+				SELECT 1 FROM pg_enum e
+				JOIN pg_type t ON t.oid = e.enumtypid
+				WHERE t.typname = 'message_status'
+				AND e.enumlabel = 'scheduled'
+			) THEN
+				ALTER TYPE message_status ADD VALUE 'scheduled';
+			END IF;
+		END
+		$$;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Add scheduled_at column to conversation_messages
+	_, err = db.Exec(`
+		ALTER TABLE conversation_messages ADD COLUMN IF NOT EXISTS scheduled_at TIMESTAMPTZ NULL;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Add two-factor authentication columns to users
+	_, err = db.Exec(`
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_secret TEXT NULL;
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_enabled BOOL DEFAULT FALSE NOT NULL;
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_recovery_codes TEXT[] DEFAULT '{}'::TEXT[] NOT NULL;
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_last_used_step BIGINT NULL;
+		ALTER TABLE roles ADD COLUMN IF NOT EXISTS require_two_factor BOOL DEFAULT FALSE NOT NULL;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Add two-factor activity log types
+	for _, typ := range []string{"agent_2fa_enabled", "agent_2fa_disabled", "agent_2fa_reset"} {
+		_, err = db.Exec(`
+			DO $$
+			BEGIN
+				IF NOT EXISTS (
+					SELECT 1 FROM pg_enum e
+					JOIN pg_type t ON t.oid = e.enumtypid
+					WHERE t.typname = 'activity_log_type'
+					AND e.enumlabel = '` + typ + `'
+				) THEN
+					ALTER TYPE activity_log_type ADD VALUE '` + typ + `';
+				END IF;
+			END
+			$$;
+		`)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Add just-in-time provisioning and group mapping columns to oidc
+	_, err = db.Exec(`
+		ALTER TABLE oidc ADD COLUMN IF NOT EXISTS provision_users BOOL DEFAULT FALSE NOT NULL;
+		ALTER TABLE oidc ADD COLUMN IF NOT EXISTS default_role TEXT DEFAULT '' NOT NULL;
+		ALTER TABLE oidc ADD COLUMN IF NOT EXISTS groups_claim TEXT DEFAULT 'groups' NOT NULL;
+		ALTER TABLE oidc ADD COLUMN IF NOT EXISTS group_mappings JSONB DEFAULT '[]'::jsonb NOT NULL;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Add SAML identity providers
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS saml (
+			id SERIAL PRIMARY KEY,
+			created_at TIMESTAMPTZ DEFAULT NOW(),
+			updated_at TIMESTAMPTZ DEFAULT NOW(),
+			"name" TEXT NOT NULL,
+			enabled bool DEFAULT TRUE NOT NULL,
+			idp_metadata_url TEXT DEFAULT '' NOT NULL,
+			idp_metadata TEXT NOT NULL,
+			email_attribute TEXT DEFAULT '' NOT NULL,
+			first_name_attribute TEXT DEFAULT '' NOT NULL,
+			last_name_attribute TEXT DEFAULT '' NOT NULL,
+			sp_certificate TEXT NOT NULL,
+			sp_private_key TEXT NOT NULL,
+			CONSTRAINT constraint_saml_on_name CHECK (length("name") <= 140)
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Add SCIM settings, SCIM is disabled until a token is generated.
+	_, err = db.Exec(`
+		INSERT INTO settings (key, value)
+		VALUES
+			('scim.default_role', '"Agent"'::jsonb),
+			('scim.token_hash', '""'::jsonb)
+		ON CONFLICT (key) DO NOTHING;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Move API keys from users to api_keys, agents can have multiple named keys with restricted permissions.
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS api_keys (
+			id SERIAL PRIMARY KEY,
+			created_at TIMESTAMPTZ DEFAULT NOW(),
+			updated_at TIMESTAMPTZ DEFAULT NOW(),
+			user_id INT REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+			"name" TEXT NOT NULL,
+			api_key TEXT NOT NULL UNIQUE,
+			secret_hash TEXT NOT NULL,
+			permissions TEXT[] DEFAULT '{}'::TEXT[] NOT NULL,
+			allowed_ips TEXT[] DEFAULT '{}'::TEXT[] NOT NULL,
+			expires_at TIMESTAMPTZ NULL,
+			last_used_at TIMESTAMPTZ NULL,
+			last_used_ip TEXT NULL,
+			CONSTRAINT constraint_api_keys_on_name CHECK (length("name") <= 140)
+		);
+		CREATE INDEX IF NOT EXISTS index_api_keys_on_user_id ON api_keys(user_id);
+
+		DO $$
+		BEGIN
+			IF EXISTS (
+				SELECT 1 FROM information_schema.columns
+				WHERE table_name = 'users' AND column_name = 'api_key'
+			) THEN
+				INSERT INTO api_keys (user_id, "name", api_key, secret_hash, last_used_at)
+				SELECT id, 'Default', api_key, api_secret, api_key_last_used_at
+				FROM users
+				WHERE api_key IS NOT NULL AND api_secret IS NOT NULL
+				ON CONFLICT (api_key) DO NOTHING;
+
+				DROP INDEX IF EXISTS index_users_on_api_key;
+				ALTER TABLE users
+					DROP COLUMN api_key,
+					DROP COLUMN api_secret,
+					DROP COLUMN api_key_last_used_at;
+			END IF;
+		END
+		$$;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Add login failure and lockout activity log types
+	for _, typ := range []string{"agent_login_failed", "agent_locked_out", "agent_unlocked"} {
+		_, err = db.Exec(`
+			DO $$
+			BEGIN
+				IF NOT EXISTS (
+					SELECT 1 FROM pg_enum e
+					JOIN pg_type t ON t.oid = e.enumtypid
+					WHERE t.typname = 'activity_log_type'
+					AND e.enumlabel = '` + typ + `'
+				) THEN
+					ALTER TYPE activity_log_type ADD VALUE '` + typ + `';
+				END IF;
+			END
+			$$;
+		`)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Add password policy settings, password history and password age
+	_, err = db.Exec(`
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS password_changed_at TIMESTAMPTZ DEFAULT NOW() NOT NULL;
+
+		CREATE TABLE IF NOT EXISTS user_password_history (
+			id BIGSERIAL PRIMARY KEY,
+			created_at TIMESTAMPTZ DEFAULT NOW(),
+			user_id INT REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+			"password" TEXT NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS index_user_password_history_on_user_id ON user_password_history(user_id);
+
+		INSERT INTO settings (key, value)
+		VALUES
+			('password.min_length', '10'::jsonb),
+			('password.require_uppercase', 'true'::jsonb),
+			('password.require_lowercase', 'true'::jsonb),
+			('password.require_number', 'true'::jsonb),
+			('password.require_special', 'true'::jsonb),
+			('password.banned_passwords', '["Password@123", "Password@1234", "P@ssw0rd123", "Welcome@123", "Admin@12345", "Qwerty@12345", "Abcd@123456", "Libredesk@123"]'::jsonb),
+			('password.history_count', '0'::jsonb),
+			('password.max_age_days', '0'::jsonb)
+		ON CONFLICT (key) DO NOTHING;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Add inbox and team scopes to roles
+	_, err = db.Exec(`
+		ALTER TABLE roles ADD COLUMN IF NOT EXISTS inbox_ids INT[] DEFAULT '{}'::INT[] NOT NULL;
+		ALTER TABLE roles ADD COLUMN IF NOT EXISTS team_ids INT[] DEFAULT '{}'::INT[] NOT NULL;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Add conversation to AI usage to scope the usage report
+	_, err = db.Exec(`
+		ALTER TABLE ai_usage ADD COLUMN IF NOT EXISTS conversation_id BIGINT REFERENCES conversations(id) ON DELETE SET NULL ON UPDATE CASCADE NULL;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Grant all roles the permissions of routes that previously only required authentication.
+	permissionsToAdd := []string{
+		"conversations:update_custom_attributes",
+		"contacts:update_custom_attributes",
+		"macros:read",
+		"macros:apply",
+		"tags:read",
+		"inboxes:read",
+		"ai:use",
+	}
+	for _, permission := range permissionsToAdd {
+		_, err = db.Exec(`
+			UPDATE roles
+			SET permissions = array_append(permissions, $1)
+			WHERE NOT ($1 = ANY(permissions));
+		`, permission)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
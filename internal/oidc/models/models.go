@@ -1,6 +1,10 @@
 package models
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"slices"
 	"time"
 )
 
@@ -17,6 +21,14 @@ type OIDC struct {
 	ProviderURL     string    `db:"provider_url" json:"provider_url"`
 	RedirectURI     string    `db:"-" json:"redirect_uri"`
 	ProviderLogoURL string    `db:"-" json:"logo_url"`
+
+	// ProvisionUsers creates agents that do not exist yet on their first login.
+	ProvisionUsers bool `db:"provision_users" json:"provision_users"`
+	// DefaultRole is assigned to provisioned agents when none of their groups map to a role.
+	DefaultRole string `db:"default_role" json:"default_role"`
+	// GroupsClaim is the ID token claim holding the groups of the user, nested claims are separated by dots, e.g. `realm_access.roles`.
+	GroupsClaim   string        `db:"groups_claim" json:"groups_claim"`
+	GroupMappings GroupMappings `db:"group_mappings" json:"group_mappings"`
 }
 
 // providerLogos holds known provider logos.
@@ -33,3 +45,66 @@ func (oidc *OIDC) SetProviderLogo() {
 		}
 	}
 }
+
+// GroupMapping maps a group from the ID token to libredesk roles and teams.
+type GroupMapping struct {
+	Group string   `json:"group"`
+	Roles []string `json:"roles"`
+	Teams []string `json:"teams"`
+}
+
+type GroupMappings []GroupMapping
+
+// Value implements the driver.Valuer interface.
+func (gm GroupMappings) Value() (driver.Value, error) {
+	if gm == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(gm)
+}
+
+// Scan implements the sql.Scanner interface.
+func (gm *GroupMappings) Scan(src any) error {
+	var data []byte
+
+	switch v := src.(type) {
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		return fmt.Errorf("unsupported type: %T", src)
+	}
+	return json.Unmarshal(data, gm)
+}
+
+// MapsRoles returns true if any of the mappings assigns roles.
+func (gm GroupMappings) MapsRoles() bool {
+	return slices.ContainsFunc(gm, func(m GroupMapping) bool { return len(m.Roles) > 0 })
+}
+
+// MapsTeams returns true if any of the mappings assigns teams.
+func (gm GroupMappings) MapsTeams() bool {
+	return slices.ContainsFunc(gm, func(m GroupMapping) bool { return len(m.Teams) > 0 })
+}
+
+// Resolve returns the deduplicated roles and teams the given groups map to.
+func (gm GroupMappings) Resolve(groups []string) ([]string, []string) {
+	var roles, teams = []string{}, []string{}
+	for _, m := range gm {
+		if !slices.Contains(groups, m.Group) {
+			continue
+		}
+		for _, role := range m.Roles {
+			if !slices.Contains(roles, role) {
+				roles = append(roles, role)
+			}
+		}
+		for _, team := range m.Teams {
+			if !slices.Contains(teams, team) {
+				teams = append(teams, team)
+			}
+		}
+	}
+	return roles, teams
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGroupMappingsResolve(t *testing.T) {
+	gm := GroupMappings{
+		{Group: "support", Roles: []string{"Agent"}, Teams: []string{"Support"}},
+		{Group: "billing", Roles: []string{"Agent"}, Teams: []string{"Billing"}},
+		{Group: "admins", Roles: []string{"Admin"}},
+	}
+
+	tests := []struct {
+		name   string
+		groups []string
+		roles  []string
+		teams  []string
+	}{
+		{"no groups", nil, []string{}, []string{}},
+		{"unmapped group", []string{"sales"}, []string{}, []string{}},
+		{"single group", []string{"support"}, []string{"Agent"}, []string{"Support"}},
+		{"deduplicated roles", []string{"support", "billing"}, []string{"Agent"}, []string{"Support", "Billing"}},
+		{"role only", []string{"admins", "support"}, []string{"Agent", "Admin"}, []string{"Support"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			roles, teams := gm.Resolve(tt.groups)
+			if !reflect.DeepEqual(roles, tt.roles) {
+				t.Errorf("roles = %v, want %v", roles, tt.roles)
+			}
+			if !reflect.DeepEqual(teams, tt.teams) {
+				t.Errorf("teams = %v, want %v", teams, tt.teams)
+			}
+		})
+	}
+
+	if !gm.MapsRoles() || !gm.MapsTeams() {
+		t.Error("expected mappings to map roles and teams")
+	}
+	if (GroupMappings{{Group: "admins", Roles: []string{"Admin"}}}).MapsTeams() {
+		t.Error("expected mappings without teams to not map teams")
+	}
+}
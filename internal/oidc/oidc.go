@@ -19,6 +19,9 @@ var (
 	//go:embed queries.sql
 	efs         embed.FS
 	redirectURL = "/api/v1/oidc/%d/finish"
+
+	// defaultGroupsClaim is the ID token claim used for group mappings when none is set.
+	defaultGroupsClaim = "groups"
 )
 
 // Manager handles oidc-related operations.
@@ -126,8 +129,9 @@ func (o *Manager) GetAllEnabled() ([]models.OIDC, error) {
 
 // Create adds a new oidc.
 func (o *Manager) Create(oidc models.OIDC) (models.OIDC, error) {
+	normalizeGroupMappings(&oidc)
 	var createdOIDC models.OIDC
-	if err := o.q.InsertOIDC.Get(&createdOIDC, oidc.Name, oidc.Provider, oidc.ProviderURL, oidc.ClientID, oidc.ClientSecret); err != nil {
+	if err := o.q.InsertOIDC.Get(&createdOIDC, oidc.Name, oidc.Provider, oidc.ProviderURL, oidc.ClientID, oidc.ClientSecret, oidc.ProvisionUsers, oidc.DefaultRole, oidc.GroupsClaim, oidc.GroupMappings); err != nil {
 		o.lo.Error("error inserting oidc", "error", err)
 		return models.OIDC{}, envelope.NewError(envelope.GeneralError, o.i18n.Ts("globals.messages.errorCreating", "name", "{globals.terms.oidcProvider}"), nil)
 	}
@@ -143,8 +147,9 @@ func (o *Manager) Update(id int, oidc models.OIDC) (models.OIDC, error) {
 	if oidc.ClientSecret == "" {
 		oidc.ClientSecret = current.ClientSecret
 	}
+	normalizeGroupMappings(&oidc)
 	var updatedOIDC models.OIDC
-	if err := o.q.UpdateOIDC.Get(&updatedOIDC, id, oidc.Name, oidc.Provider, oidc.ProviderURL, oidc.ClientID, oidc.ClientSecret, oidc.Enabled); err != nil {
+	if err := o.q.UpdateOIDC.Get(&updatedOIDC, id, oidc.Name, oidc.Provider, oidc.ProviderURL, oidc.ClientID, oidc.ClientSecret, oidc.Enabled, oidc.ProvisionUsers, oidc.DefaultRole, oidc.GroupsClaim, oidc.GroupMappings); err != nil {
 		o.lo.Error("error updating oidc", "error", err)
 		return models.OIDC{}, envelope.NewError(envelope.GeneralError, o.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.oidcProvider}"), nil)
 	}
@@ -159,3 +164,21 @@ func (o *Manager) Delete(id int) error {
 	}
 	return nil
 }
+
+// normalizeGroupMappings trims the groups claim and mappings, dropping mappings without a group.
+func normalizeGroupMappings(oidc *models.OIDC) {
+	oidc.DefaultRole = strings.TrimSpace(oidc.DefaultRole)
+	oidc.GroupsClaim = strings.TrimSpace(oidc.GroupsClaim)
+	if oidc.GroupsClaim == "" {
+		oidc.GroupsClaim = defaultGroupsClaim
+	}
+	mappings := make(models.GroupMappings, 0, len(oidc.GroupMappings))
+	for _, m := range oidc.GroupMappings {
+		m.Group = strings.TrimSpace(m.Group)
+		if m.Group == "" {
+			continue
+		}
+		mappings = append(mappings, m)
+	}
+	oidc.GroupMappings = mappings
+}
@@ -1,5 +1,5 @@
 -- name: get-all-oidc
-SELECT id, created_at, updated_at, name, provider, client_id, client_secret, provider_url, enabled FROM oidc order by updated_at desc;
+SELECT id, created_at, updated_at, name, provider, client_id, client_secret, provider_url, enabled, provision_users, default_role, groups_claim, group_mappings FROM oidc order by updated_at desc;
 
 -- name: get-all-enabled
 SELECT id, name, enabled, provider, client_id, updated_at FROM oidc WHERE enabled = true order by updated_at desc;
@@ -8,13 +8,13 @@ SELECT id, name, enabled, provider, client_id, updated_at FROM oidc WHERE enable
 SELECT * FROM oidc WHERE id = $1;
 
 -- name: insert-oidc
-INSERT INTO oidc (name, provider, provider_url, client_id, client_secret) 
-VALUES ($1, $2, $3, $4, $5)
+INSERT INTO oidc (name, provider, provider_url, client_id, client_secret, provision_users, default_role, groups_claim, group_mappings) 
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 RETURNING *;
 
 -- name: update-oidc
 UPDATE oidc 
-SET name = $2, provider = $3, provider_url = $4, client_id = $5, client_secret = $6, enabled = $7, updated_at = now()
+SET name = $2, provider = $3, provider_url = $4, client_id = $5, client_secret = $6, enabled = $7, provision_users = $8, default_role = $9, groups_claim = $10, group_mappings = $11, updated_at = now()
 WHERE id = $1
 RETURNING *;
 
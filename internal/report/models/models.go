@@ -1,5 +1,11 @@
 package models
 
+import (
+	"time"
+
+	"github.com/volatiletech/null/v9"
+)
+
 type OverviewSLA struct {
 	FirstResponseMetCount      int     `json:"first_response_met_count" db:"first_response_met_count"`
 	FirstResponseBreachedCount int     `json:"first_response_breached_count" db:"first_response_breached_count"`
@@ -11,3 +17,62 @@ type OverviewSLA struct {
 	ResolutionBreachedCount    int     `json:"resolution_breached_count" db:"resolution_breached_count"`
 	AvgResolutionTimeSec       float64 `json:"avg_resolution_time_sec" db:"avg_resolution_time_sec"`
 }
+
+// SLACompliance represents SLA compliance of a metric for a group i.e. policy, team or agent.
+type SLACompliance struct {
+	GroupID            null.Int `json:"group_id" db:"group_id"`
+	GroupName          string   `json:"group_name" db:"group_name"`
+	Metric             string   `json:"metric" db:"metric"`
+	TotalCount         int      `json:"total_count" db:"total_count"`
+	MetCount           int      `json:"met_count" db:"met_count"`
+	BreachedCount      int      `json:"breached_count" db:"breached_count"`
+	CompliancePercent  float64  `json:"compliance_percent" db:"compliance_percent"`
+	AvgTimeToBreachSec float64  `json:"avg_time_to_breach_sec" db:"avg_time_to_breach_sec"`
+}
+
+// SLABreach represents a breached SLA metric of a conversation.
+type SLABreach struct {
+	Total                       int         `json:"-" db:"total"`
+	ConversationUUID            string      `json:"conversation_uuid" db:"conversation_uuid"`
+	ConversationReferenceNumber string      `json:"conversation_reference_number" db:"conversation_reference_number"`
+	ConversationSubject         null.String `json:"conversation_subject" db:"conversation_subject"`
+	SLAPolicyID                 int         `json:"sla_policy_id" db:"sla_policy_id"`
+	SLAPolicyName               string      `json:"sla_policy_name" db:"sla_policy_name"`
+	Metric                      string      `json:"metric" db:"metric"`
+	StartedAt                   time.Time   `json:"started_at" db:"started_at"`
+	DeadlineAt                  null.Time   `json:"deadline_at" db:"deadline_at"`
+	BreachedAt                  null.Time   `json:"breached_at" db:"breached_at"`
+	MetAt                       null.Time   `json:"met_at" db:"met_at"`
+	AssignedTeamID              null.Int    `json:"assigned_team_id" db:"assigned_team_id"`
+	AssignedTeamName            null.String `json:"assigned_team_name" db:"assigned_team_name"`
+	AssignedUserID              null.Int    `json:"assigned_user_id" db:"assigned_user_id"`
+	AssignedUserName            null.String `json:"assigned_user_name" db:"assigned_user_name"`
+}
+
+// CSATReport represents the CSAT survey scores of a survey type for a group i.e. agent, team or inbox.
+type CSATReport struct {
+	GroupID        null.Int     `json:"group_id" db:"group_id"`
+	GroupName      string       `json:"group_name" db:"group_name"`
+	SurveyType     string       `json:"survey_type" db:"survey_type"`
+	ResponseCount  int          `json:"response_count" db:"response_count"`
+	AvgRating      float64      `json:"avg_rating" db:"avg_rating"`
+	SatisfiedCount int          `json:"satisfied_count" db:"satisfied_count"`
+	PromoterCount  int          `json:"promoter_count" db:"promoter_count"`
+	PassiveCount   int          `json:"passive_count" db:"passive_count"`
+	DetractorCount int          `json:"detractor_count" db:"detractor_count"`
+	CSATPercent    null.Float64 `json:"csat_percent" db:"csat_percent"`
+	NPS            null.Float64 `json:"nps" db:"nps"`
+}
+
+// AIUsageReport represents the AI requests and tokens of a group i.e. day, agent, prompt or provider.
+type AIUsageReport struct {
+	GroupID          null.Int `json:"group_id" db:"group_id"`
+	GroupName        string   `json:"group_name" db:"group_name"`
+	RequestCount     int      `json:"request_count" db:"request_count"`
+	ErrorCount       int      `json:"error_count" db:"error_count"`
+	RateLimitedCount int      `json:"rate_limited_count" db:"rate_limited_count"`
+	InputTokens      int64    `json:"input_tokens" db:"input_tokens"`
+	OutputTokens     int64    `json:"output_tokens" db:"output_tokens"`
+	TotalTokens      int64    `json:"total_tokens" db:"total_tokens"`
+	AvgLatencyMs     int64    `json:"avg_latency_ms" db:"avg_latency_ms"`
+}
@@ -70,7 +70,8 @@ FROM
     conversations c
     INNER JOIN conversation_statuses s ON c.status_id = s.id
 WHERE
-    s.name not in ('Resolved', 'Closed');
+    s.name not in ('Resolved', 'Closed')
+    AND %s;
 
 -- name: get-overview-sla-counts
 WITH first_and_resolution AS (
@@ -96,7 +97,7 @@ WITH first_and_resolution AS (
                 EXTRACT(
                     EPOCH
                     FROM
-                        (first_response_met_at - created_at)
+                        (first_response_met_at - a.created_at)
                 )
             ) FILTER (
                 WHERE
@@ -109,7 +110,7 @@ WITH first_and_resolution AS (
                 EXTRACT(
                     EPOCH
                     FROM
-                        (resolution_met_at - created_at)
+                        (resolution_met_at - a.created_at)
                 )
             ) FILTER (
                 WHERE
@@ -118,44 +119,49 @@ WITH first_and_resolution AS (
             0
         ) AS avg_resolution_time_sec
     FROM
-        applied_slas
+        applied_slas a
+        INNER JOIN conversations c ON c.id = a.conversation_id
     WHERE
-        created_at >= CASE
+        a.created_at >= CASE
             WHEN %d = 0 THEN CURRENT_DATE
             ELSE NOW() - INTERVAL '%d days'
         END
+        AND %s
 ),
 next_response AS (
     SELECT
         COUNT(*) FILTER (
             WHERE
-                met_at IS NOT NULL
+                e.met_at IS NOT NULL
         ) AS next_response_met_count,
         COUNT(*) FILTER (
             WHERE
-                breached_at IS NOT NULL
+                e.breached_at IS NOT NULL
         ) AS next_response_breached_count,
         COALESCE(
             AVG(
                 EXTRACT(
                     EPOCH
                     FROM
-                        (met_at - created_at)
+                        (e.met_at - e.created_at)
                 )
             ) FILTER (
                 WHERE
-                    met_at IS NOT NULL
+                    e.met_at IS NOT NULL
             ),
             0
         ) AS avg_next_response_time_sec
     FROM
-        sla_events
+        sla_events e
+        INNER JOIN applied_slas a ON a.id = e.applied_sla_id
+        INNER JOIN conversations c ON c.id = a.conversation_id
     WHERE
-        created_at >= CASE
+        e.created_at >= CASE
             WHEN %d = 0 THEN CURRENT_DATE
             ELSE NOW() - INTERVAL '%d days'
         END
-        AND type = 'next_response'
+        AND e.type = 'next_response'
+        AND %s
 )
 SELECT
     fas.first_response_met_count,
@@ -187,6 +193,7 @@ WITH new_conversations AS (
                     WHEN %d = 0 THEN CURRENT_DATE
                     ELSE NOW() - INTERVAL '%d days'
                 END
+                AND %s
             GROUP BY
                 date
             ORDER BY
@@ -209,6 +216,7 @@ resolved_conversations AS (
                     WHEN %d = 0 THEN CURRENT_DATE
                     ELSE NOW() - INTERVAL '%d days'
                 END
+                AND %s
             GROUP BY
                 date
             ORDER BY
@@ -231,4 +239,222 @@ SELECT
             FROM
                 resolved_conversations
         )
-    ) AS result;
\ No newline at end of file
+    ) AS result;
+
+-- name: get-sla-compliance
+-- Compliance per metric over a date range, grouped by the passed group expressions (policy, team, agent).
+WITH sla_metrics AS (
+    SELECT
+        a.sla_policy_id,
+        a.conversation_id,
+        a.created_at AS started_at,
+        'first_response' AS metric,
+        a.first_response_deadline_at AS deadline_at,
+        a.first_response_met_at AS met_at,
+        a.first_response_breached_at AS breached_at
+    FROM
+        applied_slas a
+    WHERE
+        a.first_response_deadline_at IS NOT NULL
+    UNION ALL
+    SELECT
+        a.sla_policy_id,
+        a.conversation_id,
+        a.created_at,
+        'resolution',
+        a.resolution_deadline_at,
+        a.resolution_met_at,
+        a.resolution_breached_at
+    FROM
+        applied_slas a
+    WHERE
+        a.resolution_deadline_at IS NOT NULL
+    UNION ALL
+    SELECT
+        e.sla_policy_id,
+        a.conversation_id,
+        e.created_at,
+        'next_response',
+        e.deadline_at,
+        e.met_at,
+        e.breached_at
+    FROM
+        sla_events e
+        INNER JOIN applied_slas a ON a.id = e.applied_sla_id
+    WHERE
+        e.type = 'next_response'
+)
+SELECT
+    %s AS group_id,
+    %s AS group_name,
+    m.metric,
+    COUNT(*) AS total_count,
+    COUNT(*) FILTER (WHERE m.breached_at IS NULL AND m.met_at IS NOT NULL) AS met_count,
+    COUNT(*) FILTER (WHERE m.breached_at IS NOT NULL) AS breached_count,
+    COALESCE(
+        ROUND(
+            100.0 * COUNT(*) FILTER (WHERE m.breached_at IS NULL AND m.met_at IS NOT NULL)
+            / NULLIF(COUNT(*) FILTER (WHERE m.met_at IS NOT NULL OR m.breached_at IS NOT NULL), 0),
+            2
+        ),
+        0
+    ) AS compliance_percent,
+    COALESCE(
+        AVG(EXTRACT(EPOCH FROM (m.breached_at - m.started_at))) FILTER (WHERE m.breached_at IS NOT NULL),
+        0
+    ) AS avg_time_to_breach_sec
+FROM
+    sla_metrics m
+    INNER JOIN conversations c ON c.id = m.conversation_id
+    INNER JOIN sla_policies p ON p.id = m.sla_policy_id
+    LEFT JOIN teams t ON t.id = c.assigned_team_id
+    LEFT JOIN users u ON u.id = c.assigned_user_id
+WHERE
+    m.started_at >= $1::date
+    AND m.started_at < $2::date + 1
+    AND %s
+GROUP BY
+    1, 2, m.metric
+ORDER BY
+    2, m.metric;
+
+-- name: get-sla-breaches
+-- Breached SLA metrics over a date range with the deadline and the actual time the metric was met.
+WITH sla_breaches AS (
+    SELECT
+        a.sla_policy_id,
+        a.conversation_id,
+        a.created_at AS started_at,
+        'first_response' AS metric,
+        a.first_response_deadline_at AS deadline_at,
+        a.first_response_breached_at AS breached_at,
+        c.first_reply_at AS met_at
+    FROM
+        applied_slas a
+        INNER JOIN conversations c ON c.id = a.conversation_id
+    WHERE
+        a.first_response_breached_at IS NOT NULL
+    UNION ALL
+    SELECT
+        a.sla_policy_id,
+        a.conversation_id,
+        a.created_at,
+        'resolution',
+        a.resolution_deadline_at,
+        a.resolution_breached_at,
+        c.resolved_at
+    FROM
+        applied_slas a
+        INNER JOIN conversations c ON c.id = a.conversation_id
+    WHERE
+        a.resolution_breached_at IS NOT NULL
+    UNION ALL
+    SELECT
+        e.sla_policy_id,
+        a.conversation_id,
+        e.created_at,
+        'next_response',
+        e.deadline_at,
+        e.breached_at,
+        e.met_at
+    FROM
+        sla_events e
+        INNER JOIN applied_slas a ON a.id = e.applied_sla_id
+    WHERE
+        e.type = 'next_response'
+        AND e.breached_at IS NOT NULL
+)
+SELECT
+    COUNT(*) OVER () AS total,
+    c.uuid AS conversation_uuid,
+    c.reference_number AS conversation_reference_number,
+    c.subject AS conversation_subject,
+    p.id AS sla_policy_id,
+    p.name AS sla_policy_name,
+    b.metric,
+    b.started_at,
+    b.deadline_at,
+    b.breached_at,
+    b.met_at,
+    c.assigned_team_id,
+    t.name AS assigned_team_name,
+    c.assigned_user_id,
+    NULLIF(CONCAT(u.first_name, ' ', u.last_name), ' ') AS assigned_user_name
+FROM
+    sla_breaches b
+    INNER JOIN conversations c ON c.id = b.conversation_id
+    INNER JOIN sla_policies p ON p.id = b.sla_policy_id
+    LEFT JOIN teams t ON t.id = c.assigned_team_id
+    LEFT JOIN users u ON u.id = c.assigned_user_id
+WHERE
+    b.started_at >= $1::date
+    AND b.started_at < $2::date + 1
+    AND ($3 = '' OR b.metric = $3)
+    AND ($4 = 0 OR b.sla_policy_id = $4)
+    AND ($5 = 0 OR c.assigned_team_id = $5)
+    AND ($6 = 0 OR c.assigned_user_id = $6)
+    AND %s
+ORDER BY
+    b.breached_at DESC
+LIMIT $7 OFFSET $8;
+
+-- name: get-csat-report
+-- CSAT (share of 4-5 star ratings), NPS (promoters minus detractors) and CES averages of the responses submitted in a date range,
+-- grouped by the passed group expressions (agent, team, inbox) and survey type as scores of different survey types are not comparable.
+SELECT
+    %s AS group_id,
+    %s AS group_name,
+    r.survey_type,
+    COUNT(*) AS response_count,
+    ROUND(AVG(r.rating)::numeric, 2) AS avg_rating,
+    COUNT(*) FILTER (WHERE r.survey_type = 'stars' AND r.rating >= 4) AS satisfied_count,
+    COUNT(*) FILTER (WHERE r.survey_type = 'nps' AND r.rating >= 9) AS promoter_count,
+    COUNT(*) FILTER (WHERE r.survey_type = 'nps' AND r.rating BETWEEN 7 AND 8) AS passive_count,
+    COUNT(*) FILTER (WHERE r.survey_type = 'nps' AND r.rating <= 6) AS detractor_count,
+    CASE WHEN r.survey_type = 'stars' THEN
+        ROUND(COUNT(*) FILTER (WHERE r.rating >= 4) * 100.0 / COUNT(*), 2)
+    END AS csat_percent,
+    CASE WHEN r.survey_type = 'nps' THEN
+        ROUND((COUNT(*) FILTER (WHERE r.rating >= 9) - COUNT(*) FILTER (WHERE r.rating <= 6)) * 100.0 / COUNT(*), 2)
+    END AS nps
+FROM
+    csat_responses r
+    INNER JOIN conversations c ON c.id = r.conversation_id
+    LEFT JOIN users u ON u.id = c.assigned_user_id
+    LEFT JOIN teams t ON t.id = c.assigned_team_id
+    LEFT JOIN inboxes i ON i.id = c.inbox_id
+WHERE
+    r.response_timestamp IS NOT NULL
+    AND r.response_timestamp >= $1::date
+    AND r.response_timestamp < $2::date + 1
+    AND %s
+GROUP BY
+    1, 2, r.survey_type
+ORDER BY
+    2, r.survey_type;
+
+-- name: get-ai-usage-report
+-- AI requests, tokens and average latency of the requests sent to a provider in a date range, grouped by the passed group expressions (day, agent, prompt, provider).
+SELECT
+    %s AS group_id,
+    %s AS group_name,
+    COUNT(*) AS request_count,
+    COUNT(*) FILTER (WHERE a.outcome = 'error') AS error_count,
+    COUNT(*) FILTER (WHERE a.outcome = 'rate_limited') AS rate_limited_count,
+    COALESCE(SUM(a.input_tokens), 0) AS input_tokens,
+    COALESCE(SUM(a.output_tokens), 0) AS output_tokens,
+    COALESCE(SUM(a.input_tokens + a.output_tokens), 0) AS total_tokens,
+    COALESCE(ROUND(AVG(a.latency_ms) FILTER (WHERE a.outcome != 'rate_limited')), 0) AS avg_latency_ms
+FROM
+    ai_usage a
+    LEFT JOIN users u ON u.id = a.user_id
+    LEFT JOIN ai_providers p ON p.id = a.provider_id
+    LEFT JOIN conversations c ON c.id = a.conversation_id
+WHERE
+    a.created_at >= $1::date
+    AND a.created_at < $2::date + 1
+    AND %s
+GROUP BY
+    1, 2
+ORDER BY
+    2;
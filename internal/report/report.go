@@ -7,7 +7,9 @@ import (
 	"embed"
 	"encoding/json"
 	"fmt"
+	"time"
 
+	authzModels "github.com/abhinavxd/libredesk/internal/authz/models"
 	"github.com/abhinavxd/libredesk/internal/dbutil"
 	"github.com/abhinavxd/libredesk/internal/envelope"
 	"github.com/abhinavxd/libredesk/internal/report/models"
@@ -21,6 +23,10 @@ var (
 	efs embed.FS
 )
 
+const (
+	maxReportPageSize = 100
+)
+
 type Manager struct {
 	q    queries
 	lo   *logf.Logger
@@ -40,6 +46,64 @@ type queries struct {
 	GetOverviewCharts string `query:"get-overview-charts"`
 	GetOverviewCounts string `query:"get-overview-counts"`
 	GetOverviewSLA    string `query:"get-overview-sla-counts"`
+	GetSLACompliance  string `query:"get-sla-compliance"`
+	GetSLABreaches    string `query:"get-sla-breaches"`
+	GetCSATReport     string `query:"get-csat-report"`
+	GetAIUsageReport  string `query:"get-ai-usage-report"`
+}
+
+// SLA compliance report group by options.
+const (
+	SLAGroupByPolicy = "policy"
+	SLAGroupByTeam   = "team"
+	SLAGroupByAgent  = "agent"
+	SLAGroupByMetric = "metric"
+)
+
+// slaGroupByExprs maps the SLA compliance report group by options to their group ID and group name SQL expressions.
+var slaGroupByExprs = map[string][2]string{
+	SLAGroupByPolicy: {"p.id", "p.name"},
+	SLAGroupByTeam:   {"t.id", "COALESCE(t.name, '')"},
+	SLAGroupByAgent:  {"u.id", "COALESCE(NULLIF(CONCAT(u.first_name, ' ', u.last_name), ' '), '')"},
+	SLAGroupByMetric: {"NULL::INT", "''"},
+}
+
+// CSAT report group by options.
+const (
+	CSATGroupByAgent = "agent"
+	CSATGroupByTeam  = "team"
+	CSATGroupByInbox = "inbox"
+)
+
+// csatGroupByExprs maps the CSAT report group by options to their group ID and group name SQL expressions.
+var csatGroupByExprs = map[string][2]string{
+	CSATGroupByAgent: slaGroupByExprs[SLAGroupByAgent],
+	CSATGroupByTeam:  slaGroupByExprs[SLAGroupByTeam],
+	CSATGroupByInbox: {"i.id", "COALESCE(i.name, '')"},
+}
+
+// AI usage report group by options.
+const (
+	AIUsageGroupByDay      = "day"
+	AIUsageGroupByAgent    = "agent"
+	AIUsageGroupByPrompt   = "prompt"
+	AIUsageGroupByProvider = "provider"
+)
+
+// aiUsageGroupByExprs maps the AI usage report group by options to their group ID and group name SQL expressions.
+var aiUsageGroupByExprs = map[string][2]string{
+	AIUsageGroupByDay:      {"NULL::INT", "to_char(date_trunc('day', a.created_at), 'YYYY-MM-DD')"},
+	AIUsageGroupByAgent:    slaGroupByExprs[SLAGroupByAgent],
+	AIUsageGroupByPrompt:   {"NULL::INT", "a.prompt_key"},
+	AIUsageGroupByProvider: {"p.id", "COALESCE(p.name, '')"},
+}
+
+// SLABreachFilters holds the filters for the SLA breaches drill-down.
+type SLABreachFilters struct {
+	Metric      string
+	SLAPolicyID int
+	TeamID      int
+	AgentID     int
 }
 
 // New creates and returns a new instance of the Manager.
@@ -56,8 +120,8 @@ func New(opts Opts) (*Manager, error) {
 	}, nil
 }
 
-// GetOverViewCounts returns overview counts
-func (m *Manager) GetOverViewCounts() (json.RawMessage, error) {
+// GetOverViewCounts returns overview counts of the conversations in the scopes.
+func (m *Manager) GetOverViewCounts(scopes authzModels.Scopes) (json.RawMessage, error) {
 	var counts = json.RawMessage{}
 	tx, err := m.db.BeginTxx(context.Background(), &sql.TxOptions{
 		ReadOnly: true,
@@ -68,7 +132,7 @@ func (m *Manager) GetOverViewCounts() (json.RawMessage, error) {
 	}
 	defer tx.Rollback()
 
-	if err := tx.Get(&counts, m.q.GetOverviewCounts); err != nil {
+	if err := tx.Get(&counts, fmt.Sprintf(m.q.GetOverviewCounts, conversationScope(scopes))); err != nil {
 		m.lo.Error("error fetching overview counts", "error", err)
 		return nil, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetchingCount", "name", "{globals.terms.overview}"), nil)
 	}
@@ -81,8 +145,8 @@ func (m *Manager) GetOverViewCounts() (json.RawMessage, error) {
 	return counts, nil
 }
 
-// GetOverviewSLA returns overview SLA data
-func (m *Manager) GetOverviewSLA(days int) (json.RawMessage, error) {
+// GetOverviewSLA returns overview SLA data of the conversations in the scopes.
+func (m *Manager) GetOverviewSLA(days int, scopes authzModels.Scopes) (json.RawMessage, error) {
 	tx, err := m.db.BeginTxx(context.Background(), &sql.TxOptions{
 		ReadOnly: true,
 	})
@@ -94,7 +158,8 @@ func (m *Manager) GetOverviewSLA(days int) (json.RawMessage, error) {
 
 	var result models.OverviewSLA
 	// Format query with days parameter for both CTEs
-	query := fmt.Sprintf(m.q.GetOverviewSLA, days, days, days, days)
+	scope := conversationScope(scopes)
+	query := fmt.Sprintf(m.q.GetOverviewSLA, days, days, scope, days, days, scope)
 	if err := tx.Get(&result, query); err != nil {
 		m.lo.Error("error fetching overview SLA data", "error", err)
 		return nil, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetchingCount", "name", "{globals.terms.overview}"), nil)
@@ -114,8 +179,8 @@ func (m *Manager) GetOverviewSLA(days int) (json.RawMessage, error) {
 	return slaData, nil
 }
 
-// GetOverviewChart returns overview chart data
-func (m *Manager) GetOverviewChart(days int) (json.RawMessage, error) {
+// GetOverviewChart returns overview chart data of the conversations in the scopes.
+func (m *Manager) GetOverviewChart(days int, scopes authzModels.Scopes) (json.RawMessage, error) {
 	var stats = json.RawMessage{}
 	tx, err := m.db.BeginTxx(context.Background(), &sql.TxOptions{
 		ReadOnly: true,
@@ -126,10 +191,118 @@ func (m *Manager) GetOverviewChart(days int) (json.RawMessage, error) {
 	}
 	defer tx.Rollback()
 
-	query := fmt.Sprintf(m.q.GetOverviewCharts, days, days, days, days)
+	scope := conversationScope(scopes)
+	query := fmt.Sprintf(m.q.GetOverviewCharts, days, days, scope, days, days, scope)
 	if err := tx.Get(&stats, query); err != nil {
 		m.lo.Error("error fetching overview charts", "error", err)
 		return nil, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetchingChart", "name", "{globals.terms.overview}"), nil)
 	}
 	return stats, nil
 }
+
+// GetSLACompliance returns SLA compliance per metric of the conversations in the scopes between the from and to dates (inclusive)
+// grouped by policy, team, agent or just metric.
+func (m *Manager) GetSLACompliance(from, to time.Time, groupBy string, scopes authzModels.Scopes) ([]models.SLACompliance, error) {
+	exprs, ok := slaGroupByExprs[groupBy]
+	if !ok {
+		return nil, envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.invalid", "name", "`group_by`"), nil)
+	}
+
+	tx, err := m.db.BeginTxx(context.Background(), &sql.TxOptions{
+		ReadOnly: true,
+	})
+	if err != nil {
+		m.lo.Error("error starting db txn", "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.sla}"), nil)
+	}
+	defer tx.Rollback()
+
+	var result = make([]models.SLACompliance, 0)
+	query := fmt.Sprintf(m.q.GetSLACompliance, exprs[0], exprs[1], conversationScope(scopes))
+	if err := tx.Select(&result, query, from.Format(time.DateOnly), to.Format(time.DateOnly)); err != nil {
+		m.lo.Error("error fetching SLA compliance", "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.sla}"), nil)
+	}
+	return result, nil
+}
+
+// GetSLABreaches returns a page of breached SLA metrics of the conversations in the scopes between the from and to dates (inclusive).
+func (m *Manager) GetSLABreaches(from, to time.Time, filters SLABreachFilters, scopes authzModels.Scopes, page, pageSize int) ([]models.SLABreach, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > maxReportPageSize {
+		pageSize = maxReportPageSize
+	}
+
+	tx, err := m.db.BeginTxx(context.Background(), &sql.TxOptions{
+		ReadOnly: true,
+	})
+	if err != nil {
+		m.lo.Error("error starting db txn", "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.sla}"), nil)
+	}
+	defer tx.Rollback()
+
+	var result = make([]models.SLABreach, 0)
+	if err := tx.Select(&result, fmt.Sprintf(m.q.GetSLABreaches, conversationScope(scopes)),
+		from.Format(time.DateOnly),
+		to.Format(time.DateOnly),
+		filters.Metric,
+		filters.SLAPolicyID,
+		filters.TeamID,
+		filters.AgentID,
+		pageSize,
+		(page-1)*pageSize,
+	); err != nil {
+		m.lo.Error("error fetching SLA breaches", "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.sla}"), nil)
+	}
+	return result, nil
+}
+
+// GetCSATReport returns CSAT, NPS and CES scores of the responses to conversations in the scopes submitted between the from and to dates (inclusive)
+// grouped by agent, team or inbox.
+func (m *Manager) GetCSATReport(from, to time.Time, groupBy string, scopes authzModels.Scopes) ([]models.CSATReport, error) {
+	exprs, ok := csatGroupByExprs[groupBy]
+	if !ok {
+		return nil, envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.invalid", "name", "`group_by`"), nil)
+	}
+	tx, err := m.db.BeginTxx(context.Background(), &sql.TxOptions{
+		ReadOnly: true,
+	})
+	if err != nil {
+		m.lo.Error("error starting db txn", "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.csat}"), nil)
+	}
+	defer tx.Rollback()
+
+	var result = make([]models.CSATReport, 0)
+	query := fmt.Sprintf(m.q.GetCSATReport, exprs[0], exprs[1], conversationScope(scopes))
+	if err := tx.Select(&result, query, from.Format(time.DateOnly), to.Format(time.DateOnly)); err != nil {
+		m.lo.Error("error fetching CSAT report", "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.csat}"), nil)
+	}
+	return result, nil
+}
+
+// GetAIUsageReport returns the AI requests, tokens and latency in a date range grouped by day, agent, prompt or provider,
+// restricted to prompts sent in conversations in the scopes unless the scopes are unrestricted.
+func (m *Manager) GetAIUsageReport(from, to time.Time, groupBy string, scopes authzModels.Scopes) ([]models.AIUsageReport, error) {
+	exprs, ok := aiUsageGroupByExprs[groupBy]
+	if !ok {
+		return nil, envelope.NewError(envelope.InputError, m.i18n.Ts("globals.messages.invalid", "name", "`group_by`"), nil)
+	}
+	var result = make([]models.AIUsageReport, 0)
+	query := fmt.Sprintf(m.q.GetAIUsageReport, exprs[0], exprs[1], conversationScope(scopes))
+	if err := m.db.Select(&result, query, from.Format(time.DateOnly), to.Format(time.DateOnly)); err != nil {
+		m.lo.Error("error fetching AI usage report", "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.usage}"), nil)
+	}
+	return result, nil
+}
+
+// conversationScope returns the SQL condition restricting report queries to the conversations `c` in the scopes.
+func conversationScope(scopes authzModels.Scopes) string {
+	return scopes.Condition("c.inbox_id", "c.assigned_team_id")
+}
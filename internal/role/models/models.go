@@ -23,4 +23,10 @@ type Role struct {
 	Name        string         `db:"name" json:"name"`
 	Description string         `db:"description" json:"description"`
 	Permissions pq.StringArray `db:"permissions" json:"permissions"`
+	// RequireTwoFactor requires users with the role to set up two-factor authentication to log in.
+	RequireTwoFactor bool `db:"require_two_factor" json:"require_two_factor"`
+	// InboxIDs and TeamIDs restrict the conversation, message and report permissions of the role
+	// to conversations in the inboxes and assigned to the teams, empty for all.
+	InboxIDs pq.Int64Array `db:"inbox_ids" json:"inbox_ids"`
+	TeamIDs  pq.Int64Array `db:"team_ids" json:"team_ids"`
 }
@@ -1,5 +1,5 @@
 -- name: get-all
-SELECT id, created_at, updated_at, name, description, permissions FROM roles;
+SELECT id, created_at, updated_at, name, description, permissions, require_two_factor, inbox_ids, team_ids FROM roles;
 
 -- name: get-role
 SELECT * FROM roles where id = $1;
@@ -8,7 +8,7 @@ SELECT * FROM roles where id = $1;
 DELETE FROM roles where id = $1;
 
 -- name: insert-role
-INSERT INTO roles (name, description, permissions) VALUES ($1, $2, $3) RETURNING *;
+INSERT INTO roles (name, description, permissions, require_two_factor, inbox_ids, team_ids) VALUES ($1, $2, $3, $4, $5, $6) RETURNING *;
 
 -- name: update-role
-UPDATE roles SET name = $2, description = $3, permissions = $4 WHERE id = $1 RETURNING *;
\ No newline at end of file
+UPDATE roles SET name = $2, description = $3, permissions = $4, require_two_factor = $5, inbox_ids = $6, team_ids = $7 WHERE id = $1 RETURNING *;
\ No newline at end of file
@@ -4,6 +4,7 @@ package role
 import (
 	"database/sql"
 	"embed"
+	"slices"
 
 	amodels "github.com/abhinavxd/libredesk/internal/authz/models"
 	"github.com/abhinavxd/libredesk/internal/dbutil"
@@ -110,7 +111,7 @@ func (u *Manager) Create(r models.Role) (models.Role, error) {
 		return models.Role{}, envelope.NewError(envelope.InputError, u.i18n.Ts("globals.messages.empty", "name", u.i18n.P("globals.terms.permission")), nil)
 	}
 	var result models.Role
-	if err := u.q.Insert.Get(&result, r.Name, r.Description, pq.Array(validPermissions)); err != nil {
+	if err := u.q.Insert.Get(&result, r.Name, r.Description, pq.Array(validPermissions), r.RequireTwoFactor, scopeIDs(r.InboxIDs), scopeIDs(r.TeamIDs)); err != nil {
 		if dbutil.IsUniqueViolationError(err) {
 			return models.Role{}, envelope.NewError(envelope.InputError, u.i18n.Ts("globals.messages.errorAlreadyExists", "name", "{globals.terms.role}"), nil)
 		}
@@ -134,12 +135,18 @@ func (u *Manager) Update(id int, r models.Role) (models.Role, error) {
 	if err != nil {
 		return models.Role{}, err
 	}
+	// Only two-factor enforcement can be changed for the `Admin` role.
 	if role.Name == models.RoleAdmin {
-		return models.Role{}, envelope.NewError(envelope.InputError, u.i18n.T("admin.role.cannotModifyAdminRole"), nil)
+		if r.Name != role.Name || !samePermissions(validPermissions, role.Permissions) {
+			return models.Role{}, envelope.NewError(envelope.InputError, u.i18n.T("admin.role.cannotModifyAdminRole"), nil)
+		}
+		r.Description = role.Description
+		validPermissions = role.Permissions
+		r.InboxIDs, r.TeamIDs = nil, nil
 	}
 
 	var result models.Role
-	if err := u.q.Update.Get(&result, id, r.Name, r.Description, pq.Array(validPermissions)); err != nil {
+	if err := u.q.Update.Get(&result, id, r.Name, r.Description, pq.Array(validPermissions), r.RequireTwoFactor, scopeIDs(r.InboxIDs), scopeIDs(r.TeamIDs)); err != nil {
 		u.lo.Error("error updating role", "error", err)
 		return models.Role{}, envelope.NewError(envelope.GeneralError, u.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.role}"), nil)
 	}
@@ -158,3 +165,23 @@ func (u *Manager) filterValidPermissions(permissions []string) ([]string, error)
 	}
 	return validPermissions, nil
 }
+
+// samePermissions returns true if both lists contain the same permissions regardless of order.
+func samePermissions(a, b []string) bool {
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(slices.Compact(a), slices.Compact(b))
+}
+
+// scopeIDs returns the sorted, unique and positive inbox or team IDs of a role scope.
+func scopeIDs(ids []int64) pq.Int64Array {
+	out := make(pq.Int64Array, 0, len(ids))
+	for _, id := range ids {
+		if id > 0 {
+			out = append(out, id)
+		}
+	}
+	slices.Sort(out)
+	return slices.Compact(out)
+}
@@ -0,0 +1,73 @@
+// Package safehttp provides an HTTP client for fetching user supplied URLs that can only reach public addresses.
+package safehttp
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// maxRedirects is the number of redirects followed before giving up.
+const maxRedirects = 5
+
+// ErrDisallowedAddress is returned when a request resolves to a loopback, private, link-local or otherwise non-public address.
+var ErrDisallowedAddress = errors.New("address not allowed")
+
+// NewClient returns an HTTP client that refuses to connect to non-public addresses.
+// Addresses are checked on every connection after DNS resolution, so redirects and DNS rebinding can't reach internal hosts.
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: control,
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			// Proxies from the environment would be dialed instead of the target and bypass the address check.
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+		},
+		CheckRedirect: checkRedirect,
+	}
+}
+
+// control rejects connections to non-public addresses.
+func control(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !IsPublicIP(ip) {
+		return fmt.Errorf("%w: %s", ErrDisallowedAddress, host)
+	}
+	return nil
+}
+
+// checkRedirect only follows a limited number of http and https redirects.
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+	if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+		return fmt.Errorf("unsupported redirect scheme %q", req.URL.Scheme)
+	}
+	return nil
+}
+
+// IsPublicIP returns true if the IP isn't a loopback, private, link-local, multicast or unspecified address.
+func IsPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return false
+	}
+	// Carrier-grade NAT, 100.64.0.0/10.
+	if ip4 := ip.To4(); ip4 != nil && ip4[0] == 100 && ip4[1]&0xc0 == 64 {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,45 @@
+package safehttp
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestIsPublicIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"8.8.8.8", true},
+		{"2606:4700:4700::1111", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+	}
+	for _, tt := range tests {
+		if got := IsPublicIP(net.ParseIP(tt.ip)); got != tt.want {
+			t.Errorf("IsPublicIP(%s) = %v, want %v", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestClientRejectsLoopback(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	_, err := NewClient(time.Second).Get(srv.URL)
+	if !errors.Is(err, ErrDisallowedAddress) {
+		t.Fatalf("Get(%s) error = %v, want ErrDisallowedAddress", srv.URL, err)
+	}
+}
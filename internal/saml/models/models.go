@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+)
+
+// SAML represents a SAML 2.0 identity provider configuration, libredesk acts as the service provider.
+type SAML struct {
+	ID        int       `db:"id" json:"id"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+	Name      string    `db:"name" json:"name"`
+	Enabled   bool      `db:"enabled" json:"enabled"`
+	// IDPMetadataURL is fetched on save and stored as IDPMetadata, if not set the metadata XML is provided directly.
+	IDPMetadataURL string `db:"idp_metadata_url" json:"idp_metadata_url"`
+	IDPMetadata    string `db:"idp_metadata" json:"idp_metadata,omitempty"`
+	// Attributes of the assertion mapped to the user, the NameID is used for the email if the attribute is empty or missing.
+	EmailAttribute     string `db:"email_attribute" json:"email_attribute"`
+	FirstNameAttribute string `db:"first_name_attribute" json:"first_name_attribute"`
+	LastNameAttribute  string `db:"last_name_attribute" json:"last_name_attribute"`
+	// SPCertificate and SPPrivateKey are generated when the provider is created, the certificate is published in the metadata.
+	SPCertificate string `db:"sp_certificate" json:"sp_certificate"`
+	SPPrivateKey  string `db:"sp_private_key" json:"-"`
+	// EntityID is the URL of the service provider metadata, also used as the entity ID.
+	EntityID string `db:"-" json:"entity_id"`
+	ACSURL   string `db:"-" json:"acs_url"`
+}
@@ -0,0 +1,22 @@
+-- name: get-all-saml
+SELECT id, created_at, updated_at, name, enabled, idp_metadata_url, idp_metadata, email_attribute, first_name_attribute, last_name_attribute, sp_certificate, sp_private_key FROM saml order by updated_at desc;
+
+-- name: get-all-enabled
+SELECT id, name, enabled, updated_at FROM saml WHERE enabled = true order by updated_at desc;
+
+-- name: get-saml
+SELECT * FROM saml WHERE id = $1;
+
+-- name: insert-saml
+INSERT INTO saml (name, idp_metadata_url, idp_metadata, email_attribute, first_name_attribute, last_name_attribute, sp_certificate, sp_private_key)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING *;
+
+-- name: update-saml
+UPDATE saml
+SET name = $2, idp_metadata_url = $3, idp_metadata = $4, email_attribute = $5, first_name_attribute = $6, last_name_attribute = $7, enabled = $8, updated_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: delete-saml
+DELETE FROM saml WHERE id = $1;
@@ -0,0 +1,257 @@
+// Package saml manages the SAML 2.0 identity provider configurations used for single sign-on.
+package saml
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"database/sql"
+	"embed"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/abhinavxd/libredesk/internal/dbutil"
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	"github.com/abhinavxd/libredesk/internal/safehttp"
+	"github.com/abhinavxd/libredesk/internal/saml/models"
+	"github.com/crewjam/saml/samlsp"
+	"github.com/jmoiron/sqlx"
+	"github.com/knadh/go-i18n"
+	"github.com/zerodha/logf"
+)
+
+var (
+	//go:embed queries.sql
+	efs         embed.FS
+	metadataURL = "/api/v1/saml/%d/metadata"
+	acsURL      = "/api/v1/saml/%d/acs"
+)
+
+const (
+	metadataFetchTimeout  = 10 * time.Second
+	maxMetadataSize       = 1 << 20
+	spKeyBits             = 2048
+	spCertificateValidity = 10 * 365 * 24 * time.Hour
+)
+
+// Manager handles saml-related operations.
+type Manager struct {
+	q       queries
+	lo      *logf.Logger
+	i18n    *i18n.I18n
+	setting settingsStore
+}
+
+// Opts contains options for initializing the Manager.
+type Opts struct {
+	DB   *sqlx.DB
+	Lo   *logf.Logger
+	I18n *i18n.I18n
+}
+
+// queries contains prepared SQL queries.
+type queries struct {
+	GetAllSAML    *sqlx.Stmt `query:"get-all-saml"`
+	GetAllEnabled *sqlx.Stmt `query:"get-all-enabled"`
+	GetSAML       *sqlx.Stmt `query:"get-saml"`
+	InsertSAML    *sqlx.Stmt `query:"insert-saml"`
+	UpdateSAML    *sqlx.Stmt `query:"update-saml"`
+	DeleteSAML    *sqlx.Stmt `query:"delete-saml"`
+}
+
+type settingsStore interface {
+	GetAppRootURL() (string, error)
+}
+
+// New creates and returns a new instance of the saml Manager.
+func New(opts Opts, setting settingsStore) (*Manager, error) {
+	var q queries
+	if err := dbutil.ScanSQLFile("queries.sql", &q, opts.DB, efs); err != nil {
+		return nil, err
+	}
+	return &Manager{
+		q:       q,
+		lo:      opts.Lo,
+		i18n:    opts.I18n,
+		setting: setting,
+	}, nil
+}
+
+// Get returns a saml provider by id, the private key is only included if includeSecret is set.
+func (s *Manager) Get(id int, includeSecret bool) (models.SAML, error) {
+	var saml models.SAML
+	if err := s.q.GetSAML.Get(&saml, id); err != nil {
+		if err == sql.ErrNoRows {
+			return saml, envelope.NewError(envelope.NotFoundError, s.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.samlProvider}"), nil)
+		}
+		s.lo.Error("error fetching saml", "error", err)
+		return saml, envelope.NewError(envelope.GeneralError, s.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.samlProvider}"), nil)
+	}
+	if err := s.setURLs(&saml); err != nil {
+		return models.SAML{}, err
+	}
+	if !includeSecret {
+		saml.SPPrivateKey = ""
+	}
+	return saml, nil
+}
+
+// GetAll retrieves all saml providers including the private keys.
+func (s *Manager) GetAll() ([]models.SAML, error) {
+	var saml = make([]models.SAML, 0)
+	if err := s.q.GetAllSAML.Select(&saml); err != nil {
+		s.lo.Error("error fetching saml", "error", err)
+		return saml, envelope.NewError(envelope.GeneralError, s.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.samlProvider}"), nil)
+	}
+	for i := range saml {
+		if err := s.setURLs(&saml[i]); err != nil {
+			return nil, err
+		}
+	}
+	return saml, nil
+}
+
+// GetAllEnabled retrieves all enabled saml providers.
+func (s *Manager) GetAllEnabled() ([]models.SAML, error) {
+	var saml = make([]models.SAML, 0)
+	if err := s.q.GetAllEnabled.Select(&saml); err != nil {
+		s.lo.Error("error fetching saml", "error", err)
+		return saml, envelope.NewError(envelope.GeneralError, s.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.samlProvider}"), nil)
+	}
+	return saml, nil
+}
+
+// Create adds a new saml provider with a generated service provider certificate.
+func (s *Manager) Create(saml models.SAML) (models.SAML, error) {
+	if err := s.loadIDPMetadata(&saml); err != nil {
+		return models.SAML{}, err
+	}
+	cert, key, err := generateCertificate(saml.Name)
+	if err != nil {
+		s.lo.Error("error generating saml certificate", "error", err)
+		return models.SAML{}, envelope.NewError(envelope.GeneralError, s.i18n.Ts("globals.messages.errorCreating", "name", "{globals.terms.samlProvider}"), nil)
+	}
+
+	var created models.SAML
+	if err := s.q.InsertSAML.Get(&created, saml.Name, saml.IDPMetadataURL, saml.IDPMetadata, saml.EmailAttribute, saml.FirstNameAttribute, saml.LastNameAttribute, cert, key); err != nil {
+		s.lo.Error("error inserting saml", "error", err)
+		return models.SAML{}, envelope.NewError(envelope.GeneralError, s.i18n.Ts("globals.messages.errorCreating", "name", "{globals.terms.samlProvider}"), nil)
+	}
+	if err := s.setURLs(&created); err != nil {
+		return models.SAML{}, err
+	}
+	created.SPPrivateKey = ""
+	return created, nil
+}
+
+// Update updates a saml provider by id, the metadata is fetched again if a metadata URL is set.
+func (s *Manager) Update(id int, saml models.SAML) (models.SAML, error) {
+	current, err := s.Get(id, false)
+	if err != nil {
+		return models.SAML{}, err
+	}
+	if saml.IDPMetadataURL == "" && strings.TrimSpace(saml.IDPMetadata) == "" {
+		saml.IDPMetadata = current.IDPMetadata
+	}
+	if err := s.loadIDPMetadata(&saml); err != nil {
+		return models.SAML{}, err
+	}
+
+	var updated models.SAML
+	if err := s.q.UpdateSAML.Get(&updated, id, saml.Name, saml.IDPMetadataURL, saml.IDPMetadata, saml.EmailAttribute, saml.FirstNameAttribute, saml.LastNameAttribute, saml.Enabled); err != nil {
+		s.lo.Error("error updating saml", "error", err)
+		return models.SAML{}, envelope.NewError(envelope.GeneralError, s.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.samlProvider}"), nil)
+	}
+	if err := s.setURLs(&updated); err != nil {
+		return models.SAML{}, err
+	}
+	updated.SPPrivateKey = ""
+	return updated, nil
+}
+
+// Delete deletes a saml provider by its id.
+func (s *Manager) Delete(id int) error {
+	if _, err := s.q.DeleteSAML.Exec(id); err != nil {
+		s.lo.Error("error deleting saml", "error", err)
+		return envelope.NewError(envelope.GeneralError, s.i18n.Ts("globals.messages.errorDeleting", "name", "{globals.terms.samlProvider}"), nil)
+	}
+	return nil
+}
+
+// setURLs sets the service provider metadata and assertion consumer service URLs.
+func (s *Manager) setURLs(saml *models.SAML) error {
+	rootURL, err := s.setting.GetAppRootURL()
+	if err != nil {
+		return err
+	}
+	saml.EntityID = fmt.Sprintf(rootURL+metadataURL, saml.ID)
+	saml.ACSURL = fmt.Sprintf(rootURL+acsURL, saml.ID)
+	return nil
+}
+
+// loadIDPMetadata fetches the identity provider metadata if a URL is set and validates it.
+func (s *Manager) loadIDPMetadata(saml *models.SAML) error {
+	saml.IDPMetadataURL = strings.TrimSpace(saml.IDPMetadataURL)
+	if saml.IDPMetadataURL != "" {
+		b, err := fetchMetadata(saml.IDPMetadataURL)
+		if err != nil {
+			s.lo.Error("error fetching saml idp metadata", "url", saml.IDPMetadataURL, "error", err)
+			return envelope.NewError(envelope.InputError, s.i18n.T("admin.saml.errorFetchingIDPMetadata"), nil)
+		}
+		saml.IDPMetadata = string(b)
+	}
+
+	md, err := samlsp.ParseMetadata([]byte(saml.IDPMetadata))
+	if err != nil || len(md.IDPSSODescriptors) == 0 {
+		s.lo.Error("error parsing saml idp metadata", "error", err)
+		return envelope.NewError(envelope.InputError, s.i18n.T("admin.saml.invalidIDPMetadata"), nil)
+	}
+	return nil
+}
+
+// fetchMetadata downloads the identity provider metadata XML, only from public addresses.
+func fetchMetadata(url string) ([]byte, error) {
+	resp, err := safehttp.NewClient(metadataFetchTimeout).Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxMetadataSize))
+}
+
+// generateCertificate generates a self-signed certificate and RSA key for the service provider, both PEM encoded.
+func generateCertificate(name string) (string, string, error) {
+	key, err := rsa.GenerateKey(rand.Reader, spKeyBits)
+	if err != nil {
+		return "", "", err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return "", "", err
+	}
+	now := time.Now()
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             now,
+		NotAfter:              now.Add(spCertificateValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return "", "", err
+	}
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	return string(cert), string(keyPEM), nil
+}
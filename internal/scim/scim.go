@@ -0,0 +1,411 @@
+// Package scim implements the resources of the SCIM 2.0 protocol (RFC 7643 and RFC 7644),
+// used by identity providers to provision agents as users and teams as groups.
+package scim
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tmodels "github.com/abhinavxd/libredesk/internal/team/models"
+	umodels "github.com/abhinavxd/libredesk/internal/user/models"
+)
+
+const (
+	SchemaUser         = "urn:ietf:params:scim:schemas:core:2.0:User"
+	SchemaGroup        = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	SchemaListResponse = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	SchemaPatchOp      = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	SchemaError        = "urn:ietf:params:scim:api:messages:2.0:Error"
+
+	ContentType = "application/scim+json"
+
+	// Error types, RFC 7644 section 3.12.
+	ErrInvalidFilter = "invalidFilter"
+	ErrInvalidSyntax = "invalidSyntax"
+	ErrInvalidValue  = "invalidValue"
+	ErrUniqueness    = "uniqueness"
+
+	ResourceTypeUser  = "User"
+	ResourceTypeGroup = "Group"
+)
+
+var ErrUnsupportedFilter = errors.New("only filters of the form `attribute eq \"value\"` are supported")
+
+type Name struct {
+	GivenName  string `json:"givenName"`
+	FamilyName string `json:"familyName"`
+	Formatted  string `json:"formatted,omitempty"`
+}
+
+type Email struct {
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+// MultiValue is an item of a multi-valued attribute such as roles or group members.
+type MultiValue struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+}
+
+type Meta struct {
+	ResourceType string    `json:"resourceType"`
+	Created      time.Time `json:"created"`
+	LastModified time.Time `json:"lastModified"`
+	Location     string    `json:"location"`
+}
+
+// User is an agent, the user name is the email of the agent.
+type User struct {
+	Schemas     []string     `json:"schemas"`
+	ID          string       `json:"id,omitempty"`
+	UserName    string       `json:"userName"`
+	Name        Name         `json:"name"`
+	DisplayName string       `json:"displayName,omitempty"`
+	Emails      []Email      `json:"emails,omitempty"`
+	Active      *bool        `json:"active,omitempty"`
+	Roles       []MultiValue `json:"roles,omitempty"`
+	Groups      []MultiValue `json:"groups,omitempty"`
+	Meta        *Meta        `json:"meta,omitempty"`
+}
+
+// Group is a team, the members are agents.
+type Group struct {
+	Schemas     []string     `json:"schemas"`
+	ID          string       `json:"id,omitempty"`
+	DisplayName string       `json:"displayName"`
+	Members     []MultiValue `json:"members"`
+	Meta        *Meta        `json:"meta,omitempty"`
+}
+
+type ListResponse struct {
+	Schemas      []string `json:"schemas"`
+	TotalResults int      `json:"totalResults"`
+	StartIndex   int      `json:"startIndex"`
+	ItemsPerPage int      `json:"itemsPerPage"`
+	Resources    any      `json:"Resources"`
+}
+
+type Error struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+}
+
+type PatchRequest struct {
+	Schemas    []string         `json:"schemas"`
+	Operations []PatchOperation `json:"Operations"`
+}
+
+type PatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value"`
+}
+
+// GroupPatch is the change to a group from the operations of a patch request.
+type GroupPatch struct {
+	DisplayName string
+	// ReplaceMembers is set when the members are replaced with Members, Add and Remove are applied otherwise.
+	ReplaceMembers bool
+	Members        []string
+	Add            []string
+	Remove         []string
+}
+
+// NewUser returns the SCIM user of an agent, location is the URL of the resource.
+func NewUser(agent umodels.User, location string) User {
+	var (
+		active = agent.Enabled
+		user   = User{
+			Schemas:     []string{SchemaUser},
+			ID:          strconv.Itoa(agent.ID),
+			UserName:    agent.Email.String,
+			Name:        Name{GivenName: agent.FirstName, FamilyName: agent.LastName},
+			DisplayName: strings.TrimSpace(agent.FirstName + " " + agent.LastName),
+			Emails:      []Email{{Value: agent.Email.String, Type: "work", Primary: true}},
+			Active:      &active,
+			Meta: &Meta{
+				ResourceType: ResourceTypeUser,
+				Created:      agent.CreatedAt,
+				LastModified: agent.UpdatedAt,
+				Location:     location,
+			},
+		}
+	)
+	for _, role := range agent.Roles {
+		user.Roles = append(user.Roles, MultiValue{Value: role})
+	}
+	for _, team := range agent.Teams {
+		user.Groups = append(user.Groups, MultiValue{Value: strconv.Itoa(team.ID), Display: team.Name})
+	}
+	return user
+}
+
+// NewGroup returns the SCIM group of a team and its member agents, location is the URL of the resource.
+func NewGroup(team tmodels.Team, members []umodels.User, location string) Group {
+	group := Group{
+		Schemas:     []string{SchemaGroup},
+		ID:          strconv.Itoa(team.ID),
+		DisplayName: team.Name,
+		Members:     make([]MultiValue, 0, len(members)),
+		Meta: &Meta{
+			ResourceType: ResourceTypeGroup,
+			Created:      team.CreatedAt,
+			LastModified: team.UpdatedAt,
+			Location:     location,
+		},
+	}
+	for _, m := range members {
+		group.Members = append(group.Members, MultiValue{Value: strconv.Itoa(m.ID), Display: m.Email.String})
+	}
+	return group
+}
+
+// NewListResponse returns a page of resources.
+func NewListResponse(resources any, total, startIndex, itemsPerPage int) ListResponse {
+	return ListResponse{
+		Schemas:      []string{SchemaListResponse},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: itemsPerPage,
+		Resources:    resources,
+	}
+}
+
+// NewError returns an error response with the HTTP status code.
+func NewError(status int, scimType, detail string) Error {
+	return Error{
+		Schemas:  []string{SchemaError},
+		Status:   strconv.Itoa(status),
+		ScimType: scimType,
+		Detail:   detail,
+	}
+}
+
+// Email returns the primary email of the user, the first email or the user name.
+func (u User) Email() string {
+	for _, e := range u.Emails {
+		if e.Primary && e.Value != "" {
+			return e.Value
+		}
+	}
+	for _, e := range u.Emails {
+		if e.Value != "" {
+			return e.Value
+		}
+	}
+	return u.UserName
+}
+
+// RoleNames returns the values of the roles of the user.
+func (u User) RoleNames() []string {
+	var names []string
+	for _, r := range u.Roles {
+		if r.Value != "" {
+			names = append(names, r.Value)
+		}
+	}
+	return names
+}
+
+// Values returns the values of the items of a multi-valued attribute.
+func Values(items []MultiValue) []string {
+	values := make([]string, 0, len(items))
+	for _, item := range items {
+		values = append(values, item.Value)
+	}
+	return values
+}
+
+// ParseFilter parses a filter of the form `attribute eq "value"`, the only form used by identity providers to look up
+// existing users and groups. The attribute is returned in lower case.
+func ParseFilter(filter string) (string, string, error) {
+	attr, rest, ok := strings.Cut(strings.TrimSpace(filter), " ")
+	if !ok {
+		return "", "", ErrUnsupportedFilter
+	}
+	op, value, ok := strings.Cut(strings.TrimSpace(rest), " ")
+	if !ok || !strings.EqualFold(op, "eq") {
+		return "", "", ErrUnsupportedFilter
+	}
+	var s string
+	if err := json.Unmarshal([]byte(strings.TrimSpace(value)), &s); err != nil {
+		return "", "", ErrUnsupportedFilter
+	}
+	return strings.ToLower(attr), s, nil
+}
+
+// ApplyUserPatch applies the add and replace operations of a patch request to the user.
+// Attributes that are not stored for agents are ignored, as are remove operations since all the stored attributes are required.
+func ApplyUserPatch(user *User, ops []PatchOperation) error {
+	for _, op := range ops {
+		switch strings.ToLower(op.Op) {
+		case "add", "replace":
+		case "remove":
+			continue
+		default:
+			return fmt.Errorf("invalid op `%s`", op.Op)
+		}
+
+		// Without a path the value is an object of attributes.
+		if op.Path == "" {
+			var attrs map[string]json.RawMessage
+			if err := json.Unmarshal(op.Value, &attrs); err != nil {
+				return fmt.Errorf("invalid value: %w", err)
+			}
+			for path, value := range attrs {
+				if err := applyUserAttribute(user, path, value); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if err := applyUserAttribute(user, op.Path, op.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ApplyGroupPatch returns the change to a group from the operations of a patch request,
+// attributes other than the display name and members are ignored.
+func ApplyGroupPatch(ops []PatchOperation) (GroupPatch, error) {
+	var patch GroupPatch
+	for _, op := range ops {
+		var (
+			kind = strings.ToLower(op.Op)
+			path = strings.ToLower(op.Path)
+		)
+		if kind != "add" && kind != "replace" && kind != "remove" {
+			return patch, fmt.Errorf("invalid op `%s`", op.Op)
+		}
+
+		// Remove a single member with a value filter, e.g. members[value eq "2"].
+		if kind == "remove" && strings.HasPrefix(path, "members[") && strings.HasSuffix(path, "]") {
+			attr, value, err := ParseFilter(op.Path[len("members[") : len(op.Path)-1])
+			if err != nil || attr != "value" {
+				return patch, fmt.Errorf("invalid path `%s`", op.Path)
+			}
+			patch.Remove = append(patch.Remove, value)
+			continue
+		}
+
+		attrs := map[string]json.RawMessage{path: op.Value}
+		if path == "" {
+			if err := json.Unmarshal(op.Value, &attrs); err != nil {
+				return patch, fmt.Errorf("invalid value: %w", err)
+			}
+		}
+		for attr, value := range attrs {
+			switch strings.ToLower(attr) {
+			case "displayname":
+				if kind == "remove" {
+					continue
+				}
+				if err := json.Unmarshal(value, &patch.DisplayName); err != nil {
+					return patch, fmt.Errorf("invalid displayName: %w", err)
+				}
+			case "members":
+				var members []MultiValue
+				if len(value) > 0 && string(value) != "null" {
+					if err := json.Unmarshal(value, &members); err != nil {
+						return patch, fmt.Errorf("invalid members: %w", err)
+					}
+				}
+				ids := make([]string, 0, len(members))
+				for _, m := range members {
+					ids = append(ids, m.Value)
+				}
+				switch {
+				case kind == "add":
+					patch.Add = append(patch.Add, ids...)
+				case kind == "remove" && len(ids) > 0:
+					patch.Remove = append(patch.Remove, ids...)
+				default:
+					// Replace, or remove without a value which removes all members.
+					patch.ReplaceMembers = true
+					patch.Members = ids
+					patch.Add, patch.Remove = nil, nil
+				}
+			}
+		}
+	}
+	return patch, nil
+}
+
+// HashToken returns the hex encoded SHA-256 hash of a bearer token,
+// tokens are random so a fast hash is sufficient.
+func HashToken(token string) string {
+	h := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(h[:])
+}
+
+// applyUserAttribute sets an attribute of the user from a patch value.
+func applyUserAttribute(user *User, path string, value json.RawMessage) error {
+	var (
+		lpath = strings.ToLower(path)
+		err   error
+	)
+	switch {
+	case lpath == "active":
+		var active bool
+		active, err = parseBool(value)
+		user.Active = &active
+	case lpath == "username":
+		err = json.Unmarshal(value, &user.UserName)
+	case lpath == "displayname":
+		err = json.Unmarshal(value, &user.DisplayName)
+	case lpath == "name":
+		var name Name
+		if err = json.Unmarshal(value, &name); err == nil {
+			if name.GivenName != "" {
+				user.Name.GivenName = name.GivenName
+			}
+			if name.FamilyName != "" {
+				user.Name.FamilyName = name.FamilyName
+			}
+		}
+	case lpath == "name.givenname":
+		err = json.Unmarshal(value, &user.Name.GivenName)
+	case lpath == "name.familyname":
+		err = json.Unmarshal(value, &user.Name.FamilyName)
+	case lpath == "emails":
+		err = json.Unmarshal(value, &user.Emails)
+	case strings.HasPrefix(lpath, "emails[") && strings.HasSuffix(lpath, "].value"):
+		// e.g. emails[type eq "work"].value, agents have a single email.
+		var email string
+		if err = json.Unmarshal(value, &email); err == nil {
+			user.Emails = []Email{{Value: email, Primary: true}}
+		}
+	case lpath == "roles":
+		err = json.Unmarshal(value, &user.Roles)
+	default:
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("invalid value for `%s`: %w", path, err)
+	}
+	return nil
+}
+
+// parseBool parses a JSON boolean, some identity providers send booleans as strings.
+func parseBool(value json.RawMessage) (bool, error) {
+	var b bool
+	if err := json.Unmarshal(value, &b); err == nil {
+		return b, nil
+	}
+	var s string
+	if err := json.Unmarshal(value, &s); err != nil {
+		return false, err
+	}
+	return strconv.ParseBool(s)
+}
@@ -0,0 +1,106 @@
+package scim
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestParseFilter(t *testing.T) {
+	tests := []struct {
+		filter string
+		attr   string
+		value  string
+		err    bool
+	}{
+		{`userName eq "jane@example.com"`, "username", "jane@example.com", false},
+		{`displayName EQ "Support \"L1\""`, "displayname", `Support "L1"`, false},
+		{`userName co "jane"`, "", "", true},
+		{`userName eq jane`, "", "", true},
+		{`userName`, "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			attr, value, err := ParseFilter(tt.filter)
+			if (err != nil) != tt.err {
+				t.Fatalf("err = %v, want error %v", err, tt.err)
+			}
+			if attr != tt.attr || value != tt.value {
+				t.Errorf("got %q %q, want %q %q", attr, value, tt.attr, tt.value)
+			}
+		})
+	}
+}
+
+func TestApplyUserPatch(t *testing.T) {
+	user := User{UserName: "jane@example.com", Name: Name{GivenName: "Jane", FamilyName: "Doe"}}
+	ops := []PatchOperation{
+		{Op: "Replace", Path: "active", Value: json.RawMessage(`"False"`)},
+		{Op: "replace", Path: "name.familyName", Value: json.RawMessage(`"Smith"`)},
+		{Op: "replace", Path: `emails[type eq "work"].value`, Value: json.RawMessage(`"jane.smith@example.com"`)},
+		{Op: "add", Value: json.RawMessage(`{"title": "Lead", "name": {"givenName": "Janet"}}`)},
+		{Op: "remove", Path: "title"},
+	}
+	if err := ApplyUserPatch(&user, ops); err != nil {
+		t.Fatal(err)
+	}
+	if user.Active == nil || *user.Active {
+		t.Error("expected user to be inactive")
+	}
+	if user.Name.GivenName != "Janet" || user.Name.FamilyName != "Smith" {
+		t.Errorf("name = %+v", user.Name)
+	}
+	if user.Email() != "jane.smith@example.com" {
+		t.Errorf("email = %s", user.Email())
+	}
+
+	if err := ApplyUserPatch(&user, []PatchOperation{{Op: "move", Path: "active"}}); err == nil {
+		t.Error("expected error for invalid op")
+	}
+}
+
+func TestApplyGroupPatch(t *testing.T) {
+	tests := []struct {
+		name string
+		ops  []PatchOperation
+		want GroupPatch
+	}{
+		{
+			"add and remove members",
+			[]PatchOperation{
+				{Op: "add", Path: "members", Value: json.RawMessage(`[{"value": "1"}, {"value": "2"}]`)},
+				{Op: "remove", Path: `members[value eq "3"]`},
+			},
+			GroupPatch{Add: []string{"1", "2"}, Remove: []string{"3"}},
+		},
+		{
+			"rename without path",
+			[]PatchOperation{{Op: "replace", Value: json.RawMessage(`{"id": "4", "displayName": "Billing"}`)}},
+			GroupPatch{DisplayName: "Billing"},
+		},
+		{
+			"replace members",
+			[]PatchOperation{
+				{Op: "add", Path: "members", Value: json.RawMessage(`[{"value": "1"}]`)},
+				{Op: "replace", Path: "members", Value: json.RawMessage(`[{"value": "2"}]`)},
+			},
+			GroupPatch{ReplaceMembers: true, Members: []string{"2"}},
+		},
+		{
+			"remove all members",
+			[]PatchOperation{{Op: "remove", Path: "members"}},
+			GroupPatch{ReplaceMembers: true, Members: []string{}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ApplyGroupPatch(tt.ops)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -5,7 +5,8 @@ SELECT
     conversations.reference_number,
     conversations.subject
 FROM conversations
-WHERE reference_number::text = $1;
+WHERE reference_number::text = $1
+AND %s;
 
 -- name: search-conversations-by-contact-email
 SELECT
@@ -16,6 +17,7 @@ SELECT
 FROM conversations
 JOIN users ON conversations.contact_id = users.id
 WHERE users.email = $1
+AND %s
 ORDER BY conversations.created_at DESC
 LIMIT 1000;
 
@@ -27,7 +29,8 @@ SELECT
     m.text_content
 FROM conversation_messages m
     JOIN conversations c ON m.conversation_id = c.id
-WHERE m.type != 'activity' and m.text_content ILIKE '%' || $1 || '%'
+WHERE m.type != 'activity' and m.text_content ILIKE '%%' || $1 || '%%'
+AND %s
 LIMIT 30;
 
 -- name: search-contacts
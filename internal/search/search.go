@@ -3,7 +3,9 @@ package search
 
 import (
 	"embed"
+	"fmt"
 
+	authzModels "github.com/abhinavxd/libredesk/internal/authz/models"
 	"github.com/abhinavxd/libredesk/internal/dbutil"
 	"github.com/abhinavxd/libredesk/internal/envelope"
 	models "github.com/abhinavxd/libredesk/internal/search/models"
@@ -20,6 +22,7 @@ var (
 // Manager is the search manager
 type Manager struct {
    q    queries
+   db   *sqlx.DB
    lo   *logf.Logger
    i18n *i18n.I18n
 }
@@ -33,9 +36,9 @@ type Opts struct {
 
 // queries contains all the prepared queries
 type queries struct {
-   SearchConversationsByRefNum       *sqlx.Stmt `query:"search-conversations-by-reference-number"`
-   SearchConversationsByContactEmail *sqlx.Stmt `query:"search-conversations-by-contact-email"`
-   SearchMessages                    *sqlx.Stmt `query:"search-messages"`
+   SearchConversationsByRefNum       string     `query:"search-conversations-by-reference-number"`
+   SearchConversationsByContactEmail string     `query:"search-conversations-by-contact-email"`
+   SearchMessages                    string     `query:"search-messages"`
    SearchContacts                    *sqlx.Stmt `query:"search-contacts"`
 }
 
@@ -45,29 +48,35 @@ func New(opts Opts) (*Manager, error) {
    if err := dbutil.ScanSQLFile("queries.sql", &q, opts.DB, efs); err != nil {
    	return nil, err
    }
-   return &Manager{q: q, lo: opts.Lo, i18n: opts.I18n}, nil
+   return &Manager{q: q, db: opts.DB, lo: opts.Lo, i18n: opts.I18n}, nil
 }
 
-// Conversations searches conversations based on the query
-func (s *Manager) Conversations(query string) ([]models.Conversation, error) {
-   var refNumResults = make([]models.Conversation, 0)
-   if err := s.q.SearchConversationsByRefNum.Select(&refNumResults, query); err != nil {
+// Conversations searches conversations based on the query, restricted to conversations in the scopes.
+func (s *Manager) Conversations(query string, scopes authzModels.Scopes) ([]models.Conversation, error) {
+   var (
+   	scope         = scopes.Condition("conversations.inbox_id", "conversations.assigned_team_id")
+   	refNumResults = make([]models.Conversation, 0)
+   )
+   if err := s.db.Select(&refNumResults, fmt.Sprintf(s.q.SearchConversationsByRefNum, scope), query); err != nil {
    	s.lo.Error("error searching conversations", "error", err)
    	return nil, envelope.NewError(envelope.GeneralError, s.i18n.Ts("globals.messages.errorSearching", "name", s.i18n.Ts("globals.terms.conversation")), nil)
    }
 
    var emailResults = make([]models.Conversation, 0)
-   if err := s.q.SearchConversationsByContactEmail.Select(&emailResults, query); err != nil {
+   if err := s.db.Select(&emailResults, fmt.Sprintf(s.q.SearchConversationsByContactEmail, scope), query); err != nil {
    	s.lo.Error("error searching conversations", "error", err)
    	return nil, envelope.NewError(envelope.GeneralError, s.i18n.Ts("globals.messages.errorSearching", "name", s.i18n.Ts("globals.terms.conversation")), nil)
    }
    return append(refNumResults, emailResults...), nil
 }
 
-// Messages searches messages based on the query
-func (s *Manager) Messages(query string) ([]models.Message, error) {
-   var results = make([]models.Message, 0)
-   if err := s.q.SearchMessages.Select(&results, query); err != nil {
+// Messages searches messages based on the query, restricted to conversations in the scopes.
+func (s *Manager) Messages(query string, scopes authzModels.Scopes) ([]models.Message, error) {
+   var (
+   	scope   = scopes.Condition("c.inbox_id", "c.assigned_team_id")
+   	results = make([]models.Message, 0)
+   )
+   if err := s.db.Select(&results, fmt.Sprintf(s.q.SearchMessages, scope), query); err != nil {
    	s.lo.Error("error searching messages", "error", err)
    	return nil, envelope.NewError(envelope.GeneralError, s.i18n.Ts("globals.messages.errorSearching", "name", s.i18n.Ts("globals.terms.message")), nil)
    }
@@ -37,6 +37,33 @@ type ArticleSettings struct {
 	CustomScript	string `json:"article.custom_script" db:"article.custom_script"`
 }
 
+// AISettings holds the daily token limits of AI features, a limit of 0 is unlimited.
+type AISettings struct {
+	UserDailyTokenLimit      int `json:"ai.user_daily_token_limit" db:"ai.user_daily_token_limit"`
+	WorkspaceDailyTokenLimit int `json:"ai.workspace_daily_token_limit" db:"ai.workspace_daily_token_limit"`
+}
+
+// SCIMSettings holds the role of agents provisioned over SCIM without roles and the hash of the SCIM bearer token,
+// SCIM is disabled while no token is generated.
+type SCIMSettings struct {
+	DefaultRole string `json:"scim.default_role" db:"scim.default_role"`
+	TokenHash   string `json:"scim.token_hash" db:"scim.token_hash"`
+}
+
+// PasswordPolicy holds the rules agent passwords must meet, a HistoryCount or MaxAgeDays of 0 disables the rule.
+type PasswordPolicy struct {
+	MinLength        int      `json:"password.min_length" db:"password.min_length"`
+	RequireUppercase bool     `json:"password.require_uppercase" db:"password.require_uppercase"`
+	RequireLowercase bool     `json:"password.require_lowercase" db:"password.require_lowercase"`
+	RequireNumber    bool     `json:"password.require_number" db:"password.require_number"`
+	RequireSpecial   bool     `json:"password.require_special" db:"password.require_special"`
+	BannedPasswords  []string `json:"password.banned_passwords" db:"password.banned_passwords"`
+	// HistoryCount is the number of last passwords, including the current one, that can't be reused.
+	HistoryCount int `json:"password.history_count" db:"password.history_count"`
+	// MaxAgeDays is the age after which a password has to be reset on login.
+	MaxAgeDays int `json:"password.max_age_days" db:"password.max_age_days"`
+}
+
 type Settings struct {
 	EmailNotification
 	General
@@ -38,24 +38,9 @@ func (m *Manager) CalculateDeadline(start time.Time, slaMinutes int, businessHou
 	remainingMinutes := slaMinutes
 	maxIterations := ((slaMinutes+59)/60)*24 + 1
 
-	// Unmarshal working hours.
-	var workingHours map[string]models.WorkingHours
-	if err := json.Unmarshal(businessHours.Hours, &workingHours); err != nil {
-		return time.Time{}, fmt.Errorf("could not unmarshal working hours for SLA deadline calcuation: %v", err)
-	}
-
-	// Unmarshal holidays.
-	var holidays = []models.Holiday{}
-	if len(businessHours.Holidays) > 0 {
-		if err := json.Unmarshal(businessHours.Holidays, &holidays); err != nil {
-			return time.Time{}, fmt.Errorf("could not unmarshal holidays for SLA deadline calcuation: %v", err)
-		}
-	}
-
-	// Create a map of holidays.
-	holidaysMap := make(map[string]struct{})
-	for _, holiday := range holidays {
-		holidaysMap[holiday.Date] = struct{}{}
+	sched, err := parseSchedule(businessHours)
+	if err != nil {
+		return time.Time{}, err
 	}
 
 	iterations := 0
@@ -65,18 +50,11 @@ func (m *Manager) CalculateDeadline(start time.Time, slaMinutes int, businessHou
 			return time.Time{}, ErrMaxIterations
 		}
 
-		// Move to next day if current day is a holiday.
-		dateStr := currentTime.Format(time.DateOnly)
-		if _, isHoliday := holidaysMap[dateStr]; isHoliday {
-			currentTime = nextDay(currentTime, loc)
-			continue
-		}
-
-		// Get working hours for the current day.
+		// Get working hours for the current day, special hours take precedence over holidays and regular hours.
 		dayOfWeek := currentTime.Weekday().String()
-		workHours, exists := workingHours[dayOfWeek]
+		workHours, exists := sched.hoursFor(currentTime)
 
-		// Not a working day, move to next day.
+		// Holiday or not a working day, move to next day.
 		if !exists {
 			currentTime = nextDay(currentTime, loc)
 			continue
@@ -122,6 +100,148 @@ func (m *Manager) CalculateDeadline(start time.Time, slaMinutes int, businessHou
 	return currentTime, nil
 }
 
+// BusinessMinutesBetween returns the number of business minutes elapsed between start and end
+// considering the provided holidays, working hours, and time zone.
+func (m *Manager) BusinessMinutesBetween(start, end time.Time, businessHours models.BusinessHours, timeZone string) (int, error) {
+	if !end.After(start) {
+		return 0, nil
+	}
+
+	// If business is always open, every minute counts.
+	if businessHours.IsAlwaysOpen {
+		return int(end.Sub(start).Minutes()), nil
+	}
+
+	loc, err := time.LoadLocation(timeZone)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time zone %s: %v", timeZone, err)
+	}
+
+	sched, err := parseSchedule(businessHours)
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		minutes     float64
+		currentTime = start.In(loc)
+		endTime     = end.In(loc)
+	)
+	for currentTime.Before(endTime) {
+		// Skip holidays and non working days.
+		dayOfWeek := currentTime.Weekday().String()
+		workHours, exists := sched.hoursFor(currentTime)
+		if !exists {
+			currentTime = nextDay(currentTime, loc)
+			continue
+		}
+
+		startOfWork, err := parseTime(currentTime, workHours.Open, loc)
+		if err != nil {
+			return 0, fmt.Errorf("invalid open time %s for %s: %v", workHours.Open, dayOfWeek, err)
+		}
+		endOfWork, err := parseTime(currentTime, workHours.Close, loc)
+		if err != nil {
+			return 0, fmt.Errorf("invalid close time %s for %s: %v", workHours.Close, dayOfWeek, err)
+		}
+
+		// Count the overlap of [currentTime, endTime] with today's working window.
+		from, to := startOfWork, endOfWork
+		if currentTime.After(from) {
+			from = currentTime
+		}
+		if endTime.Before(to) {
+			to = endTime
+		}
+		if to.After(from) {
+			minutes += to.Sub(from).Minutes()
+		}
+
+		currentTime = nextDay(currentTime, loc)
+	}
+
+	return int(minutes), nil
+}
+
+// schedule holds the parsed working hours, holidays and special hours of business hours.
+type schedule struct {
+	workingHours map[string]models.WorkingHours
+	// holidays and specialHours are keyed by date (YYYY-MM-DD), their recurring counterparts by month and day (MM-DD).
+	holidays              map[string]struct{}
+	recurringHolidays     map[string]struct{}
+	specialHours          map[string]models.WorkingHours
+	recurringSpecialHours map[string]models.WorkingHours
+}
+
+// hoursFor returns the working hours for the day of t and whether it is a working day.
+func (s schedule) hoursFor(t time.Time) (models.WorkingHours, bool) {
+	var (
+		date     = t.Format(time.DateOnly)
+		monthDay = t.Format("01-02")
+	)
+	if hours, ok := s.specialHours[date]; ok {
+		return hours, true
+	}
+	if hours, ok := s.recurringSpecialHours[monthDay]; ok {
+		return hours, true
+	}
+	if _, ok := s.holidays[date]; ok {
+		return models.WorkingHours{}, false
+	}
+	if _, ok := s.recurringHolidays[monthDay]; ok {
+		return models.WorkingHours{}, false
+	}
+	hours, ok := s.workingHours[t.Weekday().String()]
+	return hours, ok
+}
+
+// parseSchedule unmarshals the working hours, holidays and special hours of the business hours.
+func parseSchedule(businessHours models.BusinessHours) (schedule, error) {
+	sched := schedule{
+		holidays:              make(map[string]struct{}),
+		recurringHolidays:     make(map[string]struct{}),
+		specialHours:          make(map[string]models.WorkingHours),
+		recurringSpecialHours: make(map[string]models.WorkingHours),
+	}
+
+	// Unmarshal working hours.
+	if err := json.Unmarshal(businessHours.Hours, &sched.workingHours); err != nil {
+		return sched, fmt.Errorf("could not unmarshal working hours for SLA deadline calcuation: %v", err)
+	}
+
+	// Unmarshal holidays.
+	var holidays = []models.Holiday{}
+	if len(businessHours.Holidays) > 0 {
+		if err := json.Unmarshal(businessHours.Holidays, &holidays); err != nil {
+			return sched, fmt.Errorf("could not unmarshal holidays for SLA deadline calcuation: %v", err)
+		}
+	}
+	for _, holiday := range holidays {
+		if holiday.Recurring && len(holiday.Date) == len(time.DateOnly) {
+			sched.recurringHolidays[holiday.Date[5:]] = struct{}{}
+			continue
+		}
+		sched.holidays[holiday.Date] = struct{}{}
+	}
+
+	// Unmarshal special hours.
+	var specialHours = []models.SpecialHours{}
+	if len(businessHours.SpecialHours) > 0 {
+		if err := json.Unmarshal(businessHours.SpecialHours, &specialHours); err != nil {
+			return sched, fmt.Errorf("could not unmarshal special hours for SLA deadline calcuation: %v", err)
+		}
+	}
+	for _, sh := range specialHours {
+		hours := models.WorkingHours{Open: sh.Open, Close: sh.Close}
+		if sh.Recurring && len(sh.Date) == len(time.DateOnly) {
+			sched.recurringSpecialHours[sh.Date[5:]] = hours
+			continue
+		}
+		sched.specialHours[sh.Date] = hours
+	}
+	return sched, nil
+}
+
 // nextDay advances the time to the start of the next day in the specified time zone.
 func nextDay(t time.Time, loc *time.Location) time.Time {
 	return time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
@@ -494,6 +494,48 @@ func TestCalculateDeadline(t *testing.T) {
 			timeZone:       "Asia/Kolkata",
 			expectedResult: time.Date(2025, 03, 27, 10, 10, 0, 0, locIST),
 		},
+		{
+			name:       "Start Time on Recurring Holiday",
+			startTime:  time.Date(2023, 10, 10, 10, 0, 0, 0, locUTC),
+			slaMinutes: 60,
+			businessHours: models.BusinessHours{
+				Holidays: mustMarshalJSON([]models.Holiday{{Date: "2020-10-10", Recurring: true}}),
+				Hours: mustMarshalJSON(map[string]models.WorkingHours{
+					"Tuesday": {Open: "09:00", Close: "17:00"},
+				}),
+			},
+			timeZone:       "UTC",
+			expectedResult: time.Date(2023, 10, 17, 10, 0, 0, 0, locUTC),
+		},
+		{
+			name:       "Half-day Special Hours",
+			startTime:  time.Date(2023, 10, 10, 10, 0, 0, 0, locUTC), // Tue
+			slaMinutes: 180,
+			businessHours: models.BusinessHours{
+				SpecialHours: mustMarshalJSON([]models.SpecialHours{{Date: "2023-10-10", Open: "09:00", Close: "12:00"}}),
+				Hours: mustMarshalJSON(map[string]models.WorkingHours{
+					"Tuesday":   {Open: "09:00", Close: "17:00"},
+					"Wednesday": {Open: "09:00", Close: "17:00"},
+				}),
+			},
+			timeZone: "UTC",
+			// 2 hours on the half-day, remaining hour on Wednesday.
+			expectedResult: time.Date(2023, 10, 11, 10, 0, 0, 0, locUTC),
+		},
+		{
+			name:       "Special Hours on Non Working Day and Holiday",
+			startTime:  time.Date(2023, 10, 14, 9, 0, 0, 0, locUTC), // Sat
+			slaMinutes: 60,
+			businessHours: models.BusinessHours{
+				Holidays:     mustMarshalJSON([]models.Holiday{{Date: "2023-10-14"}}),
+				SpecialHours: mustMarshalJSON([]models.SpecialHours{{Date: "2020-10-14", Open: "10:00", Close: "14:00", Recurring: true}}),
+				Hours: mustMarshalJSON(map[string]models.WorkingHours{
+					"Monday": {Open: "09:00", Close: "17:00"},
+				}),
+			},
+			timeZone:       "UTC",
+			expectedResult: time.Date(2023, 10, 14, 11, 0, 0, 0, locUTC),
+		},
 	}
 
 	for _, tt := range tests {
@@ -511,3 +553,86 @@ func TestCalculateDeadline(t *testing.T) {
 		})
 	}
 }
+
+func TestBusinessMinutesBetween(t *testing.T) {
+	locUTC := time.UTC
+	weekdays := mustMarshalJSON(map[string]models.WorkingHours{
+		"Monday":    {Open: "09:00", Close: "17:00"},
+		"Tuesday":   {Open: "09:00", Close: "17:00"},
+		"Wednesday": {Open: "09:00", Close: "17:00"},
+		"Thursday":  {Open: "09:00", Close: "17:00"},
+		"Friday":    {Open: "09:00", Close: "17:00"},
+	})
+
+	tests := []struct {
+		name           string
+		start          time.Time
+		end            time.Time
+		businessHours  models.BusinessHours
+		timeZone       string
+		expectedResult int
+	}{
+		{
+			name:  "Always Open Business",
+			start: time.Date(2023, 10, 10, 9, 0, 0, 0, locUTC),
+			end:   time.Date(2023, 10, 10, 23, 30, 0, 0, locUTC),
+			businessHours: models.BusinessHours{
+				IsAlwaysOpen: true,
+			},
+			timeZone:       "UTC",
+			expectedResult: 870,
+		},
+		{
+			name:           "End Before Start",
+			start:          time.Date(2023, 10, 10, 10, 0, 0, 0, locUTC),
+			end:            time.Date(2023, 10, 10, 9, 0, 0, 0, locUTC),
+			businessHours:  models.BusinessHours{Hours: weekdays},
+			timeZone:       "UTC",
+			expectedResult: 0,
+		},
+		{
+			name:           "Within Same Working Day",
+			start:          time.Date(2023, 10, 10, 10, 0, 0, 0, locUTC),
+			end:            time.Date(2023, 10, 10, 12, 15, 0, 0, locUTC),
+			businessHours:  models.BusinessHours{Hours: weekdays},
+			timeZone:       "UTC",
+			expectedResult: 135,
+		},
+		{
+			name:           "Entirely Outside Working Hours",
+			start:          time.Date(2023, 10, 10, 18, 0, 0, 0, locUTC),
+			end:            time.Date(2023, 10, 11, 8, 0, 0, 0, locUTC),
+			businessHours:  models.BusinessHours{Hours: weekdays},
+			timeZone:       "UTC",
+			expectedResult: 0,
+		},
+		{
+			name:           "Spanning Weekend",
+			start:          time.Date(2023, 10, 13, 16, 0, 0, 0, locUTC), // Fri
+			end:            time.Date(2023, 10, 16, 10, 0, 0, 0, locUTC), // Mon
+			businessHours:  models.BusinessHours{Hours: weekdays},
+			timeZone:       "UTC",
+			expectedResult: 120,
+		},
+		{
+			name:  "Spanning Holiday",
+			start: time.Date(2023, 10, 10, 16, 0, 0, 0, locUTC),
+			end:   time.Date(2023, 10, 12, 10, 0, 0, 0, locUTC),
+			businessHours: models.BusinessHours{
+				Hours:    weekdays,
+				Holidays: mustMarshalJSON([]models.Holiday{{Date: "2023-10-11"}}),
+			},
+			timeZone:       "UTC",
+			expectedResult: 120,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Manager{}
+			result, err := m.BusinessMinutesBetween(tt.start, tt.end, tt.businessHours, tt.timeZone)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedResult, result)
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"time"
 
+	amodels "github.com/abhinavxd/libredesk/internal/automation/models"
 	"github.com/lib/pq"
 	"github.com/volatiletech/null/v9"
 )
@@ -21,6 +22,8 @@ type SLAPolicy struct {
 	NextResponseTime  null.String      `db:"next_response_time" json:"next_response_time"`
 	ResolutionTime    null.String      `db:"resolution_time" json:"resolution_time"`
 	Notifications     SlaNotifications `db:"notifications" json:"notifications"`
+	PauseOnStatuses   pq.StringArray   `db:"pause_on_statuses" json:"pause_on_statuses"`
+	Escalations       SlaEscalations   `db:"escalations" json:"escalations"`
 }
 
 type SlaNotifications []SlaNotification
@@ -54,6 +57,34 @@ type SlaNotification struct {
 	Metric        string   `db:"metric" json:"metric"`
 }
 
+type SlaEscalations []SlaEscalation
+
+// Value implements the driver.Valuer interface.
+func (se SlaEscalations) Value() (driver.Value, error) {
+	return json.Marshal(se)
+}
+
+// Scan implements the sql.Scanner interface.
+func (se *SlaEscalations) Scan(src any) error {
+	var data []byte
+
+	switch v := src.(type) {
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		return fmt.Errorf("unsupported type: %T", src)
+	}
+	return json.Unmarshal(data, se)
+}
+
+// SlaEscalation represents the actions executed on a conversation when an SLA metric is breached
+type SlaEscalation struct {
+	Metric  string               `db:"metric" json:"metric"`
+	Actions []amodels.RuleAction `db:"actions" json:"actions"`
+}
+
 // ScheduledSLANotification represents a scheduled SLA notification
 type ScheduledSLANotification struct {
 	ID               int            `db:"id" json:"id"`
@@ -89,7 +120,23 @@ type AppliedSLA struct {
 	ConversationReferenceNumber string    `db:"conversation_reference_number"`
 	ConversationSubject         string    `db:"conversation_subject"`
 	ConversationAssignedUserID  null.Int  `db:"conversation_assigned_user_id"`
+	ConversationAssignedTeamID  null.Int  `db:"conversation_assigned_team_id"`
 	ConversationStatus          string    `db:"conversation_status"`
+
+	// Pause fields.
+	PauseOnStatuses pq.StringArray `db:"pause_on_statuses"`
+	PausedAt        null.Time      `db:"paused_at"`
+}
+
+// AppliedSLAPause represents an interval during which the SLA clock of an applied SLA was paused.
+type AppliedSLAPause struct {
+	ID            int       `db:"id" json:"id"`
+	CreatedAt     time.Time `db:"created_at" json:"created_at"`
+	AppliedSLAID  int       `db:"applied_sla_id" json:"applied_sla_id"`
+	Status        string    `db:"status" json:"status"`
+	PausedAt      time.Time `db:"paused_at" json:"paused_at"`
+	ResumedAt     null.Time `db:"resumed_at" json:"resumed_at"`
+	PausedMinutes int       `db:"paused_minutes" json:"paused_minutes"`
 }
 
 type SLAEvent struct {
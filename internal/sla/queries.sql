@@ -1,5 +1,5 @@
 -- name: get-sla-policy
-SELECT id, name, description, first_response_time, resolution_time, next_response_time, notifications, created_at, updated_at FROM sla_policies WHERE id = $1;
+SELECT id, name, description, first_response_time, resolution_time, next_response_time, notifications, pause_on_statuses, escalations, created_at, updated_at FROM sla_policies WHERE id = $1;
 
 -- name: get-all-sla-policies
 SELECT id, name, created_at, updated_at FROM sla_policies ORDER BY updated_at DESC;
@@ -11,8 +11,10 @@ INSERT INTO sla_policies (
    first_response_time,
    resolution_time,
    next_response_time,
-   notifications
-) VALUES ($1, $2, $3, $4, $5, $6)
+   notifications,
+   pause_on_statuses,
+   escalations
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 RETURNING *;
 
 -- name: update-sla-policy
@@ -23,6 +25,8 @@ UPDATE sla_policies SET
    resolution_time = $5,
    next_response_time = $6,
    notifications = $7,
+   pause_on_statuses = $8,
+   escalations = $9,
    updated_at = NOW()
 WHERE id = $1
 RETURNING *;
@@ -52,9 +56,13 @@ RETURNING ns.id;
 -- name: get-pending-applied-sla
 -- Get all the applied SLAs (applied to a conversation) that are pending
 SELECT a.id, a.first_response_deadline_at, c.first_reply_at as conversation_first_response_at, a.sla_policy_id,
-a.resolution_deadline_at, c.resolved_at as conversation_resolved_at, c.id as conversation_id, a.first_response_met_at, a.resolution_met_at, a.first_response_breached_at, a.resolution_breached_at
+a.resolution_deadline_at, c.resolved_at as conversation_resolved_at, c.id as conversation_id, a.first_response_met_at, a.resolution_met_at, a.first_response_breached_at, a.resolution_breached_at,
+c.assigned_team_id as conversation_assigned_team_id, s.name as conversation_status, p.pause_on_statuses, ap.paused_at
 FROM applied_slas a 
 JOIN conversations c ON a.conversation_id = c.id and c.sla_policy_id = a.sla_policy_id
+JOIN sla_policies p ON a.sla_policy_id = p.id
+LEFT JOIN conversation_statuses s ON c.status_id = s.id
+LEFT JOIN applied_sla_pauses ap ON ap.applied_sla_id = a.id AND ap.resumed_at IS NULL
 WHERE a.status = 'pending'::applied_sla_status;
 
 -- name: update-applied-sla-breached-at
@@ -148,9 +156,14 @@ SELECT a.id,
    c.reference_number as conversation_reference_number,
    c.subject as conversation_subject,
    c.assigned_user_id as conversation_assigned_user_id,
-   s.name as conversation_status
+   c.assigned_team_id as conversation_assigned_team_id,
+   s.name as conversation_status,
+   p.pause_on_statuses,
+   ap.paused_at
 FROM applied_slas a INNER JOIN conversations c on a.conversation_id = c.id
+INNER JOIN sla_policies p ON a.sla_policy_id = p.id
 LEFT JOIN conversation_statuses s ON c.status_id = s.id
+LEFT JOIN applied_sla_pauses ap ON ap.applied_sla_id = a.id AND ap.resumed_at IS NULL
 WHERE a.id = $1;
 
 -- name: update-notification-processed
@@ -196,6 +209,51 @@ FROM sla_events
 WHERE id = $1;
 
 -- name: get-pending-sla-events
+-- Events of paused applied SLAs are skipped unless already met, they cannot breach while the SLA clock is paused.
 SELECT id
 FROM sla_events
-WHERE status = 'pending' AND deadline_at IS NOT NULL;
+WHERE status = 'pending' AND deadline_at IS NOT NULL
+AND (met_at IS NOT NULL OR NOT EXISTS (
+  SELECT 1 FROM applied_sla_pauses p
+  WHERE p.applied_sla_id = sla_events.applied_sla_id AND p.resumed_at IS NULL
+));
+
+-- name: get-unmet-sla-events
+SELECT id, created_at, updated_at, applied_sla_id, sla_policy_id, type, deadline_at, met_at, breached_at
+FROM sla_events
+WHERE applied_sla_id = $1 AND status = 'pending' AND met_at IS NULL;
+
+-- name: update-sla-event-deadline
+UPDATE sla_events
+SET deadline_at = $2,
+    updated_at = NOW()
+WHERE id = $1;
+
+-- name: insert-applied-sla-pause
+INSERT INTO applied_sla_pauses (applied_sla_id, status)
+VALUES ($1, $2)
+ON CONFLICT (applied_sla_id) WHERE resumed_at IS NULL DO NOTHING;
+
+-- name: resume-applied-sla-pause
+UPDATE applied_sla_pauses
+SET resumed_at = $2,
+    paused_minutes = $3
+WHERE applied_sla_id = $1 AND resumed_at IS NULL;
+
+-- name: get-applied-sla-pauses
+SELECT id, created_at, applied_sla_id, status, paused_at, resumed_at, paused_minutes
+FROM applied_sla_pauses
+WHERE applied_sla_id = $1
+ORDER BY paused_at ASC;
+
+-- name: extend-applied-sla-deadlines
+-- Only deadlines that are neither met nor breached are extended.
+UPDATE applied_slas SET
+   first_response_deadline_at = CASE WHEN first_response_met_at IS NULL AND first_response_breached_at IS NULL THEN COALESCE($2, first_response_deadline_at) ELSE first_response_deadline_at END,
+   resolution_deadline_at = CASE WHEN resolution_met_at IS NULL AND resolution_breached_at IS NULL THEN COALESCE($3, resolution_deadline_at) ELSE resolution_deadline_at END,
+   updated_at = NOW()
+WHERE id = $1;
+
+-- name: delete-pending-sla-warning-notifications
+DELETE FROM scheduled_sla_notifications
+WHERE applied_sla_id = $1 AND processed_at IS NULL AND notification_type = 'warning';
@@ -7,10 +7,12 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
 	"strconv"
 	"sync"
 	"time"
 
+	amodels "github.com/abhinavxd/libredesk/internal/automation/models"
 	businesshours "github.com/abhinavxd/libredesk/internal/business_hours"
 	bmodels "github.com/abhinavxd/libredesk/internal/business_hours/models"
 	cmodels "github.com/abhinavxd/libredesk/internal/conversation/models"
@@ -54,17 +56,18 @@ var metricLabels = map[string]string{
 }
 
 type Manager struct {
-	q                queries
-	lo               *logf.Logger
-	i18n             *i18n.I18n
-	teamStore        teamStore
-	userStore        userStore
-	appSettingsStore appSettingsStore
-	businessHrsStore businessHrsStore
-	notifier         *notifier.Service
-	template         *template.Manager
-	wg               sync.WaitGroup
-	opts             Opts
+	q                 queries
+	lo                *logf.Logger
+	i18n              *i18n.I18n
+	teamStore         teamStore
+	userStore         userStore
+	appSettingsStore  appSettingsStore
+	businessHrsStore  businessHrsStore
+	conversationStore conversationStore
+	notifier          *notifier.Service
+	template          *template.Manager
+	wg                sync.WaitGroup
+	opts              Opts
 }
 
 // Opts defines the options for creating SLA manager.
@@ -104,6 +107,11 @@ type businessHrsStore interface {
 	Get(id int) (bmodels.BusinessHours, error)
 }
 
+type conversationStore interface {
+	GetConversation(id int, uuid string) (cmodels.Conversation, error)
+	ApplyEscalationAction(action amodels.RuleAction, conversation cmodels.Conversation, user umodels.User) error
+}
+
 // queries hold prepared SQL queries.
 type queries struct {
 	GetSLAPolicy                      *sqlx.Stmt `query:"get-sla-policy"`
@@ -113,9 +121,16 @@ type queries struct {
 	GetScheduledSLANotifications      *sqlx.Stmt `query:"get-scheduled-sla-notifications"`
 	GetPendingAppliedSLA              *sqlx.Stmt `query:"get-pending-applied-sla"`
 	GetPendingSLAEvents               *sqlx.Stmt `query:"get-pending-sla-events"`
+	GetUnmetSLAEvents                 *sqlx.Stmt `query:"get-unmet-sla-events"`
+	GetAppliedSLAPauses               *sqlx.Stmt `query:"get-applied-sla-pauses"`
 	InsertScheduledSLANotification    *sqlx.Stmt `query:"insert-scheduled-sla-notification"`
 	InsertSLAPolicy                   *sqlx.Stmt `query:"insert-sla-policy"`
 	InsertNextResponseSLAEvent        *sqlx.Stmt `query:"insert-next-response-sla-event"`
+	InsertAppliedSLAPause             *sqlx.Stmt `query:"insert-applied-sla-pause"`
+	ResumeAppliedSLAPause             *sqlx.Stmt `query:"resume-applied-sla-pause"`
+	ExtendAppliedSLADeadlines         *sqlx.Stmt `query:"extend-applied-sla-deadlines"`
+	UpdateSLAEventDeadline            *sqlx.Stmt `query:"update-sla-event-deadline"`
+	DeletePendingSLAWarnings          *sqlx.Stmt `query:"delete-pending-sla-warning-notifications"`
 	UpdateSLAPolicy                   *sqlx.Stmt `query:"update-sla-policy"`
 	UpdateAppliedSLABreachedAt        *sqlx.Stmt `query:"update-applied-sla-breached-at"`
 	UpdateAppliedSLAMetAt             *sqlx.Stmt `query:"update-applied-sla-met-at"`
@@ -162,6 +177,11 @@ func New(
 	}, nil
 }
 
+// SetConversationStore sets conversations store.
+func (m *Manager) SetConversationStore(store conversationStore) {
+	m.conversationStore = store
+}
+
 // Get retrieves an SLA by ID.
 func (m *Manager) Get(id int) (models.SLAPolicy, error) {
 	var sla models.SLAPolicy
@@ -186,9 +206,15 @@ func (m *Manager) GetAll() ([]models.SLAPolicy, error) {
 }
 
 // Create creates a new SLA policy.
-func (m *Manager) Create(name, description string, firstResponseTime, resolutionTime, nextResponseTime null.String, notifications models.SlaNotifications) (models.SLAPolicy, error) {
+func (m *Manager) Create(name, description string, firstResponseTime, resolutionTime, nextResponseTime null.String, notifications models.SlaNotifications, pauseOnStatuses []string, escalations models.SlaEscalations) (models.SLAPolicy, error) {
 	var result models.SLAPolicy
-	if err := m.q.InsertSLAPolicy.Get(&result, name, description, firstResponseTime, resolutionTime, nextResponseTime, notifications); err != nil {
+	if pauseOnStatuses == nil {
+		pauseOnStatuses = []string{}
+	}
+	if escalations == nil {
+		escalations = models.SlaEscalations{}
+	}
+	if err := m.q.InsertSLAPolicy.Get(&result, name, description, firstResponseTime, resolutionTime, nextResponseTime, notifications, pq.Array(pauseOnStatuses), escalations); err != nil {
 		m.lo.Error("error inserting SLA", "error", err)
 		return models.SLAPolicy{}, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorCreating", "name", "{globals.terms.sla}"), nil)
 	}
@@ -196,9 +222,15 @@ func (m *Manager) Create(name, description string, firstResponseTime, resolution
 }
 
 // Update updates a SLA policy.
-func (m *Manager) Update(id int, name, description string, firstResponseTime, resolutionTime, nextResponseTime null.String, notifications models.SlaNotifications) (models.SLAPolicy, error) {
+func (m *Manager) Update(id int, name, description string, firstResponseTime, resolutionTime, nextResponseTime null.String, notifications models.SlaNotifications, pauseOnStatuses []string, escalations models.SlaEscalations) (models.SLAPolicy, error) {
 	var result models.SLAPolicy
-	if err := m.q.UpdateSLAPolicy.Get(&result, id, name, description, firstResponseTime, resolutionTime, nextResponseTime, notifications); err != nil {
+	if pauseOnStatuses == nil {
+		pauseOnStatuses = []string{}
+	}
+	if escalations == nil {
+		escalations = models.SlaEscalations{}
+	}
+	if err := m.q.UpdateSLAPolicy.Get(&result, id, name, description, firstResponseTime, resolutionTime, nextResponseTime, notifications, pq.Array(pauseOnStatuses), escalations); err != nil {
 		m.lo.Error("error updating SLA", "error", err)
 		return models.SLAPolicy{}, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.sla}"), nil)
 	}
@@ -379,6 +411,138 @@ func (m *Manager) SetLatestSLAEventMetAt(appliedSLAID int, metric string) (time.
 	return metAt, nil
 }
 
+// GetPauses returns the pause intervals recorded for an applied SLA.
+func (m *Manager) GetPauses(appliedSLAID int) ([]models.AppliedSLAPause, error) {
+	var pauses = make([]models.AppliedSLAPause, 0)
+	if err := m.q.GetAppliedSLAPauses.Select(&pauses, appliedSLAID); err != nil {
+		m.lo.Error("error fetching applied SLA pauses", "applied_sla_id", appliedSLAID, "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, m.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.sla}"), nil)
+	}
+	return pauses, nil
+}
+
+// SyncPause pauses or resumes the SLA clock of an applied SLA based on the current conversation status
+// and the statuses the SLA policy pauses on.
+func (m *Manager) SyncPause(appliedSLAID int) error {
+	var appliedSLA models.AppliedSLA
+	if err := m.q.GetAppliedSLA.Get(&appliedSLA, appliedSLAID); err != nil {
+		m.lo.Error("error fetching applied SLA", "applied_sla_id", appliedSLAID, "error", err)
+		return fmt.Errorf("fetching applied SLA: %w", err)
+	}
+	_, err := m.syncPause(appliedSLA)
+	return err
+}
+
+// syncPause opens a pause when the conversation enters one of the policy's pause statuses and closes it,
+// extending the deadlines, when the conversation leaves them. Returns whether the SLA clock is paused.
+func (m *Manager) syncPause(appliedSLA models.AppliedSLA) (bool, error) {
+	shouldPause := slices.Contains(appliedSLA.PauseOnStatuses, appliedSLA.ConversationStatus)
+	switch {
+	case shouldPause && !appliedSLA.PausedAt.Valid:
+		m.lo.Info("pausing SLA clock", "applied_sla_id", appliedSLA.ID, "conversation_id", appliedSLA.ConversationID, "status", appliedSLA.ConversationStatus)
+		if _, err := m.q.InsertAppliedSLAPause.Exec(appliedSLA.ID, appliedSLA.ConversationStatus); err != nil {
+			m.lo.Error("error pausing SLA", "applied_sla_id", appliedSLA.ID, "error", err)
+			return false, fmt.Errorf("pausing SLA: %w", err)
+		}
+		return true, nil
+	case !shouldPause && appliedSLA.PausedAt.Valid:
+		if err := m.resume(appliedSLA); err != nil {
+			return true, err
+		}
+		return false, nil
+	}
+	return appliedSLA.PausedAt.Valid, nil
+}
+
+// resume closes the open pause of an applied SLA and extends all unmet deadlines by the paused business time.
+func (m *Manager) resume(appliedSLA models.AppliedSLA) error {
+	now := time.Now()
+	businessHrs, timezone, err := m.getBusinessHoursAndTimezone(appliedSLA.ConversationAssignedTeamID.Int)
+	if err != nil {
+		return fmt.Errorf("fetching business hours for resuming SLA: %w", err)
+	}
+
+	pausedMinutes, err := m.BusinessMinutesBetween(appliedSLA.PausedAt.Time, now, businessHrs, timezone)
+	if err != nil {
+		return fmt.Errorf("calculating paused business minutes: %w", err)
+	}
+
+	m.lo.Info("resuming SLA clock", "applied_sla_id", appliedSLA.ID, "conversation_id", appliedSLA.ConversationID, "paused_minutes", pausedMinutes)
+	if _, err := m.q.ResumeAppliedSLAPause.Exec(appliedSLA.ID, now, pausedMinutes); err != nil {
+		m.lo.Error("error resuming SLA", "applied_sla_id", appliedSLA.ID, "error", err)
+		return fmt.Errorf("resuming SLA: %w", err)
+	}
+
+	// Paused entirely outside business hours, nothing to extend.
+	if pausedMinutes <= 0 {
+		return nil
+	}
+
+	extend := func(deadline null.Time, metAt, breachedAt null.Time) (null.Time, error) {
+		if !deadline.Valid || metAt.Valid || breachedAt.Valid {
+			return null.Time{}, nil
+		}
+		d, err := m.CalculateDeadline(deadline.Time, pausedMinutes, businessHrs, timezone)
+		if err != nil {
+			return null.Time{}, err
+		}
+		return null.TimeFrom(d), nil
+	}
+
+	var deadlines Deadlines
+	if deadlines.FirstResponse, err = extend(appliedSLA.FirstResponseDeadlineAt, appliedSLA.FirstResponseMetAt, appliedSLA.FirstResponseBreachedAt); err != nil {
+		return fmt.Errorf("extending first response deadline: %w", err)
+	}
+	if deadlines.Resolution, err = extend(appliedSLA.ResolutionDeadlineAt, appliedSLA.ResolutionMetAt, appliedSLA.ResolutionBreachedAt); err != nil {
+		return fmt.Errorf("extending resolution deadline: %w", err)
+	}
+	if _, err := m.q.ExtendAppliedSLADeadlines.Exec(appliedSLA.ID, deadlines.FirstResponse, deadlines.Resolution); err != nil {
+		m.lo.Error("error extending applied SLA deadlines", "applied_sla_id", appliedSLA.ID, "error", err)
+		return fmt.Errorf("extending applied SLA deadlines: %w", err)
+	}
+
+	// Warnings scheduled for the old deadlines are rescheduled for the extended ones.
+	if _, err := m.q.DeletePendingSLAWarnings.Exec(appliedSLA.ID); err != nil {
+		m.lo.Error("error deleting pending SLA warning notifications", "applied_sla_id", appliedSLA.ID, "error", err)
+	}
+	sla, err := m.Get(appliedSLA.SLAPolicyID)
+	if err != nil {
+		return err
+	}
+	m.createNotificationSchedule(sla.Notifications, appliedSLA.ID, null.Int{}, deadlines, Breaches{})
+
+	// Extend unmet SLA events i.e. next response.
+	var (
+		events       []models.SLAEvent
+		nextResponse null.Time
+	)
+	if err := m.q.GetUnmetSLAEvents.Select(&events, appliedSLA.ID); err != nil {
+		m.lo.Error("error fetching unmet SLA events", "applied_sla_id", appliedSLA.ID, "error", err)
+		return fmt.Errorf("fetching unmet SLA events: %w", err)
+	}
+	for _, event := range events {
+		deadline, err := m.CalculateDeadline(event.DeadlineAt, pausedMinutes, businessHrs, timezone)
+		if err != nil {
+			return fmt.Errorf("extending SLA event deadline: %w", err)
+		}
+		if _, err := m.q.UpdateSLAEventDeadline.Exec(event.ID, deadline); err != nil {
+			m.lo.Error("error updating SLA event deadline", "sla_event_id", event.ID, "error", err)
+			return fmt.Errorf("updating SLA event deadline: %w", err)
+		}
+		if event.Type == MetricNextResponse {
+			nextResponse = null.TimeFrom(deadline)
+		}
+		m.createNotificationSchedule(sla.Notifications, appliedSLA.ID, null.IntFrom(event.ID), Deadlines{NextResponse: null.TimeFrom(deadline)}, Breaches{})
+	}
+
+	// Update next SLA deadline (SLA target) in the conversation.
+	if _, err := m.q.UpdateConversationNextSLADeadline.Exec(appliedSLA.ConversationID, nextResponse); err != nil {
+		m.lo.Error("error updating conversation next SLA deadline", "conversation_id", appliedSLA.ConversationID, "error", err)
+		return fmt.Errorf("updating conversation next SLA deadline: %w", err)
+	}
+	return nil
+}
+
 // evaluatePendingSLAEvents fetches pending SLA events, updates their status based on deadlines, and schedules notifications for breached SLAs.
 func (m *Manager) evaluatePendingSLAEvents(ctx context.Context) error {
 	var slaEvents []models.SLAEvent
@@ -445,6 +609,14 @@ func (m *Manager) evaluatePendingSLAEvents(ctx context.Context) error {
 			m.createNotificationSchedule(slaPolicy.Notifications, event.AppliedSLAID, null.IntFrom(event.ID), Deadlines{}, Breaches{
 				NextResponse: null.TimeFrom(time.Now()),
 			})
+
+			// Execute escalations for the breach.
+			var appliedSLA models.AppliedSLA
+			if err := m.q.GetAppliedSLA.GetContext(ctx, &appliedSLA, event.AppliedSLAID); err != nil {
+				m.lo.Error("error fetching applied SLA", "error", err)
+				continue
+			}
+			m.escalate(appliedSLA.ConversationID, slaPolicy.Escalations, MetricNextResponse)
 		}
 	}
 	return nil
@@ -552,6 +724,15 @@ func (m *Manager) SendNotification(scheduledNotification models.ScheduledSLANoti
 		return nil
 	}
 
+	// Warnings are rescheduled when a paused SLA resumes, skip them while the SLA clock is paused.
+	if appliedSLA.PausedAt.Valid && scheduledNotification.NotificationType == NotificationTypeWarning {
+		m.lo.Info("marking sla notification as processed as the SLA is paused", "applied_sla_id", appliedSLA.ID, "scheduled_notification_id", scheduledNotification.ID)
+		if _, err := m.q.UpdateSLANotificationProcessed.Exec(scheduledNotification.ID); err != nil {
+			m.lo.Error("error marking notification as processed", "error", err)
+		}
+		return nil
+	}
+
 	// Send to all recipients (agents).
 	for _, recipientS := range scheduledNotification.Recipients {
 		// Check if SLA is already met, if met mark notification as processed and return.
@@ -852,6 +1033,19 @@ func (m *Manager) evaluatePendingSLAs(ctx context.Context) error {
 // evaluateSLA evaluates an SLA policy on an applied SLA.
 func (m *Manager) evaluateSLA(appliedSLA models.AppliedSLA) error {
 	m.lo.Debug("evaluating SLA", "conversation_id", appliedSLA.ConversationID, "applied_sla_id", appliedSLA.ID)
+
+	// Pause or resume the SLA clock, deadlines cannot breach while paused.
+	paused, err := m.syncPause(appliedSLA)
+	if err != nil {
+		return fmt.Errorf("syncing SLA pause: %w", err)
+	}
+	if !paused && appliedSLA.PausedAt.Valid {
+		// Resumed, re-fetch the extended deadlines.
+		if err := m.q.GetAppliedSLA.Get(&appliedSLA, appliedSLA.ID); err != nil {
+			return fmt.Errorf("fetching applied SLA: %w", err)
+		}
+	}
+
 	checkDeadline := func(deadline time.Time, metAt null.Time, metric string) error {
 		if deadline.IsZero() {
 			m.lo.Warn("deadline zero, skipping checking the deadline", "conversation_id", appliedSLA.ConversationID, "applied_sla_id", appliedSLA.ID, "metric", metric)
@@ -859,9 +1053,9 @@ func (m *Manager) evaluateSLA(appliedSLA models.AppliedSLA) error {
 		}
 
 		now := time.Now()
-		if !metAt.Valid && now.After(deadline) {
+		if !metAt.Valid && now.After(deadline) && !paused {
 			m.lo.Debug("SLA breached as current time is after deadline", "deadline", deadline, "now", now, "metric", metric)
-			if err := m.handleSLABreach(appliedSLA.ID, appliedSLA.SLAPolicyID, metric); err != nil {
+			if err := m.handleSLABreach(appliedSLA.ID, appliedSLA.SLAPolicyID, appliedSLA.ConversationID, metric); err != nil {
 				return fmt.Errorf("updating SLA breach timestamp: %w", err)
 			}
 			return nil
@@ -870,7 +1064,7 @@ func (m *Manager) evaluateSLA(appliedSLA models.AppliedSLA) error {
 		if metAt.Valid {
 			if metAt.Time.After(deadline) {
 				m.lo.Debug("SLA breached as met_at is after deadline", "deadline", deadline, "met_at", metAt.Time, "metric", metric)
-				if err := m.handleSLABreach(appliedSLA.ID, appliedSLA.SLAPolicyID, metric); err != nil {
+				if err := m.handleSLABreach(appliedSLA.ID, appliedSLA.SLAPolicyID, appliedSLA.ConversationID, metric); err != nil {
 					return fmt.Errorf("updating SLA breach: %w", err)
 				}
 			} else {
@@ -913,8 +1107,8 @@ func (m *Manager) evaluateSLA(appliedSLA models.AppliedSLA) error {
 }
 
 // handleSLABreach processes a breach for the given SLA metric on an applied SLA.
-// It updates the breach timestamp and schedules breach notifications if applicable.
-func (m *Manager) handleSLABreach(appliedSLAID, slaPolicyID int, metric string) error {
+// It updates the breach timestamp, schedules breach notifications and executes escalations if applicable.
+func (m *Manager) handleSLABreach(appliedSLAID, slaPolicyID, conversationID int, metric string) error {
 	if _, err := m.q.UpdateAppliedSLABreachedAt.Exec(appliedSLAID, metric); err != nil {
 		return err
 	}
@@ -939,5 +1133,34 @@ func (m *Manager) handleSLABreach(appliedSLAID, slaPolicyID int, metric string)
 		Resolution:    resolution,
 	})
 
+	// Execute escalations for the breach.
+	m.escalate(conversationID, sla.Escalations, metric)
+
 	return nil
 }
+
+// escalate executes the escalation actions configured for the breached metric on the conversation.
+// Actions are applied as the system user so they are recorded in the conversation activity.
+func (m *Manager) escalate(conversationID int, escalations models.SlaEscalations, metric string) {
+	if len(escalations) == 0 || m.conversationStore == nil {
+		return
+	}
+
+	conversation, err := m.conversationStore.GetConversation(conversationID, "")
+	if err != nil {
+		m.lo.Error("error fetching conversation for SLA escalation", "conversation_id", conversationID, "error", err)
+		return
+	}
+
+	for _, escalation := range escalations {
+		if escalation.Metric != metric && escalation.Metric != MetricAll {
+			continue
+		}
+		for _, action := range escalation.Actions {
+			m.lo.Info("executing SLA escalation action", "conversation_id", conversationID, "metric", metric, "action", action.Type)
+			if err := m.conversationStore.ApplyEscalationAction(action, conversation, umodels.User{}); err != nil {
+				m.lo.Error("error executing SLA escalation action", "conversation_id", conversationID, "metric", metric, "action", action.Type, "error", err)
+			}
+		}
+	}
+}
@@ -8,7 +8,7 @@ SELECT id, name, emoji from teams order by name;
 SELECT id, emoji, created_at, updated_at, name, conversation_assignment_type, timezone, max_auto_assigned_conversations from teams WHERE id IN (SELECT team_id FROM team_members WHERE user_id = $1) order by updated_at desc;
 
 -- name: get-team
-SELECT id, emoji, name, conversation_assignment_type, timezone, business_hours_id, sla_policy_id, max_auto_assigned_conversations from teams where id = $1;
+SELECT id, created_at, updated_at, emoji, name, conversation_assignment_type, timezone, business_hours_id, sla_policy_id, max_auto_assigned_conversations from teams where id = $1;
 
 -- name: get-team-members
 SELECT u.id, t.id as team_id, u.availability_status
@@ -17,6 +17,22 @@ JOIN team_members tm ON tm.user_id = u.id
 JOIN teams t ON t.id = tm.team_id
 WHERE t.id = $1 AND u.deleted_at IS NULL AND u.type = 'agent' AND u.enabled = true;
 
+-- name: get-team-agents
+SELECT u.id, u.email, u.first_name, u.last_name
+FROM users u
+JOIN team_members tm ON tm.user_id = u.id
+WHERE tm.team_id = $1 AND u.deleted_at IS NULL AND u.type = 'agent'
+ORDER BY u.id;
+
+-- name: add-team-members
+INSERT INTO team_members (team_id, user_id)
+SELECT $1, u.id FROM users u
+WHERE u.id = ANY($2::INT[]) AND u.deleted_at IS NULL AND u.type = 'agent'
+ON CONFLICT DO NOTHING;
+
+-- name: remove-team-members
+DELETE FROM team_members WHERE team_id = $1 AND user_id = ANY($2::INT[]);
+
 -- name: insert-team
 INSERT INTO teams (name, timezone, conversation_assignment_type, business_hours_id, sla_policy_id, emoji, max_auto_assigned_conversations) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING *;
 
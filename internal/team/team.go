@@ -47,6 +47,9 @@ type queries struct {
 	UpdateTeam        *sqlx.Stmt `query:"update-team"`
 	DeleteTeam        *sqlx.Stmt `query:"delete-team"`
 	GetTeamMembers    *sqlx.Stmt `query:"get-team-members"`
+	GetTeamAgents     *sqlx.Stmt `query:"get-team-agents"`
+	AddTeamMembers    *sqlx.Stmt `query:"add-team-members"`
+	RemoveTeamMembers *sqlx.Stmt `query:"remove-team-members"`
 	UpsertUserTeams   *sqlx.Stmt `query:"upsert-user-teams"`
 	UserBelongsToTeam *sqlx.Stmt `query:"user-belongs-to-team"`
 }
@@ -180,3 +183,31 @@ func (u *Manager) GetMembers(id int) ([]umodels.User, error) {
 	}
 	return users, nil
 }
+
+// GetAllMembers retrieves all agents of a team including disabled agents.
+func (u *Manager) GetAllMembers(id int) ([]umodels.User, error) {
+	var users = make([]umodels.User, 0)
+	if err := u.q.GetTeamAgents.Select(&users, id); err != nil {
+		u.lo.Error("error fetching team members", "team_id", id, "error", err)
+		return users, envelope.NewError(envelope.GeneralError, u.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.team}"), nil)
+	}
+	return users, nil
+}
+
+// AddMembers adds agents to a team, IDs that are not agents are ignored.
+func (u *Manager) AddMembers(id int, userIDs []int) error {
+	if _, err := u.q.AddTeamMembers.Exec(id, pq.Array(userIDs)); err != nil {
+		u.lo.Error("error adding team members", "team_id", id, "error", err)
+		return envelope.NewError(envelope.GeneralError, u.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.team}"), nil)
+	}
+	return nil
+}
+
+// RemoveMembers removes agents from a team.
+func (u *Manager) RemoveMembers(id int, userIDs []int) error {
+	if _, err := u.q.RemoveTeamMembers.Exec(id, pq.Array(userIDs)); err != nil {
+		u.lo.Error("error removing team members", "team_id", id, "error", err)
+		return envelope.NewError(envelope.GeneralError, u.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.team}"), nil)
+	}
+	return nil
+}
@@ -82,9 +82,40 @@ func (u *Manager) GetAgentsCompact() ([]models.User, error) {
 	return users, nil
 }
 
-// CreateAgent creates a new agent user.
+// GetAgentsRange returns agents ordered by creation starting at the offset along with the total number of agents, e.g. for SCIM lists.
+func (u *Manager) GetAgentsRange(offset, limit int) ([]models.User, int, error) {
+	var (
+		users = make([]models.User, 0)
+		total int
+	)
+	if err := u.q.CountAgents.Get(&total); err != nil {
+		u.lo.Error("error counting agents", "error", err)
+		return users, 0, envelope.NewError(envelope.GeneralError, u.i18n.Ts("globals.messages.errorFetching", "name", u.i18n.P("globals.terms.user")), nil)
+	}
+	if limit <= 0 || offset >= total {
+		return users, total, nil
+	}
+	if err := u.q.GetAgentsRange.Select(&users, offset, limit); err != nil {
+		u.lo.Error("error fetching agents", "error", err)
+		return users, 0, envelope.NewError(envelope.GeneralError, u.i18n.Ts("globals.messages.errorFetching", "name", u.i18n.P("globals.terms.user")), nil)
+	}
+	return users, total, nil
+}
+
+// CreateAgent creates a new agent user, with the password meeting the password policy if provided or a random one.
 func (u *Manager) CreateAgent(user *models.User) (error) {
-	password, err := u.generatePassword()
+	var (
+		password []byte
+		err      error
+	)
+	if user.NewPassword != "" {
+		if err := u.checkPassword(0, user.NewPassword); err != nil {
+			return err
+		}
+		password, err = bcrypt.GenerateFromPassword([]byte(user.NewPassword), bcrypt.DefaultCost)
+	} else {
+		password, err = u.generatePassword()
+	}
 	if err != nil {
 		u.lo.Error("error generating password", "error", err)
 		return envelope.NewError(envelope.GeneralError, u.i18n.Ts("globals.messages.errorCreating", "name", "{globals.terms.user}"), nil)
@@ -109,14 +140,15 @@ func (u *Manager) UpdateAgent(id int, user models.User) error {
 
 	// Set password?
 	if user.NewPassword != "" {
-		if !IsStrongPassword(user.NewPassword) {
-			return envelope.NewError(envelope.InputError, PasswordHint, nil)
+		if err := u.checkPassword(id, user.NewPassword); err != nil {
+			return err
 		}
 		hashedPassword, err = bcrypt.GenerateFromPassword([]byte(user.NewPassword), bcrypt.DefaultCost)
 		if err != nil {
 			u.lo.Error("error generating bcrypt password", "error", err)
 			return envelope.NewError(envelope.GeneralError, u.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.user}"), nil)
 		}
+		u.archivePassword(id)
 		u.lo.Info("setting new password for user", "user_id", id)
 	}
 
@@ -129,6 +161,16 @@ func (u *Manager) UpdateAgent(id int, user models.User) error {
 	return nil
 }
 
+// UpdateAgentRoles replaces the roles of an agent, e.g. with the roles mapped from the groups of an SSO login.
+func (u *Manager) UpdateAgentRoles(id int, roles []string) error {
+	if _, err := u.q.UpdateAgentRoles.Exec(id, pq.Array(roles)); err != nil {
+		u.lo.Error("error updating agent roles", "user_id", id, "error", err)
+		return envelope.NewError(envelope.GeneralError, u.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.user}"), nil)
+	}
+	u.InvalidateAgentCache(id)
+	return nil
+}
+
 // SoftDeleteAgent soft deletes an agent by ID.
 func (u *Manager) SoftDeleteAgent(id int) error {
 	// Disallow if user is system user.
@@ -0,0 +1,144 @@
+package user
+
+import (
+	"database/sql"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	"github.com/abhinavxd/libredesk/internal/stringutil"
+	"github.com/abhinavxd/libredesk/internal/user/models"
+	"github.com/lib/pq"
+	"github.com/volatiletech/null/v9"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	apiKeyLength    = 32
+	apiSecretLength = 64
+	maxAPIKeyName   = 140
+)
+
+// GetAPIKeys returns the API keys of a user.
+func (u *Manager) GetAPIKeys(userID int) ([]models.APIKey, error) {
+	var keys = make([]models.APIKey, 0)
+	if err := u.q.GetAPIKeys.Select(&keys, userID); err != nil {
+		u.lo.Error("error fetching API keys", "error", err, "user_id", userID)
+		return keys, envelope.NewError(envelope.GeneralError, u.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.apiKey}"), nil)
+	}
+	return keys, nil
+}
+
+// CreateAPIKey creates a named API key for a user restricted to the given subset of the user's permissions, all permissions if empty.
+// The key can optionally expire and be restricted to IP addresses or CIDR ranges.
+// Returns the created key and its secret, the secret is only stored hashed and can't be retrieved later.
+func (u *Manager) CreateAPIKey(user models.User, name string, permissions, allowedIPs []string, expiresAt null.Time) (models.APIKey, string, error) {
+	var key models.APIKey
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return key, "", envelope.NewError(envelope.InputError, u.i18n.Ts("globals.messages.empty", "name", "`name`"), nil)
+	}
+	if len(name) > maxAPIKeyName {
+		return key, "", envelope.NewError(envelope.InputError, u.i18n.Ts("globals.messages.invalid", "name", "`name`"), nil)
+	}
+	for _, p := range permissions {
+		if !slices.Contains(user.Permissions, p) {
+			return key, "", envelope.NewError(envelope.InputError, u.i18n.T("user.apiKeyPermissionNotAllowed"), nil)
+		}
+	}
+	for _, ip := range allowedIPs {
+		if !models.ValidIPOrCIDR(ip) {
+			return key, "", envelope.NewError(envelope.InputError, u.i18n.Ts("globals.messages.invalid", "name", "{globals.terms.ipAddress}"), nil)
+		}
+	}
+	if expiresAt.Valid && !expiresAt.Time.After(time.Now()) {
+		return key, "", envelope.NewError(envelope.InputError, u.i18n.T("user.apiKeyExpiryInPast"), nil)
+	}
+
+	apiKey, err := stringutil.RandomAlphanumeric(apiKeyLength)
+	if err != nil {
+		u.lo.Error("error generating API key", "error", err, "user_id", user.ID)
+		return key, "", envelope.NewError(envelope.GeneralError, u.i18n.Ts("globals.messages.errorGenerating", "name", "{globals.terms.apiKey}"), nil)
+	}
+	apiSecret, err := stringutil.RandomAlphanumeric(apiSecretLength)
+	if err != nil {
+		u.lo.Error("error generating API secret", "error", err, "user_id", user.ID)
+		return key, "", envelope.NewError(envelope.GeneralError, u.i18n.Ts("globals.messages.errorGenerating", "name", "{globals.terms.apiKey}"), nil)
+	}
+	secretHash, err := bcrypt.GenerateFromPassword([]byte(apiSecret), bcrypt.DefaultCost)
+	if err != nil {
+		u.lo.Error("error hashing API secret", "error", err, "user_id", user.ID)
+		return key, "", envelope.NewError(envelope.GeneralError, u.i18n.Ts("globals.messages.errorGenerating", "name", "{globals.terms.apiKey}"), nil)
+	}
+
+	// Columns are not nullable, store empty arrays instead of nil.
+	if permissions == nil {
+		permissions = []string{}
+	}
+	if allowedIPs == nil {
+		allowedIPs = []string{}
+	}
+	if err := u.q.InsertAPIKey.Get(&key, user.ID, name, apiKey, string(secretHash), pq.StringArray(permissions), pq.StringArray(allowedIPs), expiresAt); err != nil {
+		u.lo.Error("error saving API key", "error", err, "user_id", user.ID)
+		return key, "", envelope.NewError(envelope.GeneralError, u.i18n.Ts("globals.messages.errorGenerating", "name", "{globals.terms.apiKey}"), nil)
+	}
+	return key, apiSecret, nil
+}
+
+// ValidateAPIKey validates an API key and secret used from the given IP and returns the key's user,
+// the user's permissions are restricted to the key's permissions.
+func (u *Manager) ValidateAPIKey(apiKey, apiSecret, ip string) (models.User, error) {
+	var (
+		key  models.APIKey
+		user models.User
+	)
+	if err := u.q.GetAPIKey.Get(&key, apiKey); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return user, envelope.NewError(envelope.UnauthorizedError, u.i18n.Ts("globals.messages.invalid", "name", u.i18n.T("globals.terms.credential")), nil)
+		}
+		u.lo.Error("error fetching API key", "error", err)
+		return user, envelope.NewError(envelope.GeneralError, u.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.apiKey}"), nil)
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(key.SecretHash), []byte(apiSecret)); err != nil {
+		return user, envelope.NewError(envelope.UnauthorizedError, u.i18n.Ts("globals.messages.invalid", "name", u.i18n.T("globals.terms.credential")), nil)
+	}
+	if key.Expired(time.Now()) {
+		return user, envelope.NewError(envelope.UnauthorizedError, u.i18n.T("user.apiKeyExpired"), nil)
+	}
+	if !key.AllowsIP(ip) {
+		u.lo.Warn("API key used from IP not in allowlist", "api_key_id", key.ID, "user_id", key.UserID, "ip", ip)
+		return user, envelope.NewError(envelope.PermissionError, u.i18n.T("user.apiKeyIPNotAllowed"), nil)
+	}
+
+	user, err := u.GetAgentCachedOrLoad(key.UserID)
+	if err != nil {
+		return user, err
+	}
+	if !user.Enabled {
+		return user, envelope.NewError(envelope.PermissionError, u.i18n.T("user.accountDisabled"), nil)
+	}
+	user.APIKeyID = key.ID
+	user.Permissions = key.Scope(user.Permissions)
+
+	if _, err := u.q.UpdateAPIKeyLastUsed.Exec(key.ID, ip); err != nil {
+		u.lo.Error("error updating API key last used", "error", err, "api_key_id", key.ID)
+	}
+	return user, nil
+}
+
+// RevokeAPIKey deletes an API key of a user.
+func (u *Manager) RevokeAPIKey(userID, keyID int) error {
+	res, err := u.q.DeleteAPIKey.Exec(keyID, userID)
+	if err != nil {
+		u.lo.Error("error revoking API key", "error", err, "user_id", userID, "api_key_id", keyID)
+		return envelope.NewError(envelope.GeneralError, u.i18n.Ts("globals.messages.errorRevoking", "name", "{globals.terms.apiKey}"), nil)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return envelope.NewError(envelope.NotFoundError, u.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.apiKey}"), nil)
+	}
+	return nil
+}
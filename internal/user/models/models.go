@@ -2,9 +2,11 @@ package models
 
 import (
 	"encoding/json"
+	"net/netip"
 	"slices"
 	"time"
 
+	authzModels "github.com/abhinavxd/libredesk/internal/authz/models"
 	rmodels "github.com/abhinavxd/libredesk/internal/role/models"
 	tmodels "github.com/abhinavxd/libredesk/internal/team/models"
 	"github.com/lib/pq"
@@ -46,6 +48,7 @@ type User struct {
 	Password               string          `db:"password" json:"-"`
 	LastActiveAt           null.Time       `db:"last_active_at" json:"last_active_at"`
 	LastLoginAt            null.Time       `db:"last_login_at" json:"last_login_at"`
+	PasswordChangedAt      null.Time       `db:"password_changed_at" json:"-"`
 	Roles                  pq.StringArray  `db:"roles" json:"roles"`
 	Permissions            pq.StringArray  `db:"permissions" json:"permissions"`
 	Meta                   pq.StringArray  `db:"meta" json:"meta"`
@@ -58,14 +61,96 @@ type User struct {
 	SourceChannel          null.String     `json:"-"`
 	SourceChannelID        null.String     `json:"-"`
 
-	// API Key fields
-	APIKey           null.String `db:"api_key" json:"api_key"`
-	APIKeyLastUsedAt null.Time   `db:"api_key_last_used_at" json:"api_key_last_used_at"`
-	APISecret        null.String `db:"api_secret" json:"-"`
+	// RoleScopes are the inboxes and teams the permissions of each role of the user are restricted to.
+	RoleScopes authzModels.Scopes `db:"role_scopes" json:"-"`
+
+	// APIKeyID is set when the user is authenticated with an API key, Permissions are then restricted to the key's permissions.
+	APIKeyID int `db:"-" json:"-"`
+
+	// RoutePermission is the permission the request was authorized with, conversation access checks require it
+	// to be granted in the scope of the conversation.
+	RoutePermission string `db:"-" json:"-"`
+
+	// LockedUntil is set when the user is locked out after too many failed login attempts.
+	LockedUntil null.Time `db:"-" json:"locked_until,omitempty"`
+
+	// Two-factor authentication fields, TOTPRequired is set if any of the user's roles requires it.
+	TOTPEnabled  bool `db:"totp_enabled" json:"totp_enabled"`
+	TOTPRequired bool `db:"totp_required" json:"totp_required"`
 
 	Total int `json:"total,omitempty"`
 }
 
+// APIKey is a named API key of an agent.
+type APIKey struct {
+	ID         int       `db:"id" json:"id"`
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt  time.Time `db:"updated_at" json:"updated_at"`
+	UserID     int       `db:"user_id" json:"user_id"`
+	Name       string    `db:"name" json:"name"`
+	Key        string    `db:"api_key" json:"api_key"`
+	SecretHash string    `db:"secret_hash" json:"-"`
+	// Permissions is the subset of the user's permissions the key is restricted to, empty for all of the user's permissions.
+	Permissions pq.StringArray `db:"permissions" json:"permissions"`
+	// AllowedIPs are the IP addresses or CIDR ranges the key can be used from, empty for any.
+	AllowedIPs pq.StringArray `db:"allowed_ips" json:"allowed_ips"`
+	ExpiresAt  null.Time      `db:"expires_at" json:"expires_at"`
+	LastUsedAt null.Time      `db:"last_used_at" json:"last_used_at"`
+	LastUsedIP null.String    `db:"last_used_ip" json:"last_used_ip"`
+}
+
+// Expired returns true if the key has an expiry date before now.
+func (k APIKey) Expired(now time.Time) bool {
+	return k.ExpiresAt.Valid && !now.Before(k.ExpiresAt.Time)
+}
+
+// AllowsIP returns true if the key has no IP allowlist or the IP matches one of its addresses or CIDR ranges.
+func (k APIKey) AllowsIP(ip string) bool {
+	if len(k.AllowedIPs) == 0 {
+		return true
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, allowed := range k.AllowedIPs {
+		if prefix, err := netip.ParsePrefix(allowed); err == nil {
+			if prefix.Contains(addr) {
+				return true
+			}
+			continue
+		}
+		if a, err := netip.ParseAddr(allowed); err == nil && a.Unmap() == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// Scope returns the permissions of a user restricted to the key's permissions.
+func (k APIKey) Scope(permissions []string) []string {
+	if len(k.Permissions) == 0 {
+		return permissions
+	}
+	scoped := make([]string, 0, len(permissions))
+	for _, p := range permissions {
+		if slices.Contains(k.Permissions, p) {
+			scoped = append(scoped, p)
+		}
+	}
+	return scoped
+}
+
+// ValidIPOrCIDR returns true if the string is an IP address or a CIDR range.
+func ValidIPOrCIDR(s string) bool {
+	if _, err := netip.ParsePrefix(s); err == nil {
+		return true
+	}
+	_, err := netip.ParseAddr(s)
+	return err == nil
+}
+
 type Note struct {
 	ID        int         `db:"id" json:"id"`
 	CreatedAt time.Time   `db:"created_at" json:"created_at"`
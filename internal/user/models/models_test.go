@@ -0,0 +1,63 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/volatiletech/null/v9"
+)
+
+func TestAPIKeyAllowsIP(t *testing.T) {
+	key := APIKey{AllowedIPs: []string{"10.0.0.0/8", "192.168.1.10", "2001:db8::/32"}}
+
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"10.1.2.3", true},
+		{"192.168.1.10", true},
+		{"::ffff:192.168.1.10", true},
+		{"192.168.1.11", false},
+		{"2001:db8::1", true},
+		{"2001:db9::1", false},
+		{"invalid", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			if got := key.AllowsIP(tt.ip); got != tt.want {
+				t.Errorf("AllowsIP(%q) = %v, want %v", tt.ip, got, tt.want)
+			}
+		})
+	}
+
+	if !(APIKey{}).AllowsIP("203.0.113.1") {
+		t.Error("expected key without allowlist to allow any IP")
+	}
+}
+
+func TestAPIKeyExpired(t *testing.T) {
+	now := time.Now()
+	if (APIKey{}).Expired(now) {
+		t.Error("expected key without expiry to not be expired")
+	}
+	if !(APIKey{ExpiresAt: null.TimeFrom(now.Add(-time.Minute))}).Expired(now) {
+		t.Error("expected key to be expired")
+	}
+	if (APIKey{ExpiresAt: null.TimeFrom(now.Add(time.Minute))}).Expired(now) {
+		t.Error("expected key to not be expired")
+	}
+}
+
+func TestAPIKeyScope(t *testing.T) {
+	perms := []string{"conversations:read", "conversations:write", "users:manage"}
+
+	if got := (APIKey{}).Scope(perms); !reflect.DeepEqual(got, perms) {
+		t.Errorf("Scope() = %v, want all permissions", got)
+	}
+
+	key := APIKey{Permissions: []string{"conversations:read", "reports:manage"}}
+	if got, want := key.Scope(perms), []string{"conversations:read"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Scope() = %v, want %v", got, want)
+	}
+}
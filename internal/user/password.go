@@ -0,0 +1,158 @@
+package user
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	smodels "github.com/abhinavxd/libredesk/internal/setting/models"
+	"github.com/abhinavxd/libredesk/internal/user/models"
+	"github.com/jmoiron/sqlx/types"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// MinPolicyPasswordLength and MaxPolicyHistoryCount bound the password policy settings.
+	MinPolicyPasswordLength = 8
+	MaxPolicyHistoryCount   = 24
+	// MaxPasswordLength is the length limit of bcrypt.
+	MaxPasswordLength = 72
+)
+
+// defaultPasswordPolicy applies to the system user and to settings missing from the password policy.
+var defaultPasswordPolicy = smodels.PasswordPolicy{
+	MinLength:        minPassword,
+	RequireUppercase: true,
+	RequireLowercase: true,
+	RequireNumber:    true,
+	RequireSpecial:   true,
+}
+
+// settingsStore provides the password policy settings.
+type settingsStore interface {
+	GetByPrefix(prefix string) (types.JSONText, error)
+}
+
+// GetPasswordPolicy returns the password policy agent passwords must meet.
+func (u *Manager) GetPasswordPolicy() (smodels.PasswordPolicy, error) {
+	policy := defaultPasswordPolicy
+	out, err := u.setting.GetByPrefix("password.")
+	if err != nil {
+		return policy, err
+	}
+	if err := json.Unmarshal(out, &policy); err != nil {
+		u.lo.Error("error unmarshalling password policy", "error", err)
+		return policy, envelope.NewError(envelope.GeneralError, u.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.setting}"), nil)
+	}
+	return policy, nil
+}
+
+// PasswordExpired returns true if the password of the user is older than the max age of the password policy.
+func (u *Manager) PasswordExpired(user models.User) bool {
+	policy, err := u.GetPasswordPolicy()
+	if err != nil {
+		return false
+	}
+	return passwordExpired(policy, user.PasswordChangedAt.Time, time.Now())
+}
+
+// checkPassword returns an error if the password doesn't meet the password policy or reuses one of the last passwords of the user,
+// userID is 0 for new users.
+func (u *Manager) checkPassword(userID int, password string) error {
+	policy, err := u.GetPasswordPolicy()
+	if err != nil {
+		return err
+	}
+	if !meetsPasswordPolicy(policy, password) {
+		return envelope.NewError(envelope.InputError, u.passwordPolicyHint(policy), nil)
+	}
+	if isBannedPassword(policy, password) {
+		return envelope.NewError(envelope.InputError, u.i18n.T("user.passwordBanned"), nil)
+	}
+	if userID == 0 || policy.HistoryCount <= 0 {
+		return nil
+	}
+
+	var hashes []string
+	if err := u.q.GetPasswordHistory.Select(&hashes, userID, policy.HistoryCount-1); err != nil {
+		u.lo.Error("error fetching password history", "user_id", userID, "error", err)
+		return envelope.NewError(envelope.GeneralError, u.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.password}"), nil)
+	}
+	for _, hash := range hashes {
+		if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil {
+			return envelope.NewError(envelope.InputError, u.i18n.Ts("user.passwordReused", "count", strconv.Itoa(policy.HistoryCount)), nil)
+		}
+	}
+	return nil
+}
+
+// archivePassword adds the current password of a user to their password history before it's changed.
+func (u *Manager) archivePassword(userID int) {
+	if _, err := u.q.ArchivePassword.Exec(userID, MaxPolicyHistoryCount-1); err != nil {
+		u.lo.Error("error archiving password", "user_id", userID, "error", err)
+	}
+}
+
+// passwordPolicyHint describes the length and character rules of the password policy.
+func (u *Manager) passwordPolicyHint(policy smodels.PasswordPolicy) string {
+	hint := []string{u.i18n.Ts("user.passwordPolicy.length", "min", strconv.Itoa(policy.MinLength), "max", strconv.Itoa(maxPassword))}
+	if policy.RequireUppercase {
+		hint = append(hint, u.i18n.T("user.passwordPolicy.uppercase"))
+	}
+	if policy.RequireLowercase {
+		hint = append(hint, u.i18n.T("user.passwordPolicy.lowercase"))
+	}
+	if policy.RequireNumber {
+		hint = append(hint, u.i18n.T("user.passwordPolicy.number"))
+	}
+	if policy.RequireSpecial {
+		hint = append(hint, u.i18n.T("user.passwordPolicy.special"))
+	}
+	return strings.Join(hint, " ")
+}
+
+// meetsPasswordPolicy checks the password against the length and character rules of the password policy.
+func meetsPasswordPolicy(policy smodels.PasswordPolicy, password string) bool {
+	// bcrypt hashes at most 72 bytes.
+	if len(password) > maxPassword || len([]rune(password)) < max(policy.MinLength, MinPolicyPasswordLength) {
+		return false
+	}
+	var hasUpper, hasLower, hasNumber, hasSpecial bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasNumber = true
+		case !unicode.IsLetter(r):
+			hasSpecial = true
+		}
+	}
+	return (!policy.RequireUppercase || hasUpper) &&
+		(!policy.RequireLowercase || hasLower) &&
+		(!policy.RequireNumber || hasNumber) &&
+		(!policy.RequireSpecial || hasSpecial)
+}
+
+// isBannedPassword checks if the password is in the banned passwords of the password policy, ignoring case.
+func isBannedPassword(policy smodels.PasswordPolicy, password string) bool {
+	for _, banned := range policy.BannedPasswords {
+		if strings.EqualFold(strings.TrimSpace(banned), password) {
+			return true
+		}
+	}
+	return false
+}
+
+// passwordExpired returns true if a password changed at changedAt is older than the max age of the password policy at now.
+func passwordExpired(policy smodels.PasswordPolicy, changedAt, now time.Time) bool {
+	if policy.MaxAgeDays <= 0 || changedAt.IsZero() {
+		return false
+	}
+	return now.Sub(changedAt) > time.Duration(policy.MaxAgeDays)*24*time.Hour
+}
@@ -0,0 +1,66 @@
+package user
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	smodels "github.com/abhinavxd/libredesk/internal/setting/models"
+)
+
+func TestMeetsPasswordPolicy(t *testing.T) {
+	lengthOnly := smodels.PasswordPolicy{MinLength: 12}
+	tests := []struct {
+		name     string
+		policy   smodels.PasswordPolicy
+		password string
+		want     bool
+	}{
+		{"default policy", defaultPasswordPolicy, "Str0ng_pass", true},
+		{"too short", defaultPasswordPolicy, "Sh0rt_pw", false},
+		{"too long", defaultPasswordPolicy, "Aa1_" + strings.Repeat("a", MaxPasswordLength), false},
+		{"missing uppercase", defaultPasswordPolicy, "str0ng_pass", false},
+		{"missing lowercase", defaultPasswordPolicy, "STR0NG_PASS", false},
+		{"missing number", defaultPasswordPolicy, "Strong_pass", false},
+		{"missing special", defaultPasswordPolicy, "Str0ngpass1", false},
+		{"length only", lengthOnly, "correcthorsebattery", true},
+		{"length only too short", lengthOnly, "correcthors", false},
+		{"min length below floor", smodels.PasswordPolicy{MinLength: 1}, "abc", false},
+	}
+	for _, tt := range tests {
+		if got := meetsPasswordPolicy(tt.policy, tt.password); got != tt.want {
+			t.Errorf("%s: meetsPasswordPolicy(%q) = %v, want %v", tt.name, tt.password, got, tt.want)
+		}
+	}
+}
+
+func TestIsBannedPassword(t *testing.T) {
+	policy := smodels.PasswordPolicy{BannedPasswords: []string{"Password@123", " Welcome@123 "}}
+	for password, want := range map[string]bool{
+		"Password@123": true,
+		"password@123": true,
+		"Welcome@123":  true,
+		"Password@124": false,
+	} {
+		if got := isBannedPassword(policy, password); got != want {
+			t.Errorf("isBannedPassword(%q) = %v, want %v", password, got, want)
+		}
+	}
+}
+
+func TestPasswordExpired(t *testing.T) {
+	now := time.Now()
+	policy := smodels.PasswordPolicy{MaxAgeDays: 90}
+	if passwordExpired(policy, now.AddDate(0, 0, -89), now) {
+		t.Error("password changed 89 days ago should not be expired")
+	}
+	if !passwordExpired(policy, now.AddDate(0, 0, -91), now) {
+		t.Error("password changed 91 days ago should be expired")
+	}
+	if passwordExpired(smodels.PasswordPolicy{}, now.AddDate(-5, 0, 0), now) {
+		t.Error("password should not expire without a max age")
+	}
+	if passwordExpired(policy, time.Time{}, now) {
+		t.Error("password without a change time should not be expired")
+	}
+}
@@ -29,6 +29,16 @@ FROM users u
 WHERE u.email != 'System' AND u.deleted_at IS NULL AND u.type = 'agent'
 ORDER BY u.updated_at DESC;
 
+-- name: get-agents-range
+SELECT users.id, users.avatar_url, users.type, users.created_at, users.updated_at, users.first_name, users.last_name, users.email, users.enabled
+FROM users
+WHERE users.email != 'System' AND users.deleted_at IS NULL AND users.type = 'agent'
+ORDER BY users.created_at, users.id
+OFFSET $1 LIMIT $2;
+
+-- name: count-agents
+SELECT COUNT(*) FROM users WHERE email != 'System' AND deleted_at IS NULL AND type = 'agent';
+
 -- name: get-user
 SELECT
     u.id,
@@ -46,10 +56,11 @@ SELECT
     u.availability_status,
     u.last_active_at,
     u.last_login_at,
+    u.password_changed_at,
     u.phone_number_calling_code,
     u.phone_number,
-    u.api_key,
-    u.api_key_last_used_at,
+    u.totp_enabled,
+    COALESCE(bool_or(r.require_two_factor), false) AS totp_required,
     array_agg(DISTINCT r.name) FILTER (WHERE r.name IS NOT NULL) AS roles,
     COALESCE(
         (SELECT json_agg(json_build_object('id', t.id, 'name', t.name, 'emoji', t.emoji))
@@ -58,7 +69,14 @@ SELECT
          WHERE tm.user_id = u.id),
         '[]'
     ) AS teams,
-    array_agg(DISTINCT p ORDER BY p) FILTER (WHERE p IS NOT NULL) AS permissions
+    array_agg(DISTINCT p ORDER BY p) FILTER (WHERE p IS NOT NULL) AS permissions,
+    COALESCE(
+        (SELECT json_agg(json_build_object('permissions', sr.permissions, 'inbox_ids', sr.inbox_ids, 'team_ids', sr.team_ids))
+         FROM user_roles sur
+         JOIN roles sr ON sr.id = sur.role_id
+         WHERE sur.user_id = u.id),
+        '[]'
+    ) AS role_scopes
 FROM users u
 LEFT JOIN user_roles ur ON ur.user_id = u.id
 LEFT JOIN roles r ON r.id = ur.role_id
@@ -68,7 +86,7 @@ GROUP BY u.id;
 
 -- name: set-user-password
 UPDATE users
-SET password = $1, updated_at = now()
+SET password = $1, password_changed_at = now(), updated_at = now()
 WHERE id = $2;
 
 -- name: update-agent
@@ -92,11 +110,25 @@ SET first_name = COALESCE($2, first_name),
  email = COALESCE($4, email),
  avatar_url = COALESCE($6, avatar_url), 
  password = COALESCE($7, password),
+ password_changed_at = CASE WHEN $7 IS NOT NULL THEN now() ELSE password_changed_at END,
  enabled = COALESCE($8, enabled),
  availability_status = COALESCE($9, availability_status),
  updated_at = now()
 WHERE id = $1;
 
+-- name: update-agent-roles
+WITH new_roles AS (
+ SELECT r.id FROM roles r WHERE r.name = ANY($2::text[])
+),
+old_roles AS (
+ DELETE FROM user_roles
+ WHERE user_id = $1
+ AND role_id NOT IN (SELECT id FROM new_roles)
+)
+INSERT INTO user_roles (user_id, role_id)
+SELECT $1, id FROM new_roles
+ON CONFLICT (user_id, role_id) DO NOTHING;
+
 -- name: update-custom-attributes
 UPDATE users
 SET custom_attributes = $2,
@@ -132,10 +164,15 @@ UPDATE users
 SET reset_password_token = $2, reset_password_token_expiry = now() + interval '1 day'
 WHERE id = $1 AND type = 'agent';
 
+-- name: get-reset-password-user
+SELECT id FROM users
+WHERE reset_password_token = $1 AND reset_password_token_expiry > now() AND type = 'agent';
+
 -- name: set-password
 UPDATE users  
-SET password = $1, reset_password_token = NULL, reset_password_token_expiry = NULL
-WHERE reset_password_token = $2 AND reset_password_token_expiry > now() AND type = 'agent';
+SET password = $1, password_changed_at = now(), reset_password_token = NULL, reset_password_token_expiry = NULL
+WHERE reset_password_token = $2 AND reset_password_token_expiry > now() AND type = 'agent'
+RETURNING id;
 
 -- name: insert-agent
 WITH inserted_user AS (
@@ -223,50 +260,65 @@ FROM contact_notes cn
 INNER JOIN users u ON u.id = cn.user_id
 WHERE cn.id = $1;
 
--- name: get-user-by-api-key
-SELECT
-    u.id,
-    u.created_at,
-    u.updated_at,
-    u.email,
-    u.type,
-    u.enabled,
-    u.avatar_url,
-    u.first_name,
-    u.last_name,
-    u.availability_status,
-    u.last_active_at,
-    u.last_login_at,
-    u.phone_number_calling_code,
-    u.phone_number,
-    u.api_secret,
-    array_agg(DISTINCT r.name) FILTER (WHERE r.name IS NOT NULL) AS roles,
-    COALESCE(
-        (SELECT json_agg(json_build_object('id', t.id, 'name', t.name, 'emoji', t.emoji))
-         FROM team_members tm
-         JOIN teams t ON tm.team_id = t.id
-         WHERE tm.user_id = u.id),
-        '[]'
-    ) AS teams,
-    array_agg(DISTINCT p ORDER BY p) FILTER (WHERE p IS NOT NULL) AS permissions
-FROM users u
-LEFT JOIN user_roles ur ON ur.user_id = u.id
-LEFT JOIN roles r ON r.id = ur.role_id
-LEFT JOIN LATERAL unnest(r.permissions) AS p ON true
-WHERE u.api_key = $1 AND u.enabled = true AND u.deleted_at IS NULL
-GROUP BY u.id;
+-- name: get-api-keys
+SELECT id, created_at, updated_at, user_id, "name", api_key, permissions, allowed_ips, expires_at, last_used_at, last_used_ip
+FROM api_keys
+WHERE user_id = $1
+ORDER BY created_at DESC;
 
--- name: generate-api-key
-UPDATE users 
-SET api_key = $2, api_secret = $3, api_key_last_used_at = NULL, updated_at = now()
-WHERE id = $1;
+-- name: get-api-key
+SELECT id, created_at, updated_at, user_id, "name", api_key, secret_hash, permissions, allowed_ips, expires_at, last_used_at, last_used_ip
+FROM api_keys
+WHERE api_key = $1;
 
--- name: revoke-api-key
-UPDATE users 
-SET api_key = NULL, api_secret = NULL, api_key_last_used_at = NULL, updated_at = now()
-WHERE id = $1;
+-- name: insert-api-key
+INSERT INTO api_keys (user_id, "name", api_key, secret_hash, permissions, allowed_ips, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, created_at, updated_at, user_id, "name", api_key, permissions, allowed_ips, expires_at, last_used_at, last_used_ip;
+
+-- name: delete-api-key
+DELETE FROM api_keys WHERE id = $1 AND user_id = $2;
 
 -- name: update-api-key-last-used
-UPDATE users 
-SET api_key_last_used_at = now()
-WHERE id = $1;
\ No newline at end of file
+UPDATE api_keys
+SET last_used_at = now(), last_used_ip = $2
+WHERE id = $1;
+
+-- name: get-password-history
+-- Returns the current password hash of a user followed by the last $2 previous ones.
+(SELECT "password" FROM users WHERE id = $1 AND "password" IS NOT NULL)
+UNION ALL
+(SELECT "password" FROM user_password_history WHERE user_id = $1 ORDER BY id DESC LIMIT $2);
+
+-- name: archive-password
+-- Adds the current password hash of a user to their history before it's changed, keeping it and the last $2 hashes before it.
+WITH archived AS (
+    INSERT INTO user_password_history (user_id, "password")
+    SELECT id, "password" FROM users WHERE id = $1 AND "password" IS NOT NULL
+)
+DELETE FROM user_password_history
+WHERE user_id = $1 AND id NOT IN (
+    SELECT id FROM user_password_history WHERE user_id = $1 ORDER BY id DESC LIMIT $2
+);
+
+-- name: get-totp
+SELECT totp_secret, totp_enabled, totp_recovery_codes FROM users WHERE id = $1 AND deleted_at IS NULL;
+
+-- name: set-totp-secret
+-- Sets a new secret for a user who has not enabled two-factor authentication yet.
+UPDATE users SET totp_secret = $2, updated_at = now() WHERE id = $1 AND totp_enabled = false;
+
+-- name: enable-totp
+UPDATE users SET totp_enabled = true, totp_recovery_codes = $2, totp_last_used_step = $3, updated_at = now() WHERE id = $1 AND totp_secret IS NOT NULL;
+
+-- name: use-totp-step
+-- Only succeeds for a time step later than the last accepted one so a code can't be used twice.
+UPDATE users SET totp_last_used_step = $2
+WHERE id = $1 AND totp_enabled = true AND (totp_last_used_step IS NULL OR totp_last_used_step < $2);
+
+-- name: use-totp-recovery-code
+UPDATE users SET totp_recovery_codes = array_remove(totp_recovery_codes, $2), updated_at = now()
+WHERE id = $1 AND totp_enabled = true AND $2 = ANY(totp_recovery_codes);
+
+-- name: disable-totp
+UPDATE users SET totp_secret = NULL, totp_enabled = false, totp_recovery_codes = '{}'::TEXT[], totp_last_used_step = NULL, updated_at = now() WHERE id = $1;
@@ -0,0 +1,208 @@
+package user
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"image/png"
+	"strings"
+	"time"
+
+	"github.com/abhinavxd/libredesk/internal/envelope"
+	"github.com/abhinavxd/libredesk/internal/stringutil"
+	"github.com/lib/pq"
+	"github.com/pquerna/otp/totp"
+	"github.com/volatiletech/null/v9"
+)
+
+const (
+	// recoveryCodeCount is the number of recovery codes generated when two-factor authentication is enabled.
+	recoveryCodeCount  = 10
+	recoveryCodeLength = 10
+	totpQRCodeSize     = 200
+
+	// totpPeriod is the number of seconds a code is valid for, totpSkew is the number of periods before and after the current one also accepted.
+	totpPeriod = 30
+	totpSkew   = 1
+)
+
+// TOTPEnrollment is the secret and QR code shown to a user setting up two-factor authentication.
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URL    string `json:"url"`
+	// QRCode is a PNG image of the URL as a data URI.
+	QRCode string `json:"qr_code"`
+}
+
+type totpRecord struct {
+	Secret        null.String    `db:"totp_secret"`
+	Enabled       bool           `db:"totp_enabled"`
+	RecoveryCodes pq.StringArray `db:"totp_recovery_codes"`
+}
+
+// GenerateTOTPSecret generates and stores a new TOTP secret for a user who has not enabled two-factor authentication yet,
+// the secret is only used after the user confirms it with EnableTOTP.
+func (u *Manager) GenerateTOTPSecret(userID int, issuer, accountName string) (TOTPEnrollment, error) {
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      issuer,
+		AccountName: accountName,
+	})
+	if err != nil {
+		u.lo.Error("error generating totp secret", "user_id", userID, "error", err)
+		return TOTPEnrollment{}, envelope.NewError(envelope.GeneralError, u.i18n.Ts("globals.messages.errorGenerating", "name", "{globals.terms.twoFactorSecret}"), nil)
+	}
+
+	img, err := key.Image(totpQRCodeSize, totpQRCodeSize)
+	if err != nil {
+		u.lo.Error("error generating totp qr code", "user_id", userID, "error", err)
+		return TOTPEnrollment{}, envelope.NewError(envelope.GeneralError, u.i18n.Ts("globals.messages.errorGenerating", "name", "{globals.terms.twoFactorSecret}"), nil)
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		u.lo.Error("error encoding totp qr code", "user_id", userID, "error", err)
+		return TOTPEnrollment{}, envelope.NewError(envelope.GeneralError, u.i18n.Ts("globals.messages.errorGenerating", "name", "{globals.terms.twoFactorSecret}"), nil)
+	}
+
+	res, err := u.q.SetTOTPSecret.Exec(userID, key.Secret())
+	if err != nil {
+		u.lo.Error("error saving totp secret", "user_id", userID, "error", err)
+		return TOTPEnrollment{}, envelope.NewError(envelope.GeneralError, u.i18n.Ts("globals.messages.errorGenerating", "name", "{globals.terms.twoFactorSecret}"), nil)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return TOTPEnrollment{}, envelope.NewError(envelope.InputError, u.i18n.T("user.twoFactorAlreadyEnabled"), nil)
+	}
+
+	return TOTPEnrollment{
+		Secret: key.Secret(),
+		URL:    key.URL(),
+		QRCode: "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()),
+	}, nil
+}
+
+// EnableTOTP enables two-factor authentication for a user after validating a code generated with the pending secret,
+// returns the recovery codes which are only stored hashed.
+func (u *Manager) EnableTOTP(userID int, code string) ([]string, error) {
+	rec, err := u.getTOTP(userID)
+	if err != nil {
+		return nil, err
+	}
+	if rec.Enabled {
+		return nil, envelope.NewError(envelope.InputError, u.i18n.T("user.twoFactorAlreadyEnabled"), nil)
+	}
+	if !rec.Secret.Valid {
+		return nil, envelope.NewError(envelope.InputError, u.i18n.T("user.invalidTwoFactorCode"), nil)
+	}
+	step, ok := validateTOTPStep(strings.TrimSpace(code), rec.Secret.String, time.Now())
+	if !ok {
+		return nil, envelope.NewError(envelope.InputError, u.i18n.T("user.invalidTwoFactorCode"), nil)
+	}
+
+	var (
+		codes  = make([]string, 0, recoveryCodeCount)
+		hashes = make([]string, 0, recoveryCodeCount)
+	)
+	for range recoveryCodeCount {
+		code, err := stringutil.RandomAlphanumeric(recoveryCodeLength)
+		if err != nil {
+			u.lo.Error("error generating recovery code", "user_id", userID, "error", err)
+			return nil, envelope.NewError(envelope.GeneralError, u.i18n.Ts("globals.messages.errorGenerating", "name", "{globals.terms.recoveryCode}"), nil)
+		}
+		code = strings.ToLower(code)
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+
+	if _, err := u.q.EnableTOTP.Exec(userID, pq.Array(hashes), step); err != nil {
+		u.lo.Error("error enabling totp", "user_id", userID, "error", err)
+		return nil, envelope.NewError(envelope.GeneralError, u.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.user}"), nil)
+	}
+	return codes, nil
+}
+
+// VerifyTOTP validates a code from the authenticator app or an unused recovery code, recovery codes can only be used once.
+// A code from the authenticator app is rejected if a code for the same or a later time step was already accepted, so it can't be replayed.
+func (u *Manager) VerifyTOTP(userID int, code string) error {
+	rec, err := u.getTOTP(userID)
+	if err != nil {
+		return err
+	}
+	if !rec.Enabled || !rec.Secret.Valid {
+		return envelope.NewError(envelope.InputError, u.i18n.T("user.twoFactorNotEnabled"), nil)
+	}
+
+	code = strings.TrimSpace(code)
+	if step, ok := validateTOTPStep(code, rec.Secret.String, time.Now()); ok {
+		res, err := u.q.UseTOTPStep.Exec(userID, step)
+		if err != nil {
+			u.lo.Error("error saving totp step", "user_id", userID, "error", err)
+			return envelope.NewError(envelope.GeneralError, u.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.user}"), nil)
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			u.lo.Warn("reused totp code rejected", "user_id", userID)
+			return envelope.NewError(envelope.InputError, u.i18n.T("user.invalidTwoFactorCode"), nil)
+		}
+		return nil
+	}
+
+	res, err := u.q.UseTOTPRecoveryCode.Exec(userID, hashRecoveryCode(strings.ToLower(code)))
+	if err != nil {
+		u.lo.Error("error using recovery code", "user_id", userID, "error", err)
+		return envelope.NewError(envelope.GeneralError, u.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.user}"), nil)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return envelope.NewError(envelope.InputError, u.i18n.T("user.invalidTwoFactorCode"), nil)
+	}
+	u.lo.Info("two-factor recovery code used", "user_id", userID)
+	return nil
+}
+
+// DisableTOTP disables two-factor authentication for a user and removes the secret and recovery codes.
+func (u *Manager) DisableTOTP(userID int) error {
+	if _, err := u.q.DisableTOTP.Exec(userID); err != nil {
+		u.lo.Error("error disabling totp", "user_id", userID, "error", err)
+		return envelope.NewError(envelope.GeneralError, u.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.user}"), nil)
+	}
+	return nil
+}
+
+// getTOTP returns the two-factor authentication record of a user.
+func (u *Manager) getTOTP(userID int) (totpRecord, error) {
+	var rec totpRecord
+	if err := u.q.GetTOTP.Get(&rec, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return rec, envelope.NewError(envelope.NotFoundError, u.i18n.Ts("globals.messages.notFound", "name", "{globals.terms.user}"), nil)
+		}
+		u.lo.Error("error fetching totp", "user_id", userID, "error", err)
+		return rec, envelope.NewError(envelope.GeneralError, u.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.user}"), nil)
+	}
+	return rec, nil
+}
+
+// validateTOTPStep returns the time step the code was generated for if it matches the current step or one within the skew.
+func validateTOTPStep(code, secret string, now time.Time) (int64, bool) {
+	if code == "" {
+		return 0, false
+	}
+	current := now.Unix() / totpPeriod
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		expected, err := totp.GenerateCode(secret, time.Unix(step*totpPeriod, 0))
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(code), []byte(expected)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// hashRecoveryCode returns the hex encoded SHA-256 hash of a recovery code,
+// recovery codes are random so a fast hash is sufficient.
+func hashRecoveryCode(code string) string {
+	h := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(h[:])
+}
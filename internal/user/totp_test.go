@@ -0,0 +1,39 @@
+package user
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pquerna/otp/totp"
+)
+
+func TestValidateTOTPStep(t *testing.T) {
+	const secret = "JBSWY3DPEHPK3PXP"
+	now := time.Unix(1_700_000_010, 0)
+	current := now.Unix() / totpPeriod
+
+	tests := []struct {
+		name   string
+		at     time.Time
+		want   int64
+		wantOK bool
+	}{
+		{"current step", now, current, true},
+		{"previous step", now.Add(-totpPeriod * time.Second), current - 1, true},
+		{"next step", now.Add(totpPeriod * time.Second), current + 1, true},
+		{"outside skew", now.Add(-2 * totpPeriod * time.Second), 0, false},
+	}
+	for _, tt := range tests {
+		code, err := totp.GenerateCode(secret, tt.at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		step, ok := validateTOTPStep(code, secret, now)
+		if ok != tt.wantOK || step != tt.want {
+			t.Errorf("%s: validateTOTPStep() = %d, %v, want %d, %v", tt.name, step, ok, tt.want, tt.wantOK)
+		}
+	}
+	if _, ok := validateTOTPStep("", secret, now); ok {
+		t.Error("validateTOTPStep() accepted an empty code")
+	}
+}
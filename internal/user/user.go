@@ -9,7 +9,6 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"regexp"
 	"strings"
 	"sync"
 
@@ -32,7 +31,7 @@ var (
 	efs embed.FS
 
 	minPassword     = 10
-	maxPassword     = 72
+	maxPassword     = MaxPasswordLength
 	maxListPageSize = 100
 
 	// ErrPasswordTooLong is returned when the password passed to
@@ -50,6 +49,7 @@ type Manager struct {
 	db           *sqlx.DB
 	agentCache   map[int]models.User
 	agentCacheMu sync.RWMutex
+	setting      settingsStore
 }
 
 // Opts contains options for initializing the Manager.
@@ -65,8 +65,11 @@ type queries struct {
 	GetNotes               *sqlx.Stmt `query:"get-notes"`
 	GetNote                *sqlx.Stmt `query:"get-note"`
 	GetAgentsCompact       *sqlx.Stmt `query:"get-agents-compact"`
+	GetAgentsRange         *sqlx.Stmt `query:"get-agents-range"`
+	CountAgents            *sqlx.Stmt `query:"count-agents"`
 	UpdateContact          *sqlx.Stmt `query:"update-contact"`
 	UpdateAgent            *sqlx.Stmt `query:"update-agent"`
+	UpdateAgentRoles       *sqlx.Stmt `query:"update-agent-roles"`
 	UpdateCustomAttributes *sqlx.Stmt `query:"update-custom-attributes"`
 	UpdateAvatar           *sqlx.Stmt `query:"update-avatar"`
 	UpdateAvailability     *sqlx.Stmt `query:"update-availability"`
@@ -77,20 +80,31 @@ type queries struct {
 	SetUserPassword        *sqlx.Stmt `query:"set-user-password"`
 	SetResetPasswordToken  *sqlx.Stmt `query:"set-reset-password-token"`
 	SetPassword            *sqlx.Stmt `query:"set-password"`
+	GetResetPasswordUser   *sqlx.Stmt `query:"get-reset-password-user"`
+	GetPasswordHistory     *sqlx.Stmt `query:"get-password-history"`
+	ArchivePassword        *sqlx.Stmt `query:"archive-password"`
 	DeleteNote             *sqlx.Stmt `query:"delete-note"`
 	InsertAgent            *sqlx.Stmt `query:"insert-agent"`
 	InsertContact          *sqlx.Stmt `query:"insert-contact"`
 	InsertNote             *sqlx.Stmt `query:"insert-note"`
 	ToggleEnable           *sqlx.Stmt `query:"toggle-enable"`
 	// API key queries
-	GetUserByAPIKey      *sqlx.Stmt `query:"get-user-by-api-key"`
-	GenerateAPIKey       *sqlx.Stmt `query:"generate-api-key"`
-	RevokeAPIKey         *sqlx.Stmt `query:"revoke-api-key"`
+	GetAPIKeys           *sqlx.Stmt `query:"get-api-keys"`
+	GetAPIKey            *sqlx.Stmt `query:"get-api-key"`
+	InsertAPIKey         *sqlx.Stmt `query:"insert-api-key"`
+	DeleteAPIKey         *sqlx.Stmt `query:"delete-api-key"`
 	UpdateAPIKeyLastUsed *sqlx.Stmt `query:"update-api-key-last-used"`
+	// Two-factor authentication queries
+	GetTOTP             *sqlx.Stmt `query:"get-totp"`
+	SetTOTPSecret       *sqlx.Stmt `query:"set-totp-secret"`
+	EnableTOTP          *sqlx.Stmt `query:"enable-totp"`
+	UseTOTPRecoveryCode *sqlx.Stmt `query:"use-totp-recovery-code"`
+	UseTOTPStep         *sqlx.Stmt `query:"use-totp-step"`
+	DisableTOTP         *sqlx.Stmt `query:"disable-totp"`
 }
 
 // New creates and returns a new instance of the Manager.
-func New(i18n *i18n.I18n, opts Opts) (*Manager, error) {
+func New(i18n *i18n.I18n, opts Opts, setting settingsStore) (*Manager, error) {
 	var q queries
 	if err := dbutil.ScanSQLFile("queries.sql", &q, opts.DB, efs); err != nil {
 		return nil, err
@@ -101,6 +115,7 @@ func New(i18n *i18n.I18n, opts Opts) (*Manager, error) {
 		i18n:       i18n,
 		db:         opts.DB,
 		agentCache: make(map[int]models.User),
+		setting:    setting,
 	}, nil
 }
 
@@ -198,26 +213,34 @@ func (u *Manager) SetResetPasswordToken(id int) (string, error) {
 	return token, nil
 }
 
-// ResetPassword sets a new password for an user.
-func (u *Manager) ResetPassword(token, password string) error {
-	if !IsStrongPassword(password) {
-		return envelope.NewError(envelope.InputError, "Password is not strong enough, "+PasswordHint, nil)
+// ResetPassword sets a new password meeting the password policy for the user of a reset password token and returns the user ID.
+func (u *Manager) ResetPassword(token, password string) (int, error) {
+	var id int
+	if err := u.q.GetResetPasswordUser.Get(&id, token); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, envelope.NewError(envelope.InputError, u.i18n.T("user.resetPasswordTokenExpired"), nil)
+		}
+		u.lo.Error("error fetching reset password token user", "error", err)
+		return 0, envelope.NewError(envelope.GeneralError, u.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.password}"), nil)
+	}
+	if err := u.checkPassword(id, password); err != nil {
+		return 0, err
 	}
 	// Hash password.
 	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
 		u.lo.Error("error generating bcrypt password", "error", err)
-		return envelope.NewError(envelope.GeneralError, u.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.password}"), nil)
+		return 0, envelope.NewError(envelope.GeneralError, u.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.password}"), nil)
 	}
-	rows, err := u.q.SetPassword.Exec(passwordHash, token)
-	if err != nil {
+	u.archivePassword(id)
+	if err := u.q.SetPassword.Get(&id, passwordHash, token); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, envelope.NewError(envelope.InputError, u.i18n.T("user.resetPasswordTokenExpired"), nil)
+		}
 		u.lo.Error("error setting new password", "error", err)
-		return envelope.NewError(envelope.GeneralError, u.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.password}"), nil)
-	}
-	if count, _ := rows.RowsAffected(); count == 0 {
-		return envelope.NewError(envelope.InputError, u.i18n.T("user.resetPasswordTokenExpired"), nil)
+		return 0, envelope.NewError(envelope.GeneralError, u.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.password}"), nil)
 	}
-	return nil
+	return id, nil
 }
 
 // UpdateAvailability updates the availability status of an user.
@@ -299,72 +322,7 @@ func (u *Manager) ToggleEnabled(id int, typ string, enabled bool) error {
 		u.lo.Error("error toggling user enabled status", "error", err)
 		return envelope.NewError(envelope.GeneralError, u.i18n.Ts("globals.messages.errorUpdating", "name", "{globals.terms.user}"), nil)
 	}
-	return nil
-}
-
-// GenerateAPIKey generates a new API key and secret for a user
-func (u *Manager) GenerateAPIKey(userID int) (string, string, error) {
-	// Generate API key (32 characters)
-	apiKey, err := stringutil.RandomAlphanumeric(32)
-	if err != nil {
-		u.lo.Error("error generating API key", "error", err, "user_id", userID)
-		return "", "", envelope.NewError(envelope.GeneralError, u.i18n.Ts("globals.messages.errorGenerating", "name", "{globals.terms.apiKey}"), nil)
-	}
-
-	// Generate API secret (64 characters)
-	apiSecret, err := stringutil.RandomAlphanumeric(64)
-	if err != nil {
-		u.lo.Error("error generating API secret", "error", err, "user_id", userID)
-		return "", "", envelope.NewError(envelope.GeneralError, u.i18n.Ts("globals.messages.errorGenerating", "name", "{globals.terms.apiKey}"), nil)
-	}
-
-	// Hash the API secret for storage
-	secretHash, err := bcrypt.GenerateFromPassword([]byte(apiSecret), bcrypt.DefaultCost)
-	if err != nil {
-		u.lo.Error("error hashing API secret", "error", err, "user_id", userID)
-		return "", "", envelope.NewError(envelope.GeneralError, u.i18n.Ts("globals.messages.errorGenerating", "name", "{globals.terms.apiKey}"), nil)
-	}
-
-	// Update user with API key.
-	if _, err := u.q.GenerateAPIKey.Exec(userID, apiKey, string(secretHash)); err != nil {
-		u.lo.Error("error saving API key", "error", err, "user_id", userID)
-		return "", "", envelope.NewError(envelope.GeneralError, u.i18n.Ts("globals.messages.errorGenerating", "name", "{globals.terms.apiKey}"), nil)
-	}
-
-	return apiKey, apiSecret, nil
-}
-
-// ValidateAPIKey validates API key and secret and returns the user
-func (u *Manager) ValidateAPIKey(apiKey, apiSecret string) (models.User, error) {
-	var user models.User
-
-	// Find user by API key.
-	if err := u.q.GetUserByAPIKey.Get(&user, apiKey); err != nil {
-		if err == sql.ErrNoRows {
-			return user, envelope.NewError(envelope.UnauthorizedError, u.i18n.Ts("globals.messages.invalid", "name", u.i18n.P("globals.terms.credential")), nil)
-		}
-		return user, envelope.NewError(envelope.GeneralError, u.i18n.Ts("globals.messages.errorFetching", "name", "{globals.terms.user}"), nil)
-	}
-
-	// Verify API secret.
-	if err := bcrypt.CompareHashAndPassword([]byte(user.APISecret.String), []byte(apiSecret)); err != nil {
-		return user, envelope.NewError(envelope.UnauthorizedError, u.i18n.Ts("globals.messages.invalid", "name", u.i18n.T("globals.terms.credential")), nil)
-	}
-
-	// Update last used timestamp.
-	if _, err := u.q.UpdateAPIKeyLastUsed.Exec(user.ID); err != nil {
-		u.lo.Error("failed to update API key last used timestamp", "error", err, "user_id", user.ID)
-	}
-
-	return user, nil
-}
-
-// RevokeAPIKey deactivates the API key for a user
-func (u *Manager) RevokeAPIKey(userID int) error {
-	if _, err := u.q.RevokeAPIKey.Exec(userID); err != nil {
-		u.lo.Error("error revoking API key", "error", err, "user_id", userID)
-		return envelope.NewError(envelope.GeneralError, u.i18n.Ts("globals.messages.errorRevoking", "name", "{globals.terms.apiKey}"), nil)
-	}
+	u.InvalidateAgentCache(id)
 	return nil
 }
 
@@ -421,17 +379,9 @@ func CreateSystemUser(ctx context.Context, password string, db *sqlx.DB) error {
 	return nil
 }
 
-// IsStrongPassword checks if the password meets the required strength for system user.
+// IsStrongPassword checks if the password meets the default password policy, required for the system user.
 func IsStrongPassword(password string) bool {
-	if len(password) < minPassword || len(password) > maxPassword {
-		return false
-	}
-	hasUppercase := regexp.MustCompile(`[A-Z]`).MatchString(password)
-	hasLowercase := regexp.MustCompile(`[a-z]`).MatchString(password)
-	hasNumber := regexp.MustCompile(`[0-9]`).MatchString(password)
-	// Matches special characters
-	hasSpecial := regexp.MustCompile(`[\W_]`).MatchString(password)
-	return hasUppercase && hasLowercase && hasNumber && hasSpecial
+	return meetsPasswordPolicy(defaultPasswordPolicy, password)
 }
 
 // promptAndHashPassword handles password input and validation, and returns the hashed password.
@@ -30,6 +30,9 @@ const (
 	EventConversationAssigned      WebhookEvent = "conversation.assigned"
 	EventConversationUnassigned    WebhookEvent = "conversation.unassigned"
 
+	// Escalation event, delivered directly to a webhook by the `send_webhook` action.
+	EventConversationEscalated WebhookEvent = "conversation.escalated"
+
 	// Message events
 	EventMessageCreated WebhookEvent = "message.created"
 	EventMessageUpdated WebhookEvent = "message.updated"
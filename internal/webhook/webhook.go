@@ -60,6 +60,8 @@ type Opts struct {
 type DeliveryTask struct {
 	Event   models.WebhookEvent
 	Payload any
+	// WebhookID, if set, delivers the task only to this webhook regardless of its subscribed events.
+	WebhookID int
 }
 
 // queries contains prepared SQL queries.
@@ -204,6 +206,25 @@ func (m *Manager) TriggerEvent(event models.WebhookEvent, data any) {
 	}
 }
 
+// TriggerWebhook triggers a single webhook for an event with the provided data.
+func (m *Manager) TriggerWebhook(id int, event models.WebhookEvent, data any) {
+	m.closedMu.RLock()
+	defer m.closedMu.RUnlock()
+	if m.closed {
+		return
+	}
+
+	select {
+	case m.deliveryQueue <- DeliveryTask{
+		Event:     event,
+		Payload:   data,
+		WebhookID: id,
+	}:
+	default:
+		m.lo.Warn("webhook delivery queue is full, dropping webhook delivery", "event", event, "webhook_id", id, "queue_size", len(m.deliveryQueue))
+	}
+}
+
 // Run starts the webhook delivery worker pool.
 func (m *Manager) Run(ctx context.Context) {
 	for i := 0; i < m.workers; i++ {
@@ -244,6 +265,20 @@ func (m *Manager) worker(ctx context.Context) {
 
 // deliverWebhook delivers webhooks for an event by making HTTP requests.
 func (m *Manager) deliverWebhook(task DeliveryTask) {
+	if task.WebhookID > 0 {
+		webhook, err := m.Get(task.WebhookID)
+		if err != nil {
+			m.lo.Error("error fetching webhook for delivery", "webhook_id", task.WebhookID, "event", task.Event, "error", err)
+			return
+		}
+		if !webhook.IsActive {
+			m.lo.Info("skipping delivery to inactive webhook", "webhook_id", task.WebhookID, "event", task.Event)
+			return
+		}
+		m.deliverSingleWebhook(webhook, task)
+		return
+	}
+
 	webhooks, err := m.getWebhooksByEvent(string(task.Event))
 	if err != nil {
 		m.lo.Error("error fetching webhooks for event", "event", task.Event, "error", err)
@@ -36,6 +36,9 @@ type Client struct {
 	// Client ID.
 	ID int
 
+	// SessionID is the ID of the login session the client connected with.
+	SessionID string
+
 	// Hub.
 	Hub *Hub
 
@@ -6,6 +6,7 @@ const (
 	MessageTypeConversationPropertyUpdate = "conversation_prop_update"
 	MessageTypeNewMessage                 = "new_message"
 	MessageTypeNewConversation            = "new_conversation"
+	MessageTypeBulkActionProgress         = "bulk_action_progress"
 	MessageTypeError                      = "error"
 )
 
@@ -51,6 +51,28 @@ func (h *Hub) RemoveClient(client *Client) {
 	}
 }
 
+// DisconnectUser closes the connections of all clients of a user, e.g. when the user is logged out of all sessions.
+func (h *Hub) DisconnectUser(userID int) {
+	h.DisconnectSession(userID, "")
+}
+
+// DisconnectSession closes the connections of the clients of a user connected with a session, all clients if sessionID is empty.
+// Clients are removed from the hub once their connection is closed.
+func (h *Hub) DisconnectSession(userID int, sessionID string) {
+	h.clientsMutex.Lock()
+	var clients []*Client
+	for _, client := range h.clients[userID] {
+		if sessionID == "" || client.SessionID == sessionID {
+			clients = append(clients, client)
+		}
+	}
+	h.clientsMutex.Unlock()
+
+	for _, client := range clients {
+		client.Conn.Close()
+	}
+}
+
 // BroadcastMessage broadcasts a message to the specified users.
 // If no users are specified, the message is broadcast to all users.
 func (h *Hub) BroadcastMessage(msg models.BroadcastMessage) {
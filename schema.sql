@@ -3,12 +3,13 @@ CREATE EXTENSION IF NOT EXISTS pg_trgm;
 DROP TYPE IF EXISTS "channels" CASCADE; CREATE TYPE "channels" AS ENUM ('email');
 DROP TYPE IF EXISTS "message_type" CASCADE; CREATE TYPE "message_type" AS ENUM ('incoming','outgoing','activity');
 DROP TYPE IF EXISTS "message_sender_type" CASCADE; CREATE TYPE "message_sender_type" AS ENUM ('agent','contact');
-DROP TYPE IF EXISTS "message_status" CASCADE; CREATE TYPE "message_status" AS ENUM ('received','sent','failed','pending');
+DROP TYPE IF EXISTS "message_status" CASCADE; CREATE TYPE "message_status" AS ENUM ('received','sent','failed','pending','scheduled');
 DROP TYPE IF EXISTS "content_type" CASCADE; CREATE TYPE "content_type" AS ENUM ('text','html');
 DROP TYPE IF EXISTS "conversation_assignment_type" CASCADE; CREATE TYPE "conversation_assignment_type" AS ENUM ('Round robin','Manual');
 DROP TYPE IF EXISTS "template_type" CASCADE; CREATE TYPE "template_type" AS ENUM ('email_outgoing', 'email_notification');
 DROP TYPE IF EXISTS "user_type" CASCADE; CREATE TYPE "user_type" AS ENUM ('agent', 'contact');
-DROP TYPE IF EXISTS "ai_provider" CASCADE; CREATE TYPE "ai_provider" AS ENUM ('openai');
+DROP TYPE IF EXISTS "ai_provider" CASCADE; CREATE TYPE "ai_provider" AS ENUM ('openai', 'anthropic');
+DROP TYPE IF EXISTS "ai_usage_outcome" CASCADE; CREATE TYPE "ai_usage_outcome" AS ENUM ('success', 'error', 'rate_limited');
 DROP TYPE IF EXISTS "automation_execution_mode" CASCADE; CREATE TYPE "automation_execution_mode" AS ENUM ('all', 'first_match');
 DROP TYPE IF EXISTS "macro_visibility" CASCADE; CREATE TYPE "macro_visibility" AS ENUM ('all', 'team', 'user');
 DROP TYPE IF EXISTS "media_disposition" CASCADE; CREATE TYPE "media_disposition" AS ENUM ('inline', 'attachment');
@@ -17,8 +18,9 @@ DROP TYPE IF EXISTS "user_availability_status" CASCADE; CREATE TYPE "user_availa
 DROP TYPE IF EXISTS "applied_sla_status" CASCADE; CREATE TYPE "applied_sla_status" AS ENUM ('pending', 'breached', 'met', 'partially_met');
 DROP TYPE IF EXISTS "sla_event_status" CASCADE; CREATE TYPE "sla_event_status" AS ENUM ('pending', 'breached', 'met');
 DROP TYPE IF EXISTS "sla_metric" CASCADE; CREATE TYPE "sla_metric" AS ENUM ('first_response', 'resolution', 'next_response');
+DROP TYPE IF EXISTS "csat_survey_type" CASCADE; CREATE TYPE "csat_survey_type" AS ENUM ('stars', 'nps', 'ces');
 DROP TYPE IF EXISTS "sla_notification_type" CASCADE; CREATE TYPE "sla_notification_type" AS ENUM ('warning', 'breach');
-DROP TYPE IF EXISTS "activity_log_type" CASCADE; CREATE TYPE "activity_log_type" AS ENUM ('agent_login', 'agent_logout', 'agent_away', 'agent_away_reassigned', 'agent_online');
+DROP TYPE IF EXISTS "activity_log_type" CASCADE; CREATE TYPE "activity_log_type" AS ENUM ('agent_login', 'agent_logout', 'agent_away', 'agent_away_reassigned', 'agent_online', 'agent_2fa_enabled', 'agent_2fa_disabled', 'agent_2fa_reset', 'agent_login_failed', 'agent_locked_out', 'agent_unlocked');
 DROP TYPE IF EXISTS "macro_visible_when" CASCADE; CREATE TYPE "macro_visible_when" AS ENUM ('replying', 'starting_conversation', 'adding_private_note');
 DROP TYPE IF EXISTS "webhook_event" CASCADE; CREATE TYPE webhook_event AS ENUM (
 	'conversation.created',
@@ -52,6 +54,10 @@ CREATE TABLE sla_policies (
 	resolution_time TEXT NOT NULL,
 	next_response_time TEXT NULL,
 	notifications JSONB DEFAULT '[]'::jsonb NOT NULL,
+	-- Conversation statuses during which the SLA clock is paused.
+	pause_on_statuses TEXT[] DEFAULT '{}'::TEXT[] NOT NULL,
+	-- Actions executed on the conversation when a metric is breached.
+	escalations JSONB DEFAULT '[]'::jsonb NOT NULL,
 	CONSTRAINT constraint_sla_policies_on_name CHECK (length(name) <= 140),
 	CONSTRAINT constraint_sla_policies_on_description CHECK (length(description) <= 300)
 );
@@ -66,6 +72,7 @@ CREATE TABLE business_hours (
 	is_always_open BOOL DEFAULT false NOT NULL,
 	hours JSONB NOT NULL,
 	holidays JSONB DEFAULT '{}'::jsonb NOT NULL,
+	special_hours JSONB DEFAULT '[]'::jsonb NOT NULL,
 	CONSTRAINT constraint_business_hours_on_name CHECK (length(name) <= 140),
 	CONSTRAINT constraint_business_hours_on_description CHECK (length(description) <= 300)
 );
@@ -114,6 +121,11 @@ CREATE TABLE roles (
     permissions TEXT[] DEFAULT '{}'::TEXT[] NOT NULL,
     "name" TEXT UNIQUE NOT NULL,
     description TEXT NULL,
+	-- Users with the role must set up two-factor authentication to log in.
+	require_two_factor BOOL DEFAULT FALSE NOT NULL,
+	-- Inboxes and teams the conversation, message and report permissions of the role are restricted to, empty for all.
+	inbox_ids INT[] DEFAULT '{}'::INT[] NOT NULL,
+	team_ids INT[] DEFAULT '{}'::INT[] NOT NULL,
 	CONSTRAINT constraint_roles_on_name CHECK (length("name") <= 50),
 	CONSTRAINT constraint_roles_on_description CHECK (length(description) <= 300)
 );
@@ -137,13 +149,17 @@ CREATE TABLE users (
 	custom_attributes JSONB DEFAULT '{}'::jsonb NOT NULL,
     reset_password_token TEXT NULL,
     reset_password_token_expiry TIMESTAMPTZ NULL,
+	-- Passwords older than the max age of the password policy have to be reset on login.
+	password_changed_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
 	availability_status user_availability_status DEFAULT 'offline' NOT NULL,
 	last_active_at TIMESTAMPTZ NULL,
 	last_login_at TIMESTAMPTZ NULL,
-	-- API key authentication fields
-	api_key TEXT NULL,
-	api_secret TEXT NULL,
-	api_key_last_used_at TIMESTAMPTZ NULL,
+	-- Two-factor authentication fields, recovery codes are stored as SHA-256 hashes
+	totp_secret TEXT NULL,
+	totp_enabled BOOL DEFAULT FALSE NOT NULL,
+	totp_recovery_codes TEXT[] DEFAULT '{}'::TEXT[] NOT NULL,
+	-- Time step of the last accepted code, codes for the same or an earlier step are rejected.
+	totp_last_used_step BIGINT NULL,
     CONSTRAINT constraint_users_on_country CHECK (LENGTH(country) <= 140),
     CONSTRAINT constraint_users_on_phone_number CHECK (LENGTH(phone_number) <= 20),
 	CONSTRAINT constraint_users_on_phone_number_calling_code CHECK (LENGTH(phone_number_calling_code) <= 10),
@@ -154,7 +170,6 @@ CREATE TABLE users (
 CREATE UNIQUE INDEX index_unique_users_on_email_and_type_when_deleted_at_is_null ON users (email, type)
 WHERE deleted_at IS NULL;
 CREATE INDEX index_tgrm_users_on_email ON users USING GIN (email gin_trgm_ops);
-CREATE INDEX index_users_on_api_key ON users(api_key);
 
 DROP TABLE IF EXISTS user_roles CASCADE;
 CREATE TABLE user_roles (
@@ -170,6 +185,37 @@ CREATE TABLE user_roles (
 );
 CREATE INDEX index_user_roles_on_user_id ON user_roles(user_id);
 
+DROP TABLE IF EXISTS api_keys CASCADE;
+CREATE TABLE api_keys (
+	id SERIAL PRIMARY KEY,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	updated_at TIMESTAMPTZ DEFAULT NOW(),
+	user_id INT REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+	"name" TEXT NOT NULL,
+	api_key TEXT NOT NULL UNIQUE,
+	-- Secrets are stored as bcrypt hashes.
+	secret_hash TEXT NOT NULL,
+	-- Subset of the user's permissions the key is restricted to, empty for all of the user's permissions.
+	permissions TEXT[] DEFAULT '{}'::TEXT[] NOT NULL,
+	-- IP addresses or CIDR ranges the key can be used from, empty for any.
+	allowed_ips TEXT[] DEFAULT '{}'::TEXT[] NOT NULL,
+	expires_at TIMESTAMPTZ NULL,
+	last_used_at TIMESTAMPTZ NULL,
+	last_used_ip TEXT NULL,
+	CONSTRAINT constraint_api_keys_on_name CHECK (length("name") <= 140)
+);
+CREATE INDEX index_api_keys_on_user_id ON api_keys(user_id);
+
+DROP TABLE IF EXISTS user_password_history CASCADE;
+CREATE TABLE user_password_history (
+	id BIGSERIAL PRIMARY KEY,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	user_id INT REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+	-- Previous password hashes of the user, to prevent reuse.
+	"password" TEXT NOT NULL
+);
+CREATE INDEX index_user_password_history_on_user_id ON user_password_history(user_id);
+
 DROP TABLE IF EXISTS conversation_statuses CASCADE;
 CREATE TABLE conversation_statuses (
 	id SERIAL PRIMARY KEY,
@@ -271,7 +317,8 @@ CREATE TABLE conversation_messages (
     source_id TEXT NULL,
  	sender_id BIGINT REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
     sender_type message_sender_type NOT NULL,
-    meta JSONB DEFAULT '{}'::JSONB NULL
+    meta JSONB DEFAULT '{}'::JSONB NULL,
+    scheduled_at TIMESTAMPTZ NULL
 );
 CREATE INDEX index_trgm_conversation_messages_on_text_content ON conversation_messages USING GIN (text_content gin_trgm_ops);
 CREATE INDEX index_conversation_messages_on_conversation_id ON conversation_messages (conversation_id);
@@ -359,9 +406,35 @@ CREATE TABLE oidc (
 	client_secret TEXT NOT NULL,
 	enabled bool DEFAULT TRUE NOT NULL,
 	provider VARCHAR NULL,
+	-- Create agents on their first login.
+	provision_users bool DEFAULT FALSE NOT NULL,
+	default_role TEXT DEFAULT '' NOT NULL,
+	groups_claim TEXT DEFAULT 'groups' NOT NULL,
+	-- Array of {group, roles, teams} objects, roles and teams are synced on every login.
+	group_mappings JSONB DEFAULT '[]'::jsonb NOT NULL,
 	CONSTRAINT constraint_oidc_on_name CHECK (length("name") <= 140)
 );
 
+DROP TABLE IF EXISTS saml CASCADE;
+CREATE TABLE saml (
+	id SERIAL PRIMARY KEY,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	updated_at TIMESTAMPTZ DEFAULT NOW(),
+	"name" TEXT NOT NULL,
+	enabled bool DEFAULT TRUE NOT NULL,
+	-- Metadata is fetched from the URL on save if set.
+	idp_metadata_url TEXT DEFAULT '' NOT NULL,
+	idp_metadata TEXT NOT NULL,
+	-- Assertion attributes mapped to the user, the NameID is used for the email if empty.
+	email_attribute TEXT DEFAULT '' NOT NULL,
+	first_name_attribute TEXT DEFAULT '' NOT NULL,
+	last_name_attribute TEXT DEFAULT '' NOT NULL,
+	-- Generated service provider certificate and key.
+	sp_certificate TEXT NOT NULL,
+	sp_private_key TEXT NOT NULL,
+	CONSTRAINT constraint_saml_on_name CHECK (length("name") <= 140)
+);
+
 DROP TABLE IF EXISTS settings CASCADE;
 CREATE TABLE settings (
 	updated_at TIMESTAMPTZ DEFAULT NOW(),
@@ -422,6 +495,17 @@ CREATE TABLE conversation_tags (
 );
 CREATE UNIQUE INDEX index_conversation_tags_on_conversation_id_and_tag_id ON conversation_tags (conversation_id, tag_id);
 
+DROP TABLE IF EXISTS csat_surveys CASCADE;
+CREATE TABLE csat_surveys (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    inbox_id INT REFERENCES inboxes(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL UNIQUE,
+    type csat_survey_type DEFAULT 'stars' NOT NULL,
+    -- Optional follow-up questions.
+    questions JSONB DEFAULT '[]'::jsonb NOT NULL
+);
+
 DROP TABLE IF EXISTS csat_responses CASCADE;
 CREATE TABLE csat_responses (
     id SERIAL PRIMARY KEY,
@@ -431,15 +515,29 @@ CREATE TABLE csat_responses (
 
 	-- Cascade deletes when conversation is deleted.
     conversation_id BIGINT REFERENCES conversations(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+    survey_id INT REFERENCES csat_surveys(id) ON DELETE SET NULL ON UPDATE CASCADE NULL,
+    survey_type csat_survey_type DEFAULT 'stars' NOT NULL,
 
     rating INT DEFAULT 0 NOT NULL,
     feedback TEXT NULL,
     response_timestamp TIMESTAMPTZ NULL,
-    CONSTRAINT constraint_csat_responses_on_rating CHECK (rating >= 0 AND rating <= 5),
+    CONSTRAINT constraint_csat_responses_on_rating CHECK (rating >= 0 AND rating <= 10),
     CONSTRAINT constraint_csat_responses_on_feedback CHECK (length(feedback) <= 1000)
 );
 CREATE INDEX index_csat_responses_on_uuid ON csat_responses(uuid);
 
+DROP TABLE IF EXISTS csat_response_answers CASCADE;
+CREATE TABLE csat_response_answers (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    csat_response_id INT REFERENCES csat_responses(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+    question_key TEXT NOT NULL,
+    question TEXT NOT NULL,
+    value TEXT NOT NULL,
+    CONSTRAINT constraint_csat_response_answers_on_value CHECK (length(value) <= 1000)
+);
+CREATE INDEX index_csat_response_answers_on_csat_response_id ON csat_response_answers(csat_response_id);
+
 DROP TABLE IF EXISTS views CASCADE;
 CREATE TABLE views (
     id SERIAL PRIMARY KEY,
@@ -491,6 +589,22 @@ CREATE TABLE sla_events (
 CREATE INDEX index_sla_events_on_applied_sla_id ON sla_events(applied_sla_id);
 CREATE INDEX index_sla_events_on_status ON sla_events(status);
 
+DROP TABLE IF EXISTS applied_sla_pauses CASCADE;
+CREATE TABLE applied_sla_pauses (
+	id BIGSERIAL PRIMARY KEY,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	applied_sla_id BIGINT REFERENCES applied_slas(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL,
+	-- Conversation status that paused the SLA clock.
+	status TEXT NOT NULL,
+	paused_at TIMESTAMPTZ DEFAULT NOW() NOT NULL,
+	resumed_at TIMESTAMPTZ NULL,
+	-- Business minutes the SLA clock was paused for, set on resume.
+	paused_minutes INT DEFAULT 0 NOT NULL
+);
+CREATE INDEX index_applied_sla_pauses_on_applied_sla_id ON applied_sla_pauses(applied_sla_id);
+-- Only one open pause per applied SLA.
+CREATE UNIQUE INDEX index_unique_open_applied_sla_pauses ON applied_sla_pauses(applied_sla_id) WHERE resumed_at IS NULL;
+
 DROP TABLE IF EXISTS scheduled_sla_notifications CASCADE;
 CREATE TABLE scheduled_sla_notifications (
   id BIGSERIAL PRIMARY KEY,
@@ -521,6 +635,19 @@ CREATE TABLE ai_providers (
 CREATE UNIQUE INDEX index_unique_ai_providers_on_is_default_when_is_default_is_true ON ai_providers USING btree (is_default)
 WHERE (is_default = true);
 
+DROP TABLE IF EXISTS ai_inbox_settings CASCADE;
+CREATE TABLE ai_inbox_settings (
+	id SERIAL PRIMARY KEY,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	inbox_id INT REFERENCES inboxes(id) ON DELETE CASCADE ON UPDATE CASCADE NOT NULL UNIQUE,
+	summarize_on_resolve BOOLEAN NOT NULL DEFAULT FALSE,
+	auto_tag_on_resolve BOOLEAN NOT NULL DEFAULT FALSE,
+	-- Key of a conversation list custom attribute set to the suggested category.
+	category_attribute_key TEXT NULL,
+	CONSTRAINT constraint_ai_inbox_settings_on_category_attribute_key CHECK (length(category_attribute_key) <= 140)
+);
+
 DROP TABLE IF EXISTS ai_prompts CASCADE;
 CREATE TABLE ai_prompts (
     id SERIAL PRIMARY KEY,
@@ -534,6 +661,24 @@ CREATE TABLE ai_prompts (
 );
 CREATE INDEX index_ai_prompts_on_key ON ai_prompts USING btree (key);
 
+DROP TABLE IF EXISTS ai_usage CASCADE;
+CREATE TABLE ai_usage (
+	id BIGSERIAL PRIMARY KEY,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	-- NULL for prompts sent by the system e.g. on resolve.
+	user_id INT REFERENCES users(id) ON DELETE SET NULL ON UPDATE CASCADE NULL,
+	provider_id INT REFERENCES ai_providers(id) ON DELETE SET NULL ON UPDATE CASCADE NULL,
+	-- NULL for prompts not sent in a conversation, used to scope the usage report to the conversations an agent can report on.
+	conversation_id BIGINT REFERENCES conversations(id) ON DELETE SET NULL ON UPDATE CASCADE NULL,
+	model TEXT NOT NULL,
+	prompt_key TEXT NOT NULL,
+	input_tokens INT NOT NULL DEFAULT 0,
+	output_tokens INT NOT NULL DEFAULT 0,
+	latency_ms INT NOT NULL DEFAULT 0,
+	outcome ai_usage_outcome NOT NULL
+);
+CREATE INDEX index_ai_usage_on_created_at ON ai_usage USING btree (created_at);
+
 DROP TABLE IF EXISTS custom_attribute_definitions CASCADE;
 CREATE TABLE custom_attribute_definitions (
 	id SERIAL PRIMARY KEY,
@@ -679,7 +824,22 @@ VALUES
     ('article.site_description', '""'::jsonb),
     ('article.custom_script', '""'::jsonb),
     ('article.primary_font', '"inter"'::jsonb),
-    ('article.logo_url', '""'::jsonb);
+    ('article.logo_url', '""'::jsonb),
+	-- AI settings, a daily token limit of 0 is unlimited
+    ('ai.user_daily_token_limit', '0'::jsonb),
+    ('ai.workspace_daily_token_limit', '0'::jsonb),
+	-- SCIM settings, SCIM is disabled until a token is generated
+    ('scim.default_role', '"Agent"'::jsonb),
+    ('scim.token_hash', '""'::jsonb),
+	-- Password policy, a history count or max age of 0 disables the rule
+    ('password.min_length', '10'::jsonb),
+    ('password.require_uppercase', 'true'::jsonb),
+    ('password.require_lowercase', 'true'::jsonb),
+    ('password.require_number', 'true'::jsonb),
+    ('password.require_special', 'true'::jsonb),
+    ('password.banned_passwords', '["Password@123", "Password@1234", "P@ssw0rd123", "Welcome@123", "Admin@12345", "Qwerty@12345", "Abcd@123456", "Libredesk@123"]'::jsonb),
+    ('password.history_count', '0'::jsonb),
+    ('password.max_age_days', '0'::jsonb);
 
 -- Default conversation priorities
 INSERT INTO conversation_priorities (name) VALUES
@@ -701,7 +861,7 @@ VALUES
 	(
 		'Agent',
 		'Role for all agents with limited access to conversations.',
-		'{conversations:read_all,conversations:read_unassigned,conversations:read_assigned,conversations:read_team_inbox,conversations:read,conversations:update_user_assignee,conversations:update_team_assignee,conversations:update_priority,conversations:update_status,conversations:update_tags,messages:read,messages:write,view:manage}'
+		'{conversations:read_all,conversations:read_unassigned,conversations:read_assigned,conversations:read_team_inbox,conversations:read,conversations:update_user_assignee,conversations:update_team_assignee,conversations:update_priority,conversations:update_status,conversations:update_tags,conversations:merge,conversations:update_custom_attributes,contacts:update_custom_attributes,messages:read,messages:write,view:manage,macros:read,macros:apply,tags:read,inboxes:read,ai:use}'
 	);
 
 INSERT INTO
@@ -710,7 +870,7 @@ VALUES
 	(
 		'Admin',
 		'Role for users who have complete access to everything.',
-		'{webhooks:manage,activity_logs:manage,custom_attributes:manage,contacts:read_all,contacts:read,contacts:write,contacts:block,contact_notes:read,contact_notes:write,contact_notes:delete,conversations:write,ai:manage,general_settings:manage,notification_settings:manage,oidc:manage,conversations:read_all,conversations:read_unassigned,conversations:read_assigned,conversations:read_team_inbox,conversations:read,conversations:update_user_assignee,conversations:update_team_assignee,conversations:update_priority,conversations:update_status,conversations:update_tags,messages:read,messages:write,view:manage,status:manage,tags:manage,macros:manage,users:manage,teams:manage,automations:manage,inboxes:manage,roles:manage,reports:manage,templates:manage,business_hours:manage,sla:manage,article_category:manage,article_section:manage,article:manage,article_setting:manage}'
+		'{webhooks:manage,activity_logs:manage,custom_attributes:manage,contacts:read_all,contacts:read,contacts:write,contacts:block,contact_notes:read,contact_notes:write,contact_notes:delete,conversations:write,ai:manage,ai_prompts:manage,general_settings:manage,notification_settings:manage,oidc:manage,conversations:read_all,conversations:read_unassigned,conversations:read_assigned,conversations:read_team_inbox,conversations:read,conversations:update_user_assignee,conversations:update_team_assignee,conversations:update_priority,conversations:update_status,conversations:update_tags,conversations:merge,conversations:update_custom_attributes,contacts:update_custom_attributes,messages:read,messages:write,view:manage,status:manage,tags:manage,tags:read,macros:manage,macros:read,macros:apply,users:manage,teams:manage,automations:manage,inboxes:manage,inboxes:read,ai:use,roles:manage,reports:manage,templates:manage,business_hours:manage,sla:manage,article_category:manage,article_section:manage,article:manage,article_setting:manage}'
 	);
 
 